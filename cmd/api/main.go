@@ -5,6 +5,11 @@ import (
 	"log"
 	"myproject/cmd/routes"
 	"myproject/internal/db"
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/mongo"
+	"myproject/pkg/authz"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/mail"
 	"net/http"
 	"os"
 	"os/signal"
@@ -25,18 +30,29 @@ func init() {
 // NO es necesaria la variable global 'httpAdapter'
 
 func main() {
-	db.ConnectDynamoDB()
-	defer db.DisconnectDynamoDB()
+	connectStorage()
+	defer disconnectStorage()
 
-	// Test real connection to DynamoDB
-	if err := db.TestDynamoDBConnection(); err != nil {
-		log.Printf("Warning: DynamoDB connection test failed: %v", err)
-		log.Println("The application will start but may fail on database operations")
+	// Cargamos las claves de firma de JWT antes de aceptar peticiones
+	if err := tokens.InitKeySet(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Cargamos las políticas de autorización para fallar al iniciar si son inválidas
+	if err := authz.Init(); err != nil {
+		log.Fatalf("Failed to load authorization policies: %v", err)
+	}
+
+	// Iniciamos la cola de emails. En Lambda se envía de forma sincrónica porque el
+	// proceso se congela al responder y los workers en segundo plano no llegarían a enviar.
+	_, isLambda := os.LookupEnv("LAMBDA_SERVER_PORT")
+	if err := mail.StartQueue(mail.QueueOptions{Synchronous: isLambda}); err != nil {
+		log.Fatalf("Failed to start mail queue: %v", err)
 	}
 
 	router := routes.InitRoutes()
 
-	if _, ok := os.LookupEnv("LAMBDA_SERVER_PORT"); ok {
+	if isLambda {
 		// ESTAMOS EN ENTORNO LAMBDA 🚀
 		log.Println("Running on AWS Lambda")
 
@@ -79,6 +95,58 @@ func main() {
 		if err := srv.Shutdown(ctx); err != nil {
 			log.Fatal("Server forced to shutdown:", err)
 		}
+
+		// Esperamos a que se envíen los emails pendientes
+		mail.StopQueue(ctx)
 		log.Println("Server exiting")
 	}
 }
+
+// connectStorage conecta el backend de almacenamiento elegido con STORAGE_BACKEND
+// y prepara sus tablas o índices si corresponde.
+func connectStorage() {
+	switch db.GetStorageBackend() {
+	case db.STORAGE_DYNAMODB:
+		db.ConnectDynamoDB()
+
+		// Test real connection to DynamoDB
+		if err := db.TestDynamoDBConnection(); err != nil {
+			log.Printf("Warning: DynamoDB connection test failed: %v", err)
+			log.Println("The application will start but may fail on database operations")
+		}
+
+		// Aprovisionamiento opcional de tablas e índices (útil con DynamoDB Local)
+		if os.Getenv("DYNAMODB_AUTO_PROVISION") == "true" {
+			if err := repositories.Provision(context.Background(), db.GetDynamoClient()); err != nil {
+				log.Fatalf("Failed to provision DynamoDB tables: %v", err)
+			}
+		}
+
+		// Las reservas de email sostienen su unicidad: sin el backfill no se puede arrancar
+		if err := repositories.CheckUserEmailsBackfilled(context.Background(), db.GetDynamoClient()); err != nil {
+			log.Fatalf("DynamoDB users table not ready: %v", err)
+		}
+
+	case db.STORAGE_MONGODB:
+		db.ConnectMongoDB()
+
+		if err := db.TestMongoDBConnection(); err != nil {
+			log.Fatalf("MongoDB connection test failed: %v", err)
+		}
+
+		// Los índices únicos sostienen la unicidad del email: sin ellos no se puede arrancar
+		if err := mongo.EnsureIndexes(context.Background(), db.GetMongoDatabase()); err != nil {
+			log.Fatalf("Failed to create MongoDB indexes: %v", err)
+		}
+	}
+}
+
+// disconnectStorage cierra la conexión del backend de almacenamiento
+func disconnectStorage() {
+	switch db.GetStorageBackend() {
+	case db.STORAGE_DYNAMODB:
+		db.DisconnectDynamoDB()
+	case db.STORAGE_MONGODB:
+		db.DisconnectMongoDB()
+	}
+}
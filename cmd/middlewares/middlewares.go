@@ -2,98 +2,171 @@ package middlewares
 
 import (
 	"context"
+	"crypto/subtle"
+	"fmt"
 	"log"
 	tokens "myproject/pkg/jwt"
+	"myproject/pkg/rbac"
 	"myproject/pkg/response"
 	"myproject/pkg/validations"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gorilla/mux"
 	"golang.org/x/time/rate"
 )
 
-var excludedRoutes = []string{
-	"/auth/login",
-	"/auth/register",
-	"/auth/refresh-token",
-	"/auth/forgot-password",
-	"/auth/reset-password",
-	"/auth/activate",
-
-	"/health",
-
-	"/webhook/wuzapi",
-	"/accept-invitation",
+// AccessTokenValidator valida un access token y retorna sus claims.
+// Lo implementa services.SessionService, que además consulta la denylist y la versión de tokens.
+type AccessTokenValidator interface {
+	ValidateAccessToken(ctx context.Context, token string) (*tokens.Claims, error)
 }
 
-/*var wuzapiRoutes = []string{
-	"/webhook/wuzapi",
-}*/
+// AuthMiddleware aplica la autenticación que cada ruta declaró en routeAuth. Las rutas sin
+// declaración exigen un access token (RouteAuth.Verify evita que existan). Las peticiones que no
+// coinciden con ninguna ruta no llegan a los middlewares: el router responde 404 o 405.
+// Con un access token deja en el contexto el ID del usuario ("user_id"), la organización para la
+// que se emitió el token ("tenant_id", vacía si no tiene) y los claims del token ("claims").
+func AuthMiddleware(routeAuth *RouteAuth, validator AccessTokenValidator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			access, declared := routeAuth.lookup(mux.CurrentRoute(r))
+			if !declared {
+				log.Printf("Route without auth declaration: %s %s", r.Method, r.URL.Path)
+			}
 
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Verifica si la ruta actual está en las rutas excluidas
-		for _, route := range excludedRoutes {
-			if route == r.URL.Path {
+			if access.public {
 				next.ServeHTTP(w, r) // Continúa sin verificar el token
 				return
 			}
-		}
+			if access.adminKey {
+				AdminKeyMiddleware(next).ServeHTTP(w, r)
+				return
+			}
+
+			// 1. Valida el token
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+				response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+				return
+			}
 
-		println("Host:", r.Host)
-		// Verificamos que si la ruta es de wuzapi, la solicitud, debe venir de un dominio especifico.
-		/*for _, route := range wuzapiRoutes {
-			if route == r.URL.Path {
-				if !strings.Contains(r.Host, "192.168.100.3:9000") {
-					response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			// Solo se aceptan access tokens vigentes: ni refresh tokens ni tokens revocados
+			claims, err := validator.ValidateAccessToken(r.Context(), tokenString)
+			if err != nil || claims == nil {
+				response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+				return
+			}
+
+			// 2. El ID del usuario viaja en el claim "sub" y no puede estar vacío
+			userID := claims.Subject
+			if userID == "" {
+				response.ResponseError(w, validations.ErrInvalidUserID, http.StatusUnauthorized)
+				return
+			}
+
+			// 3. Los tokens de clientes OAuth solo llegan a las rutas que declaran scopes: el resto de
+			// la API es de primera parte y no debe quedar al alcance de un tercero con un token delegado
+			if claims.ClientID != "" && len(access.scopes) == 0 {
+				response.ResponseError(w, validations.ErrOAuthTokenNotAllowed, http.StatusForbidden)
+				return
+			}
+
+			// 4. Verifica los roles y scopes que exige la ruta
+			if len(access.roles) > 0 && !slices.Contains(access.roles, claims.Role) {
+				response.ResponseError(w, validations.ErrForbidden, http.StatusForbidden)
+				return
+			}
+			granted := strings.Fields(claims.Scope)
+			for _, scope := range access.scopes {
+				if !slices.Contains(granted, scope) {
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(access.scopes, " ")))
+					response.ResponseError(w, validations.ErrInsufficientScope, http.StatusForbidden)
 					return
 				}
 			}
-		}*/
 
-		// Valida el token para las demás rutas
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
-			return
-		}
+			// 5. Crea un nuevo contexto con el ID del usuario, el tenant y los claims del token
+			ctx := context.WithValue(r.Context(), "user_id", userID)
+			ctx = context.WithValue(ctx, "tenant_id", claims.OrganizationID)
+			ctx = context.WithValue(ctx, "claims", claims)
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		claims, err := tokens.GetClaims(tokenString)
-		if err != nil || claims == nil {
-			response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
-			return
-		}
+			// 6. Llama al siguiente handler (previa verificación de permisos) con el nuevo contexto
+			handler := next
+			if len(access.permissions) > 0 {
+				handler = RequirePermission(access.permissions...)(next)
+			}
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequirePermission exige que el rol del usuario autenticado tenga todos los permisos indicados.
+// Se aplica por ruta o con Use en un subrouter, siempre detrás de AuthMiddleware:
+//
+//	router.Handle("/users/{id}/role", middlewares.RequirePermission(rbac.PERMISSION_USERS_MANAGE)(handler))
+func RequirePermission(permissions ...rbac.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value("claims").(*tokens.Claims)
+			if !ok || claims == nil {
+				response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+				return
+			}
+
+			// Los tokens de client_credentials no tienen rol y no obtienen ningún permiso
+			for _, permission := range permissions {
+				if !rbac.HasPermission(claims.Role, permission) {
+					response.ResponseError(w, validations.ErrForbidden, http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		// 3. Extrae el ID del claim usando la clave del mapa y conviértelo a string.
-		userID, ok := (*claims)["id"].(string)
-		if !ok {
-			// Este error ocurre si el claim "id" no existe o no es un string.
-			response.ResponseError(w, validations.ErrInvalidUserID, http.StatusUnauthorized)
+// AdminKeyMiddleware exige el header X-Admin-Key igual a ADMIN_API_KEY.
+// Sin ADMIN_API_KEY configurada las rutas de administración quedan deshabilitadas.
+func AdminKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adminKey := os.Getenv("ADMIN_API_KEY")
+		if adminKey == "" {
+			response.ResponseError(w, validations.ErrDocumentNotFound, http.StatusNotFound)
 			return
 		}
 
-		// 4. Valida que el userID no esté vacío
-		if userID == "" {
-			response.ResponseError(w, validations.ErrInvalidUserID, http.StatusUnauthorized)
+		// Comparación en tiempo constante para no filtrar la clave por timing
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Admin-Key")), []byte(adminKey)) != 1 {
+			response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
 			return
 		}
 
-		// 5. Crea un nuevo contexto con el ID del usuario
-		ctx := context.WithValue(r.Context(), "user_id", userID)
-
-		// 6. Llama al siguiente handler con la petición que incluye el nuevo contexto
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r)
 	})
 }
 
+// EnableCORSMiddleware permite las peticiones de cualquier origen. Las del frontend (el origen de
+// APP_URL) pueden además enviar cookies, como la del login con proveedores externos.
 func EnableCORSMiddleware(next http.Handler) http.Handler {
+	appOrigin := originOf(os.Getenv("APP_URL"))
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Add("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); appOrigin != "" && origin == appOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		// Si es una solicitud OPTIONS, termina aquí
@@ -105,6 +178,15 @@ func EnableCORSMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// originOf retorna el origen (esquema y host) de la URL, o vacío si no es absoluta
+func originOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	return parsed.Scheme + "://" + parsed.Host
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Momento en que inicia el procesamiento
@@ -0,0 +1,178 @@
+package middlewares
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"myproject/pkg/consts"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/rbac"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRequirePermission(t *testing.T) {
+	handler := RequirePermission(rbac.PERMISSION_MEMBERS_READ, rbac.PERMISSION_MEMBERS_INVITE)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	)
+
+	tests := []struct {
+		name   string
+		claims *tokens.Claims
+		want   int
+	}{
+		{"without claims", nil, http.StatusUnauthorized},
+		{"all permissions", &tokens.Claims{Role: consts.ROLE_ADMINISTRATIVE}, http.StatusNoContent},
+		{"missing one permission", &tokens.Claims{Role: consts.ROLE_EMPLOYEE}, http.StatusForbidden},
+		{"client credentials token", &tokens.Claims{ClientID: "backend"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/orgs/members", nil)
+		if tt.claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), "claims", tt.claims))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+// fakeValidator acepta como access token el rol del usuario ("OWNER", "EMPLOYEE", ...)
+type fakeValidator struct{}
+
+func (fakeValidator) ValidateAccessToken(ctx context.Context, token string) (*tokens.Claims, error) {
+	if token == "invalid" {
+		return nil, errors.New("invalid token")
+	}
+	claims := &tokens.Claims{Role: token, Scope: "openid email"}
+	claims.Subject = "user-1"
+	// "OAUTH" es un token emitido a un cliente OAuth en nombre del usuario
+	if token == "OAUTH" {
+		claims.ClientID = "third-party"
+	}
+	return claims, nil
+}
+
+func newAuthTestRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value("claims") != nil && r.Context().Value("user_id") != "user-1" {
+			t.Errorf("%s: user_id missing from context", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	router := mux.NewRouter()
+	routeAuth := NewRouteAuth()
+	router.Use(AuthMiddleware(routeAuth, fakeValidator{}))
+
+	routeAuth.Declare(Public(), router.HandleFunc("/health", ok).Methods("GET"))
+	routeAuth.Declare(Authenticated(), router.HandleFunc("/items/{id}", ok).Methods("GET"))
+	routeAuth.Declare(Authenticated().WithRoles(consts.ROLE_OWNER), router.HandleFunc("/owners", ok).Methods("GET"))
+	routeAuth.Declare(Authenticated().WithScopes("openid", "profile"), router.HandleFunc("/profile", ok).Methods("GET"))
+	routeAuth.Declare(Authenticated().WithScopes("openid"), router.HandleFunc("/userinfo", ok).Methods("GET"))
+	routeAuth.Declare(Authenticated().WithPermissions(rbac.PERMISSION_MEMBERS_INVITE), router.HandleFunc("/invitations", ok).Methods("GET"))
+	admin := router.PathPrefix("/admin").Subrouter()
+	routeAuth.Declare(AdminKey(), admin.HandleFunc("/users", ok).Methods("GET"))
+	router.HandleFunc("/undeclared", ok).Methods("GET")
+	return router
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	t.Setenv("ADMIN_API_KEY", "secret")
+	router := newAuthTestRouter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		headers map[string]string
+		want    int
+	}{
+		{"public", "GET", "/health", nil, http.StatusNoContent},
+		{"unknown route", "GET", "/missing", nil, http.StatusNotFound},
+		{"trailing slash", "GET", "/health/", nil, http.StatusNotFound},
+		{"method not allowed", "POST", "/health", nil, http.StatusMethodNotAllowed},
+		{"path params without token", "GET", "/items/42", nil, http.StatusUnauthorized},
+		{"path params with token", "GET", "/items/42", map[string]string{"Authorization": "Bearer CLIENT"}, http.StatusNoContent},
+		{"invalid token", "GET", "/items/42", map[string]string{"Authorization": "Bearer invalid"}, http.StatusUnauthorized},
+		{"required role", "GET", "/owners", map[string]string{"Authorization": "Bearer OWNER"}, http.StatusNoContent},
+		{"other role", "GET", "/owners", map[string]string{"Authorization": "Bearer ADMINISTRATIVE"}, http.StatusForbidden},
+		{"missing scope", "GET", "/profile", map[string]string{"Authorization": "Bearer OWNER"}, http.StatusForbidden},
+		{"oauth token on first-party route", "GET", "/items/42", map[string]string{"Authorization": "Bearer OAUTH"}, http.StatusForbidden},
+		{"oauth token on scoped route", "GET", "/userinfo", map[string]string{"Authorization": "Bearer OAUTH"}, http.StatusNoContent},
+		{"oauth token without the scope", "GET", "/profile", map[string]string{"Authorization": "Bearer OAUTH"}, http.StatusForbidden},
+		{"permission granted", "GET", "/invitations", map[string]string{"Authorization": "Bearer ADMINISTRATIVE"}, http.StatusNoContent},
+		{"permission missing", "GET", "/invitations", map[string]string{"Authorization": "Bearer EMPLOYEE"}, http.StatusForbidden},
+		{"admin without key", "GET", "/admin/users", map[string]string{"Authorization": "Bearer OWNER"}, http.StatusUnauthorized},
+		{"admin with key", "GET", "/admin/users", map[string]string{"X-Admin-Key": "secret"}, http.StatusNoContent},
+		{"undeclared route", "GET", "/undeclared", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		for name, value := range tt.headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestRouteAuthVerify(t *testing.T) {
+	router := mux.NewRouter()
+	routeAuth := NewRouteAuth()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	routeAuth.Declare(Public(), router.HandleFunc("/health", handler).Methods("GET"))
+	admin := router.PathPrefix("/admin").Subrouter()
+	routeAuth.Declare(AdminKey(), admin.HandleFunc("/users", handler).Methods("GET"))
+	if err := routeAuth.Verify(router); err != nil {
+		t.Fatalf("all routes declared: %v", err)
+	}
+
+	router.HandleFunc("/items/{id}", handler).Methods("DELETE")
+	admin.HandleFunc("/clients", handler).Methods("POST")
+	err := routeAuth.Verify(router)
+	if err == nil || !strings.Contains(err.Error(), "DELETE /items/{id}") || !strings.Contains(err.Error(), "POST /admin/clients") {
+		t.Fatalf("error = %v, want the undeclared routes", err)
+	}
+}
+
+func TestEnableCORSMiddleware(t *testing.T) {
+	t.Setenv("APP_URL", "https://app.example.com/")
+	handler := EnableCORSMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		origin      string
+		wantOrigin  string
+		credentials bool
+	}{
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://evil.example.com", "*", false},
+		{"", "*", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/auth/providers/corp/callback", nil)
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+			t.Errorf("origin %q: Access-Control-Allow-Origin = %q, want %q", tt.origin, got, tt.wantOrigin)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.credentials {
+			t.Errorf("origin %q: credentials = %v, want %v", tt.origin, got, tt.credentials)
+		}
+	}
+}
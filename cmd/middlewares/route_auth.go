@@ -0,0 +1,118 @@
+package middlewares
+
+import (
+	"fmt"
+	"strings"
+
+	"myproject/pkg/rbac"
+
+	"github.com/gorilla/mux"
+)
+
+// Access es la autenticación que exige una ruta. Se declara al registrar la ruta con
+// RouteAuth.Declare y la aplica AuthMiddleware.
+type Access struct {
+	public      bool
+	adminKey    bool
+	roles       []string
+	scopes      []string
+	permissions []rbac.Permission
+}
+
+// Public no exige credenciales.
+func Public() Access {
+	return Access{public: true}
+}
+
+// AdminKey exige el header X-Admin-Key (ver AdminKeyMiddleware) en lugar de un access token.
+func AdminKey() Access {
+	return Access{adminKey: true}
+}
+
+// Authenticated exige un access token válido emitido por la propia API (login, refresh, etc.).
+// Los tokens de clientes OAuth solo se aceptan si la ruta declara además WithScopes.
+func Authenticated() Access {
+	return Access{}
+}
+
+// WithRoles exige además que el rol del token sea alguno de los indicados.
+func (a Access) WithRoles(roles ...string) Access {
+	a.roles = append(append([]string{}, a.roles...), roles...)
+	return a
+}
+
+// WithScopes exige además que el token tenga todos los scopes indicados. Es lo que habilita la ruta
+// para los tokens de clientes OAuth, que solo pueden hacer lo que les concedieron sus scopes.
+func (a Access) WithScopes(scopes ...string) Access {
+	a.scopes = append(append([]string{}, a.scopes...), scopes...)
+	return a
+}
+
+// WithPermissions exige además que el rol del token tenga todos los permisos indicados (ver RequirePermission).
+func (a Access) WithPermissions(permissions ...rbac.Permission) Access {
+	a.permissions = append(append([]rbac.Permission{}, a.permissions...), permissions...)
+	return a
+}
+
+// RouteAuth guarda la autenticación declarada para cada ruta. Las rutas se declaran al iniciar,
+// antes de atender peticiones, por lo que después solo se lee y no necesita sincronización.
+type RouteAuth struct {
+	routes map[*mux.Route]Access
+}
+
+// NewRouteAuth crea un RouteAuth sin rutas declaradas.
+func NewRouteAuth() *RouteAuth {
+	return &RouteAuth{
+		routes: make(map[*mux.Route]Access),
+	}
+}
+
+// Declare registra la autenticación que exige la ruta y la retorna:
+//
+//	routeAuth.Declare(middlewares.Public(), router.HandleFunc("/health", healthHandler).Methods("GET"))
+func (ra *RouteAuth) Declare(access Access, route *mux.Route) *mux.Route {
+	ra.routes[route] = access
+	return route
+}
+
+// lookup retorna la autenticación declarada para la ruta
+func (ra *RouteAuth) lookup(route *mux.Route) (Access, bool) {
+	if route == nil {
+		return Access{}, false
+	}
+	access, ok := ra.routes[route]
+	return access, ok
+}
+
+// Verify recorre el router y falla si alguna ruta con handler no declaró su autenticación.
+// Se llama al terminar de registrar las rutas para que olvidarse una declaración impida iniciar.
+func (ra *RouteAuth) Verify(router *mux.Router) error {
+	var undeclared []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		// Las rutas sin handler son prefijos de subrouters, no endpoints
+		if route.GetHandler() == nil {
+			return nil
+		}
+		if _, ok := ra.routes[route]; ok {
+			return nil
+		}
+
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			path = "<sin path>"
+		}
+		if methods, err := route.GetMethods(); err == nil {
+			path = strings.Join(methods, ",") + " " + path
+		}
+		undeclared = append(undeclared, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(undeclared) > 0 {
+		return fmt.Errorf("rutas sin autenticación declarada: %s", strings.Join(undeclared, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+	"myproject/internal/db"
+	"myproject/internal/repositories"
+
+	"github.com/joho/godotenv"
+)
+
+func init() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("Could not load .env file, assuming production environment")
+	}
+}
+
+// Crea (o completa) las tablas, índices y TTL de DynamoDB. Se puede ejecutar contra
+// DynamoDB Local definiendo DYNAMODB_ENDPOINT.
+func main() {
+	db.ConnectDynamoDB()
+	defer db.DisconnectDynamoDB()
+
+	if err := repositories.Provision(context.Background(), db.GetDynamoClient()); err != nil {
+		log.Fatalf("Provisioning failed: %v", err)
+	}
+
+	log.Println("✅ DynamoDB tables provisioned")
+}
@@ -1,11 +1,13 @@
 package routes
 
 import (
+	"log"
 	"myproject/cmd/middlewares"
-	"myproject/internal/db"
 	"myproject/internal/handlers"
-	"myproject/internal/repositories"
+	"myproject/internal/models"
 	"myproject/internal/services"
+	"myproject/pkg/mail"
+	"myproject/pkg/rbac"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -14,35 +16,155 @@ import (
 func InitRoutes() *mux.Router {
 	// 1. Configuramos dependencias siguiendo el patrón cebolla
 
-	// Obtenemos la conexión principal a DynamoDB
-	dynamoClient := db.GetDynamoClient()
-
-	// A. Creamos instancias de los REPOSITORIOS (Repository Layer)
-	userRepo := repositories.NewUserRepository(dynamoClient)
+	// A. Creamos instancias de los REPOSITORIOS (Repository Layer) según STORAGE_BACKEND
+	repos := newRepositorySet()
+	userRepo := repos.users
+	refreshTokenRepo := repos.refreshTokens
+	revokedTokenRepo := repos.revokedTokens
+	sessionRepo := repos.sessions
+	passwordResetRepo := repos.passwordResets
+	loginAttemptRepo := repos.loginAttempts
+	webAuthnCredentialRepo := repos.webAuthnCredentials
+	webAuthnChallengeRepo := repos.webAuthnChallenges
+	emailLoginRepo := repos.emailLogins
+	oauthClientRepo := repos.oauthClients
+	authorizationCodeRepo := repos.authorizationCodes
+	identityRepo := repos.identities
+	federatedStateRepo := repos.federatedStates
+	organizationRepo := repos.organizations
+	membershipRepo := repos.memberships
+	invitationRepo := repos.invitations
 
 	// B. Creamos instancias de los SERVICIOS (Service Layer)
-	sessionService := services.NewSessionService(userRepo)
+	notifier := services.NewMailNotifier(mail.GetQueue())
+	verificationService := services.NewVerificationService(userRepo, notifier)
+	lockoutService := services.NewLockoutService(userRepo, loginAttemptRepo, services.LoadLockoutPolicy())
+	mfaService := services.NewMFAService(userRepo)
+	organizationService := services.NewOrganizationService(organizationRepo, membershipRepo)
+	sessionService := services.NewSessionService(userRepo, refreshTokenRepo, revokedTokenRepo, sessionRepo, verificationService, lockoutService, mfaService, organizationService)
+	passwordService := services.NewPasswordService(userRepo, passwordResetRepo, sessionService, notifier)
+	userService := services.NewUserService(userRepo)
+	webAuthnService, err := services.NewWebAuthnService(userRepo, webAuthnCredentialRepo, webAuthnChallengeRepo, sessionService, services.LoadWebAuthnConfig())
+	if err != nil {
+		log.Fatalf("Invalid WebAuthn configuration: %v", err)
+	}
+	passwordlessService := services.NewPasswordlessService(userRepo, emailLoginRepo, sessionService, lockoutService, notifier, services.LoadPasswordlessPolicy())
+	oauthService := services.NewOAuthService(oauthClientRepo, authorizationCodeRepo, userRepo, sessionService)
+	invitationService := services.NewInvitationService(userRepo, invitationRepo, organizationRepo, membershipRepo, notifier)
+	federatedService, err := services.NewFederatedService(userRepo, identityRepo, federatedStateRepo, sessionService, services.LoadFederationConfig())
+	if err != nil {
+		log.Fatalf("Invalid OIDC provider configuration: %v", err)
+	}
 
 	// C. Creamos instancias de los HANDLERS (Handler Layer)
 	sessionHandler := handlers.NewSessionHandler(sessionService)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+	userHandler := handlers.NewUserHandler(userService)
+	adminHandler := handlers.NewAdminHandler(lockoutService, userService)
+	mfaHandler := handlers.NewMFAHandler(mfaService, sessionService)
+	webAuthnHandler := handlers.NewWebAuthnHandler(webAuthnService)
+	passwordlessHandler := handlers.NewPasswordlessHandler(passwordlessService)
+	oauthHandler := handlers.NewOAuthHandler(oauthService, sessionService)
+	federatedHandler := handlers.NewFederatedHandler(federatedService)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+	invitationHandler := handlers.NewInvitationHandler(invitationService)
 
 	// 2. REGISTRO DE RUTAS
+	// Cada ruta declara su autenticación con routeAuth.Declare; AuthMiddleware la aplica
 	router := mux.NewRouter()
+	routeAuth := middlewares.NewRouteAuth()
+	public := middlewares.Public()
+	authenticated := middlewares.Authenticated()
 
 	// A. Configuración de middlewares
 	router.Use(middlewares.BodySizeLimitMiddleware)
 	router.Use(middlewares.LimitRequestsMiddleware)
 	router.Use(middlewares.EnableCORSMiddleware)
 	router.Use(middlewares.LoggingMiddleware)
-	router.Use(middlewares.AuthMiddleware)
+	router.Use(middlewares.AuthMiddleware(routeAuth, sessionService))
 
 	// B. Configuración de rutas de autenticación
-	router.HandleFunc("/auth/register", sessionHandler.Register).Methods("POST", "OPTIONS")
-	router.HandleFunc("/auth/login", sessionHandler.LoginHandler).Methods("POST", "OPTIONS")
-	router.HandleFunc("/auth/refresh-token", sessionHandler.RefreshTokenHandler).Methods("GET", "OPTIONS")
+	routeAuth.Declare(public, router.HandleFunc("/auth/register", sessionHandler.Register).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/login", sessionHandler.LoginHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/refresh-token", sessionHandler.RefreshTokenHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/logout", sessionHandler.LogoutHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/logout-all", sessionHandler.LogoutAllHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/forgot-password", passwordHandler.ForgotPasswordHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/reset-password", passwordHandler.ResetPasswordHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/activate", verificationHandler.ActivateAccountHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/resend-verification", verificationHandler.ResendVerificationHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/change-email", verificationHandler.ChangeEmailHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/sessions", sessionHandler.ListSessionsHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/sessions/{id}", sessionHandler.RevokeSessionHandler).Methods("DELETE", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/switch-org", sessionHandler.SwitchOrganizationHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/mfa/totp/setup", mfaHandler.SetupTOTPHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/mfa/totp/verify", mfaHandler.VerifyTOTPHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/mfa/challenge", mfaHandler.ChallengeHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/webauthn/register/options", webAuthnHandler.RegisterOptionsHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/webauthn/register/finish", webAuthnHandler.RegisterFinishHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/webauthn/login/options", webAuthnHandler.LoginOptionsHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/webauthn/login/finish", webAuthnHandler.LoginFinishHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/webauthn/credentials", webAuthnHandler.ListCredentialsHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/webauthn/credentials/{id}", webAuthnHandler.DeleteCredentialHandler).Methods("DELETE", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/magic-link", passwordlessHandler.MagicLinkHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/magic-link/verify", passwordlessHandler.VerifyMagicLinkHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/email-otp", passwordlessHandler.EmailOTPHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/email-otp/verify", passwordlessHandler.VerifyEmailOTPHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/providers", federatedHandler.ListProvidersHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/providers/{provider}/authorize", federatedHandler.AuthorizeHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/auth/providers/{provider}/callback", federatedHandler.CallbackHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/identities", federatedHandler.ListIdentitiesHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/auth/identities/{provider}", federatedHandler.UnlinkIdentityHandler).Methods("DELETE", "OPTIONS"))
+
+	// OAuth 2.0: la pantalla de login/consentimiento y el endpoint de tokens de los clientes
+	routeAuth.Declare(public, router.HandleFunc("/oauth/authorize", oauthHandler.AuthorizeHandler).Methods("GET", "POST"))
+	routeAuth.Declare(public, router.HandleFunc("/oauth/token", oauthHandler.TokenHandler).Methods("POST", "OPTIONS"))
+
+	// OpenID Connect: datos del usuario para los clientes con el scope openid y cierre de sesión.
+	// Es la única ruta con token que aceptan los clientes OAuth (ver Authenticated)
+	routeAuth.Declare(authenticated.WithScopes(models.OAUTH_SCOPE_OPENID), router.HandleFunc("/userinfo", oauthHandler.UserInfoHandler).Methods("GET", "POST", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/oauth/logout", oauthHandler.EndSessionHandler).Methods("GET", "POST"))
+
+	// C. Perfil del usuario autenticado
+	routeAuth.Declare(authenticated, router.HandleFunc("/users/me", userHandler.GetProfileHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(authenticated, router.HandleFunc("/users/me", userHandler.UpdateProfileHandler).Methods("PATCH", "OPTIONS"))
+
+	// Organizaciones del usuario autenticado
+	routeAuth.Declare(authenticated, router.HandleFunc("/orgs", organizationHandler.ListOrganizationsHandler).Methods("GET", "OPTIONS"))
+
+	// Invitaciones a la organización del token: exigen el permiso de invitar miembros
+	inviteMembers := authenticated.WithPermissions(rbac.PERMISSION_MEMBERS_INVITE)
+	routeAuth.Declare(inviteMembers, router.HandleFunc("/orgs/invitations", invitationHandler.SendInvitationHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(inviteMembers, router.HandleFunc("/orgs/invitations", invitationHandler.ListInvitationsHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(inviteMembers, router.HandleFunc("/orgs/invitations/{id}/resend", invitationHandler.ResendInvitationHandler).Methods("POST", "OPTIONS"))
+	routeAuth.Declare(inviteMembers, router.HandleFunc("/orgs/invitations/{id}", invitationHandler.RevokeInvitationHandler).Methods("DELETE", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/accept-invitation", invitationHandler.AcceptInvitationHandler).Methods("POST", "OPTIONS"))
+
+	// Gestión de roles: además del access token, la ruta exige un permiso del rol
+	manageUsers := authenticated.WithPermissions(rbac.PERMISSION_USERS_MANAGE)
+	routeAuth.Declare(manageUsers, router.HandleFunc("/users/{id}/role", userHandler.UpdateRoleHandler).Methods("PUT", "OPTIONS"))
+
+	// D. Claves públicas para que otros servicios verifiquen nuestros tokens
+	routeAuth.Declare(public, router.HandleFunc("/.well-known/jwks.json", handlers.JWKSHandler).Methods("GET", "OPTIONS"))
+	routeAuth.Declare(public, router.HandleFunc("/.well-known/openid-configuration", handlers.OpenIDConfigurationHandler).Methods("GET", "OPTIONS"))
+
+	// E. Administración (X-Admin-Key en lugar de access token)
+	adminKey := middlewares.AdminKey()
+	admin := router.PathPrefix("/admin").Subrouter()
+	routeAuth.Declare(adminKey, admin.HandleFunc("/users/{id}/unlock", adminHandler.UnlockUserHandler).Methods("POST"))
+	routeAuth.Declare(adminKey, admin.HandleFunc("/users/{id}/role", adminHandler.SetRoleHandler).Methods("PUT"))
+	routeAuth.Declare(adminKey, admin.HandleFunc("/oauth/clients", oauthHandler.CreateClientHandler).Methods("POST"))
+	routeAuth.Declare(adminKey, admin.HandleFunc("/oauth/clients", oauthHandler.ListClientsHandler).Methods("GET"))
+	routeAuth.Declare(adminKey, admin.HandleFunc("/oauth/clients/{id}", oauthHandler.DeleteClientHandler).Methods("DELETE"))
+
+	// F. Health check
+	routeAuth.Declare(public, router.HandleFunc("/health", healthHandler).Methods("GET", "OPTIONS"))
 
-	// C. Health check
-	router.HandleFunc("/health", healthHandler).Methods("GET", "OPTIONS")
+	// 3. Ninguna ruta puede quedar sin autenticación declarada
+	if err := routeAuth.Verify(router); err != nil {
+		log.Fatalf("Invalid route configuration: %v", err)
+	}
 
 	return router
 }
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"log"
+	"myproject/internal/db"
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/memory"
+	"myproject/internal/repositories/mongo"
+)
+
+// repositorySet agrupa los repositorios del backend de almacenamiento elegido
+type repositorySet struct {
+	users          repositories.UserRepository
+	refreshTokens  repositories.RefreshTokenRepository
+	revokedTokens  repositories.RevokedTokenRepository
+	sessions       repositories.SessionRepository
+	passwordResets repositories.PasswordResetRepository
+	loginAttempts  repositories.LoginAttemptRepository
+
+	webAuthnCredentials repositories.WebAuthnCredentialRepository
+	webAuthnChallenges  repositories.WebAuthnChallengeRepository
+	emailLogins         repositories.EmailLoginRepository
+	oauthClients        repositories.OAuthClientRepository
+	authorizationCodes  repositories.AuthorizationCodeRepository
+	identities          repositories.IdentityRepository
+	federatedStates     repositories.FederatedStateRepository
+	organizations       repositories.OrganizationRepository
+	memberships         repositories.MembershipRepository
+	invitations         repositories.InvitationRepository
+}
+
+// newRepositorySet crea los repositorios según STORAGE_BACKEND. La conexión al backend
+// debe estar inicializada (ver cmd/api/main.go).
+func newRepositorySet() repositorySet {
+	switch backend := db.GetStorageBackend(); backend {
+	case db.STORAGE_DYNAMODB:
+		dynamoClient := db.GetDynamoClient()
+		return repositorySet{
+			users:          repositories.NewUserRepository(dynamoClient),
+			refreshTokens:  repositories.NewRefreshTokenRepository(dynamoClient),
+			revokedTokens:  repositories.NewRevokedTokenRepository(dynamoClient),
+			sessions:       repositories.NewSessionRepository(dynamoClient),
+			passwordResets: repositories.NewPasswordResetRepository(dynamoClient),
+			loginAttempts:  repositories.NewLoginAttemptRepository(dynamoClient),
+
+			webAuthnCredentials: repositories.NewWebAuthnCredentialRepository(dynamoClient),
+			webAuthnChallenges:  repositories.NewWebAuthnChallengeRepository(dynamoClient),
+			emailLogins:         repositories.NewEmailLoginRepository(dynamoClient),
+			oauthClients:        repositories.NewOAuthClientRepository(dynamoClient),
+			authorizationCodes:  repositories.NewAuthorizationCodeRepository(dynamoClient),
+			identities:          repositories.NewIdentityRepository(dynamoClient),
+			federatedStates:     repositories.NewFederatedStateRepository(dynamoClient),
+			organizations:       repositories.NewOrganizationRepository(dynamoClient),
+			memberships:         repositories.NewMembershipRepository(dynamoClient),
+			invitations:         repositories.NewInvitationRepository(dynamoClient),
+		}
+
+	case db.STORAGE_MONGODB:
+		database := db.GetMongoDatabase()
+		return repositorySet{
+			users:          mongo.NewUserRepository(database),
+			refreshTokens:  mongo.NewRefreshTokenRepository(database),
+			revokedTokens:  mongo.NewRevokedTokenRepository(database),
+			sessions:       mongo.NewSessionRepository(database),
+			passwordResets: mongo.NewPasswordResetRepository(database),
+			loginAttempts:  mongo.NewLoginAttemptRepository(database),
+
+			webAuthnCredentials: mongo.NewWebAuthnCredentialRepository(database),
+			webAuthnChallenges:  mongo.NewWebAuthnChallengeRepository(database),
+			emailLogins:         mongo.NewEmailLoginRepository(database),
+			oauthClients:        mongo.NewOAuthClientRepository(database),
+			authorizationCodes:  mongo.NewAuthorizationCodeRepository(database),
+			identities:          mongo.NewIdentityRepository(database),
+			federatedStates:     mongo.NewFederatedStateRepository(database),
+			organizations:       mongo.NewOrganizationRepository(database),
+			memberships:         mongo.NewMembershipRepository(database),
+			invitations:         mongo.NewInvitationRepository(database),
+		}
+
+	case db.STORAGE_MEMORY:
+		log.Println("Warning: using in-memory storage, data will be lost on restart")
+		return repositorySet{
+			users:          memory.NewUserRepository(),
+			refreshTokens:  memory.NewRefreshTokenRepository(),
+			revokedTokens:  memory.NewRevokedTokenRepository(),
+			sessions:       memory.NewSessionRepository(),
+			passwordResets: memory.NewPasswordResetRepository(),
+			loginAttempts:  memory.NewLoginAttemptRepository(),
+
+			webAuthnCredentials: memory.NewWebAuthnCredentialRepository(),
+			webAuthnChallenges:  memory.NewWebAuthnChallengeRepository(),
+			emailLogins:         memory.NewEmailLoginRepository(),
+			oauthClients:        memory.NewOAuthClientRepository(),
+			authorizationCodes:  memory.NewAuthorizationCodeRepository(),
+			identities:          memory.NewIdentityRepository(),
+			federatedStates:     memory.NewFederatedStateRepository(),
+			organizations:       memory.NewOrganizationRepository(),
+			memberships:         memory.NewMembershipRepository(),
+			invitations:         memory.NewInvitationRepository(),
+		}
+
+	default:
+		log.Fatalf("Unknown STORAGE_BACKEND %q (expected %s, %s or %s)", backend, db.STORAGE_DYNAMODB, db.STORAGE_MONGODB, db.STORAGE_MEMORY)
+		return repositorySet{}
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+var mongoClient *mongo.Client
+
+// getMongoURI retorna la URI de conexión a MongoDB desde variables de entorno
+func getMongoURI() string {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		return "mongodb://localhost:27017" // valor por defecto para desarrollo
+	}
+	return uri
+}
+
+// getMongoDatabaseName retorna el nombre de la base de datos desde variables de entorno
+func getMongoDatabaseName() string {
+	name := os.Getenv("MONGO_DATABASE")
+	if name == "" {
+		return "login" // nombre por defecto
+	}
+	return name
+}
+
+// ConnectMongoDB inicializa la conexión a MongoDB
+func ConnectMongoDB() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(getMongoURI()))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	mongoClient = client
+
+	log.Printf("MongoDB client initialized successfully - Database: %s", getMongoDatabaseName())
+}
+
+// GetMongoDatabase retorna la base de datos de MongoDB configurada
+func GetMongoDatabase() *mongo.Database {
+	if mongoClient == nil {
+		log.Fatal("MongoDB client not initialized. Call ConnectMongoDB() first.")
+	}
+	return mongoClient.Database(getMongoDatabaseName())
+}
+
+// TestMongoDBConnection verifica si realmente podemos conectarnos a MongoDB
+func TestMongoDBConnection() error {
+	if mongoClient == nil {
+		return errors.New("MongoDB client not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := mongoClient.Ping(ctx, readpref.Primary()); err != nil {
+		log.Printf("❌ MongoDB connection test failed: %v", err)
+		return err
+	}
+
+	log.Println("✅ MongoDB connection test successful")
+	return nil
+}
+
+// DisconnectMongoDB cierra la conexión a MongoDB
+func DisconnectMongoDB() {
+	if mongoClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := mongoClient.Disconnect(ctx); err != nil {
+		log.Printf("Error disconnecting from MongoDB: %v", err)
+	}
+	mongoClient = nil
+	log.Println("MongoDB client disconnected")
+}
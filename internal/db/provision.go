@@ -0,0 +1,235 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// PROVISION_WAIT_TIMEOUT es el tiempo máximo de espera para que una tabla o índice quede ACTIVE
+const PROVISION_WAIT_TIMEOUT = 10 * time.Minute
+
+const provisionPollInterval = 2 * time.Second
+
+// TableSchema describe una tabla: clave primaria, índices secundarios globales y atributo TTL.
+// Todas las claves son de tipo string.
+type TableSchema struct {
+	Name          string
+	PartitionKey  string
+	GlobalIndexes []GlobalIndex
+	TTLAttribute  string
+}
+
+// GlobalIndex es un GSI con proyección completa y una única clave de partición.
+type GlobalIndex struct {
+	Name         string
+	PartitionKey string
+}
+
+// EnsureTables crea las tablas, índices y TTL que falten. Es idempotente: no modifica ni elimina
+// lo que ya existe, por lo que puede ejecutarse en cada despliegue.
+func EnsureTables(ctx context.Context, client *dynamodb.Client, schemas []TableSchema) error {
+	for _, schema := range schemas {
+		if err := ensureTable(ctx, client, schema); err != nil {
+			return fmt.Errorf("tabla %s: %w", schema.Name, err)
+		}
+	}
+	return nil
+}
+
+// ensureTable crea la tabla si no existe; si existe, agrega los índices faltantes.
+func ensureTable(ctx context.Context, client *dynamodb.Client, schema TableSchema) error {
+	table, err := describeTable(ctx, client, schema.Name)
+	if err != nil {
+		return err
+	}
+
+	if table == nil {
+		if err := createTable(ctx, client, schema); err != nil {
+			return err
+		}
+	} else {
+		if err := createMissingIndexes(ctx, client, schema, table); err != nil {
+			return err
+		}
+	}
+
+	return ensureTTL(ctx, client, schema)
+}
+
+// describeTable retorna la descripción de la tabla o nil si no existe
+func describeTable(ctx context.Context, client *dynamodb.Client, name string) (*types.TableDescription, error) {
+	out, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+	if err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out.Table, nil
+}
+
+func createTable(ctx context.Context, client *dynamodb.Client, schema TableSchema) error {
+	input := &dynamodb.CreateTableInput{
+		TableName:            aws.String(schema.Name),
+		BillingMode:          types.BillingModePayPerRequest,
+		AttributeDefinitions: schema.attributeDefinitions(),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(schema.PartitionKey), KeyType: types.KeyTypeHash},
+		},
+	}
+	for _, index := range schema.GlobalIndexes {
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, index.definition())
+	}
+
+	log.Printf("Creating DynamoDB table %s", schema.Name)
+	if _, err := client.CreateTable(ctx, input); err != nil {
+		return err
+	}
+
+	return waitUntilActive(ctx, client, schema.Name, "")
+}
+
+// createMissingIndexes agrega los GSI que no existen. DynamoDB permite crear un solo índice
+// por UpdateTable, por eso se crean de a uno esperando a que cada uno quede ACTIVE.
+func createMissingIndexes(ctx context.Context, client *dynamodb.Client, schema TableSchema, table *types.TableDescription) error {
+	existing := map[string]bool{}
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		existing[aws.ToString(gsi.IndexName)] = true
+	}
+
+	for _, index := range schema.GlobalIndexes {
+		if existing[index.Name] {
+			continue
+		}
+
+		definition := index.definition()
+		log.Printf("Creating index %s on DynamoDB table %s", index.Name, schema.Name)
+		_, err := client.UpdateTable(ctx, &dynamodb.UpdateTableInput{
+			TableName:            aws.String(schema.Name),
+			AttributeDefinitions: schema.attributeDefinitions(),
+			GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+				{Create: &types.CreateGlobalSecondaryIndexAction{
+					IndexName:  definition.IndexName,
+					KeySchema:  definition.KeySchema,
+					Projection: definition.Projection,
+				}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := waitUntilActive(ctx, client, schema.Name, index.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureTTL habilita el TTL sobre el atributo configurado si todavía no lo está
+func ensureTTL(ctx context.Context, client *dynamodb.Client, schema TableSchema) error {
+	if schema.TTLAttribute == "" {
+		return nil
+	}
+
+	out, err := client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(schema.Name)})
+	if err != nil {
+		return err
+	}
+
+	if desc := out.TimeToLiveDescription; desc != nil {
+		switch desc.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			return nil
+		}
+	}
+
+	log.Printf("Enabling TTL (%s) on DynamoDB table %s", schema.TTLAttribute, schema.Name)
+	_, err = client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(schema.Name),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(schema.TTLAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	return err
+}
+
+// waitUntilActive espera a que la tabla (y el índice, si se indica) estén ACTIVE
+func waitUntilActive(ctx context.Context, client *dynamodb.Client, tableName, indexName string) error {
+	ctx, cancel := context.WithTimeout(ctx, PROVISION_WAIT_TIMEOUT)
+	defer cancel()
+
+	for {
+		table, err := describeTable(ctx, client, tableName)
+		if err != nil {
+			return err
+		}
+
+		if table != nil && table.TableStatus == types.TableStatusActive && isIndexActive(table, indexName) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(provisionPollInterval):
+		}
+	}
+}
+
+func isIndexActive(table *types.TableDescription, indexName string) bool {
+	if indexName == "" {
+		return true
+	}
+	for _, gsi := range table.GlobalSecondaryIndexes {
+		if aws.ToString(gsi.IndexName) == indexName {
+			return gsi.IndexStatus == types.IndexStatusActive
+		}
+	}
+	return false
+}
+
+func (index GlobalIndex) definition() types.GlobalSecondaryIndex {
+	return types.GlobalSecondaryIndex{
+		IndexName: aws.String(index.Name),
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String(index.PartitionKey), KeyType: types.KeyTypeHash},
+		},
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+}
+
+// attributeDefinitions declara el tipo de la clave primaria y de las claves de los índices
+func (schema TableSchema) attributeDefinitions() []types.AttributeDefinition {
+	seen := map[string]bool{}
+	var definitions []types.AttributeDefinition
+	for _, name := range append([]string{schema.PartitionKey}, schema.indexKeys()...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		definitions = append(definitions, types.AttributeDefinition{
+			AttributeName: aws.String(name),
+			AttributeType: types.ScalarAttributeTypeS,
+		})
+	}
+	return definitions
+}
+
+func (schema TableSchema) indexKeys() []string {
+	keys := make([]string, 0, len(schema.GlobalIndexes))
+	for _, index := range schema.GlobalIndexes {
+		keys = append(keys, index.PartitionKey)
+	}
+	return keys
+}
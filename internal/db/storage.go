@@ -0,0 +1,20 @@
+package db
+
+import "os"
+
+// Backends de almacenamiento soportados (STORAGE_BACKEND)
+const (
+	STORAGE_DYNAMODB = "dynamodb"
+	STORAGE_MONGODB  = "mongodb"
+	// STORAGE_MEMORY guarda todo en memoria: solo para desarrollo, se pierde al reiniciar
+	STORAGE_MEMORY = "memory"
+)
+
+// GetStorageBackend retorna el backend de almacenamiento desde variables de entorno
+func GetStorageBackend() string {
+	backend := os.Getenv("STORAGE_BACKEND")
+	if backend == "" {
+		return STORAGE_DYNAMODB // backend por defecto
+	}
+	return backend
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler maneja las operaciones de administración (protegidas por AdminKeyMiddleware).
+type AdminHandler struct {
+	lockoutService services.LockoutService
+	userService    services.UserService
+}
+
+// NewAdminHandler crea una nueva instancia de AdminHandler.
+func NewAdminHandler(ls services.LockoutService, us services.UserService) *AdminHandler {
+	return &AdminHandler{
+		lockoutService: ls,
+		userService:    us,
+	}
+}
+
+// UnlockUserHandler levanta el bloqueo por intentos de login fallidos de un usuario.
+func (h *AdminHandler) UnlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if err := h.lockoutService.UnlockUser(r.Context(), userID); err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// SetRoleHandler asigna cualquier rol a un usuario, sin las restricciones de jerarquía de
+// PUT /users/{id}/role. Sirve para designar al primer OWNER.
+func (h *AdminHandler) SetRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var roleReq request.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&roleReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.SetRole(r.Context(), mux.Vars(r)["id"], roleReq.Role); err != nil {
+		writeRoleError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
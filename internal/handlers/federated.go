@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// federatedBindingCookie es la cookie HttpOnly que ata el login con un proveedor externo al
+// navegador que lo inició (ver services.FederatedAuthorization)
+const federatedBindingCookie = "federated_login"
+
+// federatedCookiePath limita la cookie a las rutas del login con proveedores externos
+const federatedCookiePath = "/auth/providers"
+
+// FederatedHandler maneja las solicitudes HTTP del login con proveedores de identidad externos.
+type FederatedHandler struct {
+	federatedService services.FederatedService
+}
+
+// NewFederatedHandler crea una nueva instancia de FederatedHandler.
+func NewFederatedHandler(fs services.FederatedService) *FederatedHandler {
+	return &FederatedHandler{
+		federatedService: fs,
+	}
+}
+
+// ListProvidersHandler lista los proveedores externos con los que se puede iniciar sesión.
+func (h *FederatedHandler) ListProvidersHandler(w http.ResponseWriter, r *http.Request) {
+	providers := []response.IdentityProviderResponse{}
+	for _, provider := range h.federatedService.ListProviders() {
+		providers = append(providers, response.IdentityProviderResponse{ID: provider.ID, Name: provider.Name})
+	}
+
+	response.ResponseSuccess(w, providers, http.StatusOK)
+}
+
+// AuthorizeHandler inicia el login con el proveedor y retorna la URL a la que redirigir al usuario.
+// El binding del login queda en una cookie HttpOnly que el callback exige.
+func (h *FederatedHandler) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	authorization, err := h.federatedService.BeginLogin(r.Context(), mux.Vars(r)["provider"])
+	if err != nil {
+		writeFederatedLoginError(w, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedBindingCookie,
+		Value:    authorization.Binding,
+		Path:     federatedCookiePath,
+		MaxAge:   int(services.FEDERATED_STATE_DURATION.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	response.ResponseSuccess(w, response.FederatedAuthorizationResponse{
+		AuthorizationURL: authorization.AuthorizationURL,
+		State:            authorization.State,
+		ExpiresIn:        int(services.FEDERATED_STATE_DURATION.Seconds()),
+	}, http.StatusOK)
+}
+
+// CallbackHandler completa el login con el code y el state con los que volvió el usuario y
+// retorna los tokens de la sesión, igual que LoginHandler. Sin la cookie del binding que dejó
+// AuthorizeHandler en este navegador, el state se rechaza.
+func (h *FederatedHandler) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	var callbackReq request.FederatedCallbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&callbackReq); err != nil || callbackReq.Code == "" || callbackReq.State == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	var binding string
+	if cookie, err := r.Cookie(federatedBindingCookie); err == nil {
+		binding = cookie.Value
+	}
+	// El state sirve una sola vez: la cookie ya no se necesita
+	http.SetCookie(w, &http.Cookie{
+		Name:     federatedBindingCookie,
+		Path:     federatedCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	result, err := h.federatedService.FinishLogin(r.Context(), mux.Vars(r)["provider"], callbackReq.State, binding, callbackReq.Code, getClientInfo(r))
+	if err != nil {
+		writeFederatedLoginError(w, err)
+		return
+	}
+
+	writeLoginResult(w, result)
+}
+
+// ListIdentitiesHandler lista las cuentas externas vinculadas al usuario autenticado.
+func (h *FederatedHandler) ListIdentitiesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.federatedService.ListIdentities(r.Context(), claims.Subject)
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, identities, http.StatusOK)
+}
+
+// UnlinkIdentityHandler desvincula las cuentas del proveedor del usuario autenticado.
+func (h *FederatedHandler) UnlinkIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.federatedService.UnlinkIdentity(r.Context(), claims.Subject, mux.Vars(r)["provider"]); err != nil {
+		if errors.Is(err, validations.ErrIdentityNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// writeFederatedLoginError responde un login con proveedor externo fallido
+func writeFederatedLoginError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, validations.ErrIdentityProviderNotFound):
+		response.ResponseError(w, err, http.StatusNotFound)
+	case errors.Is(err, validations.ErrFederatedStateInvalid):
+		response.ResponseError(w, err, http.StatusBadRequest)
+	case errors.Is(err, validations.ErrIdentityProviderUnavailable):
+		response.ResponseError(w, err, http.StatusBadGateway)
+	case errors.Is(err, validations.ErrFederatedEmailNotVerified), errors.Is(err, validations.ErrFederatedAccountNotFound):
+		response.ResponseError(w, err, http.StatusForbidden)
+	default:
+		writeLoginError(w, err)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/models"
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// InvitationHandler maneja las solicitudes HTTP de las invitaciones a la organización del token.
+type InvitationHandler struct {
+	invitationService services.InvitationService
+}
+
+// NewInvitationHandler crea una nueva instancia de InvitationHandler.
+func NewInvitationHandler(is services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{
+		invitationService: is,
+	}
+}
+
+// SendInvitationHandler invita un email a la organización activa con un rol.
+func (h *InvitationHandler) SendInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var invitationReq request.SendInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&invitationReq); err != nil || invitationReq.Email == "" || invitationReq.Role == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	invitation, err := h.invitationService.Invite(r.Context(), claims.OrganizationID, claims.Subject, invitationReq.Email, invitationReq.Role)
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, newInvitationResponse(invitation), http.StatusCreated)
+}
+
+// ListInvitationsHandler lista las invitaciones vigentes de la organización activa.
+func (h *InvitationHandler) ListInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	invitations, err := h.invitationService.ListInvitations(r.Context(), claims.OrganizationID, claims.Subject)
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	result := make([]response.InvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		result = append(result, newInvitationResponse(&invitations[i]))
+	}
+
+	response.ResponseSuccess(w, result, http.StatusOK)
+}
+
+// ResendInvitationHandler reenvía una invitación con un link nuevo.
+func (h *InvitationHandler) ResendInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	invitation, err := h.invitationService.ResendInvitation(r.Context(), claims.OrganizationID, claims.Subject, mux.Vars(r)["id"])
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, newInvitationResponse(invitation), http.StatusOK)
+}
+
+// RevokeInvitationHandler revoca una invitación pendiente.
+func (h *InvitationHandler) RevokeInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.invitationService.RevokeInvitation(r.Context(), claims.OrganizationID, claims.Subject, mux.Vars(r)["id"]); err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// AcceptInvitationHandler acepta la invitación del link. No requiere access token: el token de la
+// invitación identifica al destinatario, que puede no tener cuenta todavía.
+func (h *InvitationHandler) AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	var acceptReq request.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&acceptReq); err != nil || acceptReq.Token == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	accepted, err := h.invitationService.AcceptInvitation(r.Context(), acceptReq)
+	if err != nil {
+		writeInvitationError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, response.AcceptInvitationResponse{
+		OrganizationID:   accepted.Organization.ID,
+		OrganizationName: accepted.Organization.Name,
+		Role:             accepted.Role,
+		Registered:       accepted.Registered,
+	}, http.StatusOK)
+}
+
+func newInvitationResponse(invitation *models.Invitation) response.InvitationResponse {
+	return response.InvitationResponse{
+		ID:        invitation.ID,
+		Email:     invitation.Email,
+		Role:      invitation.Role,
+		InvitedBy: invitation.InvitedBy,
+		CreatedAt: invitation.CreatedAt,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+}
+
+// writeInvitationError responde una operación sobre invitaciones fallida
+func writeInvitationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, validations.ErrInvalidEmail), errors.Is(err, validations.ErrInvalidRole),
+		errors.Is(err, validations.ErrOrganizationRequired), errors.Is(err, validations.ErrInvitationInvalid),
+		errors.Is(err, validations.ErrRegistrationRequired), errors.Is(err, validations.ErrInvalidName),
+		errors.Is(err, validations.ErrInvalidLastName), errors.Is(err, validations.ErrPasswordChars),
+		errors.Is(err, validations.ErrPasswordComplexity):
+		response.ResponseError(w, err, http.StatusBadRequest)
+	case errors.Is(err, validations.ErrForbidden), errors.Is(err, validations.ErrRoleNotAssignable),
+		errors.Is(err, validations.ErrUserInactive):
+		response.ResponseError(w, err, http.StatusForbidden)
+	case errors.Is(err, validations.ErrCompanyNotFound), errors.Is(err, validations.ErrInvitationNotFound):
+		response.ResponseError(w, err, http.StatusNotFound)
+	case errors.Is(err, validations.ErrInvitationPending), errors.Is(err, validations.ErrAlreadyMember),
+		errors.Is(err, services.ErrUserAlreadyExists):
+		response.ResponseError(w, err, http.StatusConflict)
+	default:
+		response.ResponseError(w, err, http.StatusInternalServerError)
+	}
+}
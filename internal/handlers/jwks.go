@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/response"
+)
+
+// JWKSHandler publica las claves públicas de verificación como JWK Set (RFC 7517).
+// La respuesta no usa el envoltorio de response.Response porque la consumen librerías estándar.
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	keySet, err := tokens.GetKeySet()
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keySet.JWKS())
+}
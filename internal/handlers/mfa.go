@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+)
+
+// MFAHandler maneja las solicitudes HTTP del segundo factor de autenticación.
+type MFAHandler struct {
+	mfaService     services.MFAService
+	sessionService services.SessionService
+}
+
+// NewMFAHandler crea una nueva instancia de MFAHandler.
+func NewMFAHandler(ms services.MFAService, ss services.SessionService) *MFAHandler {
+	return &MFAHandler{
+		mfaService:     ms,
+		sessionService: ss,
+	}
+}
+
+// SetupTOTPHandler inicia el alta de TOTP del usuario autenticado y retorna el secreto.
+func (h *MFAHandler) SetupTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	setup, err := h.mfaService.SetupTOTP(r.Context(), claims.Subject)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrMFAAlreadyEnabled):
+			response.ResponseError(w, err, http.StatusConflict)
+		case errors.Is(err, validations.ErrDocumentNotFound):
+			response.ResponseError(w, err, http.StatusNotFound)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, response.TOTPSetupResponse{Secret: setup.Secret, OTPAuthURI: setup.URI}, http.StatusOK)
+}
+
+// VerifyTOTPHandler confirma el alta con un código de la app y retorna los códigos de recuperación.
+func (h *MFAHandler) VerifyTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var verifyReq request.VerifyTOTPRequest
+	if err := json.NewDecoder(r.Body).Decode(&verifyReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	codes, err := h.mfaService.VerifyTOTP(r.Context(), claims.Subject, verifyReq.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrMFAAlreadyEnabled):
+			response.ResponseError(w, err, http.StatusConflict)
+		case errors.Is(err, validations.ErrMFASetupNotStarted), errors.Is(err, validations.ErrInvalidMFACode):
+			response.ResponseError(w, err, http.StatusBadRequest)
+		case errors.Is(err, validations.ErrDocumentNotFound):
+			response.ResponseError(w, err, http.StatusNotFound)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, response.RecoveryCodesResponse{RecoveryCodes: codes}, http.StatusOK)
+}
+
+// ChallengeHandler completa un login con MFA y retorna los tokens de la sesión.
+func (h *MFAHandler) ChallengeHandler(w http.ResponseWriter, r *http.Request) {
+	var challengeReq request.MFAChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&challengeReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	newTokens, err := h.sessionService.CompleteMFAChallenge(r.Context(), challengeReq.ChallengeToken, challengeReq.Code, challengeReq.RecoveryCode, getClientInfo(r))
+	if err != nil {
+		if errors.Is(err, validations.ErrAccountLocked) {
+			setRetryAfter(w, err)
+			response.ResponseError(w, err, http.StatusTooManyRequests)
+			return
+		}
+		response.ResponseError(w, err, http.StatusUnauthorized)
+		return
+	}
+
+	response.ResponseSuccess(w, newTokens, http.StatusOK)
+}
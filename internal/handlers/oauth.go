@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
+)
+
+//go:embed templates/*.html
+var oauthTemplatesFS embed.FS
+
+// oauthTemplates son las páginas que muestra la API en el navegador (login/consentimiento y cierre de sesión)
+var oauthTemplates = template.Must(template.ParseFS(oauthTemplatesFS, "templates/*.html"))
+
+// authorizePage son los datos de la pantalla de login y consentimiento de /oauth/authorize.
+type authorizePage struct {
+	ClientName  string
+	Scopes      []string
+	Params      map[string]string
+	Email       string
+	Error       string
+	MFARequired bool
+	// Fatal indica un error que no puede informarse al cliente (client_id o redirect_uri inválidos)
+	Fatal bool
+}
+
+// OAuthHandler maneja las solicitudes HTTP del servidor de autorización OAuth 2.0.
+type OAuthHandler struct {
+	oauthService   services.OAuthService
+	sessionService services.SessionService
+}
+
+// NewOAuthHandler crea una nueva instancia de OAuthHandler.
+func NewOAuthHandler(oas services.OAuthService, ss services.SessionService) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService:   oas,
+		sessionService: ss,
+	}
+}
+
+// AuthorizeHandler muestra la pantalla de login y consentimiento (GET) y la procesa (POST).
+// Al aprobar redirige a la redirect_uri con el código; al cancelar, con error=access_denied.
+func (h *OAuthHandler) AuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderAuthorizePage(w, &authorizePage{Fatal: true, Error: "Solicitud inválida."}, http.StatusBadRequest)
+		return
+	}
+
+	// 1. Validar la solicitud; los parámetros viajan en la query (GET) o en campos ocultos (POST)
+	authorizeReq := request.AuthorizeRequest{
+		ResponseType:        r.Form.Get("response_type"),
+		ClientID:            r.Form.Get("client_id"),
+		RedirectURI:         r.Form.Get("redirect_uri"),
+		Scope:               r.Form.Get("scope"),
+		State:               r.Form.Get("state"),
+		CodeChallenge:       r.Form.Get("code_challenge"),
+		CodeChallengeMethod: r.Form.Get("code_challenge_method"),
+		Nonce:               r.Form.Get("nonce"),
+	}
+
+	authorization, err := h.oauthService.ValidateAuthorizeRequest(r.Context(), authorizeReq)
+	if err != nil {
+		var oauthErr *validations.OAuthError
+		switch {
+		case errors.As(err, &oauthErr) && authorization != nil:
+			http.Redirect(w, r, authorization.ErrorRedirectURL(oauthErr), http.StatusFound)
+		case errors.Is(err, validations.ErrOAuthClientNotFound):
+			renderAuthorizePage(w, &authorizePage{Fatal: true, Error: "La aplicación no está registrada."}, http.StatusBadRequest)
+		case errors.Is(err, validations.ErrOAuthRedirectURIInvalid):
+			renderAuthorizePage(w, &authorizePage{Fatal: true, Error: "La dirección de retorno no está registrada para la aplicación."}, http.StatusBadRequest)
+		default:
+			log.Printf("AuthorizeHandler: %v", err)
+			renderAuthorizePage(w, &authorizePage{Fatal: true, Error: "Ocurrió un error, intentá de nuevo."}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	page := &authorizePage{
+		ClientName: authorization.Client.Name,
+		Scopes:     strings.Fields(authorization.Scope),
+		Params: map[string]string{
+			"response_type":         authorizeReq.ResponseType,
+			"client_id":             authorization.Client.ID,
+			"redirect_uri":          authorization.RedirectURI,
+			"scope":                 authorization.Scope,
+			"state":                 authorization.State,
+			"code_challenge":        authorization.CodeChallenge,
+			"code_challenge_method": authorization.CodeChallengeMethod,
+			"nonce":                 authorization.Nonce,
+		},
+	}
+
+	if r.Method != http.MethodPost {
+		renderAuthorizePage(w, page, http.StatusOK)
+		return
+	}
+
+	// 2. El usuario canceló
+	if r.PostForm.Get("action") != "approve" {
+		deny := validations.NewOAuthError(validations.OAUTH_ACCESS_DENIED, "the user denied the request")
+		http.Redirect(w, r, authorization.ErrorRedirectURL(deny), http.StatusFound)
+		return
+	}
+
+	// 3. Autenticar al usuario (contraseña y, si lo tiene habilitado, TOTP)
+	page.Email = r.PostForm.Get("email")
+	user, err := h.sessionService.Authenticate(r.Context(), page.Email, r.PostForm.Get("password"), r.PostForm.Get("mfa_code"), getClientInfo(r))
+	if err != nil {
+		status := http.StatusUnauthorized
+		switch {
+		case errors.Is(err, validations.ErrMFARequired):
+			page.MFARequired = true
+			page.Error = "Ingresá de nuevo tu contraseña y el código de tu app de autenticación."
+		case errors.Is(err, validations.ErrInvalidMFACode):
+			page.MFARequired = true
+			page.Error = "El código de verificación es incorrecto."
+		case errors.Is(err, validations.ErrInvalidCredentials):
+			page.Error = "Email o contraseña incorrectos."
+		case errors.Is(err, validations.ErrAccountLocked):
+			setRetryAfter(w, err)
+			status = http.StatusTooManyRequests
+			page.Error = "Demasiados intentos fallidos, esperá unos minutos."
+		case errors.Is(err, validations.ErrEmailNotVerified):
+			status = http.StatusForbidden
+			page.Error = "Tenés que verificar tu email antes de continuar."
+		case errors.Is(err, validations.ErrUserInactive):
+			status = http.StatusForbidden
+			page.Error = "La cuenta no está activa."
+		default:
+			log.Printf("AuthorizeHandler: %v", err)
+			status = http.StatusInternalServerError
+			page.Error = "Ocurrió un error, intentá de nuevo."
+		}
+		renderAuthorizePage(w, page, status)
+		return
+	}
+
+	// 4. Emitir el código y volver a la aplicación
+	redirectURL, err := h.oauthService.IssueAuthorizationCode(r.Context(), authorization, user)
+	if err != nil {
+		log.Printf("AuthorizeHandler: %v", err)
+		serverErr := validations.NewOAuthError(validations.OAUTH_SERVER_ERROR, "")
+		http.Redirect(w, r, authorization.ErrorRedirectURL(serverErr), http.StatusFound)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
+
+// TokenHandler implementa /oauth/token (application/x-www-form-urlencoded). El cliente se
+// autentica con HTTP Basic o con client_id y client_secret en el formulario.
+func (h *OAuthHandler) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, validations.NewOAuthError(validations.OAUTH_INVALID_REQUEST, "invalid form body"), false)
+		return
+	}
+
+	tokenReq := request.OAuthTokenRequest{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientID:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+
+	// Con HTTP Basic las credenciales van codificadas como form-urlencoded (RFC 6749, sección 2.3.1)
+	clientID, clientSecret, basicAuth := r.BasicAuth()
+	if basicAuth {
+		var idErr, secretErr error
+		tokenReq.ClientID, idErr = url.QueryUnescape(clientID)
+		tokenReq.ClientSecret, secretErr = url.QueryUnescape(clientSecret)
+		if idErr != nil || secretErr != nil {
+			writeOAuthError(w, validations.NewOAuthError(validations.OAUTH_INVALID_CLIENT, "invalid client credentials encoding"), true)
+			return
+		}
+	}
+
+	token, err := h.oauthService.Token(r.Context(), tokenReq, getClientInfo(r))
+	if err != nil {
+		var oauthErr *validations.OAuthError
+		if !errors.As(err, &oauthErr) {
+			log.Printf("TokenHandler: %v", err)
+			oauthErr = validations.NewOAuthError(validations.OAUTH_SERVER_ERROR, "")
+		}
+		writeOAuthError(w, oauthErr, basicAuth)
+		return
+	}
+
+	response.ResponseOAuth(w, response.OAuthTokenResponse{
+		AccessToken:  token.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    token.ExpiresIn,
+		RefreshToken: token.RefreshToken,
+		IDToken:      token.IDToken,
+		Scope:        token.Scope,
+	}, http.StatusOK)
+}
+
+// CreateClientHandler registra un cliente OAuth. El secreto solo se muestra en esta respuesta.
+func (h *OAuthHandler) CreateClientHandler(w http.ResponseWriter, r *http.Request) {
+	var clientReq request.CreateOAuthClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&clientReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	client, secret, err := h.oauthService.RegisterClient(r.Context(), clientReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrOAuthClientNameRequired),
+			errors.Is(err, validations.ErrOAuthRedirectURIInvalid),
+			errors.Is(err, validations.ErrOAuthGrantTypeInvalid),
+			errors.Is(err, validations.ErrOAuthScopeInvalid):
+			response.ResponseError(w, err, http.StatusBadRequest)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, response.NewOAuthClientResponse(client, secret), http.StatusCreated)
+}
+
+// ListClientsHandler lista los clientes OAuth registrados.
+func (h *OAuthHandler) ListClientsHandler(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.oauthService.ListClients(r.Context())
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	clientsResp := make([]*response.OAuthClientResponse, 0, len(clients))
+	for i := range clients {
+		clientsResp = append(clientsResp, response.NewOAuthClientResponse(&clients[i], ""))
+	}
+
+	response.ResponseSuccess(w, clientsResp, http.StatusOK)
+}
+
+// DeleteClientHandler elimina un cliente OAuth.
+func (h *OAuthHandler) DeleteClientHandler(w http.ResponseWriter, r *http.Request) {
+	if err := h.oauthService.DeleteClient(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, validations.ErrOAuthClientNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// writeOAuthError escribe un error de /oauth/token: invalid_client es 401 y el resto 400 (500 si es server_error).
+func writeOAuthError(w http.ResponseWriter, err *validations.OAuthError, basicAuth bool) {
+	status := http.StatusBadRequest
+	switch err.Code {
+	case validations.OAUTH_INVALID_CLIENT:
+		status = http.StatusUnauthorized
+		if basicAuth {
+			w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	case validations.OAUTH_SERVER_ERROR:
+		status = http.StatusInternalServerError
+	}
+
+	response.ResponseOAuth(w, response.OAuthErrorResponse{
+		Error:            err.Code,
+		ErrorDescription: err.Description,
+	}, status)
+}
+
+// renderAuthorizePage muestra la pantalla de /oauth/authorize.
+func renderAuthorizePage(w http.ResponseWriter, page *authorizePage, status int) {
+	renderOAuthPage(w, "oauth_authorize.html", page, status)
+}
+
+// renderOAuthPage muestra una página de templates/. No puede mostrarse dentro de un iframe,
+// para que otra página no capture la contraseña (clickjacking).
+func renderOAuthPage(w http.ResponseWriter, name string, data interface{}, status int) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	w.WriteHeader(status)
+	if err := oauthTemplates.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("renderOAuthPage: %v", err)
+	}
+}
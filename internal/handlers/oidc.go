@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"myproject/internal/models"
+	"myproject/internal/services"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+)
+
+// logoutPage son los datos de la página que se muestra al cerrar sesión sin post_logout_redirect_uri.
+type logoutPage struct {
+	Error string
+}
+
+// OpenIDConfigurationHandler publica los metadatos del proveedor OpenID Connect (OpenID Connect Discovery).
+// Como JWKSHandler, no usa el envoltorio de response.Response porque lo consumen librerías estándar.
+func OpenIDConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	keySet, err := tokens.GetKeySet()
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	// Sin una clave asimétrica no se emiten ID tokens (ver tokens.GenerateIDToken) y openid no se anuncia
+	scopes := []string{models.OAUTH_SCOPE_PROFILE, models.OAUTH_SCOPE_EMAIL}
+	if keySet.CanSignIDTokens() {
+		scopes = append([]string{models.OAUTH_SCOPE_OPENID}, scopes...)
+	}
+
+	apiURL := services.GetAPIURL()
+	config := response.OpenIDConfigurationResponse{
+		Issuer:                            tokens.Issuer(),
+		AuthorizationEndpoint:             apiURL + "/oauth/authorize",
+		TokenEndpoint:                     apiURL + "/oauth/token",
+		UserInfoEndpoint:                  apiURL + "/userinfo",
+		JWKSURI:                           apiURL + "/.well-known/jwks.json",
+		EndSessionEndpoint:                apiURL + "/oauth/logout",
+		ScopesSupported:                   scopes,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{models.OAUTH_GRANT_AUTHORIZATION_CODE, models.OAUTH_GRANT_REFRESH_TOKEN, models.OAUTH_GRANT_CLIENT_CREDENTIALS},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  keySet.IDTokenAlgorithms(),
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{services.OAUTH_CODE_CHALLENGE_S256},
+		ClaimsSupported: []string{
+			"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "sid",
+			"name", "given_name", "family_name", "birthdate", "email", "email_verified",
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(config)
+}
+
+// UserInfoHandler retorna los datos del usuario del access token según los scopes concedidos.
+// Requiere un token emitido a un cliente OAuth con el scope openid.
+func (h *OAuthHandler) UserInfoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.oauthService.UserInfo(r.Context(), claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrInsufficientScope):
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="openid"`)
+			response.ResponseOAuth(w, response.OAuthErrorResponse{Error: "insufficient_scope", ErrorDescription: "the openid scope is required"}, http.StatusForbidden)
+		case errors.Is(err, validations.ErrInvalidToken), errors.Is(err, validations.ErrUserInactive):
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			response.ResponseOAuth(w, response.OAuthErrorResponse{Error: "invalid_token"}, http.StatusUnauthorized)
+		default:
+			log.Printf("UserInfoHandler: %v", err)
+			response.ResponseOAuth(w, response.OAuthErrorResponse{Error: validations.OAUTH_SERVER_ERROR}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseOAuth(w, response.NewUserInfoResponse(user, claims.Scope), http.StatusOK)
+}
+
+// EndSessionHandler cierra la sesión del ID token (OpenID Connect RP-Initiated Logout) y vuelve a
+// la post_logout_redirect_uri, o muestra una página de confirmación si no se indicó ninguna.
+func (h *OAuthHandler) EndSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderOAuthPage(w, "oauth_logout.html", &logoutPage{Error: "Solicitud inválida."}, http.StatusBadRequest)
+		return
+	}
+
+	redirectURL, err := h.oauthService.EndSession(r.Context(), request.EndSessionRequest{
+		IDTokenHint:           r.Form.Get("id_token_hint"),
+		PostLogoutRedirectURI: r.Form.Get("post_logout_redirect_uri"),
+		ClientID:              r.Form.Get("client_id"),
+		State:                 r.Form.Get("state"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrInvalidToken):
+			renderOAuthPage(w, "oauth_logout.html", &logoutPage{Error: "El id_token_hint no es válido."}, http.StatusBadRequest)
+		case errors.Is(err, validations.ErrOAuthClientNotFound), errors.Is(err, validations.ErrOAuthRedirectURIInvalid):
+			renderOAuthPage(w, "oauth_logout.html", &logoutPage{Error: "La dirección de retorno no está registrada para la aplicación."}, http.StatusBadRequest)
+		default:
+			log.Printf("EndSessionHandler: %v", err)
+			renderOAuthPage(w, "oauth_logout.html", &logoutPage{Error: "Ocurrió un error, intentá de nuevo."}, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if redirectURL == "" {
+		renderOAuthPage(w, "oauth_logout.html", &logoutPage{}, http.StatusOK)
+		return
+	}
+
+	http.Redirect(w, r, redirectURL, http.StatusFound)
+}
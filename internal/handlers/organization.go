@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+)
+
+// OrganizationHandler maneja las solicitudes HTTP sobre las organizaciones del usuario autenticado.
+type OrganizationHandler struct {
+	organizationService services.OrganizationService
+}
+
+// NewOrganizationHandler crea una nueva instancia de OrganizationHandler.
+func NewOrganizationHandler(os services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: os,
+	}
+}
+
+// ListOrganizationsHandler lista las organizaciones del usuario autenticado con su rol en cada una.
+func (h *OrganizationHandler) ListOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	organizations, err := h.organizationService.ListOrganizations(r.Context(), claims.Subject)
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]response.OrganizationResponse, 0, len(organizations))
+	for _, organization := range organizations {
+		result = append(result, response.OrganizationResponse{
+			ID:        organization.Organization.ID,
+			Name:      organization.Organization.Name,
+			Role:      organization.Role,
+			Current:   organization.Organization.ID == claims.OrganizationID,
+			CreatedAt: organization.Organization.CreatedAt,
+		})
+	}
+
+	response.ResponseSuccess(w, result, http.StatusOK)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+)
+
+// PasswordHandler maneja las solicitudes HTTP de recuperación de contraseña.
+type PasswordHandler struct {
+	passwordService services.PasswordService
+}
+
+// NewPasswordHandler crea una nueva instancia de PasswordHandler.
+func NewPasswordHandler(ps services.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: ps,
+	}
+}
+
+// ForgotPasswordHandler envía el link de restablecimiento. Siempre responde 200
+// para no revelar qué emails están registrados.
+func (h *PasswordHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var forgotPasswordReq request.ForgotPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&forgotPasswordReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordService.ForgotPassword(r.Context(), forgotPasswordReq.Email); err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// ResetPasswordHandler cambia la contraseña usando el token recibido por email.
+// El token puede venir en el body o en el query param "link" del email.
+func (h *PasswordHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var resetPasswordReq request.ResetPasswordRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&resetPasswordReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	token := resetPasswordReq.Token
+	if token == "" {
+		token = r.URL.Query().Get("link")
+	}
+	if token == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(r.Context(), token, resetPasswordReq.Password); err != nil {
+		if errors.Is(err, validations.ErrInvalidToken) {
+			response.ResponseError(w, err, http.StatusUnauthorized)
+			return
+		}
+		response.ResponseError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+)
+
+// PasswordlessHandler maneja las solicitudes HTTP del login sin contraseña (magic link y código por email).
+type PasswordlessHandler struct {
+	passwordlessService services.PasswordlessService
+}
+
+// NewPasswordlessHandler crea una nueva instancia de PasswordlessHandler.
+func NewPasswordlessHandler(ps services.PasswordlessService) *PasswordlessHandler {
+	return &PasswordlessHandler{
+		passwordlessService: ps,
+	}
+}
+
+// MagicLinkHandler envía un magic link al email. Siempre responde 200
+// para no revelar qué emails están registrados.
+func (h *PasswordlessHandler) MagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var emailReq request.EmailLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&emailReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordlessService.RequestMagicLink(r.Context(), emailReq.Email); err != nil {
+		writeEmailLoginRequestError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// VerifyMagicLinkHandler canjea el token del magic link por los tokens de la sesión, igual que LoginHandler.
+func (h *PasswordlessHandler) VerifyMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var verifyReq request.MagicLinkVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&verifyReq); err != nil || verifyReq.Token == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.passwordlessService.RedeemMagicLink(r.Context(), verifyReq.Token, getClientInfo(r))
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	writeLoginResult(w, result)
+}
+
+// EmailOTPHandler envía un código de un solo uso al email. Siempre responde 200
+// para no revelar qué emails están registrados.
+func (h *PasswordlessHandler) EmailOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var emailReq request.EmailLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&emailReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordlessService.RequestEmailOTP(r.Context(), emailReq.Email); err != nil {
+		writeEmailLoginRequestError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// VerifyEmailOTPHandler canjea el código por los tokens de la sesión, igual que LoginHandler.
+func (h *PasswordlessHandler) VerifyEmailOTPHandler(w http.ResponseWriter, r *http.Request) {
+	var verifyReq request.EmailOTPVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&verifyReq); err != nil || verifyReq.Email == "" || verifyReq.Code == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.passwordlessService.VerifyEmailOTP(r.Context(), verifyReq.Email, verifyReq.Code, getClientInfo(r))
+	if err != nil {
+		writeLoginError(w, err)
+		return
+	}
+
+	writeLoginResult(w, result)
+}
+
+// writeEmailLoginRequestError responde un error al pedir un magic link o código
+func writeEmailLoginRequestError(w http.ResponseWriter, err error) {
+	if errors.Is(err, validations.ErrInvalidEmail) {
+		response.ResponseError(w, err, http.StatusBadRequest)
+		return
+	}
+	response.ResponseError(w, err, http.StatusInternalServerError)
+}
@@ -2,13 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"net"
 	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 
 	"myproject/internal/services"
 	tokens "myproject/pkg/jwt"
 	"myproject/pkg/request"
 	"myproject/pkg/response"
 	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
 )
 
 // SessionHandler maneja las solicitudes HTTP relacionadas con la sesión.
@@ -35,6 +47,10 @@ func (h *SessionHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Pasar el contexto del request al service
 	err := h.sessionService.Register(r.Context(), sessionReq)
 	if err != nil {
+		if errors.Is(err, services.ErrUserAlreadyExists) {
+			response.ResponseError(w, err, http.StatusConflict)
+			return
+		}
 		response.ResponseError(w, err, http.StatusBadRequest)
 		return
 	}
@@ -51,14 +67,43 @@ func (h *SessionHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Llamar al service con contexto
-	tokens, err := h.sessionService.Login(r.Context(), sessionReq.Email, sessionReq.Password)
+	result, err := h.sessionService.Login(r.Context(), sessionReq.Email, sessionReq.Password, getClientInfo(r))
 	if err != nil {
-		response.ResponseError(w, err, http.StatusUnauthorized)
+		writeLoginError(w, err)
+		return
+	}
+
+	writeLoginResult(w, result)
+}
+
+// writeLoginResult responde un login exitoso: los tokens de la sesión o, con MFA habilitado,
+// el challenge a completar en /auth/mfa/challenge.
+func writeLoginResult(w http.ResponseWriter, result *services.LoginResult) {
+	if result.ChallengeToken != "" {
+		response.ResponseSuccess(w, response.MFAChallengeResponse{
+			MFARequired:    true,
+			ChallengeToken: result.ChallengeToken,
+			Methods:        []string{"totp", "recovery_code"},
+			ExpiresIn:      int(services.MFA_CHALLENGE_DURATION.Seconds()),
+		}, http.StatusOK)
 		return
 	}
 
 	// Se envía el token JWT en la respuesta
-	response.ResponseSuccess(w, tokens, http.StatusOK)
+	response.ResponseSuccess(w, result.Tokens, http.StatusOK)
+}
+
+// writeLoginError responde un login fallido con el status correspondiente al error.
+func writeLoginError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, validations.ErrEmailNotVerified):
+		response.ResponseError(w, err, http.StatusForbidden)
+	case errors.Is(err, validations.ErrAccountLocked):
+		setRetryAfter(w, err)
+		response.ResponseError(w, err, http.StatusTooManyRequests)
+	default:
+		response.ResponseError(w, err, http.StatusUnauthorized)
+	}
 }
 
 func (h *SessionHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Request) {
@@ -69,7 +114,7 @@ func (h *SessionHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Llamar al service con contexto
-	newTokens, err := h.sessionService.RefreshToken(r.Context(), token)
+	newTokens, err := h.sessionService.RefreshToken(r.Context(), token, getClientInfo(r))
 	if err != nil {
 		response.ResponseError(w, err, http.StatusUnauthorized)
 		return
@@ -79,74 +124,230 @@ func (h *SessionHandler) RefreshTokenHandler(w http.ResponseWriter, r *http.Requ
 	response.ResponseSuccess(w, newTokens, http.StatusOK)
 }
 
-/*
-func ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
-	var forgotPasswordReq request.ForgotPasswordRequest
+// LogoutHandler cierra la sesión actual. El refresh token de la sesión se envía en el body.
+func (h *SessionHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&forgotPasswordReq); err != nil {
+	// El body es opcional: sin refresh token solo se revoca el access token
+	var logoutReq request.LogoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&logoutReq); err != nil && !errors.Is(err, io.EOF) {
 		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
 		return
 	}
 
-	user, err := services.GetUserByFilter(nil, nil, &forgotPasswordReq.Email)
-	if err != nil {
-		response.ResponseError(w, err, http.StatusInternalServerError)
+	if err := h.sessionService.Logout(r.Context(), claims, logoutReq.RefreshToken); err != nil {
+		response.ResponseError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	go services.SendEmailToResetPassword(user.Email, user.Name, user.ID)
-
 	response.ResponseSuccess(w, nil, http.StatusOK)
 }
 
-func ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
-	var resetPasswordReq request.ResetPasswordRequest
+// LogoutAllHandler cierra todas las sesiones del usuario en todos los dispositivos.
+func (h *SessionHandler) LogoutAllHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok || userID == "" {
+		response.ResponseError(w, validations.ErrInvalidUserID, http.StatusUnauthorized)
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(&resetPasswordReq); err != nil {
-		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+	if err := h.sessionService.LogoutAll(r.Context(), userID); err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	link := r.URL.Query().Get("link")
-	if link == "" {
-		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// ListSessionsHandler lista las sesiones activas del usuario autenticado.
+func (h *SessionHandler) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok || userID == "" {
+		response.ResponseError(w, validations.ErrInvalidUserID, http.StatusUnauthorized)
 		return
 	}
 
-	emailToken, err := tokens.GetFieldInToken(link, "email")
+	var currentSessionID string
+	if claims, ok := getClaims(r); ok {
+		currentSessionID = claims.SessionID
+	}
+
+	sessions, err := h.sessionService.ListSessions(r.Context(), userID, currentSessionID)
 	if err != nil {
-		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		response.ResponseError(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	err = services.ActualizatePassword(emailToken, resetPasswordReq.Email, resetPasswordReq.Password)
-	if err != nil {
-		response.ResponseError(w, err, http.StatusTeapot)
+	response.ResponseSuccess(w, sessions, http.StatusOK)
+}
+
+// RevokeSessionHandler cierra una sesión del usuario autenticado.
+func (h *SessionHandler) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := r.Context().Value("user_id").(string)
+	if !ok || userID == "" {
+		response.ResponseError(w, validations.ErrInvalidUserID, http.StatusUnauthorized)
+		return
+	}
+
+	sessionID := mux.Vars(r)["id"]
+	if err := h.sessionService.RevokeSession(r.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, validations.ErrSessionNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
 		return
 	}
 
 	response.ResponseSuccess(w, nil, http.StatusOK)
 }
 
-func ActivateAccountHandler(w http.ResponseWriter, r *http.Request) {
-	link := r.URL.Query().Get("link")
-	if link == "" {
+// SwitchOrganizationHandler cierra la sesión actual y abre una nueva para la organización indicada.
+// Responde los tokens nuevos igual que RefreshTokenHandler.
+func (h *SessionHandler) SwitchOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var switchReq request.SwitchOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&switchReq); err != nil || switchReq.OrganizationID == "" {
 		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
 		return
 	}
 
-	emailToken, err := tokens.GetFieldInToken(link, "email")
+	newTokens, err := h.sessionService.SwitchOrganization(r.Context(), claims, switchReq.OrganizationID, getClientInfo(r))
 	if err != nil {
-		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		switch {
+		case errors.Is(err, validations.ErrCompanyNotFound):
+			response.ResponseError(w, err, http.StatusNotFound)
+		case errors.Is(err, validations.ErrInvalidToken), errors.Is(err, validations.ErrUserInactive):
+			response.ResponseError(w, err, http.StatusUnauthorized)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
 		return
 	}
 
-	err = services.ActivateAccount(emailToken)
+	response.ResponseSuccess(w, newTokens, http.StatusOK)
+}
+
+// getClaims obtiene los claims del access token que AuthMiddleware dejó en el contexto.
+func getClaims(r *http.Request) (*tokens.Claims, bool) {
+	claims, ok := r.Context().Value("claims").(*tokens.Claims)
+	return claims, ok && claims != nil
+}
+
+// getClientInfo extrae los datos del dispositivo que realiza la petición.
+func getClientInfo(r *http.Request) request.ClientInfo {
+	return request.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IP:        getClientIP(r),
+	}
+}
+
+// setRetryAfter agrega el header Retry-After (en segundos) si el error lo indica.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var retryErr *validations.RetryAfterError
+	if errors.As(err, &retryErr) {
+		seconds := int(math.Ceil(retryErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+}
+
+// getClientIP obtiene la IP del cliente. X-Forwarded-For solo se considera si la petición llega
+// desde un proxy de confianza (TRUSTED_PROXIES); si no, cualquiera podría elegir su IP.
+func getClientIP(r *http.Request) string {
+	return clientIP(r, getTrustedProxies())
+}
+
+// clientIP recorre X-Forwarded-For de derecha a izquierda: cada proxy agrega la IP de quien le
+// envió la petición, así que la primera que no es de un proxy de confianza es la del cliente.
+// Los saltos anteriores los escribió el cliente y se ignoran.
+func clientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	ip := remoteIP(r)
+	addr, err := netip.ParseAddr(ip)
+	if err != nil || !isTrustedProxy(addr, trustedProxies) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap().String()
+		if !isTrustedProxy(hop, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+// remoteIP retorna la IP de la conexión. En Lambda es la IP de origen que informa API Gateway.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
-		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
-		return
+		return r.RemoteAddr
 	}
+	return host
+}
 
-	response.ResponseSuccess(w, nil, http.StatusOK)
+func isTrustedProxy(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	trustedProxies     []netip.Prefix
+	trustedProxiesOnce sync.Once
+)
+
+// getTrustedProxies retorna los proxies de TRUSTED_PROXIES (IPs o rangos CIDR separados por coma).
+// Los valores inválidos se ignoran con un aviso en el log.
+func getTrustedProxies() []netip.Prefix {
+	trustedProxiesOnce.Do(func() {
+		trustedProxies = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	})
+	return trustedProxies
+}
+
+func parseTrustedProxies(value string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				log.Printf("TRUSTED_PROXIES: ignoring invalid address %q", entry)
+				continue
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			log.Printf("TRUSTED_PROXIES: ignoring invalid range %q", entry)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
 }
-*/
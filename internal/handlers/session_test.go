@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := parseTrustedProxies("10.0.0.0/8, 192.168.1.5, no-es-una-ip")
+	if len(trusted) != 2 {
+		t.Fatalf("trusted proxies = %v, want the two valid entries", trusted)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		want       string
+	}{
+		{"direct connection", "203.0.113.7:51234", nil, "203.0.113.7"},
+		{"spoofed header without proxy", "203.0.113.7:51234", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"behind a trusted proxy", "10.0.0.2:80", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"hops written by the client are ignored", "10.0.0.2:80", []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"chain of trusted proxies", "10.0.0.2:80", []string{"1.2.3.4, 203.0.113.7, 192.168.1.5", "10.1.1.1"}, "203.0.113.7"},
+		{"invalid hop", "10.0.0.2:80", []string{"203.0.113.7, basura"}, "10.0.0.2"},
+		{"trusted proxy without header", "10.0.0.2:80", nil, "10.0.0.2"},
+		{"lambda source ip without port", "203.0.113.7", []string{"1.2.3.4"}, "203.0.113.7"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/auth/login", nil)
+		req.RemoteAddr = tt.remoteAddr
+		for _, value := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		if got := clientIP(req, trusted); got != tt.want {
+			t.Errorf("%s: client IP = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
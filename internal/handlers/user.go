@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/models"
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// UserHandler maneja las solicitudes HTTP sobre el perfil del usuario autenticado.
+type UserHandler struct {
+	userService services.UserService
+}
+
+// NewUserHandler crea una nueva instancia de UserHandler.
+func NewUserHandler(us services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: us,
+	}
+}
+
+// GetProfileHandler retorna el perfil del usuario autenticado.
+func (h *UserHandler) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := h.userService.GetProfile(r.Context(), claims.Subject)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, response.NewUserResponse(user), http.StatusOK)
+}
+
+// UpdateProfileHandler modifica parcialmente el perfil. Requiere la versión leída por el cliente
+// y responde 409 si el usuario fue modificado desde entonces.
+func (h *UserHandler) UpdateProfileHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var updateReq request.UpdateProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil || updateReq.Version == nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	patch := models.ProfilePatch{
+		Name:      updateReq.Name,
+		LastName:  updateReq.LastName,
+		BirthDate: updateReq.BirthDate,
+	}
+
+	user, err := h.userService.UpdateProfile(r.Context(), claims.Subject, patch, *updateReq.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrVersionConflict):
+			response.ResponseError(w, err, http.StatusConflict)
+		case errors.Is(err, validations.ErrDocumentNotFound):
+			response.ResponseError(w, err, http.StatusNotFound)
+		case errors.Is(err, validations.ErrInvalidRequest), errors.Is(err, validations.ErrInvalidName), errors.Is(err, validations.ErrInvalidLastName):
+			response.ResponseError(w, err, http.StatusBadRequest)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, response.NewUserResponse(user), http.StatusOK)
+}
+
+// UpdateRoleHandler cambia el rol global de otro usuario. La ruta exige rbac.PERMISSION_USERS_MANAGE;
+// además, el rol a asignar y el del usuario no pueden ser de mayor jerarquía que el propio.
+func (h *UserHandler) UpdateRoleHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var roleReq request.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&roleReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userService.AssignRole(r.Context(), claims.Subject, mux.Vars(r)["id"], roleReq.Role); err != nil {
+		writeRoleError(w, err)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// writeRoleError responde un cambio de rol fallido
+func writeRoleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, validations.ErrInvalidRole), errors.Is(err, validations.ErrCannotChangeOwnRole):
+		response.ResponseError(w, err, http.StatusBadRequest)
+	case errors.Is(err, validations.ErrRoleNotAssignable), errors.Is(err, validations.ErrForbidden):
+		response.ResponseError(w, err, http.StatusForbidden)
+	case errors.Is(err, validations.ErrDocumentNotFound):
+		response.ResponseError(w, err, http.StatusNotFound)
+	default:
+		response.ResponseError(w, err, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+)
+
+// VerificationHandler maneja las solicitudes HTTP de verificación de email.
+type VerificationHandler struct {
+	verificationService services.VerificationService
+}
+
+// NewVerificationHandler crea una nueva instancia de VerificationHandler.
+func NewVerificationHandler(vs services.VerificationService) *VerificationHandler {
+	return &VerificationHandler{
+		verificationService: vs,
+	}
+}
+
+// ActivateAccountHandler activa la cuenta con el token del link enviado por email.
+func (h *VerificationHandler) ActivateAccountHandler(w http.ResponseWriter, r *http.Request) {
+	link := r.URL.Query().Get("link")
+	if link == "" {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verificationService.ActivateAccount(r.Context(), link); err != nil {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// ResendVerificationHandler reenvía el email de verificación.
+func (h *VerificationHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	var resendReq request.ResendVerificationRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&resendReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verificationService.ResendVerification(r.Context(), resendReq.Email); err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// ChangeEmailHandler cambia el email del usuario autenticado y envía la verificación al nuevo email.
+func (h *VerificationHandler) ChangeEmailHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var changeReq request.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&changeReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verificationService.ChangeEmail(r.Context(), claims.Subject, changeReq.Password, changeReq.Email); err != nil {
+		switch {
+		case errors.Is(err, validations.ErrInvalidEmail):
+			response.ResponseError(w, err, http.StatusBadRequest)
+		case errors.Is(err, validations.ErrInvalidCredentials):
+			response.ResponseError(w, err, http.StatusUnauthorized)
+		case errors.Is(err, services.ErrUserAlreadyExists), errors.Is(err, validations.ErrConditionFailed):
+			response.ResponseError(w, err, http.StatusConflict)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"myproject/internal/services"
+	"myproject/pkg/request"
+	"myproject/pkg/response"
+	"myproject/pkg/validations"
+
+	"github.com/gorilla/mux"
+)
+
+// WebAuthnHandler maneja las solicitudes HTTP de passkeys (WebAuthn).
+type WebAuthnHandler struct {
+	webAuthnService services.WebAuthnService
+}
+
+// NewWebAuthnHandler crea una nueva instancia de WebAuthnHandler.
+func NewWebAuthnHandler(ws services.WebAuthnService) *WebAuthnHandler {
+	return &WebAuthnHandler{
+		webAuthnService: ws,
+	}
+}
+
+// RegisterOptionsHandler inicia el alta de una passkey para el usuario autenticado.
+func (h *WebAuthnHandler) RegisterOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	ceremony, err := h.webAuthnService.BeginRegistration(r.Context(), claims.Subject)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, newWebAuthnOptionsResponse(ceremony), http.StatusOK)
+}
+
+// RegisterFinishHandler verifica la respuesta del autenticador y guarda la passkey.
+func (h *WebAuthnHandler) RegisterFinishHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	var finishReq request.WebAuthnFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&finishReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	credential, err := h.webAuthnService.FinishRegistration(r.Context(), claims.Subject, finishReq.ChallengeID, finishReq.Name, finishReq.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrWebAuthnChallengeInvalid), errors.Is(err, validations.ErrWebAuthnVerification):
+			response.ResponseError(w, err, http.StatusBadRequest)
+		case errors.Is(err, validations.ErrPasskeyAlreadyRegistered):
+			response.ResponseError(w, err, http.StatusConflict)
+		case errors.Is(err, validations.ErrDocumentNotFound):
+			response.ResponseError(w, err, http.StatusNotFound)
+		default:
+			response.ResponseError(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, credential, http.StatusCreated)
+}
+
+// LoginOptionsHandler inicia un login con passkey.
+func (h *WebAuthnHandler) LoginOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	ceremony, err := h.webAuthnService.BeginLogin(r.Context())
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, newWebAuthnOptionsResponse(ceremony), http.StatusOK)
+}
+
+// LoginFinishHandler verifica la firma del autenticador y retorna los tokens de la sesión, igual que LoginHandler.
+func (h *WebAuthnHandler) LoginFinishHandler(w http.ResponseWriter, r *http.Request) {
+	var finishReq request.WebAuthnFinishRequest
+	if err := json.NewDecoder(r.Body).Decode(&finishReq); err != nil {
+		response.ResponseError(w, validations.ErrInvalidRequest, http.StatusBadRequest)
+		return
+	}
+
+	newTokens, err := h.webAuthnService.FinishLogin(r.Context(), finishReq.ChallengeID, finishReq.Credential, getClientInfo(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrWebAuthnChallengeInvalid):
+			response.ResponseError(w, err, http.StatusBadRequest)
+		case errors.Is(err, validations.ErrEmailNotVerified):
+			response.ResponseError(w, err, http.StatusForbidden)
+		default:
+			response.ResponseError(w, err, http.StatusUnauthorized)
+		}
+		return
+	}
+
+	response.ResponseSuccess(w, newTokens, http.StatusOK)
+}
+
+// ListCredentialsHandler lista las passkeys del usuario autenticado.
+func (h *WebAuthnHandler) ListCredentialsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	credentials, err := h.webAuthnService.ListCredentials(r.Context(), claims.Subject)
+	if err != nil {
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, credentials, http.StatusOK)
+}
+
+// DeleteCredentialHandler elimina una passkey del usuario autenticado.
+func (h *WebAuthnHandler) DeleteCredentialHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := getClaims(r)
+	if !ok {
+		response.ResponseError(w, validations.ErrInvalidToken, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.webAuthnService.DeleteCredential(r.Context(), claims.Subject, mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, validations.ErrPasskeyNotFound) {
+			response.ResponseError(w, err, http.StatusNotFound)
+			return
+		}
+		response.ResponseError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.ResponseSuccess(w, nil, http.StatusOK)
+}
+
+// newWebAuthnOptionsResponse arma la respuesta con las opciones de la ceremonia
+func newWebAuthnOptionsResponse(ceremony *services.WebAuthnCeremony) response.WebAuthnOptionsResponse {
+	return response.WebAuthnOptionsResponse{
+		ChallengeID: ceremony.ChallengeID,
+		Options:     ceremony.Options,
+		ExpiresIn:   int(services.WEBAUTHN_CHALLENGE_DURATION.Seconds()),
+	}
+}
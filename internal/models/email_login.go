@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Métodos de login sin contraseña por email
+const (
+	EMAIL_LOGIN_MAGIC_LINK = "MAGIC_LINK"
+	EMAIL_LOGIN_OTP        = "EMAIL_OTP"
+)
+
+// EmailLogin es un login sin contraseña pendiente: un magic link o un código de un solo uso
+// enviado por email. Solo se persiste el hash del secreto. Hay uno por método y email, así
+// que un nuevo envío invalida el anterior.
+type EmailLogin struct {
+	Key        string `json:"key" dynamodbav:"login_key" bson:"_id"`
+	Method     string `json:"method" dynamodbav:"method" bson:"method"`
+	Email      string `json:"email" dynamodbav:"email" bson:"email"`
+	SecretHash string `json:"-" dynamodbav:"secret_hash" bson:"secret_hash"`
+	// Attempts cuenta los intentos de canje, correctos o no
+	Attempts  int        `json:"attempts" dynamodbav:"attempts" bson:"attempts"`
+	CreatedAt time.Time  `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" dynamodbav:"used_at,omitempty" bson:"used_at,omitempty"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewEmailLogin crea un login por email válido hasta expiresAt.
+func NewEmailLogin(method, email, secretHash string, expiresAt time.Time) *EmailLogin {
+	return &EmailLogin{
+		Key:        EmailLoginKey(method, email),
+		Method:     method,
+		Email:      email,
+		SecretHash: secretHash,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  expiresAt,
+		TTL:        expiresAt.Unix(),
+	}
+}
+
+// EmailLoginKey es la clave del login pendiente de un email para un método.
+func EmailLoginKey(method, email string) string {
+	return method + "#" + email
+}
+
+// IsExpired indica si el secreto ya venció
+func (l *EmailLogin) IsExpired() bool {
+	return !time.Now().Before(l.ExpiresAt)
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Identity vincula una cuenta de un proveedor de identidad externo (OpenID Connect) con un
+// usuario. Se identifica por el proveedor y el "sub" del ID token, que es estable; el email
+// solo se usa para vincular la cuenta la primera vez.
+type Identity struct {
+	ID        string    `json:"-" dynamodbav:"identity_id" bson:"_id"`
+	UserID    string    `json:"-" dynamodbav:"user_id" bson:"user_id"`
+	Provider  string    `json:"provider" dynamodbav:"provider" bson:"provider"`
+	Subject   string    `json:"subject" dynamodbav:"subject" bson:"subject"`
+	Email     string    `json:"email,omitempty" dynamodbav:"email,omitempty" bson:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+}
+
+// IdentityKey retorna la clave de la identidad: el proveedor y el subject
+func IdentityKey(provider, subject string) string {
+	return provider + "#" + subject
+}
+
+// NewIdentity crea la identidad del proveedor para el usuario.
+func NewIdentity(userID, provider, subject, email string) *Identity {
+	return &Identity{
+		ID:        IdentityKey(provider, subject),
+		UserID:    userID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+}
+
+// FederatedState es el estado de un login con un proveedor externo, guardado entre la
+// redirección al proveedor y el callback. Solo se persiste el hash del state y el del valor que
+// ata el login al navegador que lo inició; el nonce y el code_verifier de PKCE se necesitan para
+// canjear el código. Se usa una sola vez.
+type FederatedState struct {
+	StateHash    string    `json:"state_hash" dynamodbav:"state_hash" bson:"_id"`
+	BindingHash  string    `json:"-" dynamodbav:"binding_hash" bson:"binding_hash"`
+	Provider     string    `json:"provider" dynamodbav:"provider" bson:"provider"`
+	Nonce        string    `json:"-" dynamodbav:"nonce" bson:"nonce"`
+	CodeVerifier string    `json:"-" dynamodbav:"code_verifier" bson:"code_verifier"`
+	CreatedAt    time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewFederatedState crea el estado de un login válido hasta expiresAt.
+func NewFederatedState(stateHash, bindingHash, provider, nonce, codeVerifier string, expiresAt time.Time) *FederatedState {
+	return &FederatedState{
+		StateHash:    stateHash,
+		BindingHash:  bindingHash,
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		CreatedAt:    time.Now(),
+		ExpiresAt:    expiresAt,
+		TTL:          expiresAt.Unix(),
+	}
+}
+
+// IsExpired indica si el login ya venció
+func (s *FederatedState) IsExpired() bool {
+	return !time.Now().Before(s.ExpiresAt)
+}
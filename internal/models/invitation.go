@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation es una invitación pendiente a unirse a una organización con un rol. El link enviado
+// por email lleva un token firmado con el ID de la invitación y TokenID; reenviarla genera un
+// TokenID nuevo, de modo que solo el último link enviado sirve. Al aceptarla o revocarla se elimina.
+type Invitation struct {
+	ID             string    `json:"id" dynamodbav:"invitation_id" bson:"_id"`
+	OrganizationID string    `json:"organization_id" dynamodbav:"organization_id" bson:"organization_id"`
+	Email          string    `json:"email" dynamodbav:"email" bson:"email"`
+	Role           string    `json:"role" dynamodbav:"role" bson:"role"`
+	InvitedBy      string    `json:"invited_by" dynamodbav:"invited_by" bson:"invited_by"`
+	TokenID        string    `json:"-" dynamodbav:"token_id" bson:"token_id"`
+	CreatedAt      time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewInvitation crea la invitación de email a la organización con el rol indicado, válida hasta expiresAt.
+func NewInvitation(organizationID, email, role, invitedBy string, expiresAt time.Time) *Invitation {
+	return &Invitation{
+		ID:             uuid.New().String(),
+		OrganizationID: organizationID,
+		Email:          email,
+		Role:           role,
+		InvitedBy:      invitedBy,
+		TokenID:        uuid.New().String(),
+		CreatedAt:      time.Now(),
+		ExpiresAt:      expiresAt,
+		TTL:            expiresAt.Unix(),
+	}
+}
+
+// IsExpired indica si la invitación ya venció
+func (i *Invitation) IsExpired() bool {
+	return !time.Now().Before(i.ExpiresAt)
+}
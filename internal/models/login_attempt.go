@@ -0,0 +1,61 @@
+package models
+
+import (
+	"net/netip"
+	"time"
+)
+
+// LoginAttempt acumula los intentos de login fallidos de una clave: la cuenta (email)
+// o la combinación email + IP. Se elimina al vencer la ventana de conteo o al iniciar sesión.
+type LoginAttempt struct {
+	Key string `json:"key" dynamodbav:"attempt_key" bson:"_id"`
+	// Email permite desbloquear de una vez todas las claves de una cuenta
+	Email         string    `json:"email" dynamodbav:"email" bson:"email"`
+	Failures      int       `json:"failures" dynamodbav:"failures" bson:"failures"`
+	LastFailureAt time.Time `json:"last_failure_at" dynamodbav:"last_failure_at" bson:"last_failure_at"`
+	ExpiresAt     time.Time `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewLoginAttempt crea el registro del primer intento fallido de una clave.
+func NewLoginAttempt(key, email string, failedAt, expiresAt time.Time) *LoginAttempt {
+	return &LoginAttempt{
+		Key:           key,
+		Email:         email,
+		Failures:      1,
+		LastFailureAt: failedAt,
+		ExpiresAt:     expiresAt,
+		TTL:           expiresAt.Unix(),
+	}
+}
+
+// AccountAttemptKey es la clave que cuenta los fallos de una cuenta desde cualquier IP.
+func AccountAttemptKey(email string) string {
+	return "ACCOUNT#" + email
+}
+
+// EmailIPAttemptKey es la clave que cuenta los fallos de una cuenta desde una IP.
+func EmailIPAttemptKey(email, ip string) string {
+	return "EMAIL_IP#" + email + "#" + attemptNetwork(ip)
+}
+
+// attemptNetwork retorna la red que se cuenta como una misma IP. Un cliente IPv6 suele disponer
+// de un /64 completo, así que rotar de dirección dentro de él no reinicia la espera progresiva.
+func attemptNetwork(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	if addr.Is4() {
+		return addr.String()
+	}
+	return netip.PrefixFrom(addr, 64).Masked().String()
+}
+
+// IsExpired indica si la ventana de conteo ya venció
+func (a *LoginAttempt) IsExpired() bool {
+	return !time.Now().Before(a.ExpiresAt)
+}
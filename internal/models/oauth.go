@@ -0,0 +1,94 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Grant types de OAuth 2.0 soportados por /oauth/token
+const (
+	OAUTH_GRANT_AUTHORIZATION_CODE = "authorization_code"
+	OAUTH_GRANT_REFRESH_TOKEN      = "refresh_token"
+	OAUTH_GRANT_CLIENT_CREDENTIALS = "client_credentials"
+)
+
+// Scopes de OpenID Connect: openid habilita el ID token y /userinfo; profile y email definen qué
+// datos del usuario se incluyen
+const (
+	OAUTH_SCOPE_OPENID  = "openid"
+	OAUTH_SCOPE_PROFILE = "profile"
+	OAUTH_SCOPE_EMAIL   = "email"
+)
+
+// OAuthClient es una aplicación registrada que obtiene tokens a través de /oauth/authorize y
+// /oauth/token. Los clientes públicos (SPAs, apps móviles) no tienen secreto; de los
+// confidenciales solo se guarda el hash del secreto.
+type OAuthClient struct {
+	ID           string    `json:"client_id" dynamodbav:"client_id" bson:"_id"`
+	Name         string    `json:"name" dynamodbav:"name" bson:"name"`
+	SecretHash   string    `json:"-" dynamodbav:"secret_hash,omitempty" bson:"secret_hash,omitempty"`
+	RedirectURIs []string  `json:"redirect_uris" dynamodbav:"redirect_uris" bson:"redirect_uris"`
+	Scopes       []string  `json:"scopes" dynamodbav:"scopes" bson:"scopes"`
+	GrantTypes   []string  `json:"grant_types" dynamodbav:"grant_types" bson:"grant_types"`
+	CreatedAt    time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	// PostLogoutRedirectURIs son las URIs a las que puede volver el usuario después del cierre de sesión de OIDC
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris,omitempty" dynamodbav:"post_logout_redirect_uris,omitempty" bson:"post_logout_redirect_uris,omitempty"`
+}
+
+// IsPublic indica si el cliente no puede guardar un secreto
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// HasRedirectURI indica si la URI está registrada. La comparación es exacta.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// HasPostLogoutRedirectURI indica si la URI está registrada para volver del cierre de sesión. La comparación es exacta.
+func (c *OAuthClient) HasPostLogoutRedirectURI(uri string) bool {
+	return slices.Contains(c.PostLogoutRedirectURIs, uri)
+}
+
+// AllowsGrant indica si el cliente puede usar el grant type
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	return slices.Contains(c.GrantTypes, grantType)
+}
+
+// AllowsScopes indica si todos los scopes están permitidos para el cliente
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// AuthorizationCode es un código emitido por /oauth/authorize y canjeado en /oauth/token.
+// Solo se persiste su hash y puede usarse una única vez. Guarda el code_challenge de PKCE.
+type AuthorizationCode struct {
+	CodeHash            string `json:"code_hash" dynamodbav:"code_hash" bson:"_id"`
+	ClientID            string `json:"client_id" dynamodbav:"client_id" bson:"client_id"`
+	UserID              string `json:"user_id" dynamodbav:"user_id" bson:"user_id"`
+	RedirectURI         string `json:"redirect_uri" dynamodbav:"redirect_uri" bson:"redirect_uri"`
+	Scope               string `json:"scope" dynamodbav:"scope" bson:"scope"`
+	CodeChallenge       string `json:"code_challenge" dynamodbav:"code_challenge" bson:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" dynamodbav:"code_challenge_method" bson:"code_challenge_method"`
+	// Nonce y AuthTime se copian al ID token (OpenID Connect)
+	Nonce     string     `json:"nonce,omitempty" dynamodbav:"nonce,omitempty" bson:"nonce,omitempty"`
+	AuthTime  time.Time  `json:"auth_time" dynamodbav:"auth_time" bson:"auth_time"`
+	CreatedAt time.Time  `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" dynamodbav:"used_at,omitempty" bson:"used_at,omitempty"`
+	// SessionID es la sesión abierta al canjearlo; si el código se reutiliza, se revoca
+	SessionID string `json:"session_id,omitempty" dynamodbav:"session_id,omitempty" bson:"session_id,omitempty"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// IsUsable indica si el código todavía puede canjearse.
+func (c *AuthorizationCode) IsUsable() bool {
+	return c.UsedAt == nil && time.Now().Before(c.ExpiresAt)
+}
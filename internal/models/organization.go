@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// Organization es una empresa (tenant). Los usuarios pertenecen a ella a través de una Membership,
+// que define su rol dentro de la organización.
+type Organization struct {
+	ID        string    `json:"id" dynamodbav:"organization_id" bson:"_id"`
+	Name      string    `json:"name" dynamodbav:"name" bson:"name"`
+	OwnerID   string    `json:"owner_id" dynamodbav:"owner_id" bson:"owner_id"`
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+}
+
+// NewOrganization valida el nombre y crea una organización cuyo dueño es ownerID.
+func NewOrganization(name, ownerID string) (*Organization, error) {
+	nameToSave, err := validations.ValidateCompanyName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Organization{
+		ID:        uuid.New().String(),
+		Name:      nameToSave,
+		OwnerID:   ownerID,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Membership es la pertenencia de un usuario a una organización, con su rol en ella (consts.ROLE_*).
+type Membership struct {
+	ID             string    `json:"-" dynamodbav:"membership_id" bson:"_id"`
+	OrganizationID string    `json:"organization_id" dynamodbav:"organization_id" bson:"organization_id"`
+	UserID         string    `json:"user_id" dynamodbav:"user_id" bson:"user_id"`
+	Role           string    `json:"role" dynamodbav:"role" bson:"role"`
+	CreatedAt      time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+}
+
+// MembershipKey retorna la clave de la membresía: la organización y el usuario
+func MembershipKey(organizationID, userID string) string {
+	return organizationID + "#" + userID
+}
+
+// NewMembership crea la membresía del usuario en la organización con el rol indicado.
+func NewMembership(organizationID, userID, role string) *Membership {
+	return &Membership{
+		ID:             MembershipKey(organizationID, userID),
+		OrganizationID: organizationID,
+		UserID:         userID,
+		Role:           role,
+		CreatedAt:      time.Now(),
+	}
+}
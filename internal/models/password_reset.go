@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// PasswordReset representa una solicitud de restablecimiento de contraseña.
+// El token se envía por email y solo se persiste su hash; puede usarse una única vez.
+type PasswordReset struct {
+	TokenHash string     `json:"token_hash" dynamodbav:"token_hash" bson:"_id"`
+	UserID    string     `json:"user_id" dynamodbav:"user_id" bson:"user_id"`
+	CreatedAt time.Time  `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty" dynamodbav:"used_at,omitempty" bson:"used_at,omitempty"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewPasswordReset crea una solicitud de restablecimiento válida hasta expiresAt.
+func NewPasswordReset(tokenHash, userID string, expiresAt time.Time) *PasswordReset {
+	return &PasswordReset{
+		TokenHash: tokenHash,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		TTL:       expiresAt.Unix(),
+	}
+}
+
+// IsUsable indica si el token todavía puede usarse.
+func (p *PasswordReset) IsUsable() bool {
+	return p.UsedAt == nil && time.Now().Before(p.ExpiresAt)
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// RefreshToken representa un refresh token emitido y persistido en DynamoDB.
+// Nunca se guarda el token original, solo su hash SHA-256.
+type RefreshToken struct {
+	TokenHash string `json:"token_hash" dynamodbav:"token_hash" bson:"_id"`
+	// FamilyID agrupa todos los tokens obtenidos por rotación a partir del mismo login
+	FamilyID string `json:"family_id" dynamodbav:"family_id" bson:"family_id"`
+	UserID   string `json:"user_id" dynamodbav:"user_id" bson:"user_id"`
+	Device   string `json:"device" dynamodbav:"device" bson:"device"`
+
+	CreatedAt time.Time  `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty" dynamodbav:"rotated_at,omitempty" bson:"rotated_at,omitempty"`
+	// ReplacedBy contiene el hash del token que reemplazó a este en la rotación
+	ReplacedBy string `json:"replaced_by,omitempty" dynamodbav:"replaced_by,omitempty" bson:"replaced_by,omitempty"`
+	Revoked    bool   `json:"revoked" dynamodbav:"revoked" bson:"revoked"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewRefreshToken crea el registro de un refresh token a partir de su hash.
+func NewRefreshToken(tokenHash, familyID, userID, device string, expiresAt time.Time) *RefreshToken {
+	return &RefreshToken{
+		TokenHash: tokenHash,
+		FamilyID:  familyID,
+		UserID:    userID,
+		Device:    device,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		TTL:       expiresAt.Unix(),
+	}
+}
+
+// IsRotated indica si el token ya fue intercambiado por uno nuevo.
+func (t *RefreshToken) IsRotated() bool {
+	return t.ReplacedBy != ""
+}
+
+// IsExpired indica si el token ya expiró.
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RevokedToken es una entrada de la denylist de access tokens (por jti).
+// Se conserva solo hasta que el token expira; luego DynamoDB la elimina por TTL.
+type RevokedToken struct {
+	JTI       string    `json:"jti" dynamodbav:"jti" bson:"_id"`
+	UserID    string    `json:"user_id" dynamodbav:"user_id" bson:"user_id"`
+	RevokedAt time.Time `json:"revoked_at" dynamodbav:"revoked_at" bson:"revoked_at"`
+	ExpiresAt time.Time `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewRevokedToken crea una entrada de denylist válida hasta expiresAt.
+func NewRevokedToken(jti, userID string, expiresAt time.Time) *RevokedToken {
+	return &RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		RevokedAt: time.Now(),
+		ExpiresAt: expiresAt,
+		TTL:       expiresAt.Unix(),
+	}
+}
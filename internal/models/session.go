@@ -0,0 +1,42 @@
+package models
+
+import (
+	"myproject/pkg/request"
+	"myproject/pkg/useragent"
+	"time"
+)
+
+// Session representa un inicio de sesión activo en un dispositivo.
+// El ID de la sesión es el family_id de sus refresh tokens: revocar la sesión revoca la familia.
+type Session struct {
+	ID         string    `json:"id" dynamodbav:"session_id" bson:"_id"`
+	UserID     string    `json:"-" dynamodbav:"user_id" bson:"user_id"`
+	DeviceName string    `json:"device_name" dynamodbav:"device_name" bson:"device_name"`
+	UserAgent  string    `json:"user_agent" dynamodbav:"user_agent" bson:"user_agent"`
+	IP         string    `json:"ip" dynamodbav:"ip" bson:"ip"`
+	CreatedAt  time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" dynamodbav:"last_used_at" bson:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+
+	// Current indica si es la sesión del token que hizo la petición (no se persiste)
+	Current bool `json:"current" dynamodbav:"-" bson:"-"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewSession crea una sesión para la familia de refresh tokens `familyID`.
+func NewSession(familyID, userID string, client request.ClientInfo, expiresAt time.Time) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         familyID,
+		UserID:     userID,
+		DeviceName: useragent.DeviceName(client.UserAgent),
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+		TTL:        expiresAt.Unix(),
+	}
+}
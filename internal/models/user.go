@@ -1,6 +1,7 @@
 package models
 
 import (
+	"myproject/pkg/consts"
 	security "myproject/pkg/session"
 	"myproject/pkg/validations"
 	"strings"
@@ -11,42 +12,86 @@ import (
 
 // User representa la estructura de un usuario en la aplicación para DynamoDB.
 type User struct {
-	ID string `json:"id" dynamodbav:"user_id"`
+	ID string `json:"id" dynamodbav:"user_id" bson:"_id"`
 	// Información personal del usuario
-	PersonalInfo PersonalInfo `json:"personal_info" dynamodbav:"personal_info"`
+	PersonalInfo PersonalInfo `json:"personal_info" dynamodbav:"personal_info" bson:"personal_info"`
 	// Información de contacto del usuario
-	ContactInfo ContactInfo `json:"contact_info" dynamodbav:"contact_info"`
+	ContactInfo ContactInfo `json:"contact_info" dynamodbav:"contact_info" bson:"contact_info"`
+	// EmailNormalized es la clave del índice por email; la mantiene el repositorio a partir de ContactInfo
+	EmailNormalized string `json:"-" dynamodbav:"email_normalized,omitempty" bson:"email_normalized,omitempty"`
 
 	//Contraseña del usuario
-	Password string `json:"password" dynamodbav:"password"`
+	Password string `json:"password" dynamodbav:"password" bson:"password"`
+
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" dynamodbav:"updated_at,omitempty" bson:"updated_at,omitempty"`
+	DeletedAt time.Time `json:"deleted_at,omitempty" dynamodbav:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	Status    int32     `json:"status" dynamodbav:"status" bson:"status"` // Por ejemplo, 1: activo, 0: inactivo, -1: baneado
+	// Role es el rol global del usuario (consts.ROLE_*); vacío equivale a DEFAULT_USER_ROLE
+	Role string `json:"role,omitempty" dynamodbav:"role,omitempty" bson:"role,omitempty"`
+	// ActiveOrganizationID es la organización elegida en la última sesión (ver switch-org):
+	// los nuevos logins emiten tokens para ella
+	ActiveOrganizationID string    `json:"active_organization_id,omitempty" dynamodbav:"active_organization_id,omitempty" bson:"active_organization_id,omitempty"`
+	LastSession          time.Time `json:"last_session,omitempty" dynamodbav:"last_session,omitempty" bson:"last_session,omitempty"`
+	// TokenVersion se incrementa al cerrar todas las sesiones; invalida los tokens emitidos con una versión anterior
+	TokenVersion int `json:"token_version" dynamodbav:"token_version" bson:"token_version"`
+	// Version se incrementa en cada escritura y se usa para el bloqueo optimista
+	Version int64 `json:"version" dynamodbav:"version" bson:"version"`
+
+	// MFA contiene la configuración del segundo factor
+	MFA MFAInfo `json:"-" dynamodbav:"mfa" bson:"mfa"`
+}
+
+// MFAInfo agrupa la configuración del segundo factor de autenticación (TOTP).
+// Los secretos se guardan cifrados y los códigos de recuperación, hasheados.
+type MFAInfo struct {
+	TOTPEnabled bool `dynamodbav:"totp_enabled" bson:"totp_enabled"`
+	// TOTPSecret es el secreto confirmado; TOTPPendingSecret, el generado en el alta hasta que se verifica
+	TOTPSecret        string `dynamodbav:"totp_secret,omitempty" bson:"totp_secret,omitempty"`
+	TOTPPendingSecret string `dynamodbav:"totp_pending_secret,omitempty" bson:"totp_pending_secret,omitempty"`
+	// TOTPLastStep es el último paso de tiempo aceptado; impide reutilizar un código
+	TOTPLastStep  int64      `dynamodbav:"totp_last_step" bson:"totp_last_step"`
+	RecoveryCodes []string   `dynamodbav:"recovery_codes,stringset,omitempty" bson:"recovery_codes,omitempty"`
+	EnabledAt     *time.Time `dynamodbav:"enabled_at,omitempty" bson:"enabled_at,omitempty"`
+}
+
+// IsMFAEnabled indica si el login requiere un segundo factor.
+func (user *User) IsMFAEnabled() bool {
+	return user.MFA.TOTPEnabled
+}
+
+// ProfilePatch contiene los campos del perfil a modificar. Los campos nil no se modifican.
+type ProfilePatch struct {
+	Name      *string
+	LastName  *string
+	BirthDate *time.Time
+}
 
-	CreatedAt   time.Time `json:"created_at" dynamodbav:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at,omitempty" dynamodbav:"updated_at,omitempty"`
-	DeletedAt   time.Time `json:"deleted_at,omitempty" dynamodbav:"deleted_at,omitempty"`
-	Status      int32     `json:"status" dynamodbav:"status"` // Por ejemplo, 1: activo, 0: inactivo, -1: baneado
-	LastSession time.Time `json:"last_session,omitempty" dynamodbav:"last_session,omitempty"`
+// IsEmpty indica si el patch no modifica ningún campo
+func (p ProfilePatch) IsEmpty() bool {
+	return p.Name == nil && p.LastName == nil && p.BirthDate == nil
 }
 
 // PersonalInfo agrupa la información personal del usuario.
 type PersonalInfo struct {
-	Name      string     `json:"name" dynamodbav:"name"`
-	LastName  string     `json:"last_name" dynamodbav:"last_name"`
-	BirthDate *time.Time `json:"birth_date,omitempty" dynamodbav:"birth_date,omitempty"`
+	Name      string     `json:"name" dynamodbav:"name" bson:"name"`
+	LastName  string     `json:"last_name" dynamodbav:"last_name" bson:"last_name"`
+	BirthDate *time.Time `json:"birth_date,omitempty" dynamodbav:"birth_date,omitempty" bson:"birth_date,omitempty"`
 }
 
 // ContactInfo agrupa la información de contacto del usuario.
 type ContactInfo struct {
-	Email EmailDetails `json:"email" dynamodbav:"email"`
+	Email EmailDetails `json:"email" dynamodbav:"email" bson:"email"`
 	// Phone structures.Phone `json:"phone,omitempty" dynamodbav:"phone,omitempty"` // Commented out for now
 }
 
 // EmailDetails agrupa toda la información relacionada con el email.
 type EmailDetails struct {
-	Address         string    `json:"address" dynamodbav:"address"` // Nombre cambiado de Email a Address para evitar conflicto con la estructura EmailDetails
-	IsVerified      bool      `json:"is_verified" dynamodbav:"is_verified"`
-	VerifiedAt      time.Time `json:"verified_at" dynamodbav:"verified_at"`
-	IsSentForVerify bool      `json:"is_sent_for_verify" dynamodbav:"is_sent_for_verify"`
-	SentAt          time.Time `json:"sent_at" dynamodbav:"sent_at"`
+	Address         string    `json:"address" dynamodbav:"address" bson:"address"` // Nombre cambiado de Email a Address para evitar conflicto con la estructura EmailDetails
+	IsVerified      bool      `json:"is_verified" dynamodbav:"is_verified" bson:"is_verified"`
+	VerifiedAt      time.Time `json:"verified_at" dynamodbav:"verified_at" bson:"verified_at"`
+	IsSentForVerify bool      `json:"is_sent_for_verify" dynamodbav:"is_sent_for_verify" bson:"is_sent_for_verify"`
+	SentAt          time.Time `json:"sent_at" dynamodbav:"sent_at" bson:"sent_at"`
 }
 
 func NewUser(name, lastName, email, password string, companyName string) (*User, error) {
@@ -82,9 +127,32 @@ func NewUser(name, lastName, email, password string, companyName string) (*User,
 
 //bcript;
 
+// Estados posibles de un usuario (campo Status)
+const (
+	USER_STATUS_BANNED   int32 = -1
+	USER_STATUS_INACTIVE int32 = 0
+	USER_STATUS_ACTIVE   int32 = 1
+)
+
+// IsActive indica si el usuario puede iniciar sesión.
+func (user *User) IsActive() bool {
+	return user.Status == USER_STATUS_ACTIVE
+}
+
+// DEFAULT_USER_ROLE es el rol de los usuarios que no tienen uno asignado
+const DEFAULT_USER_ROLE = consts.ROLE_CLIENT
+
+// GetRole retorna el rol del usuario, o DEFAULT_USER_ROLE si no tiene uno asignado.
+func (user *User) GetRole() string {
+	if user.Role == "" {
+		return DEFAULT_USER_ROLE
+	}
+	return user.Role
+}
+
+// IsUserVerified indica si el usuario confirmó su email.
 func (user *User) IsUserVerified() bool {
-	return true
-	//return user.ContactInfo.Email.IsVerified
+	return user.ContactInfo.Email.IsVerified
 }
 
 func (user *User) GetUserDB() string {
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Ceremonias WebAuthn (campo Ceremony de WebAuthnChallenge)
+const (
+	WEBAUTHN_CEREMONY_REGISTRATION = "registration"
+	WEBAUTHN_CEREMONY_LOGIN        = "login"
+)
+
+// WebAuthnCredential es una passkey (credencial WebAuthn) registrada por un usuario.
+// El ID es el credential ID en base64url; la clave pública está en formato COSE.
+type WebAuthnCredential struct {
+	ID              string    `json:"id" dynamodbav:"credential_id" bson:"_id"`
+	UserID          string    `json:"-" dynamodbav:"user_id" bson:"user_id"`
+	Name            string    `json:"name" dynamodbav:"name" bson:"name"`
+	PublicKey       []byte    `json:"-" dynamodbav:"public_key" bson:"public_key"`
+	AttestationType string    `json:"-" dynamodbav:"attestation_type" bson:"attestation_type"`
+	AAGUID          []byte    `json:"-" dynamodbav:"aaguid,omitempty" bson:"aaguid,omitempty"`
+	Transports      []string  `json:"transports" dynamodbav:"transports,omitempty" bson:"transports,omitempty"`
+	BackupEligible  bool      `json:"backup_eligible" dynamodbav:"backup_eligible" bson:"backup_eligible"`
+	BackupState     bool      `json:"backup_state" dynamodbav:"backup_state" bson:"backup_state"`
+	CreatedAt       time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	LastUsedAt      time.Time `json:"last_used_at,omitempty" dynamodbav:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+
+	// SignCount es el contador de firmas del autenticador; si no avanza, la credencial pudo ser clonada
+	SignCount uint32 `json:"-" dynamodbav:"sign_count" bson:"sign_count"`
+}
+
+// WebAuthnChallenge es el estado de una ceremonia WebAuthn en curso (registro o login),
+// guardado entre el pedido de opciones y la respuesta del autenticador. Se usa una sola vez.
+type WebAuthnChallenge struct {
+	ID       string `json:"id" dynamodbav:"challenge_id" bson:"_id"`
+	UserID   string `json:"user_id,omitempty" dynamodbav:"user_id,omitempty" bson:"user_id,omitempty"` // vacío en el login con passkey
+	Ceremony string `json:"ceremony" dynamodbav:"ceremony" bson:"ceremony"`
+	// SessionData es el JSON de la sesión de la librería WebAuthn (challenge, RP ID, requisitos)
+	SessionData string    `json:"-" dynamodbav:"session_data" bson:"session_data"`
+	CreatedAt   time.Time `json:"created_at" dynamodbav:"created_at" bson:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" dynamodbav:"expires_at" bson:"expires_at"`
+
+	// TTL es el epoch en segundos usado por DynamoDB para eliminar el item
+	TTL int64 `json:"-" dynamodbav:"ttl" bson:"-"`
+}
+
+// NewWebAuthnChallenge crea el estado de una ceremonia válido hasta expiresAt.
+func NewWebAuthnChallenge(id, userID, ceremony, sessionData string, expiresAt time.Time) *WebAuthnChallenge {
+	return &WebAuthnChallenge{
+		ID:          id,
+		UserID:      userID,
+		Ceremony:    ceremony,
+		SessionData: sessionData,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   expiresAt,
+		TTL:         expiresAt.Unix(),
+	}
+}
+
+// IsExpired indica si la ceremonia ya venció
+func (c *WebAuthnChallenge) IsExpired() bool {
+	return !time.Now().Before(c.ExpiresAt)
+}
@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getAuthorizationCodesTableName retorna el nombre de la tabla de códigos de autorización desde variables de entorno
+func getAuthorizationCodesTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_AUTHORIZATION_CODES")
+	if tableName == "" {
+		return "authorization_codes" // nombre por defecto
+	}
+	return tableName
+}
+
+// AuthorizationCodeRepository define los métodos para persistir los códigos de autorización de OAuth.
+type AuthorizationCodeRepository interface {
+	CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error
+	GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error)
+	MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) error
+	SetAuthorizationCodeSession(ctx context.Context, codeHash, sessionID string) error
+}
+
+// authorizationCodeRepository implementa la interfaz AuthorizationCodeRepository usando DynamoDB.
+type authorizationCodeRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewAuthorizationCodeRepository crea una nueva instancia de authorizationCodeRepository.
+func NewAuthorizationCodeRepository(client *dynamodb.Client) AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateAuthorizationCode guarda un nuevo código de autorización
+func (r *authorizationCodeRepository) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	item, err := attributevalue.MarshalMap(code)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getAuthorizationCodesTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(code_hash)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetAuthorizationCode obtiene un código por su hash
+func (r *authorizationCodeRepository) GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getAuthorizationCodesTableName()),
+		Key: map[string]types.AttributeValue{
+			"code_hash": &types.AttributeValueMemberS{Value: codeHash},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var code models.AuthorizationCode
+	if err := attributevalue.UnmarshalMap(result.Item, &code); err != nil {
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+// MarkAuthorizationCodeUsed marca el código como canjeado. Si ya se había canjeado retorna
+// validations.ErrConditionFailed, lo que garantiza un único uso aun con peticiones concurrentes.
+func (r *authorizationCodeRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) error {
+	usedAt, err := attributevalue.Marshal(time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getAuthorizationCodesTableName()),
+		Key: map[string]types.AttributeValue{
+			"code_hash": &types.AttributeValueMemberS{Value: codeHash},
+		},
+		UpdateExpression:    aws.String("SET used_at = :used_at"),
+		ConditionExpression: aws.String("attribute_exists(code_hash) AND attribute_not_exists(used_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":used_at": usedAt,
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// SetAuthorizationCodeSession guarda la sesión abierta al canjear el código. Si el código no
+// existe retorna validations.ErrDocumentNotFound.
+func (r *authorizationCodeRepository) SetAuthorizationCodeSession(ctx context.Context, codeHash, sessionID string) error {
+	_, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getAuthorizationCodesTableName()),
+		Key: map[string]types.AttributeValue{
+			"code_hash": &types.AttributeValueMemberS{Value: codeHash},
+		},
+		UpdateExpression:    aws.String("SET session_id = :session_id"),
+		ConditionExpression: aws.String("attribute_exists(code_hash)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":session_id": &types.AttributeValueMemberS{Value: sessionID},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
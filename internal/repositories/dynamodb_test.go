@@ -0,0 +1,157 @@
+package repositories_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"myproject/internal/db"
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/repotest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// TestDynamoDBConformance ejecuta la suite contra DynamoDB Local. Se omite si DYNAMODB_ENDPOINT no está definido.
+//
+//	docker run -p 8000:8000 amazon/dynamodb-local
+//	DYNAMODB_ENDPOINT=http://localhost:8000 go test ./internal/repositories/...
+func TestDynamoDBConformance(t *testing.T) {
+	if os.Getenv("DYNAMODB_ENDPOINT") == "" {
+		t.Skip("DYNAMODB_ENDPOINT no definido: se omite la suite contra DynamoDB Local")
+	}
+
+	// DynamoDB Local acepta cualquier credencial, pero el SDK exige que existan
+	setDefaultEnv(t, "AWS_REGION", "us-east-1")
+	setDefaultEnv(t, "AWS_ACCESS_KEY_ID", "local")
+	setDefaultEnv(t, "AWS_SECRET_ACCESS_KEY", "local")
+
+	// Tablas con sufijo aleatorio para no mezclar datos entre ejecuciones
+	suffix := uuid.New().String()[:8]
+	tables := map[string]string{
+		"DYNAMODB_TABLE_USERS":           "users-test-" + suffix,
+		"DYNAMODB_TABLE_REFRESH_TOKENS":  "refresh_tokens-test-" + suffix,
+		"DYNAMODB_TABLE_REVOKED_TOKENS":  "revoked_tokens-test-" + suffix,
+		"DYNAMODB_TABLE_SESSIONS":        "sessions-test-" + suffix,
+		"DYNAMODB_TABLE_PASSWORD_RESETS": "password_resets-test-" + suffix,
+		"DYNAMODB_TABLE_LOGIN_ATTEMPTS":  "login_attempts-test-" + suffix,
+
+		"DYNAMODB_TABLE_WEBAUTHN_CREDENTIALS": "webauthn_credentials-test-" + suffix,
+		"DYNAMODB_TABLE_WEBAUTHN_CHALLENGES":  "webauthn_challenges-test-" + suffix,
+		"DYNAMODB_TABLE_EMAIL_LOGINS":         "email_logins-test-" + suffix,
+		"DYNAMODB_TABLE_OAUTH_CLIENTS":        "oauth_clients-test-" + suffix,
+		"DYNAMODB_TABLE_AUTHORIZATION_CODES":  "authorization_codes-test-" + suffix,
+		"DYNAMODB_TABLE_IDENTITIES":           "identities-test-" + suffix,
+		"DYNAMODB_TABLE_FEDERATED_STATES":     "federated_states-test-" + suffix,
+		"DYNAMODB_TABLE_ORGANIZATIONS":        "organizations-test-" + suffix,
+		"DYNAMODB_TABLE_MEMBERSHIPS":          "memberships-test-" + suffix,
+		"DYNAMODB_TABLE_INVITATIONS":          "invitations-test-" + suffix,
+	}
+	for key, name := range tables {
+		t.Setenv(key, name)
+	}
+
+	db.ConnectDynamoDB()
+	client := db.GetDynamoClient()
+	ctx := context.Background()
+
+	if err := repositories.Provision(ctx, client); err != nil {
+		t.Fatalf("provision: %v", err)
+	}
+	if err := repositories.CheckUserEmailsBackfilled(ctx, client); err != nil {
+		t.Fatalf("backfill check: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, name := range tables {
+			client.DeleteTable(ctx, &dynamodb.DeleteTableInput{TableName: aws.String(name)})
+		}
+	})
+
+	repotest.Run(t, repotest.Factory{
+		Users: func(t *testing.T) repositories.UserRepository { return repositories.NewUserRepository(client) },
+		RefreshTokens: func(t *testing.T) repositories.RefreshTokenRepository {
+			return repositories.NewRefreshTokenRepository(client)
+		},
+		RevokedTokens: func(t *testing.T) repositories.RevokedTokenRepository {
+			return repositories.NewRevokedTokenRepository(client)
+		},
+		Sessions: func(t *testing.T) repositories.SessionRepository { return repositories.NewSessionRepository(client) },
+		PasswordResets: func(t *testing.T) repositories.PasswordResetRepository {
+			return repositories.NewPasswordResetRepository(client)
+		},
+		LoginAttempts: func(t *testing.T) repositories.LoginAttemptRepository {
+			return repositories.NewLoginAttemptRepository(client)
+		},
+		WebAuthnCredentials: func(t *testing.T) repositories.WebAuthnCredentialRepository {
+			return repositories.NewWebAuthnCredentialRepository(client)
+		},
+		WebAuthnChallenges: func(t *testing.T) repositories.WebAuthnChallengeRepository {
+			return repositories.NewWebAuthnChallengeRepository(client)
+		},
+		EmailLogins: func(t *testing.T) repositories.EmailLoginRepository {
+			return repositories.NewEmailLoginRepository(client)
+		},
+		OAuthClients: func(t *testing.T) repositories.OAuthClientRepository {
+			return repositories.NewOAuthClientRepository(client)
+		},
+		AuthorizationCodes: func(t *testing.T) repositories.AuthorizationCodeRepository {
+			return repositories.NewAuthorizationCodeRepository(client)
+		},
+		Identities: func(t *testing.T) repositories.IdentityRepository {
+			return repositories.NewIdentityRepository(client)
+		},
+		FederatedStates: func(t *testing.T) repositories.FederatedStateRepository {
+			return repositories.NewFederatedStateRepository(client)
+		},
+		Organizations: func(t *testing.T) repositories.OrganizationRepository {
+			return repositories.NewOrganizationRepository(client)
+		},
+		Memberships: func(t *testing.T) repositories.MembershipRepository {
+			return repositories.NewMembershipRepository(client)
+		},
+		Invitations: func(t *testing.T) repositories.InvitationRepository {
+			return repositories.NewInvitationRepository(client)
+		},
+	})
+
+	t.Run("BackfillConflicts", func(t *testing.T) {
+		// Dos usuarios anteriores a las reservas con el mismo email: el segundo no puede reservarlo
+		email := "legacy-" + uuid.New().String() + "@example.com"
+		var ids []string
+		for range 2 {
+			user := repotest.NewTestUser()
+			user.ContactInfo.Email.Address = email
+			item, err := attributevalue.MarshalMap(user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.PutItem(ctx, &dynamodb.PutItemInput{TableName: aws.String(tables["DYNAMODB_TABLE_USERS"]), Item: item}); err != nil {
+				t.Fatalf("put legacy user: %v", err)
+			}
+			ids = append(ids, user.ID)
+		}
+		client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(tables["DYNAMODB_TABLE_USERS"]),
+			Key:       map[string]types.AttributeValue{"user_id": &types.AttributeValueMemberS{Value: "MIGRATION#email_reservations"}},
+		})
+
+		_, err := repositories.BackfillUserEmails(ctx, client)
+		if err == nil || (!strings.Contains(err.Error(), ids[0]) && !strings.Contains(err.Error(), ids[1])) {
+			t.Fatalf("backfill with duplicates: error = %v", err)
+		}
+		if err := repositories.CheckUserEmailsBackfilled(ctx, client); err == nil {
+			t.Fatal("backfill marked as complete with duplicate emails")
+		}
+	})
+}
+
+func setDefaultEnv(t *testing.T, key, value string) {
+	if os.Getenv(key) == "" {
+		t.Setenv(key, value)
+	}
+}
@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getEmailLoginsTableName retorna el nombre de la tabla de logins por email desde variables de entorno
+func getEmailLoginsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_EMAIL_LOGINS")
+	if tableName == "" {
+		return "email_logins" // nombre por defecto
+	}
+	return tableName
+}
+
+// EmailLoginRepository define los métodos para persistir los magic links y códigos enviados por email.
+type EmailLoginRepository interface {
+	SaveEmailLogin(ctx context.Context, login *models.EmailLogin) error
+	GetEmailLogin(ctx context.Context, key string) (*models.EmailLogin, error)
+	RegisterEmailLoginAttempt(ctx context.Context, key string, maxAttempts int) (*models.EmailLogin, error)
+	MarkEmailLoginUsed(ctx context.Context, key, secretHash string) error
+}
+
+// emailLoginRepository implementa la interfaz EmailLoginRepository usando DynamoDB.
+type emailLoginRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewEmailLoginRepository crea una nueva instancia de emailLoginRepository.
+func NewEmailLoginRepository(client *dynamodb.Client) EmailLoginRepository {
+	return &emailLoginRepository{
+		dynamoClient: client,
+	}
+}
+
+// SaveEmailLogin guarda el login pendiente, reemplazando el anterior del mismo método y email
+func (r *emailLoginRepository) SaveEmailLogin(ctx context.Context, login *models.EmailLogin) error {
+	item, err := attributevalue.MarshalMap(login)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(getEmailLoginsTableName()),
+		Item:      item,
+	})
+
+	return err
+}
+
+// GetEmailLogin obtiene el login pendiente de una clave. Si no existe o venció retorna validations.ErrDocumentNotFound.
+func (r *emailLoginRepository) GetEmailLogin(ctx context.Context, key string) (*models.EmailLogin, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getEmailLoginsTableName()),
+		Key: map[string]types.AttributeValue{
+			"login_key": &types.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var login models.EmailLogin
+	if err := attributevalue.UnmarshalMap(result.Item, &login); err != nil {
+		return nil, err
+	}
+
+	// El TTL de DynamoDB puede tardar en eliminar los items vencidos
+	if login.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &login, nil
+}
+
+// RegisterEmailLoginAttempt suma un intento de canje de forma atómica y retorna el login actualizado.
+// Si no existe, venció, ya se usó o agotó los maxAttempts intentos retorna validations.ErrConditionFailed,
+// lo que acota los intentos aun con peticiones concurrentes.
+func (r *emailLoginRepository) RegisterEmailLoginAttempt(ctx context.Context, key string, maxAttempts int) (*models.EmailLogin, error) {
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":max": maxAttempts,
+		":now": time.Now().Unix(),
+		":one": 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getEmailLoginsTableName()),
+		Key: map[string]types.AttributeValue{
+			"login_key": &types.AttributeValueMemberS{Value: key},
+		},
+		UpdateExpression:          aws.String("ADD attempts :one"),
+		ConditionExpression:       aws.String("attribute_exists(login_key) AND attribute_not_exists(used_at) AND attempts < :max AND #ttl > :now"),
+		ExpressionAttributeNames:  map[string]string{"#ttl": "ttl"},
+		ExpressionAttributeValues: values,
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+	if isConditionalCheckFailed(err) {
+		return nil, validations.ErrConditionFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var login models.EmailLogin
+	if err := attributevalue.UnmarshalMap(result.Attributes, &login); err != nil {
+		return nil, err
+	}
+
+	return &login, nil
+}
+
+// MarkEmailLoginUsed marca el login como usado si todavía corresponde al secreto secretHash.
+// Si ya se usó o fue reemplazado por un nuevo envío retorna validations.ErrConditionFailed.
+func (r *emailLoginRepository) MarkEmailLoginUsed(ctx context.Context, key, secretHash string) error {
+	usedAt, err := attributevalue.Marshal(time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getEmailLoginsTableName()),
+		Key: map[string]types.AttributeValue{
+			"login_key": &types.AttributeValueMemberS{Value: key},
+		},
+		UpdateExpression:    aws.String("SET used_at = :used_at"),
+		ConditionExpression: aws.String("attribute_exists(login_key) AND attribute_not_exists(used_at) AND secret_hash = :secret_hash"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":used_at":     usedAt,
+			":secret_hash": &types.AttributeValueMemberS{Value: secretHash},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// isConditionalCheckFailed indica si el error proviene de una ConditionExpression
+// que no se cumplió, tanto en operaciones simples como en transacciones.
+func isConditionalCheckFailed(err error) bool {
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return true
+	}
+
+	var txErr *types.TransactionCanceledException
+	if errors.As(err, &txErr) {
+		for _, reason := range txErr.CancellationReasons {
+			if reason.Code != nil && *reason.Code == "ConditionalCheckFailed" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isTransactionItemConditionFailed indica si en una transacción cancelada falló la
+// condición del item en la posición index.
+func isTransactionItemConditionFailed(err error, index int) bool {
+	var txErr *types.TransactionCanceledException
+	if !errors.As(err, &txErr) || index >= len(txErr.CancellationReasons) {
+		return false
+	}
+
+	code := txErr.CancellationReasons[index].Code
+	return code != nil && *code == "ConditionalCheckFailed"
+}
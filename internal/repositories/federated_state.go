@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getFederatedStatesTableName retorna el nombre de la tabla de logins con proveedores externos desde variables de entorno
+func getFederatedStatesTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_FEDERATED_STATES")
+	if tableName == "" {
+		return "federated_states" // nombre por defecto
+	}
+	return tableName
+}
+
+// FederatedStateRepository define los métodos para guardar el estado de los logins con proveedores externos en curso.
+type FederatedStateRepository interface {
+	CreateState(ctx context.Context, state *models.FederatedState) error
+	ConsumeState(ctx context.Context, stateHash string) (*models.FederatedState, error)
+}
+
+// federatedStateRepository implementa la interfaz FederatedStateRepository usando DynamoDB.
+type federatedStateRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewFederatedStateRepository crea una nueva instancia de federatedStateRepository.
+func NewFederatedStateRepository(client *dynamodb.Client) FederatedStateRepository {
+	return &federatedStateRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateState guarda el estado de un nuevo login
+func (r *federatedStateRepository) CreateState(ctx context.Context, state *models.FederatedState) error {
+	item, err := attributevalue.MarshalMap(state)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getFederatedStatesTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(state_hash)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// ConsumeState elimina el estado y lo retorna, de modo que el callback solo pueda completarse
+// una vez aun con peticiones concurrentes. Si no existe o ya venció retorna ErrDocumentNotFound.
+func (r *federatedStateRepository) ConsumeState(ctx context.Context, stateHash string) (*models.FederatedState, error) {
+	result, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getFederatedStatesTableName()),
+		Key: map[string]types.AttributeValue{
+			"state_hash": &types.AttributeValueMemberS{Value: stateHash},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Attributes == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var state models.FederatedState
+	if err := attributevalue.UnmarshalMap(result.Attributes, &state); err != nil {
+		return nil, err
+	}
+
+	// El TTL de DynamoDB no elimina los items de inmediato
+	if state.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// identitiesUserIndex es el GSI (partition key: user_id) usado para listar las identidades de un usuario.
+const identitiesUserIndex = "user_id-index"
+
+// getIdentitiesTableName retorna el nombre de la tabla de identidades externas desde variables de entorno
+func getIdentitiesTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_IDENTITIES")
+	if tableName == "" {
+		return "identities" // nombre por defecto
+	}
+	return tableName
+}
+
+// IdentityRepository define los métodos para persistir las cuentas de proveedores externos vinculadas a los usuarios.
+type IdentityRepository interface {
+	CreateIdentity(ctx context.Context, identity *models.Identity) error
+	GetIdentity(ctx context.Context, id string) (*models.Identity, error)
+	ListIdentitiesByUser(ctx context.Context, userID string) ([]models.Identity, error)
+	DeleteIdentity(ctx context.Context, userID, id string) error
+}
+
+// identityRepository implementa la interfaz IdentityRepository usando DynamoDB.
+type identityRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewIdentityRepository crea una nueva instancia de identityRepository.
+func NewIdentityRepository(client *dynamodb.Client) IdentityRepository {
+	return &identityRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateIdentity vincula una identidad. Si ya está vinculada (a cualquier usuario) retorna ErrDocumentAlreadyExists.
+func (r *identityRepository) CreateIdentity(ctx context.Context, identity *models.Identity) error {
+	item, err := attributevalue.MarshalMap(identity)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getIdentitiesTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(identity_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetIdentity obtiene una identidad por su clave (models.IdentityKey)
+func (r *identityRepository) GetIdentity(ctx context.Context, id string) (*models.Identity, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getIdentitiesTableName()),
+		Key: map[string]types.AttributeValue{
+			"identity_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var identity models.Identity
+	if err := attributevalue.UnmarshalMap(result.Item, &identity); err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// ListIdentitiesByUser lista las identidades de un usuario, de la más antigua a la más reciente
+func (r *identityRepository) ListIdentitiesByUser(ctx context.Context, userID string) ([]models.Identity, error) {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:              aws.String(getIdentitiesTableName()),
+		IndexName:              aws.String(identitiesUserIndex),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+
+	identities := []models.Identity{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []models.Identity
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		identities = append(identities, items...)
+	}
+
+	sort.Slice(identities, func(i, j int) bool { return identities[i].CreatedAt.Before(identities[j].CreatedAt) })
+	return identities, nil
+}
+
+// DeleteIdentity desvincula una identidad del usuario. Si no existe o pertenece a otro usuario
+// retorna ErrDocumentNotFound.
+func (r *identityRepository) DeleteIdentity(ctx context.Context, userID, id string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getIdentitiesTableName()),
+		Key: map[string]types.AttributeValue{
+			"identity_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
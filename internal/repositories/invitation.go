@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// invitationsOrganizationIndex es el GSI (partition key: organization_id) usado para listar las invitaciones de una organización.
+const invitationsOrganizationIndex = "organization_id-index"
+
+// getInvitationsTableName retorna el nombre de la tabla de invitaciones desde variables de entorno
+func getInvitationsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_INVITATIONS")
+	if tableName == "" {
+		return "invitations" // nombre por defecto
+	}
+	return tableName
+}
+
+// InvitationRepository define los métodos para persistir las invitaciones pendientes a las organizaciones.
+type InvitationRepository interface {
+	CreateInvitation(ctx context.Context, invitation *models.Invitation) error
+	GetInvitation(ctx context.Context, id string) (*models.Invitation, error)
+	ListInvitationsByOrganization(ctx context.Context, organizationID string) ([]models.Invitation, error)
+	RenewInvitation(ctx context.Context, organizationID, id, tokenID string, expiresAt time.Time) error
+	DeleteInvitation(ctx context.Context, organizationID, id string) error
+	ConsumeInvitation(ctx context.Context, id, tokenID string) (*models.Invitation, error)
+}
+
+// invitationRepository implementa la interfaz InvitationRepository usando DynamoDB.
+type invitationRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewInvitationRepository crea una nueva instancia de invitationRepository.
+func NewInvitationRepository(client *dynamodb.Client) InvitationRepository {
+	return &invitationRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateInvitation guarda una nueva invitación
+func (r *invitationRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	item, err := attributevalue.MarshalMap(invitation)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getInvitationsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(invitation_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetInvitation obtiene una invitación por su ID, aunque esté vencida
+func (r *invitationRepository) GetInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getInvitationsTableName()),
+		Key: map[string]types.AttributeValue{
+			"invitation_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var invitation models.Invitation
+	if err := attributevalue.UnmarshalMap(result.Item, &invitation); err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// ListInvitationsByOrganization lista las invitaciones vigentes de una organización, de la más antigua a la más reciente
+func (r *invitationRepository) ListInvitationsByOrganization(ctx context.Context, organizationID string) ([]models.Invitation, error) {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:              aws.String(getInvitationsTableName()),
+		IndexName:              aws.String(invitationsOrganizationIndex),
+		KeyConditionExpression: aws.String("organization_id = :organization_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":organization_id": &types.AttributeValueMemberS{Value: organizationID},
+		},
+	})
+
+	invitations := []models.Invitation{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []models.Invitation
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+
+		// El TTL de DynamoDB puede tardar en eliminar los items vencidos
+		for _, invitation := range items {
+			if !invitation.IsExpired() {
+				invitations = append(invitations, invitation)
+			}
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].CreatedAt.Before(invitations[j].CreatedAt) })
+	return invitations, nil
+}
+
+// RenewInvitation reemplaza el token de la invitación y extiende su expiración. Si no existe o
+// pertenece a otra organización retorna ErrDocumentNotFound.
+func (r *invitationRepository) RenewInvitation(ctx context.Context, organizationID, id, tokenID string, expiresAt time.Time) error {
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":organization_id": organizationID,
+		":token_id":        tokenID,
+		":expires_at":      expiresAt,
+		":ttl":             expiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getInvitationsTableName()),
+		Key: map[string]types.AttributeValue{
+			"invitation_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String("SET token_id = :token_id, expires_at = :expires_at, #ttl = :ttl"),
+		ConditionExpression:       aws.String("organization_id = :organization_id"),
+		ExpressionAttributeNames:  map[string]string{"#ttl": "ttl"}, // "ttl" es palabra reservada
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
+
+// DeleteInvitation revoca una invitación de la organización. Si no existe o pertenece a otra
+// organización retorna ErrDocumentNotFound.
+func (r *invitationRepository) DeleteInvitation(ctx context.Context, organizationID, id string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getInvitationsTableName()),
+		Key: map[string]types.AttributeValue{
+			"invitation_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("organization_id = :organization_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":organization_id": &types.AttributeValueMemberS{Value: organizationID},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
+
+// ConsumeInvitation elimina la invitación y la retorna, solo si tokenID es el de su último envío,
+// de modo que cada link se acepte una sola vez aun con peticiones concurrentes. Si no existe, el
+// token fue reemplazado o ya venció retorna ErrDocumentNotFound.
+func (r *invitationRepository) ConsumeInvitation(ctx context.Context, id, tokenID string) (*models.Invitation, error) {
+	result, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getInvitationsTableName()),
+		Key: map[string]types.AttributeValue{
+			"invitation_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("token_id = :token_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":token_id": &types.AttributeValueMemberS{Value: tokenID},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if isConditionalCheckFailed(err) {
+		return nil, validations.ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var invitation models.Invitation
+	if err := attributevalue.UnmarshalMap(result.Attributes, &invitation); err != nil {
+		return nil, err
+	}
+
+	// El TTL de DynamoDB no elimina los items de inmediato
+	if invitation.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &invitation, nil
+}
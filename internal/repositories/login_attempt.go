@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// loginAttemptsEmailIndex es el GSI (partition key: email) usado para desbloquear una cuenta.
+const loginAttemptsEmailIndex = "email-index"
+
+// getLoginAttemptsTableName retorna el nombre de la tabla de intentos de login desde variables de entorno
+func getLoginAttemptsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_LOGIN_ATTEMPTS")
+	if tableName == "" {
+		return "login_attempts" // nombre por defecto
+	}
+	return tableName
+}
+
+// LoginAttemptRepository define los métodos para contar los intentos de login fallidos en DynamoDB.
+type LoginAttemptRepository interface {
+	GetLoginAttempt(ctx context.Context, key string) (*models.LoginAttempt, error)
+	RegisterFailure(ctx context.Context, key, email string, failedAt, expiresAt time.Time) (*models.LoginAttempt, error)
+	DeleteLoginAttempt(ctx context.Context, key string) error
+	DeleteLoginAttemptsByEmail(ctx context.Context, email string) error
+}
+
+// loginAttemptRepository implementa la interfaz LoginAttemptRepository usando DynamoDB.
+type loginAttemptRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewLoginAttemptRepository crea una nueva instancia de loginAttemptRepository.
+func NewLoginAttemptRepository(client *dynamodb.Client) LoginAttemptRepository {
+	return &loginAttemptRepository{
+		dynamoClient: client,
+	}
+}
+
+// GetLoginAttempt obtiene los intentos fallidos de una clave. Si no hay o la ventana venció
+// retorna validations.ErrDocumentNotFound.
+func (r *loginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getLoginAttemptsTableName()),
+		Key: map[string]types.AttributeValue{
+			"attempt_key": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var attempt models.LoginAttempt
+	if err := attributevalue.UnmarshalMap(result.Item, &attempt); err != nil {
+		return nil, err
+	}
+
+	// El TTL de DynamoDB puede tardar en eliminar los items vencidos
+	if attempt.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &attempt, nil
+}
+
+// RegisterFailure suma un fallo a la clave de forma atómica y extiende su ventana hasta expiresAt.
+// Si la ventana anterior ya había vencido, el conteo vuelve a empezar.
+func (r *loginAttemptRepository) RegisterFailure(ctx context.Context, key, email string, failedAt, expiresAt time.Time) (*models.LoginAttempt, error) {
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":email":           email,
+		":last_failure_at": failedAt,
+		":expires_at":      expiresAt,
+		":ttl":             expiresAt.Unix(),
+		":now":             failedAt.Unix(),
+		":one":             1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Dos intentos: si el item existe pero venció, se elimina y se vuelve a contar desde cero
+	for i := 0; i < 2; i++ {
+		result, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+			TableName: aws.String(getLoginAttemptsTableName()),
+			Key: map[string]types.AttributeValue{
+				"attempt_key": &types.AttributeValueMemberS{Value: key},
+			},
+			UpdateExpression:          aws.String("SET #email = :email, last_failure_at = :last_failure_at, expires_at = :expires_at, #ttl = :ttl ADD failures :one"),
+			ConditionExpression:       aws.String("attribute_not_exists(attempt_key) OR #ttl > :now"),
+			ExpressionAttributeNames:  map[string]string{"#email": "email", "#ttl": "ttl"},
+			ExpressionAttributeValues: values,
+			ReturnValues:              types.ReturnValueAllNew,
+		})
+		if err == nil {
+			var attempt models.LoginAttempt
+			if err := attributevalue.UnmarshalMap(result.Attributes, &attempt); err != nil {
+				return nil, err
+			}
+			return &attempt, nil
+		}
+		if !isConditionalCheckFailed(err) {
+			return nil, err
+		}
+
+		_, err = r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(getLoginAttemptsTableName()),
+			Key: map[string]types.AttributeValue{
+				"attempt_key": &types.AttributeValueMemberS{Value: key},
+			},
+			ConditionExpression:       aws.String("#ttl <= :now"),
+			ExpressionAttributeNames:  map[string]string{"#ttl": "ttl"},
+			ExpressionAttributeValues: map[string]types.AttributeValue{":now": values[":now"]},
+		})
+		if err != nil && !isConditionalCheckFailed(err) {
+			return nil, err
+		}
+	}
+
+	return nil, validations.ErrConditionFailed
+}
+
+// DeleteLoginAttempt reinicia el conteo de una clave
+func (r *loginAttemptRepository) DeleteLoginAttempt(ctx context.Context, key string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getLoginAttemptsTableName()),
+		Key: map[string]types.AttributeValue{
+			"attempt_key": &types.AttributeValueMemberS{Value: key},
+		},
+	})
+
+	return err
+}
+
+// DeleteLoginAttemptsByEmail reinicia el conteo de todas las claves de una cuenta (desde cualquier IP)
+func (r *loginAttemptRepository) DeleteLoginAttemptsByEmail(ctx context.Context, email string) error {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:                aws.String(getLoginAttemptsTableName()),
+		IndexName:                aws.String(loginAttemptsEmailIndex),
+		KeyConditionExpression:   aws.String("#email = :email"),
+		ExpressionAttributeNames: map[string]string{"#email": "email"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":email": &types.AttributeValueMemberS{Value: email},
+		},
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		var items []models.LoginAttempt
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return err
+		}
+
+		for _, attempt := range items {
+			if err := r.DeleteLoginAttempt(ctx, attempt.Key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
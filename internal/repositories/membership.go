@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// membershipsUserIndex es el GSI (partition key: user_id) usado para listar las organizaciones de un usuario.
+const membershipsUserIndex = "user_id-index"
+
+// getMembershipsTableName retorna el nombre de la tabla de membresías desde variables de entorno
+func getMembershipsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_MEMBERSHIPS")
+	if tableName == "" {
+		return "memberships" // nombre por defecto
+	}
+	return tableName
+}
+
+// MembershipRepository define los métodos para persistir la pertenencia de los usuarios a las organizaciones.
+type MembershipRepository interface {
+	CreateMembership(ctx context.Context, membership *models.Membership) error
+	GetMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error)
+	ListMembershipsByUser(ctx context.Context, userID string) ([]models.Membership, error)
+}
+
+// membershipRepository implementa la interfaz MembershipRepository usando DynamoDB.
+type membershipRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewMembershipRepository crea una nueva instancia de membershipRepository.
+func NewMembershipRepository(client *dynamodb.Client) MembershipRepository {
+	return &membershipRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateMembership agrega el usuario a la organización. Si ya es miembro retorna ErrDocumentAlreadyExists.
+func (r *membershipRepository) CreateMembership(ctx context.Context, membership *models.Membership) error {
+	item, err := attributevalue.MarshalMap(membership)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getMembershipsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(membership_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetMembership obtiene la membresía del usuario en la organización
+func (r *membershipRepository) GetMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getMembershipsTableName()),
+		Key: map[string]types.AttributeValue{
+			"membership_id": &types.AttributeValueMemberS{Value: models.MembershipKey(organizationID, userID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var membership models.Membership
+	if err := attributevalue.UnmarshalMap(result.Item, &membership); err != nil {
+		return nil, err
+	}
+
+	return &membership, nil
+}
+
+// ListMembershipsByUser lista las membresías de un usuario, de la más antigua a la más reciente
+func (r *membershipRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]models.Membership, error) {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:              aws.String(getMembershipsTableName()),
+		IndexName:              aws.String(membershipsUserIndex),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+
+	memberships := []models.Membership{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []models.Membership
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, items...)
+	}
+
+	sort.Slice(memberships, func(i, j int) bool { return memberships[i].CreatedAt.Before(memberships[j].CreatedAt) })
+	return memberships, nil
+}
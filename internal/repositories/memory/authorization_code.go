@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// authorizationCodeRepository implementa repositories.AuthorizationCodeRepository en memoria.
+type authorizationCodeRepository struct {
+	mu    sync.RWMutex
+	codes map[string]models.AuthorizationCode
+}
+
+// NewAuthorizationCodeRepository crea un AuthorizationCodeRepository vacío en memoria.
+func NewAuthorizationCodeRepository() repositories.AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		codes: map[string]models.AuthorizationCode{},
+	}
+}
+
+// CreateAuthorizationCode guarda un nuevo código de autorización
+func (r *authorizationCodeRepository) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.codes[code.CodeHash]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.codes[code.CodeHash] = cloneAuthorizationCode(*code)
+	return nil
+}
+
+// GetAuthorizationCode obtiene un código por su hash
+func (r *authorizationCodeRepository) GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	code = cloneAuthorizationCode(code)
+	return &code, nil
+}
+
+// MarkAuthorizationCodeUsed marca el código como canjeado. Si ya se había canjeado retorna validations.ErrConditionFailed.
+func (r *authorizationCodeRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[codeHash]
+	if !ok || code.UsedAt != nil {
+		return validations.ErrConditionFailed
+	}
+
+	usedAt := time.Now()
+	code.UsedAt = &usedAt
+	r.codes[codeHash] = code
+	return nil
+}
+
+// SetAuthorizationCodeSession guarda la sesión abierta al canjear el código.
+func (r *authorizationCodeRepository) SetAuthorizationCodeSession(ctx context.Context, codeHash, sessionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	code, ok := r.codes[codeHash]
+	if !ok {
+		return validations.ErrDocumentNotFound
+	}
+
+	code.SessionID = sessionID
+	r.codes[codeHash] = code
+	return nil
+}
+
+// cloneAuthorizationCode copia el código para que el llamador no comparta el puntero UsedAt con el almacenado
+func cloneAuthorizationCode(code models.AuthorizationCode) models.AuthorizationCode {
+	if code.UsedAt != nil {
+		usedAt := *code.UsedAt
+		code.UsedAt = &usedAt
+	}
+	return code
+}
@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// emailLoginRepository implementa repositories.EmailLoginRepository en memoria.
+type emailLoginRepository struct {
+	mu     sync.Mutex
+	logins map[string]models.EmailLogin
+}
+
+// NewEmailLoginRepository crea un EmailLoginRepository vacío en memoria.
+func NewEmailLoginRepository() repositories.EmailLoginRepository {
+	return &emailLoginRepository{
+		logins: map[string]models.EmailLogin{},
+	}
+}
+
+// SaveEmailLogin guarda el login pendiente, reemplazando el anterior del mismo método y email
+func (r *emailLoginRepository) SaveEmailLogin(ctx context.Context, login *models.EmailLogin) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.logins[login.Key] = cloneEmailLogin(*login)
+	return nil
+}
+
+// GetEmailLogin obtiene el login pendiente de una clave; si no existe o venció retorna ErrDocumentNotFound
+func (r *emailLoginRepository) GetEmailLogin(ctx context.Context, key string) (*models.EmailLogin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	login, ok := r.logins[key]
+	if !ok || login.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	login = cloneEmailLogin(login)
+	return &login, nil
+}
+
+// RegisterEmailLoginAttempt suma un intento de canje. Si no existe, venció, ya se usó o agotó
+// los intentos retorna validations.ErrConditionFailed.
+func (r *emailLoginRepository) RegisterEmailLoginAttempt(ctx context.Context, key string, maxAttempts int) (*models.EmailLogin, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	login, ok := r.logins[key]
+	if !ok || login.IsExpired() || login.UsedAt != nil || login.Attempts >= maxAttempts {
+		return nil, validations.ErrConditionFailed
+	}
+
+	login.Attempts++
+	r.logins[key] = login
+
+	login = cloneEmailLogin(login)
+	return &login, nil
+}
+
+// MarkEmailLoginUsed marca el login como usado si todavía corresponde al secreto secretHash.
+// Si ya se usó o fue reemplazado retorna validations.ErrConditionFailed.
+func (r *emailLoginRepository) MarkEmailLoginUsed(ctx context.Context, key, secretHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	login, ok := r.logins[key]
+	if !ok || login.UsedAt != nil || login.SecretHash != secretHash {
+		return validations.ErrConditionFailed
+	}
+
+	usedAt := time.Now()
+	login.UsedAt = &usedAt
+	r.logins[key] = login
+	return nil
+}
+
+// cloneEmailLogin copia el login para que el llamador no comparta el puntero UsedAt con el almacenado
+func cloneEmailLogin(login models.EmailLogin) models.EmailLogin {
+	if login.UsedAt != nil {
+		usedAt := *login.UsedAt
+		login.UsedAt = &usedAt
+	}
+	return login
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// federatedStateRepository implementa repositories.FederatedStateRepository en memoria.
+type federatedStateRepository struct {
+	mu     sync.Mutex
+	states map[string]models.FederatedState
+}
+
+// NewFederatedStateRepository crea un FederatedStateRepository vacío en memoria.
+func NewFederatedStateRepository() repositories.FederatedStateRepository {
+	return &federatedStateRepository{
+		states: map[string]models.FederatedState{},
+	}
+}
+
+// CreateState guarda el estado de un nuevo login
+func (r *federatedStateRepository) CreateState(ctx context.Context, state *models.FederatedState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.states[state.StateHash]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.states[state.StateHash] = *state
+	return nil
+}
+
+// ConsumeState elimina el estado y lo retorna; si no existe o venció retorna ErrDocumentNotFound
+func (r *federatedStateRepository) ConsumeState(ctx context.Context, stateHash string) (*models.FederatedState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[stateHash]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	delete(r.states, stateHash)
+	if state.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &state, nil
+}
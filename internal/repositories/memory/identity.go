@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// identityRepository implementa repositories.IdentityRepository en memoria.
+type identityRepository struct {
+	mu         sync.RWMutex
+	identities map[string]models.Identity
+}
+
+// NewIdentityRepository crea un IdentityRepository vacío en memoria.
+func NewIdentityRepository() repositories.IdentityRepository {
+	return &identityRepository{
+		identities: map[string]models.Identity{},
+	}
+}
+
+// CreateIdentity vincula una identidad
+func (r *identityRepository) CreateIdentity(ctx context.Context, identity *models.Identity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.identities[identity.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+// GetIdentity obtiene una identidad por su clave
+func (r *identityRepository) GetIdentity(ctx context.Context, id string) (*models.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identity, ok := r.identities[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &identity, nil
+}
+
+// ListIdentitiesByUser lista las identidades de un usuario, de la más antigua a la más reciente
+func (r *identityRepository) ListIdentitiesByUser(ctx context.Context, userID string) ([]models.Identity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	identities := []models.Identity{}
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+
+	sort.Slice(identities, func(i, j int) bool { return identities[i].CreatedAt.Before(identities[j].CreatedAt) })
+	return identities, nil
+}
+
+// DeleteIdentity desvincula una identidad del usuario
+func (r *identityRepository) DeleteIdentity(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	identity, ok := r.identities[id]
+	if !ok || identity.UserID != userID {
+		return validations.ErrDocumentNotFound
+	}
+
+	delete(r.identities, id)
+	return nil
+}
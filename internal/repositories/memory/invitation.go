@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// invitationRepository implementa repositories.InvitationRepository en memoria.
+type invitationRepository struct {
+	mu          sync.RWMutex
+	invitations map[string]models.Invitation
+}
+
+// NewInvitationRepository crea un InvitationRepository vacío en memoria.
+func NewInvitationRepository() repositories.InvitationRepository {
+	return &invitationRepository{
+		invitations: map[string]models.Invitation{},
+	}
+}
+
+// CreateInvitation guarda una nueva invitación
+func (r *invitationRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.invitations[invitation.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.invitations[invitation.ID] = *invitation
+	return nil
+}
+
+// GetInvitation obtiene una invitación por su ID, aunque esté vencida
+func (r *invitationRepository) GetInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &invitation, nil
+}
+
+// ListInvitationsByOrganization lista las invitaciones vigentes de una organización, de la más antigua a la más reciente
+func (r *invitationRepository) ListInvitationsByOrganization(ctx context.Context, organizationID string) ([]models.Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	invitations := []models.Invitation{}
+	for _, invitation := range r.invitations {
+		if invitation.OrganizationID == organizationID && !invitation.IsExpired() {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool { return invitations[i].CreatedAt.Before(invitations[j].CreatedAt) })
+	return invitations, nil
+}
+
+// RenewInvitation reemplaza el token de la invitación y extiende su expiración
+func (r *invitationRepository) RenewInvitation(ctx context.Context, organizationID, id, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.OrganizationID != organizationID {
+		return validations.ErrDocumentNotFound
+	}
+
+	invitation.TokenID = tokenID
+	invitation.ExpiresAt = expiresAt
+	invitation.TTL = expiresAt.Unix()
+	r.invitations[id] = invitation
+	return nil
+}
+
+// DeleteInvitation revoca una invitación de la organización
+func (r *invitationRepository) DeleteInvitation(ctx context.Context, organizationID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.OrganizationID != organizationID {
+		return validations.ErrDocumentNotFound
+	}
+
+	delete(r.invitations, id)
+	return nil
+}
+
+// ConsumeInvitation elimina la invitación y la retorna si tokenID es el de su último envío;
+// si no existe, el token fue reemplazado o venció retorna ErrDocumentNotFound
+func (r *invitationRepository) ConsumeInvitation(ctx context.Context, id, tokenID string) (*models.Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	invitation, ok := r.invitations[id]
+	if !ok || invitation.TokenID != tokenID {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	delete(r.invitations, id)
+	if invitation.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &invitation, nil
+}
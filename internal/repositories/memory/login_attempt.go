@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// loginAttemptRepository implementa repositories.LoginAttemptRepository en memoria.
+type loginAttemptRepository struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempt
+}
+
+// NewLoginAttemptRepository crea un LoginAttemptRepository vacío en memoria.
+func NewLoginAttemptRepository() repositories.LoginAttemptRepository {
+	return &loginAttemptRepository{
+		attempts: map[string]models.LoginAttempt{},
+	}
+}
+
+// GetLoginAttempt obtiene los intentos fallidos vigentes de una clave
+func (r *loginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok || attempt.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &attempt, nil
+}
+
+// RegisterFailure suma un fallo a la clave; si la ventana anterior venció, el conteo vuelve a empezar
+func (r *loginAttemptRepository) RegisterFailure(ctx context.Context, key, email string, failedAt, expiresAt time.Time) (*models.LoginAttempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attempt, ok := r.attempts[key]
+	if !ok || !failedAt.Before(attempt.ExpiresAt) {
+		attempt = *models.NewLoginAttempt(key, email, failedAt, expiresAt)
+	} else {
+		attempt.Email = email
+		attempt.Failures++
+		attempt.LastFailureAt = failedAt
+		attempt.ExpiresAt = expiresAt
+		attempt.TTL = expiresAt.Unix()
+	}
+
+	r.attempts[key] = attempt
+	return &attempt, nil
+}
+
+// DeleteLoginAttempt reinicia el conteo de una clave
+func (r *loginAttemptRepository) DeleteLoginAttempt(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.attempts, key)
+	return nil
+}
+
+// DeleteLoginAttemptsByEmail reinicia el conteo de todas las claves de una cuenta
+func (r *loginAttemptRepository) DeleteLoginAttemptsByEmail(ctx context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, attempt := range r.attempts {
+		if attempt.Email == email {
+			delete(r.attempts, key)
+		}
+	}
+	return nil
+}
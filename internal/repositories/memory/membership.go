@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// membershipRepository implementa repositories.MembershipRepository en memoria.
+type membershipRepository struct {
+	mu          sync.RWMutex
+	memberships map[string]models.Membership
+}
+
+// NewMembershipRepository crea un MembershipRepository vacío en memoria.
+func NewMembershipRepository() repositories.MembershipRepository {
+	return &membershipRepository{
+		memberships: map[string]models.Membership{},
+	}
+}
+
+// CreateMembership agrega el usuario a la organización
+func (r *membershipRepository) CreateMembership(ctx context.Context, membership *models.Membership) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.memberships[membership.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.memberships[membership.ID] = *membership
+	return nil
+}
+
+// GetMembership obtiene la membresía del usuario en la organización
+func (r *membershipRepository) GetMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	membership, ok := r.memberships[models.MembershipKey(organizationID, userID)]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &membership, nil
+}
+
+// ListMembershipsByUser lista las membresías de un usuario, de la más antigua a la más reciente
+func (r *membershipRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]models.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	memberships := []models.Membership{}
+	for _, membership := range r.memberships {
+		if membership.UserID == userID {
+			memberships = append(memberships, membership)
+		}
+	}
+
+	sort.Slice(memberships, func(i, j int) bool { return memberships[i].CreatedAt.Before(memberships[j].CreatedAt) })
+	return memberships, nil
+}
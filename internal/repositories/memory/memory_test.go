@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"testing"
+
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, repotest.Factory{
+		Users:          func(t *testing.T) repositories.UserRepository { return NewUserRepository() },
+		RefreshTokens:  func(t *testing.T) repositories.RefreshTokenRepository { return NewRefreshTokenRepository() },
+		RevokedTokens:  func(t *testing.T) repositories.RevokedTokenRepository { return NewRevokedTokenRepository() },
+		Sessions:       func(t *testing.T) repositories.SessionRepository { return NewSessionRepository() },
+		PasswordResets: func(t *testing.T) repositories.PasswordResetRepository { return NewPasswordResetRepository() },
+		LoginAttempts:  func(t *testing.T) repositories.LoginAttemptRepository { return NewLoginAttemptRepository() },
+		WebAuthnCredentials: func(t *testing.T) repositories.WebAuthnCredentialRepository {
+			return NewWebAuthnCredentialRepository()
+		},
+		WebAuthnChallenges: func(t *testing.T) repositories.WebAuthnChallengeRepository {
+			return NewWebAuthnChallengeRepository()
+		},
+		EmailLogins: func(t *testing.T) repositories.EmailLoginRepository {
+			return NewEmailLoginRepository()
+		},
+		OAuthClients: func(t *testing.T) repositories.OAuthClientRepository {
+			return NewOAuthClientRepository()
+		},
+		AuthorizationCodes: func(t *testing.T) repositories.AuthorizationCodeRepository {
+			return NewAuthorizationCodeRepository()
+		},
+		Identities: func(t *testing.T) repositories.IdentityRepository {
+			return NewIdentityRepository()
+		},
+		FederatedStates: func(t *testing.T) repositories.FederatedStateRepository {
+			return NewFederatedStateRepository()
+		},
+		Organizations: func(t *testing.T) repositories.OrganizationRepository {
+			return NewOrganizationRepository()
+		},
+		Memberships: func(t *testing.T) repositories.MembershipRepository {
+			return NewMembershipRepository()
+		},
+		Invitations: func(t *testing.T) repositories.InvitationRepository {
+			return NewInvitationRepository()
+		},
+	})
+}
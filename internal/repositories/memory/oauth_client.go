@@ -0,0 +1,88 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// oauthClientRepository implementa repositories.OAuthClientRepository en memoria.
+type oauthClientRepository struct {
+	mu      sync.RWMutex
+	clients map[string]models.OAuthClient
+}
+
+// NewOAuthClientRepository crea un OAuthClientRepository vacío en memoria.
+func NewOAuthClientRepository() repositories.OAuthClientRepository {
+	return &oauthClientRepository{
+		clients: map[string]models.OAuthClient{},
+	}
+}
+
+// CreateClient guarda un nuevo cliente
+func (r *oauthClientRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.clients[client.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.clients[client.ID] = cloneOAuthClient(*client)
+	return nil
+}
+
+// GetClient obtiene un cliente por su ID
+func (r *oauthClientRepository) GetClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	client, ok := r.clients[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	client = cloneOAuthClient(client)
+	return &client, nil
+}
+
+// ListClients lista todos los clientes, del más antiguo al más reciente
+func (r *oauthClientRepository) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := []models.OAuthClient{}
+	for _, client := range r.clients {
+		clients = append(clients, cloneOAuthClient(client))
+	}
+
+	sort.Slice(clients, func(i, j int) bool { return clients[i].CreatedAt.Before(clients[j].CreatedAt) })
+	return clients, nil
+}
+
+// DeleteClient elimina un cliente; si no existe retorna ErrDocumentNotFound
+func (r *oauthClientRepository) DeleteClient(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.clients[id]; !ok {
+		return validations.ErrDocumentNotFound
+	}
+
+	delete(r.clients, id)
+	return nil
+}
+
+// cloneOAuthClient copia el cliente para que el llamador no comparta los slices con el almacenado
+func cloneOAuthClient(client models.OAuthClient) models.OAuthClient {
+	client.RedirectURIs = slices.Clone(client.RedirectURIs)
+	client.Scopes = slices.Clone(client.Scopes)
+	client.GrantTypes = slices.Clone(client.GrantTypes)
+	client.PostLogoutRedirectURIs = slices.Clone(client.PostLogoutRedirectURIs)
+	return client
+}
@@ -0,0 +1,62 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// organizationRepository implementa repositories.OrganizationRepository en memoria.
+type organizationRepository struct {
+	mu            sync.RWMutex
+	organizations map[string]models.Organization
+}
+
+// NewOrganizationRepository crea un OrganizationRepository vacío en memoria.
+func NewOrganizationRepository() repositories.OrganizationRepository {
+	return &organizationRepository{
+		organizations: map[string]models.Organization{},
+	}
+}
+
+// CreateOrganization guarda una nueva organización
+func (r *organizationRepository) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.organizations[organization.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.organizations[organization.ID] = *organization
+	return nil
+}
+
+// GetOrganization obtiene una organización por su ID
+func (r *organizationRepository) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	organization, ok := r.organizations[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &organization, nil
+}
+
+// DeleteOrganization elimina una organización
+func (r *organizationRepository) DeleteOrganization(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.organizations[id]; !ok {
+		return validations.ErrDocumentNotFound
+	}
+
+	delete(r.organizations, id)
+	return nil
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// passwordResetRepository implementa repositories.PasswordResetRepository en memoria.
+type passwordResetRepository struct {
+	mu     sync.RWMutex
+	resets map[string]models.PasswordReset
+}
+
+// NewPasswordResetRepository crea un PasswordResetRepository vacío en memoria.
+func NewPasswordResetRepository() repositories.PasswordResetRepository {
+	return &passwordResetRepository{
+		resets: map[string]models.PasswordReset{},
+	}
+}
+
+// CreatePasswordReset guarda una nueva solicitud de restablecimiento
+func (r *passwordResetRepository) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.resets[reset.TokenHash]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	stored := *reset
+	stored.UsedAt = nil
+	if reset.UsedAt != nil {
+		usedAt := *reset.UsedAt
+		stored.UsedAt = &usedAt
+	}
+	r.resets[reset.TokenHash] = stored
+	return nil
+}
+
+// GetPasswordReset obtiene una solicitud de restablecimiento por el hash del token
+func (r *passwordResetRepository) GetPasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reset, ok := r.resets[tokenHash]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	if reset.UsedAt != nil {
+		usedAt := *reset.UsedAt
+		reset.UsedAt = &usedAt
+	}
+	return &reset, nil
+}
+
+// MarkPasswordResetUsed marca el token como usado. Si ya se había usado retorna validations.ErrConditionFailed.
+func (r *passwordResetRepository) MarkPasswordResetUsed(ctx context.Context, tokenHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reset, ok := r.resets[tokenHash]
+	if !ok || reset.UsedAt != nil {
+		return validations.ErrConditionFailed
+	}
+
+	usedAt := time.Now()
+	reset.UsedAt = &usedAt
+	r.resets[tokenHash] = reset
+	return nil
+}
@@ -0,0 +1,94 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// refreshTokenRepository implementa repositories.RefreshTokenRepository en memoria.
+type refreshTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]models.RefreshToken
+}
+
+// NewRefreshTokenRepository crea un RefreshTokenRepository vacío en memoria.
+func NewRefreshTokenRepository() repositories.RefreshTokenRepository {
+	return &refreshTokenRepository{
+		tokens: map[string]models.RefreshToken{},
+	}
+}
+
+// CreateRefreshToken guarda un nuevo refresh token. Falla si el hash ya existe.
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token.TokenHash]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.tokens[token.TokenHash] = cloneRefreshToken(*token)
+	return nil
+}
+
+// GetRefreshToken obtiene un refresh token por su hash
+func (r *refreshTokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	clone := cloneRefreshToken(token)
+	return &clone, nil
+}
+
+// RotateRefreshToken marca el token anterior como rotado y guarda el nuevo de forma atómica
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, oldTokenHash string, newToken *models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.tokens[oldTokenHash]
+	if !ok || old.IsRotated() || old.Revoked {
+		return validations.ErrConditionFailed
+	}
+	if _, exists := r.tokens[newToken.TokenHash]; exists {
+		return validations.ErrConditionFailed
+	}
+
+	rotatedAt := time.Now()
+	old.RotatedAt = &rotatedAt
+	old.ReplacedBy = newToken.TokenHash
+	r.tokens[oldTokenHash] = old
+	r.tokens[newToken.TokenHash] = cloneRefreshToken(*newToken)
+	return nil
+}
+
+// RevokeFamily revoca todos los refresh tokens de una familia
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, token := range r.tokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			r.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func cloneRefreshToken(token models.RefreshToken) models.RefreshToken {
+	if token.RotatedAt != nil {
+		rotatedAt := *token.RotatedAt
+		token.RotatedAt = &rotatedAt
+	}
+	return token
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// revokedTokenRepository implementa repositories.RevokedTokenRepository en memoria.
+type revokedTokenRepository struct {
+	mu     sync.RWMutex
+	tokens map[string]models.RevokedToken
+}
+
+// NewRevokedTokenRepository crea un RevokedTokenRepository vacío en memoria.
+func NewRevokedTokenRepository() repositories.RevokedTokenRepository {
+	return &revokedTokenRepository{
+		tokens: map[string]models.RevokedToken{},
+	}
+}
+
+// RevokeToken agrega un jti a la denylist
+func (r *revokedTokenRepository) RevokeToken(ctx context.Context, token *models.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tokens[token.JTI] = *token
+	return nil
+}
+
+// ConsumeToken agrega un jti a la denylist solo si no estaba; si no, retorna validations.ErrConditionFailed.
+func (r *revokedTokenRepository) ConsumeToken(ctx context.Context, token *models.RevokedToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tokens[token.JTI]; exists {
+		return validations.ErrConditionFailed
+	}
+
+	r.tokens[token.JTI] = *token
+	return nil
+}
+
+// ReleaseToken quita un jti de la denylist
+func (r *revokedTokenRepository) ReleaseToken(ctx context.Context, jti string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.tokens, jti)
+	return nil
+}
+
+// IsTokenRevoked indica si un jti está en la denylist y todavía no expiró
+func (r *revokedTokenRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[jti]
+	if !ok {
+		return false, nil
+	}
+
+	return time.Now().Before(token.ExpiresAt), nil
+}
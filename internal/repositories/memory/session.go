@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// sessionRepository implementa repositories.SessionRepository en memoria.
+type sessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session
+}
+
+// NewSessionRepository crea un SessionRepository vacío en memoria.
+func NewSessionRepository() repositories.SessionRepository {
+	return &sessionRepository{
+		sessions: map[string]models.Session{},
+	}
+}
+
+// CreateSession guarda una nueva sesión
+func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	stored := *session
+	stored.Current = false
+	r.sessions[session.ID] = stored
+	return nil
+}
+
+// GetSessionByID obtiene una sesión por su ID
+func (r *sessionRepository) GetSessionByID(ctx context.Context, id string) (*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &session, nil
+}
+
+// ListSessionsByUser lista las sesiones vigentes de un usuario, de la más reciente a la más antigua
+func (r *sessionRepository) ListSessionsByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []models.Session{}
+	now := time.Now()
+	for _, session := range r.sessions {
+		if session.UserID == userID && now.Before(session.ExpiresAt) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+// TouchSession registra un nuevo uso de la sesión y extiende su expiración
+func (r *sessionRepository) TouchSession(ctx context.Context, id, ip string, lastUsedAt, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return validations.ErrDocumentNotFound
+	}
+
+	session.IP = ip
+	session.LastUsedAt = lastUsedAt
+	session.ExpiresAt = expiresAt
+	session.TTL = expiresAt.Unix()
+	r.sessions[id] = session
+	return nil
+}
+
+// DeleteSession elimina una sesión
+func (r *sessionRepository) DeleteSession(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.sessions, id)
+	return nil
+}
+
+// DeleteSessionsByUser elimina todas las sesiones de un usuario
+func (r *sessionRepository) DeleteSessionsByUser(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, session := range r.sessions {
+		if session.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
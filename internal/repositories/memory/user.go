@@ -0,0 +1,314 @@
+// Package memory implementa los repositorios en memoria, con la misma semántica de errores que
+// las implementaciones de DynamoDB. Se usa en tests y para levantar la API sin base de datos.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// userRepository implementa repositories.UserRepository en memoria.
+type userRepository struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+	// emails reserva cada email normalizado para un usuario (equivale a los items EMAIL#)
+	emails map[string]string
+}
+
+// NewUserRepository crea un UserRepository vacío en memoria.
+func NewUserRepository() repositories.UserRepository {
+	return &userRepository{
+		users:  map[string]models.User{},
+		emails: map[string]string{},
+	}
+}
+
+// CreateUser guarda el usuario y reserva su email. Si el ID o el email ya existen
+// retorna validations.ErrDocumentAlreadyExists.
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	email := validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	if _, exists := r.users[user.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+	if _, reserved := r.emails[email]; reserved {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	user.Version = 1
+	user.EmailNormalized = email
+	r.users[user.ID] = cloneUser(*user)
+	r.emails[email] = user.ID
+	return nil
+}
+
+// GetUserByID obtiene un usuario por su ID
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	clone := cloneUser(user)
+	return &clone, nil
+}
+
+// GetUserByEmail obtiene un usuario por su email normalizado
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	email = validations.NormalizeEmail(email)
+	for _, user := range r.users {
+		if user.EmailNormalized == email {
+			clone := cloneUser(user)
+			return &clone, nil
+		}
+	}
+
+	return nil, validations.ErrDocumentNotFound
+}
+
+// UpdateUser reemplaza el usuario si su versión coincide con user.Version
+func (r *userRepository) UpdateUser(ctx context.Context, id string, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[id]
+	if !ok || stored.Version != user.Version {
+		return validations.ErrVersionConflict
+	}
+
+	user.Version++
+	user.EmailNormalized = validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	r.users[id] = cloneUser(*user)
+	return nil
+}
+
+// UpdateEmail mueve la reserva al nuevo email y lo deja sin verificar
+func (r *userRepository) UpdateEmail(ctx context.Context, id, newEmail string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return validations.ErrDocumentNotFound
+	}
+
+	oldEmail := user.ContactInfo.Email.Address
+	newEmail = validations.NormalizeEmail(newEmail)
+	if oldEmail == newEmail {
+		return nil
+	}
+
+	if _, reserved := r.emails[newEmail]; reserved {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	if owner, ok := r.emails[validations.NormalizeEmail(oldEmail)]; ok && owner == id {
+		delete(r.emails, validations.NormalizeEmail(oldEmail))
+	}
+	r.emails[newEmail] = id
+
+	user.ContactInfo.Email = models.EmailDetails{Address: newEmail}
+	user.EmailNormalized = newEmail
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+// UpdateLastSession registra la fecha del último inicio de sesión
+func (r *userRepository) UpdateLastSession(ctx context.Context, id string, lastSession time.Time) error {
+	return r.update(id, func(user *models.User) {
+		user.LastSession = lastSession
+	})
+}
+
+// UpdatePassword reemplaza el hash de la contraseña
+func (r *userRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) error {
+	return r.update(id, func(user *models.User) {
+		user.Password = hashedPassword
+		user.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateStatus cambia el estado del usuario
+func (r *userRepository) UpdateStatus(ctx context.Context, id string, status int32) error {
+	return r.update(id, func(user *models.User) {
+		user.Status = status
+		user.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateRole cambia el rol global del usuario
+func (r *userRepository) UpdateRole(ctx context.Context, id, role string) error {
+	return r.update(id, func(user *models.User) {
+		user.Role = role
+		user.UpdatedAt = time.Now()
+	})
+}
+
+// UpdateActiveOrganization cambia la organización para la que se emiten los tokens del usuario
+func (r *userRepository) UpdateActiveOrganization(ctx context.Context, id, organizationID string) error {
+	return r.update(id, func(user *models.User) {
+		user.ActiveOrganizationID = organizationID
+		user.UpdatedAt = time.Now()
+	})
+}
+
+// MarkVerificationSent registra el envío del link de activación del email
+func (r *userRepository) MarkVerificationSent(ctx context.Context, id string, sentAt time.Time) error {
+	return r.update(id, func(user *models.User) {
+		user.ContactInfo.Email.IsSentForVerify = true
+		user.ContactInfo.Email.SentAt = sentAt
+	})
+}
+
+// MarkEmailVerified marca el email como verificado si el usuario sigue teniendo ese email
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id, email string, verifiedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.ContactInfo.Email.Address != email {
+		return validations.ErrConditionFailed
+	}
+
+	user.ContactInfo.Email.IsVerified = true
+	user.ContactInfo.Email.VerifiedAt = verifiedAt
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+// PatchProfile modifica los campos presentes en el patch si la versión coincide
+func (r *userRepository) PatchProfile(ctx context.Context, id string, patch models.ProfilePatch, expectedVersion int64) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+	if user.Version != expectedVersion {
+		return nil, validations.ErrVersionConflict
+	}
+
+	if patch.Name != nil {
+		user.PersonalInfo.Name = *patch.Name
+	}
+	if patch.LastName != nil {
+		user.PersonalInfo.LastName = *patch.LastName
+	}
+	if patch.BirthDate != nil {
+		birthDate := *patch.BirthDate
+		user.PersonalInfo.BirthDate = &birthDate
+	}
+	user.UpdatedAt = time.Now()
+	user.Version++
+	r.users[id] = user
+
+	clone := cloneUser(user)
+	return &clone, nil
+}
+
+// IncrementTokenVersion incrementa la versión de tokens del usuario
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id string) error {
+	return r.update(id, func(user *models.User) {
+		user.TokenVersion++
+	})
+}
+
+// UpdateMFA reemplaza la configuración del segundo factor
+func (r *userRepository) UpdateMFA(ctx context.Context, id string, mfa models.MFAInfo) error {
+	return r.update(id, func(user *models.User) {
+		user.MFA = cloneMFA(mfa)
+		user.UpdatedAt = time.Now()
+	})
+}
+
+// ConsumeTOTPStep registra el paso de un código TOTP aceptado; falla si ya se usó
+func (r *userRepository) ConsumeTOTPStep(ctx context.Context, id string, step int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.MFA.TOTPLastStep >= step {
+		return validations.ErrConditionFailed
+	}
+
+	user.MFA.TOTPLastStep = step
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+// ConsumeRecoveryCode elimina un código de recuperación; falla si no existe o ya se usó
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return validations.ErrConditionFailed
+	}
+
+	for i, hash := range user.MFA.RecoveryCodes {
+		if hash == codeHash {
+			codes := append([]string{}, user.MFA.RecoveryCodes[:i]...)
+			user.MFA.RecoveryCodes = append(codes, user.MFA.RecoveryCodes[i+1:]...)
+			user.Version++
+			r.users[id] = user
+			return nil
+		}
+	}
+
+	return validations.ErrConditionFailed
+}
+
+// update aplica fn al usuario e incrementa su versión
+func (r *userRepository) update(id string, fn func(user *models.User)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return validations.ErrDocumentNotFound
+	}
+
+	fn(&user)
+	user.Version++
+	r.users[id] = user
+	return nil
+}
+
+// cloneUser copia el usuario para que los llamadores no modifiquen el estado interno
+func cloneUser(user models.User) models.User {
+	if user.PersonalInfo.BirthDate != nil {
+		birthDate := *user.PersonalInfo.BirthDate
+		user.PersonalInfo.BirthDate = &birthDate
+	}
+	user.MFA = cloneMFA(user.MFA)
+	return user
+}
+
+func cloneMFA(mfa models.MFAInfo) models.MFAInfo {
+	if mfa.RecoveryCodes != nil {
+		mfa.RecoveryCodes = append([]string{}, mfa.RecoveryCodes...)
+	}
+	if mfa.EnabledAt != nil {
+		enabledAt := *mfa.EnabledAt
+		mfa.EnabledAt = &enabledAt
+	}
+	return mfa
+}
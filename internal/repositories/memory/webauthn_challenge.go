@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// webAuthnChallengeRepository implementa repositories.WebAuthnChallengeRepository en memoria.
+type webAuthnChallengeRepository struct {
+	mu         sync.Mutex
+	challenges map[string]models.WebAuthnChallenge
+}
+
+// NewWebAuthnChallengeRepository crea un WebAuthnChallengeRepository vacío en memoria.
+func NewWebAuthnChallengeRepository() repositories.WebAuthnChallengeRepository {
+	return &webAuthnChallengeRepository{
+		challenges: map[string]models.WebAuthnChallenge{},
+	}
+}
+
+// CreateChallenge guarda el estado de una nueva ceremonia
+func (r *webAuthnChallengeRepository) CreateChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.challenges[challenge.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.challenges[challenge.ID] = *challenge
+	return nil
+}
+
+// ConsumeChallenge elimina la ceremonia y la retorna; si no existe o venció retorna ErrDocumentNotFound
+func (r *webAuthnChallengeRepository) ConsumeChallenge(ctx context.Context, id string) (*models.WebAuthnChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	challenge, ok := r.challenges[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	delete(r.challenges, id)
+	if challenge.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &challenge, nil
+}
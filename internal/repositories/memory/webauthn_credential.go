@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// webAuthnCredentialRepository implementa repositories.WebAuthnCredentialRepository en memoria.
+type webAuthnCredentialRepository struct {
+	mu          sync.RWMutex
+	credentials map[string]models.WebAuthnCredential
+}
+
+// NewWebAuthnCredentialRepository crea un WebAuthnCredentialRepository vacío en memoria.
+func NewWebAuthnCredentialRepository() repositories.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{
+		credentials: map[string]models.WebAuthnCredential{},
+	}
+}
+
+// CreateCredential guarda una nueva passkey
+func (r *webAuthnCredentialRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.credentials[credential.ID]; exists {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	r.credentials[credential.ID] = cloneWebAuthnCredential(*credential)
+	return nil
+}
+
+// GetCredential obtiene una passkey por su credential ID
+func (r *webAuthnCredentialRepository) GetCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credential, ok := r.credentials[id]
+	if !ok {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	credential = cloneWebAuthnCredential(credential)
+	return &credential, nil
+}
+
+// ListCredentialsByUser lista las passkeys de un usuario, de la más antigua a la más reciente
+func (r *webAuthnCredentialRepository) ListCredentialsByUser(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credentials := []models.WebAuthnCredential{}
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			credentials = append(credentials, cloneWebAuthnCredential(credential))
+		}
+	}
+
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].CreatedAt.Before(credentials[j].CreatedAt) })
+	return credentials, nil
+}
+
+// UpdateCredentialUsage registra un login con la passkey; un contador que no avanza retorna ErrConditionFailed
+func (r *webAuthnCredentialRepository) UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok || (signCount > 0 && credential.SignCount >= signCount) {
+		return validations.ErrConditionFailed
+	}
+
+	credential.SignCount = signCount
+	credential.BackupState = backupState
+	credential.LastUsedAt = usedAt
+	r.credentials[id] = credential
+	return nil
+}
+
+// DeleteCredential elimina una passkey del usuario
+func (r *webAuthnCredentialRepository) DeleteCredential(ctx context.Context, userID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credential, ok := r.credentials[id]
+	if !ok || credential.UserID != userID {
+		return validations.ErrDocumentNotFound
+	}
+
+	delete(r.credentials, id)
+	return nil
+}
+
+// cloneWebAuthnCredential copia los slices para que el llamador no modifique lo guardado
+func cloneWebAuthnCredential(credential models.WebAuthnCredential) models.WebAuthnCredential {
+	credential.PublicKey = append([]byte(nil), credential.PublicKey...)
+	credential.AAGUID = append([]byte(nil), credential.AAGUID...)
+	credential.Transports = append([]string(nil), credential.Transports...)
+	return credential
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// authorizationCodeRepository implementa repositories.AuthorizationCodeRepository usando MongoDB.
+type authorizationCodeRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewAuthorizationCodeRepository crea una nueva instancia de authorizationCodeRepository.
+func NewAuthorizationCodeRepository(database *mongodriver.Database) repositories.AuthorizationCodeRepository {
+	return &authorizationCodeRepository{
+		collection: database.Collection(authorizationCodesCollection),
+	}
+}
+
+// CreateAuthorizationCode guarda un nuevo código de autorización
+func (r *authorizationCodeRepository) CreateAuthorizationCode(ctx context.Context, code *models.AuthorizationCode) error {
+	_, err := r.collection.InsertOne(ctx, code)
+	return mapError(err)
+}
+
+// GetAuthorizationCode obtiene un código por su hash
+func (r *authorizationCodeRepository) GetAuthorizationCode(ctx context.Context, codeHash string) (*models.AuthorizationCode, error) {
+	var code models.AuthorizationCode
+	if err := r.collection.FindOne(ctx, bson.M{"_id": codeHash}).Decode(&code); err != nil {
+		return nil, mapError(err)
+	}
+	return &code, nil
+}
+
+// MarkAuthorizationCodeUsed marca el código como canjeado. Si ya se había canjeado retorna validations.ErrConditionFailed.
+func (r *authorizationCodeRepository) MarkAuthorizationCodeUsed(ctx context.Context, codeHash string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": codeHash, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
+
+// SetAuthorizationCodeSession guarda la sesión abierta al canjear el código.
+func (r *authorizationCodeRepository) SetAuthorizationCodeSession(ctx context.Context, codeHash, sessionID string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": codeHash}, bson.M{"$set": bson.M{"session_id": sessionID}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// emailLoginRepository implementa repositories.EmailLoginRepository usando MongoDB.
+type emailLoginRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewEmailLoginRepository crea una nueva instancia de emailLoginRepository.
+func NewEmailLoginRepository(database *mongodriver.Database) repositories.EmailLoginRepository {
+	return &emailLoginRepository{
+		collection: database.Collection(emailLoginsCollection),
+	}
+}
+
+// SaveEmailLogin guarda el login pendiente, reemplazando el anterior del mismo método y email
+func (r *emailLoginRepository) SaveEmailLogin(ctx context.Context, login *models.EmailLogin) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": login.Key}, login, options.Replace().SetUpsert(true))
+	return err
+}
+
+// GetEmailLogin obtiene el login pendiente y vigente de una clave
+func (r *emailLoginRepository) GetEmailLogin(ctx context.Context, key string) (*models.EmailLogin, error) {
+	var login models.EmailLogin
+	err := r.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&login)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &login, nil
+}
+
+// RegisterEmailLoginAttempt suma un intento de canje de forma atómica. Si no existe, venció,
+// ya se usó o agotó los intentos retorna validations.ErrConditionFailed.
+func (r *emailLoginRepository) RegisterEmailLoginAttempt(ctx context.Context, key string, maxAttempts int) (*models.EmailLogin, error) {
+	var login models.EmailLogin
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":        key,
+			"used_at":    bson.M{"$exists": false},
+			"attempts":   bson.M{"$lt": maxAttempts},
+			"expires_at": bson.M{"$gt": time.Now()},
+		},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&login)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return nil, validations.ErrConditionFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &login, nil
+}
+
+// MarkEmailLoginUsed marca el login como usado si todavía corresponde al secreto secretHash.
+// Si ya se usó o fue reemplazado retorna validations.ErrConditionFailed.
+func (r *emailLoginRepository) MarkEmailLoginUsed(ctx context.Context, key, secretHash string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": key, "secret_hash": secretHash, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// federatedStateRepository implementa repositories.FederatedStateRepository usando MongoDB.
+type federatedStateRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewFederatedStateRepository crea una nueva instancia de federatedStateRepository.
+func NewFederatedStateRepository(database *mongodriver.Database) repositories.FederatedStateRepository {
+	return &federatedStateRepository{
+		collection: database.Collection(federatedStatesCollection),
+	}
+}
+
+// CreateState guarda el estado de un nuevo login
+func (r *federatedStateRepository) CreateState(ctx context.Context, state *models.FederatedState) error {
+	_, err := r.collection.InsertOne(ctx, state)
+	return mapError(err)
+}
+
+// ConsumeState elimina el estado y lo retorna; si no existe o venció retorna ErrDocumentNotFound
+func (r *federatedStateRepository) ConsumeState(ctx context.Context, stateHash string) (*models.FederatedState, error) {
+	var state models.FederatedState
+	if err := r.collection.FindOneAndDelete(ctx, bson.M{"_id": stateHash}).Decode(&state); err != nil {
+		return nil, mapError(err)
+	}
+
+	// El índice TTL de MongoDB no elimina los documentos de inmediato
+	if state.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &state, nil
+}
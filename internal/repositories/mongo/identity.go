@@ -0,0 +1,70 @@
+package mongo
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// identityRepository implementa repositories.IdentityRepository usando MongoDB.
+type identityRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewIdentityRepository crea una nueva instancia de identityRepository.
+func NewIdentityRepository(database *mongodriver.Database) repositories.IdentityRepository {
+	return &identityRepository{
+		collection: database.Collection(identitiesCollection),
+	}
+}
+
+// CreateIdentity vincula una identidad
+func (r *identityRepository) CreateIdentity(ctx context.Context, identity *models.Identity) error {
+	_, err := r.collection.InsertOne(ctx, identity)
+	return mapError(err)
+}
+
+// GetIdentity obtiene una identidad por su clave
+func (r *identityRepository) GetIdentity(ctx context.Context, id string) (*models.Identity, error) {
+	var identity models.Identity
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&identity); err != nil {
+		return nil, mapError(err)
+	}
+	return &identity, nil
+}
+
+// ListIdentitiesByUser lista las identidades de un usuario, de la más antigua a la más reciente
+func (r *identityRepository) ListIdentitiesByUser(ctx context.Context, userID string) ([]models.Identity, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	identities := []models.Identity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// DeleteIdentity desvincula una identidad del usuario
+func (r *identityRepository) DeleteIdentity(ctx context.Context, userID, id string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// invitationRepository implementa repositories.InvitationRepository usando MongoDB.
+type invitationRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewInvitationRepository crea una nueva instancia de invitationRepository.
+func NewInvitationRepository(database *mongodriver.Database) repositories.InvitationRepository {
+	return &invitationRepository{
+		collection: database.Collection(invitationsCollection),
+	}
+}
+
+// CreateInvitation guarda una nueva invitación
+func (r *invitationRepository) CreateInvitation(ctx context.Context, invitation *models.Invitation) error {
+	_, err := r.collection.InsertOne(ctx, invitation)
+	return mapError(err)
+}
+
+// GetInvitation obtiene una invitación por su ID, aunque esté vencida
+func (r *invitationRepository) GetInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&invitation); err != nil {
+		return nil, mapError(err)
+	}
+	return &invitation, nil
+}
+
+// ListInvitationsByOrganization lista las invitaciones vigentes de una organización, de la más antigua a la más reciente
+func (r *invitationRepository) ListInvitationsByOrganization(ctx context.Context, organizationID string) ([]models.Invitation, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"organization_id": organizationID, "expires_at": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invitations := []models.Invitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// RenewInvitation reemplaza el token de la invitación y extiende su expiración
+func (r *invitationRepository) RenewInvitation(ctx context.Context, organizationID, id, tokenID string, expiresAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "organization_id": organizationID}, bson.M{"$set": bson.M{
+		"token_id":   tokenID,
+		"expires_at": expiresAt,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// DeleteInvitation revoca una invitación de la organización
+func (r *invitationRepository) DeleteInvitation(ctx context.Context, organizationID, id string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "organization_id": organizationID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// ConsumeInvitation elimina la invitación y la retorna si tokenID es el de su último envío;
+// si no existe, el token fue reemplazado o venció retorna ErrDocumentNotFound
+func (r *invitationRepository) ConsumeInvitation(ctx context.Context, id, tokenID string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	if err := r.collection.FindOneAndDelete(ctx, bson.M{"_id": id, "token_id": tokenID}).Decode(&invitation); err != nil {
+		return nil, mapError(err)
+	}
+
+	// El índice TTL de MongoDB no elimina los documentos de inmediato
+	if invitation.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &invitation, nil
+}
@@ -0,0 +1,78 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// loginAttemptRepository implementa repositories.LoginAttemptRepository usando MongoDB.
+type loginAttemptRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewLoginAttemptRepository crea una nueva instancia de loginAttemptRepository.
+func NewLoginAttemptRepository(database *mongodriver.Database) repositories.LoginAttemptRepository {
+	return &loginAttemptRepository{
+		collection: database.Collection(loginAttemptsCollection),
+	}
+}
+
+// GetLoginAttempt obtiene los intentos fallidos vigentes de una clave
+func (r *loginAttemptRepository) GetLoginAttempt(ctx context.Context, key string) (*models.LoginAttempt, error) {
+	var attempt models.LoginAttempt
+	err := r.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": time.Now()}}).Decode(&attempt)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &attempt, nil
+}
+
+// RegisterFailure suma un fallo a la clave de forma atómica (upsert) y extiende su ventana
+// hasta expiresAt. Si la ventana anterior ya había vencido, el conteo vuelve a empezar.
+func (r *loginAttemptRepository) RegisterFailure(ctx context.Context, key, email string, failedAt, expiresAt time.Time) (*models.LoginAttempt, error) {
+	// Dos intentos: si el documento existe pero venció, el upsert choca con su _id;
+	// se elimina y se vuelve a contar desde cero
+	for i := 0; i < 2; i++ {
+		var attempt models.LoginAttempt
+		err := r.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": key, "expires_at": bson.M{"$gt": failedAt}},
+			bson.M{
+				"$set": bson.M{"email": email, "last_failure_at": failedAt, "expires_at": expiresAt},
+				"$inc": bson.M{"failures": 1},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&attempt)
+		if err == nil {
+			return &attempt, nil
+		}
+		if !mongodriver.IsDuplicateKeyError(err) {
+			return nil, err
+		}
+
+		if _, err := r.collection.DeleteOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$lte": failedAt}}); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, validations.ErrConditionFailed
+}
+
+// DeleteLoginAttempt reinicia el conteo de una clave
+func (r *loginAttemptRepository) DeleteLoginAttempt(ctx context.Context, key string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// DeleteLoginAttemptsByEmail reinicia el conteo de todas las claves de una cuenta
+func (r *loginAttemptRepository) DeleteLoginAttemptsByEmail(ctx context.Context, email string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"email": email})
+	return err
+}
@@ -0,0 +1,57 @@
+package mongo
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// membershipRepository implementa repositories.MembershipRepository usando MongoDB.
+type membershipRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewMembershipRepository crea una nueva instancia de membershipRepository.
+func NewMembershipRepository(database *mongodriver.Database) repositories.MembershipRepository {
+	return &membershipRepository{
+		collection: database.Collection(membershipsCollection),
+	}
+}
+
+// CreateMembership agrega el usuario a la organización
+func (r *membershipRepository) CreateMembership(ctx context.Context, membership *models.Membership) error {
+	_, err := r.collection.InsertOne(ctx, membership)
+	return mapError(err)
+}
+
+// GetMembership obtiene la membresía del usuario en la organización
+func (r *membershipRepository) GetMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error) {
+	var membership models.Membership
+	if err := r.collection.FindOne(ctx, bson.M{"_id": models.MembershipKey(organizationID, userID)}).Decode(&membership); err != nil {
+		return nil, mapError(err)
+	}
+	return &membership, nil
+}
+
+// ListMembershipsByUser lista las membresías de un usuario, de la más antigua a la más reciente
+func (r *membershipRepository) ListMembershipsByUser(ctx context.Context, userID string) ([]models.Membership, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := []models.Membership{}
+	if err := cursor.All(ctx, &memberships); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
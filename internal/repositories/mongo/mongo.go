@@ -0,0 +1,119 @@
+// Package mongo implementa los repositorios sobre MongoDB, con la misma semántica de errores
+// que las implementaciones de DynamoDB (ver repositories/repotest).
+package mongo
+
+import (
+	"context"
+	"errors"
+
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Nombres de las colecciones
+const (
+	usersCollection          = "users"
+	refreshTokensCollection  = "refresh_tokens"
+	revokedTokensCollection  = "revoked_tokens"
+	sessionsCollection       = "sessions"
+	passwordResetsCollection = "password_resets"
+	loginAttemptsCollection  = "login_attempts"
+
+	webAuthnCredentialsCollection = "webauthn_credentials"
+	webAuthnChallengesCollection  = "webauthn_challenges"
+	emailLoginsCollection         = "email_logins"
+	oauthClientsCollection        = "oauth_clients"
+	authorizationCodesCollection  = "authorization_codes"
+	identitiesCollection          = "identities"
+	federatedStatesCollection     = "federated_states"
+	organizationsCollection       = "organizations"
+	membershipsCollection         = "memberships"
+	invitationsCollection         = "invitations"
+)
+
+// EnsureIndexes crea los índices que requieren los repositorios. Es idempotente.
+//   - users.email_normalized único: garantiza la unicidad del email de forma atómica.
+//   - family_id / user_id / email / organization_id: revocar familias, listar sesiones, passkeys, identidades, organizaciones e invitaciones y desbloquear cuentas.
+//   - expires_at con TTL: MongoDB elimina los documentos vencidos (equivale al TTL de DynamoDB).
+func EnsureIndexes(ctx context.Context, database *mongodriver.Database) error {
+	ttl := func() mongodriver.IndexModel {
+		return mongodriver.IndexModel{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		}
+	}
+
+	indexes := map[string][]mongodriver.IndexModel{
+		usersCollection: {
+			{Keys: bson.D{{Key: "email_normalized", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		refreshTokensCollection: {
+			{Keys: bson.D{{Key: "family_id", Value: 1}}},
+			ttl(),
+		},
+		revokedTokensCollection: {
+			ttl(),
+		},
+		sessionsCollection: {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			ttl(),
+		},
+		passwordResetsCollection: {
+			ttl(),
+		},
+		loginAttemptsCollection: {
+			{Keys: bson.D{{Key: "email", Value: 1}}},
+			ttl(),
+		},
+		webAuthnCredentialsCollection: {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		},
+		webAuthnChallengesCollection: {
+			ttl(),
+		},
+		emailLoginsCollection: {
+			ttl(),
+		},
+		authorizationCodesCollection: {
+			ttl(),
+		},
+		identitiesCollection: {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		},
+		federatedStatesCollection: {
+			ttl(),
+		},
+		membershipsCollection: {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		},
+		invitationsCollection: {
+			{Keys: bson.D{{Key: "organization_id", Value: 1}}},
+			ttl(),
+		},
+	}
+
+	for collection, models := range indexes {
+		if _, err := database.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mapError traduce los errores del driver a los errores de validations
+func mapError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongodriver.ErrNoDocuments):
+		return validations.ErrDocumentNotFound
+	case mongodriver.IsDuplicateKeyError(err):
+		return validations.ErrDocumentAlreadyExists
+	default:
+		return err
+	}
+}
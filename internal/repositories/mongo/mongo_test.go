@@ -0,0 +1,81 @@
+package mongo
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/repotest"
+
+	"github.com/google/uuid"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestConformance ejecuta la suite contra un MongoDB real. Se omite si MONGO_URI no está definido.
+//
+//	docker run -p 27017:27017 mongo:7
+//	MONGO_URI=mongodb://localhost:27017 go test ./internal/repositories/...
+func TestConformance(t *testing.T) {
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI no definido: se omite la suite contra MongoDB")
+	}
+
+	ctx := context.Background()
+	client, err := mongodriver.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	// Base de datos con sufijo aleatorio para no mezclar datos entre ejecuciones
+	database := client.Database("login_test_" + uuid.New().String()[:8])
+	t.Cleanup(func() {
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+
+	if err := EnsureIndexes(ctx, database); err != nil {
+		t.Fatalf("ensure indexes: %v", err)
+	}
+
+	repotest.Run(t, repotest.Factory{
+		Users:          func(t *testing.T) repositories.UserRepository { return NewUserRepository(database) },
+		RefreshTokens:  func(t *testing.T) repositories.RefreshTokenRepository { return NewRefreshTokenRepository(database) },
+		RevokedTokens:  func(t *testing.T) repositories.RevokedTokenRepository { return NewRevokedTokenRepository(database) },
+		Sessions:       func(t *testing.T) repositories.SessionRepository { return NewSessionRepository(database) },
+		PasswordResets: func(t *testing.T) repositories.PasswordResetRepository { return NewPasswordResetRepository(database) },
+		LoginAttempts:  func(t *testing.T) repositories.LoginAttemptRepository { return NewLoginAttemptRepository(database) },
+		WebAuthnCredentials: func(t *testing.T) repositories.WebAuthnCredentialRepository {
+			return NewWebAuthnCredentialRepository(database)
+		},
+		WebAuthnChallenges: func(t *testing.T) repositories.WebAuthnChallengeRepository {
+			return NewWebAuthnChallengeRepository(database)
+		},
+		EmailLogins: func(t *testing.T) repositories.EmailLoginRepository {
+			return NewEmailLoginRepository(database)
+		},
+		OAuthClients: func(t *testing.T) repositories.OAuthClientRepository {
+			return NewOAuthClientRepository(database)
+		},
+		AuthorizationCodes: func(t *testing.T) repositories.AuthorizationCodeRepository {
+			return NewAuthorizationCodeRepository(database)
+		},
+		Identities: func(t *testing.T) repositories.IdentityRepository {
+			return NewIdentityRepository(database)
+		},
+		FederatedStates: func(t *testing.T) repositories.FederatedStateRepository {
+			return NewFederatedStateRepository(database)
+		},
+		Organizations: func(t *testing.T) repositories.OrganizationRepository {
+			return NewOrganizationRepository(database)
+		},
+		Memberships: func(t *testing.T) repositories.MembershipRepository {
+			return NewMembershipRepository(database)
+		},
+		Invitations: func(t *testing.T) repositories.InvitationRepository {
+			return NewInvitationRepository(database)
+		},
+	})
+}
@@ -0,0 +1,67 @@
+package mongo
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// oauthClientRepository implementa repositories.OAuthClientRepository usando MongoDB.
+type oauthClientRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewOAuthClientRepository crea una nueva instancia de oauthClientRepository.
+func NewOAuthClientRepository(database *mongodriver.Database) repositories.OAuthClientRepository {
+	return &oauthClientRepository{
+		collection: database.Collection(oauthClientsCollection),
+	}
+}
+
+// CreateClient guarda un nuevo cliente
+func (r *oauthClientRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	_, err := r.collection.InsertOne(ctx, client)
+	return mapError(err)
+}
+
+// GetClient obtiene un cliente por su ID
+func (r *oauthClientRepository) GetClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	var client models.OAuthClient
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&client); err != nil {
+		return nil, mapError(err)
+	}
+	return &client, nil
+}
+
+// ListClients lista todos los clientes, del más antiguo al más reciente
+func (r *oauthClientRepository) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	clients := []models.OAuthClient{}
+	if err := cursor.All(ctx, &clients); err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// DeleteClient elimina un cliente; si no existe retorna ErrDocumentNotFound
+func (r *oauthClientRepository) DeleteClient(ctx context.Context, id string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
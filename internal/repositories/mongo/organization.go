@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// organizationRepository implementa repositories.OrganizationRepository usando MongoDB.
+type organizationRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewOrganizationRepository crea una nueva instancia de organizationRepository.
+func NewOrganizationRepository(database *mongodriver.Database) repositories.OrganizationRepository {
+	return &organizationRepository{
+		collection: database.Collection(organizationsCollection),
+	}
+}
+
+// CreateOrganization guarda una nueva organización
+func (r *organizationRepository) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	_, err := r.collection.InsertOne(ctx, organization)
+	return mapError(err)
+}
+
+// GetOrganization obtiene una organización por su ID
+func (r *organizationRepository) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	var organization models.Organization
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&organization); err != nil {
+		return nil, mapError(err)
+	}
+	return &organization, nil
+}
+
+// DeleteOrganization elimina una organización
+func (r *organizationRepository) DeleteOrganization(ctx context.Context, id string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// passwordResetRepository implementa repositories.PasswordResetRepository usando MongoDB.
+type passwordResetRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewPasswordResetRepository crea una nueva instancia de passwordResetRepository.
+func NewPasswordResetRepository(database *mongodriver.Database) repositories.PasswordResetRepository {
+	return &passwordResetRepository{
+		collection: database.Collection(passwordResetsCollection),
+	}
+}
+
+// CreatePasswordReset guarda una nueva solicitud de restablecimiento
+func (r *passwordResetRepository) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	_, err := r.collection.InsertOne(ctx, reset)
+	return mapError(err)
+}
+
+// GetPasswordReset obtiene una solicitud de restablecimiento por el hash del token
+func (r *passwordResetRepository) GetPasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	if err := r.collection.FindOne(ctx, bson.M{"_id": tokenHash}).Decode(&reset); err != nil {
+		return nil, mapError(err)
+	}
+	return &reset, nil
+}
+
+// MarkPasswordResetUsed marca el token como usado. Si ya se había usado retorna validations.ErrConditionFailed.
+func (r *passwordResetRepository) MarkPasswordResetUsed(ctx context.Context, tokenHash string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": tokenHash, "used_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"used_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// refreshTokenRepository implementa repositories.RefreshTokenRepository usando MongoDB.
+type refreshTokenRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewRefreshTokenRepository crea una nueva instancia de refreshTokenRepository.
+func NewRefreshTokenRepository(database *mongodriver.Database) repositories.RefreshTokenRepository {
+	return &refreshTokenRepository{
+		collection: database.Collection(refreshTokensCollection),
+	}
+}
+
+// CreateRefreshToken guarda un nuevo refresh token. Falla si el hash ya existe.
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	_, err := r.collection.InsertOne(ctx, token)
+	return mapError(err)
+}
+
+// GetRefreshToken obtiene un refresh token por su hash
+func (r *refreshTokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	if err := r.collection.FindOne(ctx, bson.M{"_id": tokenHash}).Decode(&token); err != nil {
+		return nil, mapError(err)
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken marca el token anterior como rotado y guarda el nuevo. La actualización
+// condicional del anterior es atómica, así que solo una petición concurrente puede rotarlo; si
+// luego falla la inserción del nuevo, se deshace la marca. Sin transacciones multi-documento
+// para no requerir un replica set.
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, oldTokenHash string, newToken *models.RefreshToken) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": oldTokenHash, "replaced_by": bson.M{"$exists": false}, "revoked": false},
+		bson.M{"$set": bson.M{"rotated_at": time.Now(), "replaced_by": newToken.TokenHash}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+
+	if _, err := r.collection.InsertOne(ctx, newToken); err != nil {
+		r.collection.UpdateOne(ctx,
+			bson.M{"_id": oldTokenHash, "replaced_by": newToken.TokenHash},
+			bson.M{"$unset": bson.M{"rotated_at": "", "replaced_by": ""}},
+		)
+		if mongodriver.IsDuplicateKeyError(err) {
+			return validations.ErrConditionFailed
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RevokeFamily revoca todos los refresh tokens que pertenecen a una misma familia.
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := r.collection.UpdateMany(ctx, bson.M{"family_id": familyID}, bson.M{"$set": bson.M{"revoked": true}})
+	return err
+}
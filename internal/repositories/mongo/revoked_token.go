@@ -0,0 +1,63 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// revokedTokenRepository implementa repositories.RevokedTokenRepository usando MongoDB.
+type revokedTokenRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewRevokedTokenRepository crea una nueva instancia de revokedTokenRepository.
+func NewRevokedTokenRepository(database *mongodriver.Database) repositories.RevokedTokenRepository {
+	return &revokedTokenRepository{
+		collection: database.Collection(revokedTokensCollection),
+	}
+}
+
+// RevokeToken agrega un jti a la denylist
+func (r *revokedTokenRepository) RevokeToken(ctx context.Context, token *models.RevokedToken) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": token.JTI}, token, options.Replace().SetUpsert(true))
+	return err
+}
+
+// ConsumeToken agrega un jti a la denylist solo si no estaba; si no, retorna validations.ErrConditionFailed.
+func (r *revokedTokenRepository) ConsumeToken(ctx context.Context, token *models.RevokedToken) error {
+	_, err := r.collection.InsertOne(ctx, token)
+	if mongodriver.IsDuplicateKeyError(err) {
+		return validations.ErrConditionFailed
+	}
+	return err
+}
+
+// ReleaseToken quita un jti de la denylist
+func (r *revokedTokenRepository) ReleaseToken(ctx context.Context, jti string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": jti})
+	return err
+}
+
+// IsTokenRevoked indica si un jti está en la denylist.
+// El TTL de MongoDB no elimina los documentos de inmediato, por eso también se compara la expiración.
+func (r *revokedTokenRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var token models.RevokedToken
+	err := r.collection.FindOne(ctx, bson.M{"_id": jti}).Decode(&token)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return time.Now().Before(token.ExpiresAt), nil
+}
@@ -0,0 +1,87 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// sessionRepository implementa repositories.SessionRepository usando MongoDB.
+type sessionRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewSessionRepository crea una nueva instancia de sessionRepository.
+func NewSessionRepository(database *mongodriver.Database) repositories.SessionRepository {
+	return &sessionRepository{
+		collection: database.Collection(sessionsCollection),
+	}
+}
+
+// CreateSession guarda una nueva sesión
+func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	_, err := r.collection.InsertOne(ctx, session)
+	return mapError(err)
+}
+
+// GetSessionByID obtiene una sesión por su ID
+func (r *sessionRepository) GetSessionByID(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session); err != nil {
+		return nil, mapError(err)
+	}
+	return &session, nil
+}
+
+// ListSessionsByUser lista las sesiones vigentes de un usuario, de la más reciente a la más antigua
+func (r *sessionRepository) ListSessionsByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"user_id": userID, "expires_at": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// TouchSession registra un nuevo uso de la sesión y extiende su expiración
+func (r *sessionRepository) TouchSession(ctx context.Context, id, ip string, lastUsedAt, expiresAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"ip":           ip,
+		"last_used_at": lastUsedAt,
+		"expires_at":   expiresAt,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// DeleteSession elimina una sesión
+func (r *sessionRepository) DeleteSession(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// DeleteSessionsByUser elimina todas las sesiones de un usuario
+func (r *sessionRepository) DeleteSessionsByUser(ctx context.Context, userID string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
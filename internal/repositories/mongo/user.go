@@ -0,0 +1,264 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// userRepository implementa repositories.UserRepository usando MongoDB.
+type userRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewUserRepository crea una nueva instancia de userRepository.
+func NewUserRepository(database *mongodriver.Database) repositories.UserRepository {
+	return &userRepository{
+		collection: database.Collection(usersCollection),
+	}
+}
+
+// CreateUser crea un nuevo usuario. El índice único sobre email_normalized garantiza la unicidad
+// del email: si el ID o el email ya existen retorna validations.ErrDocumentAlreadyExists.
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	user.Version = 1
+	user.EmailNormalized = validations.NormalizeEmail(user.ContactInfo.Email.Address)
+
+	_, err := r.collection.InsertOne(ctx, user)
+	return mapError(err)
+}
+
+// GetUserByID obtiene un usuario por su ID
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// GetUserByEmail obtiene un usuario por su email normalizado
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, bson.M{"email_normalized": validations.NormalizeEmail(email)})
+}
+
+func (r *userRepository) findOne(ctx context.Context, filter bson.M) (*models.User, error) {
+	var user models.User
+	if err := r.collection.FindOne(ctx, filter).Decode(&user); err != nil {
+		return nil, mapError(err)
+	}
+	return &user, nil
+}
+
+// UpdateUser reemplaza el usuario si no fue modificado desde que se leyó (user.Version).
+// Si otra escritura lo modificó antes retorna validations.ErrVersionConflict.
+func (r *userRepository) UpdateUser(ctx context.Context, id string, user *models.User) error {
+	expectedVersion := user.Version
+
+	user.EmailNormalized = validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	user.Version = expectedVersion + 1
+
+	result, err := r.collection.ReplaceOne(ctx, versionFilter(id, expectedVersion), user)
+	if err == nil && result.MatchedCount == 0 {
+		err = validations.ErrVersionConflict
+	}
+	if err != nil {
+		user.Version = expectedVersion
+		return mapError(err)
+	}
+
+	return nil
+}
+
+// UpdateEmail cambia el email del usuario, que vuelve a quedar sin verificar. El índice único
+// impide tomar un email de otro usuario (validations.ErrDocumentAlreadyExists).
+func (r *userRepository) UpdateEmail(ctx context.Context, id, newEmail string) error {
+	user, err := r.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	oldEmail := user.ContactInfo.Email.Address
+	newEmail = validations.NormalizeEmail(newEmail)
+	if oldEmail == newEmail {
+		return nil
+	}
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "contact_info.email.address": oldEmail},
+		bson.M{
+			"$set": bson.M{
+				"contact_info.email": models.EmailDetails{Address: newEmail},
+				"email_normalized":   newEmail,
+			},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+
+	return nil
+}
+
+// UpdateLastSession registra la fecha del último inicio de sesión
+func (r *userRepository) UpdateLastSession(ctx context.Context, id string, lastSession time.Time) error {
+	return r.updateFields(ctx, id, bson.M{"last_session": lastSession})
+}
+
+// UpdatePassword reemplaza el hash de la contraseña
+func (r *userRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) error {
+	return r.updateFields(ctx, id, bson.M{"password": hashedPassword, "updated_at": time.Now()})
+}
+
+// UpdateStatus cambia el estado del usuario (activo, inactivo, baneado)
+func (r *userRepository) UpdateStatus(ctx context.Context, id string, status int32) error {
+	return r.updateFields(ctx, id, bson.M{"status": status, "updated_at": time.Now()})
+}
+
+// UpdateRole cambia el rol global del usuario
+func (r *userRepository) UpdateRole(ctx context.Context, id, role string) error {
+	return r.updateFields(ctx, id, bson.M{"role": role, "updated_at": time.Now()})
+}
+
+// UpdateActiveOrganization cambia la organización para la que se emiten los tokens del usuario
+func (r *userRepository) UpdateActiveOrganization(ctx context.Context, id, organizationID string) error {
+	return r.updateFields(ctx, id, bson.M{"active_organization_id": organizationID, "updated_at": time.Now()})
+}
+
+// MarkVerificationSent registra el envío del link de activación del email
+func (r *userRepository) MarkVerificationSent(ctx context.Context, id string, sentAt time.Time) error {
+	return r.updateFields(ctx, id, bson.M{"contact_info.email.is_sent_for_verify": true, "contact_info.email.sent_at": sentAt})
+}
+
+// MarkEmailVerified marca el email como verificado, solo si el usuario sigue teniendo ese email.
+// Si no existe o cambió de email retorna validations.ErrConditionFailed.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id, email string, verifiedAt time.Time) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "contact_info.email.address": email},
+		bson.M{
+			"$set": bson.M{"contact_info.email.is_verified": true, "contact_info.email.verified_at": verifiedAt},
+			"$inc": bson.M{"version": 1},
+		},
+	)
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
+
+// PatchProfile modifica solo los campos presentes en el patch si la versión del usuario es
+// expectedVersion, y retorna el usuario actualizado.
+func (r *userRepository) PatchProfile(ctx context.Context, id string, patch models.ProfilePatch, expectedVersion int64) (*models.User, error) {
+	set := bson.M{"updated_at": time.Now()}
+	if patch.Name != nil {
+		set["personal_info.name"] = *patch.Name
+	}
+	if patch.LastName != nil {
+		set["personal_info.last_name"] = *patch.LastName
+	}
+	if patch.BirthDate != nil {
+		set["personal_info.birth_date"] = *patch.BirthDate
+	}
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx,
+		versionFilter(id, expectedVersion),
+		bson.M{"$set": set, "$inc": bson.M{"version": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if errors.Is(err, mongodriver.ErrNoDocuments) {
+		if _, getErr := r.GetUserByID(ctx, id); errors.Is(getErr, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrDocumentNotFound
+		}
+		return nil, validations.ErrVersionConflict
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &user, nil
+}
+
+// IncrementTokenVersion incrementa atómicamente la versión de tokens del usuario
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id string) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"token_version": 1, "version": 1}})
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// UpdateMFA reemplaza la configuración del segundo factor
+func (r *userRepository) UpdateMFA(ctx context.Context, id string, mfa models.MFAInfo) error {
+	return r.updateFields(ctx, id, bson.M{"mfa": mfa, "updated_at": time.Now()})
+}
+
+// ConsumeTOTPStep registra el paso de tiempo de un código TOTP aceptado. Si ese paso (o uno
+// posterior) ya se usó retorna validations.ErrConditionFailed: cada código sirve una sola vez.
+func (r *userRepository) ConsumeTOTPStep(ctx context.Context, id string, step int64) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "mfa.totp_last_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"mfa.totp_last_step": step}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
+
+// ConsumeRecoveryCode elimina un código de recuperación (por su hash). Si no existe o ya se
+// usó retorna validations.ErrConditionFailed.
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) error {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "mfa.recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"mfa.recovery_codes": codeHash}, "$inc": bson.M{"version": 1}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
+
+// updateFields actualiza los campos indicados e incrementa la versión
+func (r *userRepository) updateFields(ctx context.Context, id string, set bson.M) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set, "$inc": bson.M{"version": 1}})
+	if err != nil {
+		return mapError(err)
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
+
+// versionFilter filtra el usuario por ID y versión esperada. Los documentos creados antes
+// de existir el campo version se consideran en la versión 0.
+func versionFilter(id string, expectedVersion int64) bson.M {
+	if expectedVersion == 0 {
+		return bson.M{"_id": id, "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	return bson.M{"_id": id, "version": expectedVersion}
+}
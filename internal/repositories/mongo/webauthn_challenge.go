@@ -0,0 +1,45 @@
+package mongo
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+// webAuthnChallengeRepository implementa repositories.WebAuthnChallengeRepository usando MongoDB.
+type webAuthnChallengeRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewWebAuthnChallengeRepository crea una nueva instancia de webAuthnChallengeRepository.
+func NewWebAuthnChallengeRepository(database *mongodriver.Database) repositories.WebAuthnChallengeRepository {
+	return &webAuthnChallengeRepository{
+		collection: database.Collection(webAuthnChallengesCollection),
+	}
+}
+
+// CreateChallenge guarda el estado de una nueva ceremonia
+func (r *webAuthnChallengeRepository) CreateChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	_, err := r.collection.InsertOne(ctx, challenge)
+	return mapError(err)
+}
+
+// ConsumeChallenge elimina la ceremonia y la retorna; si no existe o venció retorna ErrDocumentNotFound
+func (r *webAuthnChallengeRepository) ConsumeChallenge(ctx context.Context, id string) (*models.WebAuthnChallenge, error) {
+	var challenge models.WebAuthnChallenge
+	if err := r.collection.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&challenge); err != nil {
+		return nil, mapError(err)
+	}
+
+	// El índice TTL de MongoDB no elimina los documentos de inmediato
+	if challenge.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &challenge, nil
+}
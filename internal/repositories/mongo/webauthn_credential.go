@@ -0,0 +1,92 @@
+package mongo
+
+import (
+	"context"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"go.mongodb.org/mongo-driver/bson"
+	mongodriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// webAuthnCredentialRepository implementa repositories.WebAuthnCredentialRepository usando MongoDB.
+type webAuthnCredentialRepository struct {
+	collection *mongodriver.Collection
+}
+
+// NewWebAuthnCredentialRepository crea una nueva instancia de webAuthnCredentialRepository.
+func NewWebAuthnCredentialRepository(database *mongodriver.Database) repositories.WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{
+		collection: database.Collection(webAuthnCredentialsCollection),
+	}
+}
+
+// CreateCredential guarda una nueva passkey
+func (r *webAuthnCredentialRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	_, err := r.collection.InsertOne(ctx, credential)
+	return mapError(err)
+}
+
+// GetCredential obtiene una passkey por su credential ID
+func (r *webAuthnCredentialRepository) GetCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&credential); err != nil {
+		return nil, mapError(err)
+	}
+	return &credential, nil
+}
+
+// ListCredentialsByUser lista las passkeys de un usuario, de la más antigua a la más reciente
+func (r *webAuthnCredentialRepository) ListCredentialsByUser(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := []models.WebAuthnCredential{}
+	if err := cursor.All(ctx, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateCredentialUsage registra un login con la passkey; un contador que no avanza retorna ErrConditionFailed
+func (r *webAuthnCredentialRepository) UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	filter := bson.M{"_id": id}
+	if signCount > 0 {
+		filter["sign_count"] = bson.M{"$lt": signCount}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"sign_count":   signCount,
+		"backup_state": backupState,
+		"last_used_at": usedAt,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return validations.ErrConditionFailed
+	}
+	return nil
+}
+
+// DeleteCredential elimina una passkey del usuario
+func (r *webAuthnCredentialRepository) DeleteCredential(ctx context.Context, userID, id string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return validations.ErrDocumentNotFound
+	}
+	return nil
+}
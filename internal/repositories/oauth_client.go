@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getOAuthClientsTableName retorna el nombre de la tabla de clientes OAuth desde variables de entorno
+func getOAuthClientsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_OAUTH_CLIENTS")
+	if tableName == "" {
+		return "oauth_clients" // nombre por defecto
+	}
+	return tableName
+}
+
+// OAuthClientRepository define los métodos para persistir los clientes OAuth registrados.
+type OAuthClientRepository interface {
+	CreateClient(ctx context.Context, client *models.OAuthClient) error
+	GetClient(ctx context.Context, id string) (*models.OAuthClient, error)
+	ListClients(ctx context.Context) ([]models.OAuthClient, error)
+	DeleteClient(ctx context.Context, id string) error
+}
+
+// oauthClientRepository implementa la interfaz OAuthClientRepository usando DynamoDB.
+type oauthClientRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewOAuthClientRepository crea una nueva instancia de oauthClientRepository.
+func NewOAuthClientRepository(client *dynamodb.Client) OAuthClientRepository {
+	return &oauthClientRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateClient guarda un nuevo cliente. Si el ID ya existe retorna validations.ErrDocumentAlreadyExists.
+func (r *oauthClientRepository) CreateClient(ctx context.Context, client *models.OAuthClient) error {
+	item, err := attributevalue.MarshalMap(client)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getOAuthClientsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(client_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetClient obtiene un cliente por su ID
+func (r *oauthClientRepository) GetClient(ctx context.Context, id string) (*models.OAuthClient, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getOAuthClientsTableName()),
+		Key: map[string]types.AttributeValue{
+			"client_id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var client models.OAuthClient
+	if err := attributevalue.UnmarshalMap(result.Item, &client); err != nil {
+		return nil, err
+	}
+
+	return &client, nil
+}
+
+// ListClients lista todos los clientes, del más antiguo al más reciente.
+// Son pocos y solo los consulta la administración, por lo que alcanza con un Scan.
+func (r *oauthClientRepository) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	paginator := dynamodb.NewScanPaginator(r.dynamoClient, &dynamodb.ScanInput{
+		TableName: aws.String(getOAuthClientsTableName()),
+	})
+
+	clients := []models.OAuthClient{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []models.OAuthClient
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		clients = append(clients, items...)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].CreatedAt.Before(clients[j].CreatedAt)
+	})
+
+	return clients, nil
+}
+
+// DeleteClient elimina un cliente. Si no existe retorna validations.ErrDocumentNotFound.
+func (r *oauthClientRepository) DeleteClient(ctx context.Context, id string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getOAuthClientsTableName()),
+		Key: map[string]types.AttributeValue{
+			"client_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("attribute_exists(client_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getOrganizationsTableName retorna el nombre de la tabla de organizaciones desde variables de entorno
+func getOrganizationsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_ORGANIZATIONS")
+	if tableName == "" {
+		return "organizations" // nombre por defecto
+	}
+	return tableName
+}
+
+// OrganizationRepository define los métodos para persistir organizaciones en DynamoDB.
+type OrganizationRepository interface {
+	CreateOrganization(ctx context.Context, organization *models.Organization) error
+	GetOrganization(ctx context.Context, id string) (*models.Organization, error)
+	DeleteOrganization(ctx context.Context, id string) error
+}
+
+// organizationRepository implementa la interfaz OrganizationRepository usando DynamoDB.
+type organizationRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewOrganizationRepository crea una nueva instancia de organizationRepository.
+func NewOrganizationRepository(client *dynamodb.Client) OrganizationRepository {
+	return &organizationRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateOrganization guarda una nueva organización. Falla si el ID ya existe.
+func (r *organizationRepository) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	item, err := attributevalue.MarshalMap(organization)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getOrganizationsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(organization_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetOrganization obtiene una organización por su ID
+func (r *organizationRepository) GetOrganization(ctx context.Context, id string) (*models.Organization, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getOrganizationsTableName()),
+		Key: map[string]types.AttributeValue{
+			"organization_id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var organization models.Organization
+	if err := attributevalue.UnmarshalMap(result.Item, &organization); err != nil {
+		return nil, err
+	}
+
+	return &organization, nil
+}
+
+// DeleteOrganization elimina una organización. Si no existe retorna ErrDocumentNotFound.
+func (r *organizationRepository) DeleteOrganization(ctx context.Context, id string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getOrganizationsTableName()),
+		Key: map[string]types.AttributeValue{
+			"organization_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("attribute_exists(organization_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
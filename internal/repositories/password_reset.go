@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getPasswordResetsTableName retorna el nombre de la tabla de restablecimientos desde variables de entorno
+func getPasswordResetsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_PASSWORD_RESETS")
+	if tableName == "" {
+		return "password_resets" // nombre por defecto
+	}
+	return tableName
+}
+
+// PasswordResetRepository define los métodos para persistir tokens de restablecimiento de contraseña.
+type PasswordResetRepository interface {
+	CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error
+	GetPasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error)
+	MarkPasswordResetUsed(ctx context.Context, tokenHash string) error
+}
+
+// passwordResetRepository implementa la interfaz PasswordResetRepository usando DynamoDB.
+type passwordResetRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewPasswordResetRepository crea una nueva instancia de passwordResetRepository.
+func NewPasswordResetRepository(client *dynamodb.Client) PasswordResetRepository {
+	return &passwordResetRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreatePasswordReset guarda una nueva solicitud de restablecimiento
+func (r *passwordResetRepository) CreatePasswordReset(ctx context.Context, reset *models.PasswordReset) error {
+	item, err := attributevalue.MarshalMap(reset)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getPasswordResetsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(token_hash)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetPasswordReset obtiene una solicitud de restablecimiento por el hash del token
+func (r *passwordResetRepository) GetPasswordReset(ctx context.Context, tokenHash string) (*models.PasswordReset, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getPasswordResetsTableName()),
+		Key: map[string]types.AttributeValue{
+			"token_hash": &types.AttributeValueMemberS{Value: tokenHash},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var reset models.PasswordReset
+	if err := attributevalue.UnmarshalMap(result.Item, &reset); err != nil {
+		return nil, err
+	}
+
+	return &reset, nil
+}
+
+// MarkPasswordResetUsed marca el token como usado. Si ya se había usado retorna validations.ErrConditionFailed,
+// lo que garantiza un único uso aun con peticiones concurrentes.
+func (r *passwordResetRepository) MarkPasswordResetUsed(ctx context.Context, tokenHash string) error {
+	usedAt, err := attributevalue.Marshal(time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getPasswordResetsTableName()),
+		Key: map[string]types.AttributeValue{
+			"token_hash": &types.AttributeValueMemberS{Value: tokenHash},
+		},
+		UpdateExpression:    aws.String("SET used_at = :used_at"),
+		ConditionExpression: aws.String("attribute_exists(token_hash) AND attribute_not_exists(used_at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":used_at": usedAt,
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// refreshTokensFamilyIndex es el GSI (partition key: family_id) usado para revocar familias completas.
+const refreshTokensFamilyIndex = "family_id-index"
+
+// getRefreshTokensTableName retorna el nombre de la tabla de refresh tokens desde variables de entorno
+func getRefreshTokensTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_REFRESH_TOKENS")
+	if tableName == "" {
+		return "refresh_tokens" // nombre por defecto
+	}
+	return tableName
+}
+
+// RefreshTokenRepository define los métodos para persistir refresh tokens en DynamoDB.
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldTokenHash string, newToken *models.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID string) error
+}
+
+// refreshTokenRepository implementa la interfaz RefreshTokenRepository usando DynamoDB.
+type refreshTokenRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewRefreshTokenRepository crea una nueva instancia de refreshTokenRepository.
+func NewRefreshTokenRepository(client *dynamodb.Client) RefreshTokenRepository {
+	return &refreshTokenRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateRefreshToken guarda un nuevo refresh token. Falla si el hash ya existe.
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getRefreshTokensTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(token_hash)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetRefreshToken obtiene un refresh token por su hash
+func (r *refreshTokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getRefreshTokensTableName()),
+		Key: map[string]types.AttributeValue{
+			"token_hash": &types.AttributeValueMemberS{Value: tokenHash},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var token models.RefreshToken
+	if err := attributevalue.UnmarshalMap(result.Item, &token); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken marca el token anterior como rotado y guarda el nuevo en una única transacción.
+// Si el token anterior ya había sido rotado o revocado retorna validations.ErrConditionFailed.
+func (r *refreshTokenRepository) RotateRefreshToken(ctx context.Context, oldTokenHash string, newToken *models.RefreshToken) error {
+	item, err := attributevalue.MarshalMap(newToken)
+	if err != nil {
+		return err
+	}
+
+	rotatedAt, err := attributevalue.Marshal(time.Now())
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Update: &types.Update{
+					TableName: aws.String(getRefreshTokensTableName()),
+					Key: map[string]types.AttributeValue{
+						"token_hash": &types.AttributeValueMemberS{Value: oldTokenHash},
+					},
+					UpdateExpression:    aws.String("SET rotated_at = :rotated_at, replaced_by = :replaced_by"),
+					ConditionExpression: aws.String("attribute_exists(token_hash) AND attribute_not_exists(replaced_by) AND revoked = :false"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":rotated_at":  rotatedAt,
+						":replaced_by": &types.AttributeValueMemberS{Value: newToken.TokenHash},
+						":false":       &types.AttributeValueMemberBOOL{Value: false},
+					},
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           aws.String(getRefreshTokensTableName()),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(token_hash)"),
+				},
+			},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// RevokeFamily revoca todos los refresh tokens que pertenecen a una misma familia.
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:              aws.String(getRefreshTokensTableName()),
+		IndexName:              aws.String(refreshTokensFamilyIndex),
+		KeyConditionExpression: aws.String("family_id = :family_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":family_id": &types.AttributeValueMemberS{Value: familyID},
+		},
+		ProjectionExpression: aws.String("token_hash"),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page.Items {
+			_, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName:           aws.String(getRefreshTokensTableName()),
+				Key:                 map[string]types.AttributeValue{"token_hash": item["token_hash"]},
+				UpdateExpression:    aws.String("SET revoked = :true"),
+				ConditionExpression: aws.String("attribute_exists(token_hash)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":true": &types.AttributeValueMemberBOOL{Value: true},
+				},
+			})
+			// Si el item expiró entre la consulta y la actualización no hay nada que revocar
+			if err != nil && !isConditionalCheckFailed(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestAuthorizationCodeRepository verifica el contrato de repositories.AuthorizationCodeRepository.
+func TestAuthorizationCodeRepository(t *testing.T, newRepo func(t *testing.T) repositories.AuthorizationCodeRepository) {
+	ctx := context.Background()
+	newCode := func() *models.AuthorizationCode {
+		now := time.Now()
+		expiresAt := now.Add(5 * time.Minute)
+		return &models.AuthorizationCode{
+			CodeHash:            uuid.New().String(),
+			ClientID:            uuid.New().String(),
+			UserID:              uuid.New().String(),
+			RedirectURI:         "https://app.example.com/callback",
+			Scope:               "openid profile",
+			CodeChallenge:       "challenge",
+			CodeChallengeMethod: "S256",
+			CreatedAt:           now,
+			ExpiresAt:           expiresAt,
+			TTL:                 expiresAt.Unix(),
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		code := newCode()
+		mustNoError(t, repo.CreateAuthorizationCode(ctx, code))
+		mustBe(t, repo.CreateAuthorizationCode(ctx, code), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetAuthorizationCode(ctx, code.CodeHash)
+		mustNoError(t, err)
+		if stored.ClientID != code.ClientID || stored.UserID != code.UserID || stored.RedirectURI != code.RedirectURI ||
+			stored.Scope != code.Scope || stored.CodeChallenge != code.CodeChallenge || !stored.IsUsable() {
+			t.Fatalf("unexpected code: %+v", stored)
+		}
+
+		_, err = repo.GetAuthorizationCode(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("MarkUsedOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		code := newCode()
+		mustNoError(t, repo.CreateAuthorizationCode(ctx, code))
+
+		mustNoError(t, repo.MarkAuthorizationCodeUsed(ctx, code.CodeHash))
+		mustBe(t, repo.MarkAuthorizationCodeUsed(ctx, code.CodeHash), validations.ErrConditionFailed)
+
+		stored, err := repo.GetAuthorizationCode(ctx, code.CodeHash)
+		mustNoError(t, err)
+		if stored.UsedAt == nil || stored.IsUsable() {
+			t.Fatalf("code not marked as used: %+v", stored)
+		}
+
+		mustBe(t, repo.MarkAuthorizationCodeUsed(ctx, uuid.New().String()), validations.ErrConditionFailed)
+	})
+
+	t.Run("SetSession", func(t *testing.T) {
+		repo := newRepo(t)
+		code := newCode()
+		mustNoError(t, repo.CreateAuthorizationCode(ctx, code))
+		mustNoError(t, repo.MarkAuthorizationCodeUsed(ctx, code.CodeHash))
+
+		sessionID := uuid.New().String()
+		mustNoError(t, repo.SetAuthorizationCodeSession(ctx, code.CodeHash, sessionID))
+		stored, err := repo.GetAuthorizationCode(ctx, code.CodeHash)
+		mustNoError(t, err)
+		if stored.SessionID != sessionID || stored.UsedAt == nil {
+			t.Fatalf("unexpected code: %+v", stored)
+		}
+
+		mustBe(t, repo.SetAuthorizationCodeSession(ctx, uuid.New().String(), sessionID), validations.ErrDocumentNotFound)
+	})
+}
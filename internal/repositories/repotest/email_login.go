@@ -0,0 +1,92 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestEmailLoginRepository verifica el contrato de repositories.EmailLoginRepository.
+func TestEmailLoginRepository(t *testing.T, newRepo func(t *testing.T) repositories.EmailLoginRepository) {
+	ctx := context.Background()
+	newLogin := func(secretHash string, expiresAt time.Time) *models.EmailLogin {
+		return models.NewEmailLogin(models.EMAIL_LOGIN_OTP, uuid.New().String()+"@example.com", secretHash, expiresAt)
+	}
+
+	t.Run("SaveReplacesPrevious", func(t *testing.T) {
+		repo := newRepo(t)
+		first := newLogin("first", time.Now().Add(time.Hour))
+		mustNoError(t, repo.SaveEmailLogin(ctx, first))
+		_, err := repo.RegisterEmailLoginAttempt(ctx, first.Key, 5)
+		mustNoError(t, err)
+
+		second := models.NewEmailLogin(first.Method, first.Email, "second", time.Now().Add(time.Hour))
+		mustNoError(t, repo.SaveEmailLogin(ctx, second))
+
+		stored, err := repo.GetEmailLogin(ctx, first.Key)
+		mustNoError(t, err)
+		if stored.SecretHash != "second" || stored.Attempts != 0 || stored.Email != first.Email {
+			t.Fatalf("unexpected login: %+v", stored)
+		}
+
+		_, err = repo.GetEmailLogin(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("ExpiredIsNotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		login := newLogin("hash", time.Now().Add(-time.Minute))
+		mustNoError(t, repo.SaveEmailLogin(ctx, login))
+
+		_, err := repo.GetEmailLogin(ctx, login.Key)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+		_, err = repo.RegisterEmailLoginAttempt(ctx, login.Key, 5)
+		mustBe(t, err, validations.ErrConditionFailed)
+	})
+
+	t.Run("AttemptsAreBounded", func(t *testing.T) {
+		repo := newRepo(t)
+		login := newLogin("hash", time.Now().Add(time.Hour))
+		mustNoError(t, repo.SaveEmailLogin(ctx, login))
+
+		for i := 1; i <= 3; i++ {
+			stored, err := repo.RegisterEmailLoginAttempt(ctx, login.Key, 3)
+			mustNoError(t, err)
+			if stored.Attempts != i || stored.SecretHash != "hash" {
+				t.Fatalf("attempt %d: unexpected login %+v", i, stored)
+			}
+		}
+
+		_, err := repo.RegisterEmailLoginAttempt(ctx, login.Key, 3)
+		mustBe(t, err, validations.ErrConditionFailed)
+		_, err = repo.RegisterEmailLoginAttempt(ctx, uuid.New().String(), 3)
+		mustBe(t, err, validations.ErrConditionFailed)
+	})
+
+	t.Run("MarkUsedOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		login := newLogin("hash", time.Now().Add(time.Hour))
+		mustNoError(t, repo.SaveEmailLogin(ctx, login))
+
+		// Solo se marca el secreto vigente
+		mustBe(t, repo.MarkEmailLoginUsed(ctx, login.Key, "other"), validations.ErrConditionFailed)
+		mustNoError(t, repo.MarkEmailLoginUsed(ctx, login.Key, "hash"))
+		mustBe(t, repo.MarkEmailLoginUsed(ctx, login.Key, "hash"), validations.ErrConditionFailed)
+
+		stored, err := repo.GetEmailLogin(ctx, login.Key)
+		mustNoError(t, err)
+		if stored.UsedAt == nil {
+			t.Fatalf("login not marked as used: %+v", stored)
+		}
+
+		_, err = repo.RegisterEmailLoginAttempt(ctx, login.Key, 5)
+		mustBe(t, err, validations.ErrConditionFailed)
+		mustBe(t, repo.MarkEmailLoginUsed(ctx, uuid.New().String(), "hash"), validations.ErrConditionFailed)
+	})
+}
@@ -0,0 +1,98 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestIdentityRepository verifica el contrato de repositories.IdentityRepository.
+func TestIdentityRepository(t *testing.T, newRepo func(t *testing.T) repositories.IdentityRepository) {
+	ctx := context.Background()
+	newIdentity := func(userID, provider string, createdAt time.Time) *models.Identity {
+		identity := models.NewIdentity(userID, provider, uuid.New().String(), "juan@example.com")
+		identity.CreatedAt = createdAt.UTC().Truncate(time.Millisecond)
+		return identity
+	}
+
+	t.Run("CreateGetAndList", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := uuid.New().String()
+
+		older := newIdentity(userID, "google", time.Now().Add(-time.Hour))
+		newer := newIdentity(userID, "corp", time.Now())
+		foreign := newIdentity(uuid.New().String(), "google", time.Now())
+		for _, identity := range []*models.Identity{newer, older, foreign} {
+			mustNoError(t, repo.CreateIdentity(ctx, identity))
+		}
+
+		// Una identidad del proveedor se vincula a un solo usuario
+		taken := *older
+		taken.UserID = uuid.New().String()
+		mustBe(t, repo.CreateIdentity(ctx, &taken), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetIdentity(ctx, models.IdentityKey("google", older.Subject))
+		mustNoError(t, err)
+		if stored.UserID != userID || stored.Provider != "google" || stored.Subject != older.Subject || stored.Email != older.Email {
+			t.Fatalf("unexpected identity: %+v", stored)
+		}
+
+		_, err = repo.GetIdentity(ctx, models.IdentityKey("corp", older.Subject))
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		identities, err := repo.ListIdentitiesByUser(ctx, userID)
+		mustNoError(t, err)
+		if len(identities) != 2 || identities[0].ID != older.ID || identities[1].ID != newer.ID {
+			t.Fatalf("identities = %+v, want [older, newer] without foreign identities", identities)
+		}
+	})
+
+	t.Run("DeleteOnlyOwnIdentity", func(t *testing.T) {
+		repo := newRepo(t)
+		identity := newIdentity(uuid.New().String(), "google", time.Now())
+		mustNoError(t, repo.CreateIdentity(ctx, identity))
+
+		mustBe(t, repo.DeleteIdentity(ctx, uuid.New().String(), identity.ID), validations.ErrDocumentNotFound)
+		mustNoError(t, repo.DeleteIdentity(ctx, identity.UserID, identity.ID))
+		mustBe(t, repo.DeleteIdentity(ctx, identity.UserID, identity.ID), validations.ErrDocumentNotFound)
+
+		_, err := repo.GetIdentity(ctx, identity.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+}
+
+// TestFederatedStateRepository verifica el contrato de repositories.FederatedStateRepository.
+func TestFederatedStateRepository(t *testing.T, newRepo func(t *testing.T) repositories.FederatedStateRepository) {
+	ctx := context.Background()
+
+	t.Run("ConsumeOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		state := models.NewFederatedState(uuid.New().String(), "binding", "google", "nonce", "verifier", time.Now().Add(time.Minute))
+		mustNoError(t, repo.CreateState(ctx, state))
+		mustBe(t, repo.CreateState(ctx, state), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.ConsumeState(ctx, state.StateHash)
+		mustNoError(t, err)
+		if stored.Provider != "google" || stored.BindingHash != "binding" || stored.Nonce != "nonce" || stored.CodeVerifier != "verifier" {
+			t.Fatalf("unexpected state: %+v", stored)
+		}
+
+		_, err = repo.ConsumeState(ctx, state.StateHash)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("ExpiredState", func(t *testing.T) {
+		repo := newRepo(t)
+		state := models.NewFederatedState(uuid.New().String(), "binding", "google", "nonce", "verifier", time.Now().Add(-time.Second))
+		mustNoError(t, repo.CreateState(ctx, state))
+
+		_, err := repo.ConsumeState(ctx, state.StateHash)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+}
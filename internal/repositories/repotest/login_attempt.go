@@ -0,0 +1,126 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestLoginAttemptRepository verifica el contrato de repositories.LoginAttemptRepository.
+func TestLoginAttemptRepository(t *testing.T, newRepo func(t *testing.T) repositories.LoginAttemptRepository) {
+	ctx := context.Background()
+	newEmail := func() string { return uuid.New().String() + "@example.com" }
+
+	t.Run("RegisterAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		email := newEmail()
+		key := models.AccountAttemptKey(email)
+
+		_, err := repo.GetLoginAttempt(ctx, key)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		now := time.Now()
+		for i := 1; i <= 3; i++ {
+			attempt, err := repo.RegisterFailure(ctx, key, email, now, now.Add(time.Hour))
+			mustNoError(t, err)
+			if attempt.Failures != i {
+				t.Fatalf("expected %d failures, got %d", i, attempt.Failures)
+			}
+		}
+
+		stored, err := repo.GetLoginAttempt(ctx, key)
+		mustNoError(t, err)
+		if stored.Failures != 3 || stored.Email != email {
+			t.Fatalf("unexpected attempt: %+v", stored)
+		}
+	})
+
+	t.Run("ExpiredWindowRestarts", func(t *testing.T) {
+		repo := newRepo(t)
+		email := newEmail()
+		key := models.AccountAttemptKey(email)
+
+		past := time.Now().Add(-2 * time.Hour)
+		_, err := repo.RegisterFailure(ctx, key, email, past, past.Add(time.Hour))
+		mustNoError(t, err)
+		_, err = repo.RegisterFailure(ctx, key, email, past, past.Add(time.Hour))
+		mustNoError(t, err)
+
+		// La ventana venció: no se informa y el siguiente fallo cuenta desde cero
+		_, err = repo.GetLoginAttempt(ctx, key)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		now := time.Now()
+		attempt, err := repo.RegisterFailure(ctx, key, email, now, now.Add(time.Hour))
+		mustNoError(t, err)
+		if attempt.Failures != 1 {
+			t.Fatalf("expected counter to restart, got %d failures", attempt.Failures)
+		}
+	})
+
+	t.Run("ConcurrentFailures", func(t *testing.T) {
+		repo := newRepo(t)
+		email := newEmail()
+		key := models.AccountAttemptKey(email)
+
+		const workers = 10
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				now := time.Now()
+				if _, err := repo.RegisterFailure(ctx, key, email, now, now.Add(time.Hour)); err != nil {
+					t.Errorf("register failure: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		stored, err := repo.GetLoginAttempt(ctx, key)
+		mustNoError(t, err)
+		if stored.Failures != workers {
+			t.Fatalf("expected %d failures, got %d", workers, stored.Failures)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		email, other := newEmail(), newEmail()
+		now := time.Now()
+
+		keys := []string{
+			models.AccountAttemptKey(email),
+			models.EmailIPAttemptKey(email, "10.0.0.1"),
+			models.EmailIPAttemptKey(email, "10.0.0.2"),
+		}
+		for _, key := range keys {
+			_, err := repo.RegisterFailure(ctx, key, email, now, now.Add(time.Hour))
+			mustNoError(t, err)
+		}
+		otherKey := models.AccountAttemptKey(other)
+		_, err := repo.RegisterFailure(ctx, otherKey, other, now, now.Add(time.Hour))
+		mustNoError(t, err)
+
+		mustNoError(t, repo.DeleteLoginAttempt(ctx, keys[1]))
+		_, err = repo.GetLoginAttempt(ctx, keys[1])
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		mustNoError(t, repo.DeleteLoginAttemptsByEmail(ctx, email))
+		for _, key := range keys {
+			_, err := repo.GetLoginAttempt(ctx, key)
+			mustBe(t, err, validations.ErrDocumentNotFound)
+		}
+
+		// Las claves de otras cuentas no se tocan
+		_, err = repo.GetLoginAttempt(ctx, otherKey)
+		mustNoError(t, err)
+	})
+}
@@ -0,0 +1,82 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestOAuthClientRepository verifica el contrato de repositories.OAuthClientRepository.
+func TestOAuthClientRepository(t *testing.T, newRepo func(t *testing.T) repositories.OAuthClientRepository) {
+	ctx := context.Background()
+	newClient := func(createdAt time.Time) *models.OAuthClient {
+		return &models.OAuthClient{
+			ID:           uuid.New().String(),
+			Name:         "App",
+			SecretHash:   "hash",
+			RedirectURIs: []string{"https://app.example.com/callback"},
+			Scopes:       []string{"openid", "profile"},
+			GrantTypes:   []string{models.OAUTH_GRANT_AUTHORIZATION_CODE},
+			CreatedAt:    createdAt.UTC().Truncate(time.Millisecond),
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		client := newClient(time.Now())
+		mustNoError(t, repo.CreateClient(ctx, client))
+		mustBe(t, repo.CreateClient(ctx, client), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetClient(ctx, client.ID)
+		mustNoError(t, err)
+		if stored.Name != client.Name || stored.SecretHash != client.SecretHash ||
+			!stored.HasRedirectURI("https://app.example.com/callback") || !stored.AllowsScopes([]string{"openid", "profile"}) ||
+			!stored.AllowsGrant(models.OAUTH_GRANT_AUTHORIZATION_CODE) {
+			t.Fatalf("unexpected client: %+v", stored)
+		}
+
+		_, err = repo.GetClient(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("ListSortedByCreation", func(t *testing.T) {
+		repo := newRepo(t)
+		now := time.Now()
+		newer := newClient(now)
+		older := newClient(now.Add(-time.Hour))
+		mustNoError(t, repo.CreateClient(ctx, newer))
+		mustNoError(t, repo.CreateClient(ctx, older))
+
+		clients, err := repo.ListClients(ctx)
+		mustNoError(t, err)
+		olderIndex, newerIndex := -1, -1
+		for i, client := range clients {
+			switch client.ID {
+			case older.ID:
+				olderIndex = i
+			case newer.ID:
+				newerIndex = i
+			}
+		}
+		if olderIndex == -1 || newerIndex == -1 || olderIndex > newerIndex {
+			t.Fatalf("unexpected order: older at %d, newer at %d", olderIndex, newerIndex)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		client := newClient(time.Now())
+		mustNoError(t, repo.CreateClient(ctx, client))
+
+		mustNoError(t, repo.DeleteClient(ctx, client.ID))
+		_, err := repo.GetClient(ctx, client.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+		mustBe(t, repo.DeleteClient(ctx, client.ID), validations.ErrDocumentNotFound)
+	})
+}
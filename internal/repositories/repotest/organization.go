@@ -0,0 +1,182 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/consts"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestOrganizationRepository verifica el contrato de repositories.OrganizationRepository.
+func TestOrganizationRepository(t *testing.T, newRepo func(t *testing.T) repositories.OrganizationRepository) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		organization, err := models.NewOrganization("  Panadería La Espiga ", uuid.New().String())
+		mustNoError(t, err)
+		organization.CreatedAt = organization.CreatedAt.UTC().Truncate(time.Millisecond)
+
+		mustNoError(t, repo.CreateOrganization(ctx, organization))
+		mustBe(t, repo.CreateOrganization(ctx, organization), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetOrganization(ctx, organization.ID)
+		mustNoError(t, err)
+		if stored.Name != "Panadería La Espiga" || stored.OwnerID != organization.OwnerID || !stored.CreatedAt.Equal(organization.CreatedAt) {
+			t.Fatalf("unexpected organization: %+v", stored)
+		}
+
+		_, err = repo.GetOrganization(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		mustNoError(t, repo.DeleteOrganization(ctx, organization.ID))
+		_, err = repo.GetOrganization(ctx, organization.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+		mustBe(t, repo.DeleteOrganization(ctx, organization.ID), validations.ErrDocumentNotFound)
+	})
+}
+
+// TestMembershipRepository verifica el contrato de repositories.MembershipRepository.
+func TestMembershipRepository(t *testing.T, newRepo func(t *testing.T) repositories.MembershipRepository) {
+	ctx := context.Background()
+	newMembership := func(organizationID, userID, role string, createdAt time.Time) *models.Membership {
+		membership := models.NewMembership(organizationID, userID, role)
+		membership.CreatedAt = createdAt.UTC().Truncate(time.Millisecond)
+		return membership
+	}
+
+	t.Run("CreateGetAndList", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := uuid.New().String()
+		organizationID := uuid.New().String()
+
+		older := newMembership(organizationID, userID, consts.ROLE_OWNER, time.Now().Add(-time.Hour))
+		newer := newMembership(uuid.New().String(), userID, consts.ROLE_EMPLOYEE, time.Now())
+		colleague := newMembership(organizationID, uuid.New().String(), consts.ROLE_EMPLOYEE, time.Now())
+		for _, membership := range []*models.Membership{newer, older, colleague} {
+			mustNoError(t, repo.CreateMembership(ctx, membership))
+		}
+
+		// Un usuario es miembro una sola vez de cada organización
+		again := newMembership(organizationID, userID, consts.ROLE_CLIENT, time.Now())
+		mustBe(t, repo.CreateMembership(ctx, again), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetMembership(ctx, organizationID, userID)
+		mustNoError(t, err)
+		if stored.OrganizationID != organizationID || stored.UserID != userID || stored.Role != consts.ROLE_OWNER {
+			t.Fatalf("unexpected membership: %+v", stored)
+		}
+
+		_, err = repo.GetMembership(ctx, newer.OrganizationID, colleague.UserID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		memberships, err := repo.ListMembershipsByUser(ctx, userID)
+		mustNoError(t, err)
+		if len(memberships) != 2 || memberships[0].ID != older.ID || memberships[1].ID != newer.ID {
+			t.Fatalf("memberships = %+v, want [older, newer] without other users", memberships)
+		}
+	})
+}
+
+// TestInvitationRepository verifica el contrato de repositories.InvitationRepository.
+func TestInvitationRepository(t *testing.T, newRepo func(t *testing.T) repositories.InvitationRepository) {
+	ctx := context.Background()
+	newInvitation := func(organizationID string, createdAt, expiresAt time.Time) *models.Invitation {
+		invitation := models.NewInvitation(organizationID, uuid.New().String()+"@example.com", consts.ROLE_EMPLOYEE, uuid.New().String(), expiresAt.UTC().Truncate(time.Millisecond))
+		invitation.CreatedAt = createdAt.UTC().Truncate(time.Millisecond)
+		return invitation
+	}
+
+	t.Run("CreateGetAndList", func(t *testing.T) {
+		repo := newRepo(t)
+		organizationID := uuid.New().String()
+		expiresAt := time.Now().Add(time.Hour)
+
+		older := newInvitation(organizationID, time.Now().Add(-time.Hour), expiresAt)
+		newer := newInvitation(organizationID, time.Now(), expiresAt)
+		expired := newInvitation(organizationID, time.Now(), time.Now().Add(-time.Second))
+		foreign := newInvitation(uuid.New().String(), time.Now(), expiresAt)
+		for _, invitation := range []*models.Invitation{newer, older, expired, foreign} {
+			mustNoError(t, repo.CreateInvitation(ctx, invitation))
+		}
+		mustBe(t, repo.CreateInvitation(ctx, older), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetInvitation(ctx, older.ID)
+		mustNoError(t, err)
+		if stored.OrganizationID != organizationID || stored.Email != older.Email || stored.Role != consts.ROLE_EMPLOYEE ||
+			stored.InvitedBy != older.InvitedBy || stored.TokenID != older.TokenID || !stored.ExpiresAt.Equal(older.ExpiresAt) {
+			t.Fatalf("unexpected invitation: %+v", stored)
+		}
+
+		_, err = repo.GetInvitation(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		invitations, err := repo.ListInvitationsByOrganization(ctx, organizationID)
+		mustNoError(t, err)
+		if len(invitations) != 2 || invitations[0].ID != older.ID || invitations[1].ID != newer.ID {
+			t.Fatalf("invitations = %+v, want [older, newer] without expired or foreign invitations", invitations)
+		}
+	})
+
+	t.Run("RenewReplacesToken", func(t *testing.T) {
+		repo := newRepo(t)
+		invitation := newInvitation(uuid.New().String(), time.Now(), time.Now().Add(time.Minute))
+		mustNoError(t, repo.CreateInvitation(ctx, invitation))
+
+		tokenID := uuid.New().String()
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Millisecond)
+		mustBe(t, repo.RenewInvitation(ctx, uuid.New().String(), invitation.ID, tokenID, expiresAt), validations.ErrDocumentNotFound)
+		mustBe(t, repo.RenewInvitation(ctx, invitation.OrganizationID, uuid.New().String(), tokenID, expiresAt), validations.ErrDocumentNotFound)
+		mustNoError(t, repo.RenewInvitation(ctx, invitation.OrganizationID, invitation.ID, tokenID, expiresAt))
+
+		stored, err := repo.GetInvitation(ctx, invitation.ID)
+		mustNoError(t, err)
+		if stored.TokenID != tokenID || !stored.ExpiresAt.Equal(expiresAt) {
+			t.Fatalf("invitation not renewed: %+v", stored)
+		}
+
+		// El token anterior deja de servir
+		_, err = repo.ConsumeInvitation(ctx, invitation.ID, invitation.TokenID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+		_, err = repo.ConsumeInvitation(ctx, invitation.ID, tokenID)
+		mustNoError(t, err)
+	})
+
+	t.Run("ConsumeOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		invitation := newInvitation(uuid.New().String(), time.Now(), time.Now().Add(time.Minute))
+		mustNoError(t, repo.CreateInvitation(ctx, invitation))
+
+		stored, err := repo.ConsumeInvitation(ctx, invitation.ID, invitation.TokenID)
+		mustNoError(t, err)
+		if stored.ID != invitation.ID || stored.Email != invitation.Email {
+			t.Fatalf("unexpected invitation: %+v", stored)
+		}
+
+		_, err = repo.ConsumeInvitation(ctx, invitation.ID, invitation.TokenID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+		_, err = repo.GetInvitation(ctx, invitation.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		expired := newInvitation(invitation.OrganizationID, time.Now(), time.Now().Add(-time.Second))
+		mustNoError(t, repo.CreateInvitation(ctx, expired))
+		_, err = repo.ConsumeInvitation(ctx, expired.ID, expired.TokenID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("DeleteOnlyOwnInvitation", func(t *testing.T) {
+		repo := newRepo(t)
+		invitation := newInvitation(uuid.New().String(), time.Now(), time.Now().Add(time.Minute))
+		mustNoError(t, repo.CreateInvitation(ctx, invitation))
+
+		mustBe(t, repo.DeleteInvitation(ctx, uuid.New().String(), invitation.ID), validations.ErrDocumentNotFound)
+		mustNoError(t, repo.DeleteInvitation(ctx, invitation.OrganizationID, invitation.ID))
+		mustBe(t, repo.DeleteInvitation(ctx, invitation.OrganizationID, invitation.ID), validations.ErrDocumentNotFound)
+	})
+}
@@ -0,0 +1,83 @@
+// Package repotest contiene la suite de conformidad de los repositorios. Cada implementación
+// (DynamoDB, memoria, ...) la ejecuta desde sus tests para garantizar la misma semántica,
+// en particular los errores de validations (not found, duplicados, conflictos).
+package repotest
+
+import (
+	"testing"
+
+	"myproject/internal/repositories"
+)
+
+// Factory crea repositorios vacíos (o aislados) para cada test. Los campos nil se omiten.
+type Factory struct {
+	Users          func(t *testing.T) repositories.UserRepository
+	RefreshTokens  func(t *testing.T) repositories.RefreshTokenRepository
+	RevokedTokens  func(t *testing.T) repositories.RevokedTokenRepository
+	Sessions       func(t *testing.T) repositories.SessionRepository
+	PasswordResets func(t *testing.T) repositories.PasswordResetRepository
+	LoginAttempts  func(t *testing.T) repositories.LoginAttemptRepository
+
+	WebAuthnCredentials func(t *testing.T) repositories.WebAuthnCredentialRepository
+	WebAuthnChallenges  func(t *testing.T) repositories.WebAuthnChallengeRepository
+	EmailLogins         func(t *testing.T) repositories.EmailLoginRepository
+	OAuthClients        func(t *testing.T) repositories.OAuthClientRepository
+	AuthorizationCodes  func(t *testing.T) repositories.AuthorizationCodeRepository
+	Identities          func(t *testing.T) repositories.IdentityRepository
+	FederatedStates     func(t *testing.T) repositories.FederatedStateRepository
+	Organizations       func(t *testing.T) repositories.OrganizationRepository
+	Memberships         func(t *testing.T) repositories.MembershipRepository
+	Invitations         func(t *testing.T) repositories.InvitationRepository
+}
+
+// Run ejecuta la suite completa contra los repositorios de la factory.
+func Run(t *testing.T, f Factory) {
+	if f.Users != nil {
+		t.Run("UserRepository", func(t *testing.T) { TestUserRepository(t, f.Users) })
+	}
+	if f.RefreshTokens != nil {
+		t.Run("RefreshTokenRepository", func(t *testing.T) { TestRefreshTokenRepository(t, f.RefreshTokens) })
+	}
+	if f.RevokedTokens != nil {
+		t.Run("RevokedTokenRepository", func(t *testing.T) { TestRevokedTokenRepository(t, f.RevokedTokens) })
+	}
+	if f.Sessions != nil {
+		t.Run("SessionRepository", func(t *testing.T) { TestSessionRepository(t, f.Sessions) })
+	}
+	if f.PasswordResets != nil {
+		t.Run("PasswordResetRepository", func(t *testing.T) { TestPasswordResetRepository(t, f.PasswordResets) })
+	}
+	if f.LoginAttempts != nil {
+		t.Run("LoginAttemptRepository", func(t *testing.T) { TestLoginAttemptRepository(t, f.LoginAttempts) })
+	}
+	if f.WebAuthnCredentials != nil {
+		t.Run("WebAuthnCredentialRepository", func(t *testing.T) { TestWebAuthnCredentialRepository(t, f.WebAuthnCredentials) })
+	}
+	if f.WebAuthnChallenges != nil {
+		t.Run("WebAuthnChallengeRepository", func(t *testing.T) { TestWebAuthnChallengeRepository(t, f.WebAuthnChallenges) })
+	}
+	if f.EmailLogins != nil {
+		t.Run("EmailLoginRepository", func(t *testing.T) { TestEmailLoginRepository(t, f.EmailLogins) })
+	}
+	if f.OAuthClients != nil {
+		t.Run("OAuthClientRepository", func(t *testing.T) { TestOAuthClientRepository(t, f.OAuthClients) })
+	}
+	if f.AuthorizationCodes != nil {
+		t.Run("AuthorizationCodeRepository", func(t *testing.T) { TestAuthorizationCodeRepository(t, f.AuthorizationCodes) })
+	}
+	if f.Identities != nil {
+		t.Run("IdentityRepository", func(t *testing.T) { TestIdentityRepository(t, f.Identities) })
+	}
+	if f.FederatedStates != nil {
+		t.Run("FederatedStateRepository", func(t *testing.T) { TestFederatedStateRepository(t, f.FederatedStates) })
+	}
+	if f.Organizations != nil {
+		t.Run("OrganizationRepository", func(t *testing.T) { TestOrganizationRepository(t, f.Organizations) })
+	}
+	if f.Memberships != nil {
+		t.Run("MembershipRepository", func(t *testing.T) { TestMembershipRepository(t, f.Memberships) })
+	}
+	if f.Invitations != nil {
+		t.Run("InvitationRepository", func(t *testing.T) { TestInvitationRepository(t, f.Invitations) })
+	}
+}
@@ -0,0 +1,77 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestSessionRepository verifica el contrato de repositories.SessionRepository.
+func TestSessionRepository(t *testing.T, newRepo func(t *testing.T) repositories.SessionRepository) {
+	ctx := context.Background()
+	client := request.ClientInfo{UserAgent: "Mozilla/5.0 (Windows NT 10.0) Chrome/120.0", IP: "10.0.0.1"}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		session := models.NewSession(uuid.New().String(), uuid.New().String(), client, time.Now().Add(time.Hour))
+		mustNoError(t, repo.CreateSession(ctx, session))
+		mustBe(t, repo.CreateSession(ctx, session), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetSessionByID(ctx, session.ID)
+		mustNoError(t, err)
+		if stored.UserID != session.UserID || stored.IP != client.IP || stored.DeviceName == "" {
+			t.Fatalf("unexpected session: %+v", stored)
+		}
+
+		_, err = repo.GetSessionByID(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("ListTouchAndDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := uuid.New().String()
+
+		older := models.NewSession(uuid.New().String(), userID, client, time.Now().Add(time.Hour))
+		newer := models.NewSession(uuid.New().String(), userID, client, time.Now().Add(time.Hour))
+		expired := models.NewSession(uuid.New().String(), userID, client, time.Now().Add(-time.Minute))
+		foreign := models.NewSession(uuid.New().String(), uuid.New().String(), client, time.Now().Add(time.Hour))
+		for _, s := range []*models.Session{older, newer, expired, foreign} {
+			mustNoError(t, repo.CreateSession(ctx, s))
+		}
+
+		// Usar la sesión más antigua la vuelve la más reciente
+		mustNoError(t, repo.TouchSession(ctx, older.ID, "10.0.0.2", time.Now().Add(time.Second), time.Now().Add(2*time.Hour)))
+		mustBe(t, repo.TouchSession(ctx, uuid.New().String(), "10.0.0.2", time.Now(), time.Now().Add(time.Hour)), validations.ErrDocumentNotFound)
+
+		sessions, err := repo.ListSessionsByUser(ctx, userID)
+		mustNoError(t, err)
+		if len(sessions) != 2 || sessions[0].ID != older.ID || sessions[1].ID != newer.ID {
+			t.Fatalf("sessions = %+v, want [older, newer] without expired or foreign sessions", sessions)
+		}
+		if sessions[0].IP != "10.0.0.2" {
+			t.Fatalf("touched session ip = %s", sessions[0].IP)
+		}
+
+		mustNoError(t, repo.DeleteSession(ctx, newer.ID))
+		_, err = repo.GetSessionByID(ctx, newer.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		mustNoError(t, repo.DeleteSessionsByUser(ctx, userID))
+		sessions, err = repo.ListSessionsByUser(ctx, userID)
+		mustNoError(t, err)
+		if len(sessions) != 0 {
+			t.Fatalf("sessions after DeleteSessionsByUser = %d", len(sessions))
+		}
+
+		if _, err := repo.GetSessionByID(ctx, foreign.ID); err != nil {
+			t.Fatalf("session of another user was deleted: %v", err)
+		}
+	})
+}
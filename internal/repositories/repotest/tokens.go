@@ -0,0 +1,153 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestRefreshTokenRepository verifica el contrato de repositories.RefreshTokenRepository.
+func TestRefreshTokenRepository(t *testing.T, newRepo func(t *testing.T) repositories.RefreshTokenRepository) {
+	ctx := context.Background()
+	newToken := func(familyID string) *models.RefreshToken {
+		return models.NewRefreshToken(uuid.New().String(), familyID, "user-"+familyID, "test", time.Now().Add(time.Hour))
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		token := newToken(uuid.New().String())
+		mustNoError(t, repo.CreateRefreshToken(ctx, token))
+		mustBe(t, repo.CreateRefreshToken(ctx, token), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetRefreshToken(ctx, token.TokenHash)
+		mustNoError(t, err)
+		if stored.FamilyID != token.FamilyID || stored.IsRotated() || stored.Revoked {
+			t.Fatalf("unexpected token: %+v", stored)
+		}
+
+		_, err = repo.GetRefreshToken(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("RotateOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		familyID := uuid.New().String()
+		first := newToken(familyID)
+		mustNoError(t, repo.CreateRefreshToken(ctx, first))
+
+		second := newToken(familyID)
+		mustNoError(t, repo.RotateRefreshToken(ctx, first.TokenHash, second))
+
+		stored, err := repo.GetRefreshToken(ctx, first.TokenHash)
+		mustNoError(t, err)
+		if stored.ReplacedBy != second.TokenHash || stored.RotatedAt == nil {
+			t.Fatalf("old token not marked as rotated: %+v", stored)
+		}
+
+		// Un token ya rotado no puede volver a rotarse
+		mustBe(t, repo.RotateRefreshToken(ctx, first.TokenHash, newToken(familyID)), validations.ErrConditionFailed)
+		mustBe(t, repo.RotateRefreshToken(ctx, uuid.New().String(), newToken(familyID)), validations.ErrConditionFailed)
+	})
+
+	t.Run("RevokeFamily", func(t *testing.T) {
+		repo := newRepo(t)
+		familyID := uuid.New().String()
+		first := newToken(familyID)
+		mustNoError(t, repo.CreateRefreshToken(ctx, first))
+		second := newToken(familyID)
+		mustNoError(t, repo.RotateRefreshToken(ctx, first.TokenHash, second))
+
+		other := newToken(uuid.New().String())
+		mustNoError(t, repo.CreateRefreshToken(ctx, other))
+
+		mustNoError(t, repo.RevokeFamily(ctx, familyID))
+
+		for _, hash := range []string{first.TokenHash, second.TokenHash} {
+			stored, err := repo.GetRefreshToken(ctx, hash)
+			mustNoError(t, err)
+			if !stored.Revoked {
+				t.Fatalf("token %s of the family not revoked", hash)
+			}
+		}
+
+		stored, err := repo.GetRefreshToken(ctx, other.TokenHash)
+		mustNoError(t, err)
+		if stored.Revoked {
+			t.Fatal("token of another family was revoked")
+		}
+
+		// Un token revocado no puede rotarse
+		mustBe(t, repo.RotateRefreshToken(ctx, second.TokenHash, newToken(familyID)), validations.ErrConditionFailed)
+	})
+}
+
+// TestRevokedTokenRepository verifica el contrato de repositories.RevokedTokenRepository.
+func TestRevokedTokenRepository(t *testing.T, newRepo func(t *testing.T) repositories.RevokedTokenRepository) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	active := models.NewRevokedToken(uuid.New().String(), "user", time.Now().Add(time.Hour))
+	expired := models.NewRevokedToken(uuid.New().String(), "user", time.Now().Add(-time.Minute))
+	mustNoError(t, repo.RevokeToken(ctx, active))
+	mustNoError(t, repo.RevokeToken(ctx, expired))
+
+	cases := map[string]bool{
+		active.JTI:          true,
+		expired.JTI:         false, // el TTL puede no haberlo eliminado todavía
+		uuid.New().String(): false,
+	}
+	for jti, want := range cases {
+		revoked, err := repo.IsTokenRevoked(ctx, jti)
+		mustNoError(t, err)
+		if revoked != want {
+			t.Fatalf("IsTokenRevoked(%s) = %v, want %v", jti, revoked, want)
+		}
+	}
+
+	// Un token de un solo uso se consume una vez; liberado, puede volver a consumirse
+	challenge := models.NewRevokedToken(uuid.New().String(), "user", time.Now().Add(time.Hour))
+	mustNoError(t, repo.ConsumeToken(ctx, challenge))
+	mustBe(t, repo.ConsumeToken(ctx, challenge), validations.ErrConditionFailed)
+	mustBe(t, repo.ConsumeToken(ctx, active), validations.ErrConditionFailed)
+
+	mustNoError(t, repo.ReleaseToken(ctx, challenge.JTI))
+	if revoked, _ := repo.IsTokenRevoked(ctx, challenge.JTI); revoked {
+		t.Fatal("released token is still revoked")
+	}
+	mustNoError(t, repo.ConsumeToken(ctx, challenge))
+}
+
+// TestPasswordResetRepository verifica el contrato de repositories.PasswordResetRepository.
+func TestPasswordResetRepository(t *testing.T, newRepo func(t *testing.T) repositories.PasswordResetRepository) {
+	ctx := context.Background()
+	repo := newRepo(t)
+
+	reset := models.NewPasswordReset(uuid.New().String(), "user", time.Now().Add(time.Hour))
+	mustNoError(t, repo.CreatePasswordReset(ctx, reset))
+	mustBe(t, repo.CreatePasswordReset(ctx, reset), validations.ErrDocumentAlreadyExists)
+
+	stored, err := repo.GetPasswordReset(ctx, reset.TokenHash)
+	mustNoError(t, err)
+	if !stored.IsUsable() {
+		t.Fatalf("new reset is not usable: %+v", stored)
+	}
+
+	mustNoError(t, repo.MarkPasswordResetUsed(ctx, reset.TokenHash))
+	mustBe(t, repo.MarkPasswordResetUsed(ctx, reset.TokenHash), validations.ErrConditionFailed)
+	mustBe(t, repo.MarkPasswordResetUsed(ctx, uuid.New().String()), validations.ErrConditionFailed)
+
+	stored, err = repo.GetPasswordReset(ctx, reset.TokenHash)
+	mustNoError(t, err)
+	if stored.IsUsable() || stored.UsedAt == nil {
+		t.Fatalf("used reset is still usable: %+v", stored)
+	}
+
+	_, err = repo.GetPasswordReset(ctx, uuid.New().String())
+	mustBe(t, err, validations.ErrDocumentNotFound)
+}
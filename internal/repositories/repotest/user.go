@@ -0,0 +1,339 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/consts"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// NewTestUser crea un usuario con ID y email únicos, para no chocar con datos de otros tests.
+func NewTestUser() *models.User {
+	id := uuid.New().String()
+	return &models.User{
+		ID:           id,
+		PersonalInfo: models.PersonalInfo{Name: "Juan", LastName: "Pérez"},
+		ContactInfo:  models.ContactInfo{Email: models.EmailDetails{Address: "user-" + id + "@example.com"}},
+		Password:     "hash",
+		CreatedAt:    time.Now(),
+		Status:       models.USER_STATUS_ACTIVE,
+	}
+}
+
+// TestUserRepository verifica el contrato de repositories.UserRepository.
+func TestUserRepository(t *testing.T, newRepo func(t *testing.T) repositories.UserRepository) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		if user.Version != 1 {
+			t.Fatalf("version after create = %d, want 1", user.Version)
+		}
+
+		byID, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+		if byID.ContactInfo.Email.Address != user.ContactInfo.Email.Address || byID.Version != 1 {
+			t.Fatalf("GetUserByID = %+v", byID)
+		}
+
+		byEmail, err := repo.GetUserByEmail(ctx, "  "+strings.ToUpper(user.ContactInfo.Email.Address)+" ")
+		mustNoError(t, err)
+		if byEmail.ID != user.ID {
+			t.Fatalf("GetUserByEmail returned %s, want %s", byEmail.ID, user.ID)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		_, err := repo.GetUserByID(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		_, err = repo.GetUserByEmail(ctx, uuid.New().String()+"@example.com")
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		missing := uuid.New().String()
+		mustBe(t, repo.UpdateLastSession(ctx, missing, time.Now()), validations.ErrDocumentNotFound)
+		mustBe(t, repo.UpdatePassword(ctx, missing, "hash"), validations.ErrDocumentNotFound)
+		mustBe(t, repo.UpdateStatus(ctx, missing, models.USER_STATUS_INACTIVE), validations.ErrDocumentNotFound)
+		mustBe(t, repo.UpdateRole(ctx, missing, consts.ROLE_ADMINISTRATIVE), validations.ErrDocumentNotFound)
+		mustBe(t, repo.UpdateActiveOrganization(ctx, missing, uuid.New().String()), validations.ErrDocumentNotFound)
+		mustBe(t, repo.IncrementTokenVersion(ctx, missing), validations.ErrDocumentNotFound)
+		mustBe(t, repo.UpdateEmail(ctx, missing, "x-"+missing+"@example.com"), validations.ErrDocumentNotFound)
+
+		name := "Ana"
+		_, err = repo.PatchProfile(ctx, missing, models.ProfilePatch{Name: &name}, 1)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		duplicate := NewTestUser()
+		duplicate.ContactInfo.Email.Address = strings.ToUpper(user.ContactInfo.Email.Address)
+		mustBe(t, repo.CreateUser(ctx, duplicate), validations.ErrDocumentAlreadyExists)
+
+		// El usuario que falló no debe haberse guardado
+		_, err := repo.GetUserByID(ctx, duplicate.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("DuplicateID", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		duplicate := NewTestUser()
+		duplicate.ID = user.ID
+		mustBe(t, repo.CreateUser(ctx, duplicate), validations.ErrDocumentAlreadyExists)
+
+		// El email del usuario que falló queda libre
+		other := NewTestUser()
+		other.ContactInfo.Email.Address = duplicate.ContactInfo.Email.Address
+		mustNoError(t, repo.CreateUser(ctx, other))
+	})
+
+	t.Run("ConcurrentCreateSameEmail", func(t *testing.T) {
+		repo := newRepo(t)
+		email := NewTestUser().ContactInfo.Email.Address
+
+		const attempts = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, attempts)
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				user := NewTestUser()
+				user.ContactInfo.Email.Address = email
+				errs <- repo.CreateUser(ctx, user)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, validations.ErrDocumentAlreadyExists):
+			default:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("%d users created with the same email, want 1", created)
+		}
+	})
+
+	t.Run("UpdateUserOptimisticLock", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		first, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+		second, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+
+		first.PersonalInfo.Name = "Primero"
+		mustNoError(t, repo.UpdateUser(ctx, first.ID, first))
+		if first.Version != 2 {
+			t.Fatalf("version after update = %d, want 2", first.Version)
+		}
+
+		second.PersonalInfo.Name = "Segundo"
+		mustBe(t, repo.UpdateUser(ctx, second.ID, second), validations.ErrVersionConflict)
+
+		stored, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+		if stored.PersonalInfo.Name != "Primero" {
+			t.Fatalf("name = %q, want the first update to win", stored.PersonalInfo.Name)
+		}
+	})
+
+	t.Run("FieldUpdates", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		stale, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+
+		lastSession := time.Now().Add(-time.Minute).UTC().Truncate(time.Millisecond)
+		mustNoError(t, repo.UpdateLastSession(ctx, user.ID, lastSession))
+		mustNoError(t, repo.UpdatePassword(ctx, user.ID, "new-hash"))
+		mustNoError(t, repo.UpdateStatus(ctx, user.ID, models.USER_STATUS_INACTIVE))
+		mustNoError(t, repo.UpdateRole(ctx, user.ID, consts.ROLE_ADMINISTRATIVE))
+		organizationID := uuid.New().String()
+		mustNoError(t, repo.UpdateActiveOrganization(ctx, user.ID, organizationID))
+		mustNoError(t, repo.IncrementTokenVersion(ctx, user.ID))
+
+		stored, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+		if !stored.LastSession.Equal(lastSession) || stored.Password != "new-hash" ||
+			stored.Status != models.USER_STATUS_INACTIVE || stored.Role != consts.ROLE_ADMINISTRATIVE || stored.ActiveOrganizationID != organizationID || stored.TokenVersion != 1 {
+			t.Fatalf("unexpected user after field updates: %+v", stored)
+		}
+		if stored.Version != 7 {
+			t.Fatalf("version = %d, want 7 (each write increments it)", stored.Version)
+		}
+
+		// Un UpdateUser con datos leídos antes no debe pisar los cambios
+		stale.PersonalInfo.Name = "Stale"
+		mustBe(t, repo.UpdateUser(ctx, stale.ID, stale), validations.ErrVersionConflict)
+	})
+
+	t.Run("EmailVerificationFields", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		stale, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+
+		sentAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Millisecond)
+		mustNoError(t, repo.MarkVerificationSent(ctx, user.ID, sentAt))
+
+		// Solo se verifica el email que recibió el link
+		verifiedAt := time.Now().UTC().Truncate(time.Millisecond)
+		mustBe(t, repo.MarkEmailVerified(ctx, user.ID, "otro@example.com", verifiedAt), validations.ErrConditionFailed)
+		mustBe(t, repo.MarkEmailVerified(ctx, uuid.New().String(), user.ContactInfo.Email.Address, verifiedAt), validations.ErrConditionFailed)
+		mustNoError(t, repo.MarkEmailVerified(ctx, user.ID, user.ContactInfo.Email.Address, verifiedAt))
+
+		stored, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+		email := stored.ContactInfo.Email
+		if !email.IsSentForVerify || !email.SentAt.Equal(sentAt) || !email.IsVerified || !email.VerifiedAt.Equal(verifiedAt) {
+			t.Fatalf("unexpected email details: %+v", email)
+		}
+		if stored.PersonalInfo.Name != "Juan" || stored.Version != 3 {
+			t.Fatalf("unexpected user after marking the email: %+v", stored)
+		}
+
+		stale.PersonalInfo.Name = "Stale"
+		mustBe(t, repo.UpdateUser(ctx, stale.ID, stale), validations.ErrVersionConflict)
+	})
+
+	t.Run("PatchProfile", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		name := "Ana"
+		birthDate := time.Date(1990, 5, 17, 0, 0, 0, 0, time.UTC)
+		patched, err := repo.PatchProfile(ctx, user.ID, models.ProfilePatch{Name: &name, BirthDate: &birthDate}, 1)
+		mustNoError(t, err)
+
+		if patched.PersonalInfo.Name != "Ana" || patched.PersonalInfo.LastName != "Pérez" {
+			t.Fatalf("personal info = %+v", patched.PersonalInfo)
+		}
+		if patched.PersonalInfo.BirthDate == nil || !patched.PersonalInfo.BirthDate.Equal(birthDate) {
+			t.Fatalf("birth date = %v", patched.PersonalInfo.BirthDate)
+		}
+		if patched.Version != 2 {
+			t.Fatalf("version = %d, want 2", patched.Version)
+		}
+
+		other := "Otro"
+		_, err = repo.PatchProfile(ctx, user.ID, models.ProfilePatch{Name: &other}, 1)
+		mustBe(t, err, validations.ErrVersionConflict)
+	})
+
+	t.Run("UpdateEmail", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		user.ContactInfo.Email.IsVerified = true
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		taken := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, taken))
+
+		oldEmail := user.ContactInfo.Email.Address
+		newEmail := "new-" + user.ID + "@example.com"
+
+		mustBe(t, repo.UpdateEmail(ctx, user.ID, taken.ContactInfo.Email.Address), validations.ErrDocumentAlreadyExists)
+		mustNoError(t, repo.UpdateEmail(ctx, user.ID, strings.ToUpper(newEmail)))
+
+		stored, err := repo.GetUserByEmail(ctx, newEmail)
+		mustNoError(t, err)
+		if stored.ID != user.ID || stored.ContactInfo.Email.Address != newEmail || stored.ContactInfo.Email.IsVerified {
+			t.Fatalf("unexpected user after email change: %+v", stored.ContactInfo.Email)
+		}
+
+		_, err = repo.GetUserByEmail(ctx, oldEmail)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		// El email anterior queda libre y el nuevo reservado
+		reuse := NewTestUser()
+		reuse.ContactInfo.Email.Address = oldEmail
+		mustNoError(t, repo.CreateUser(ctx, reuse))
+
+		clash := NewTestUser()
+		clash.ContactInfo.Email.Address = newEmail
+		mustBe(t, repo.CreateUser(ctx, clash), validations.ErrDocumentAlreadyExists)
+	})
+
+	t.Run("MFA", func(t *testing.T) {
+		repo := newRepo(t)
+		user := NewTestUser()
+		mustNoError(t, repo.CreateUser(ctx, user))
+
+		enabledAt := time.Now().Truncate(time.Millisecond)
+		mustNoError(t, repo.UpdateMFA(ctx, user.ID, models.MFAInfo{
+			TOTPEnabled:   true,
+			TOTPSecret:    "encrypted",
+			TOTPLastStep:  10,
+			RecoveryCodes: []string{"hash-a", "hash-b"},
+			EnabledAt:     &enabledAt,
+		}))
+		mustBe(t, repo.UpdateMFA(ctx, "missing-"+user.ID, models.MFAInfo{}), validations.ErrDocumentNotFound)
+
+		// Un paso de tiempo solo puede usarse una vez, y nunca uno anterior
+		mustBe(t, repo.ConsumeTOTPStep(ctx, user.ID, 10), validations.ErrConditionFailed)
+		mustNoError(t, repo.ConsumeTOTPStep(ctx, user.ID, 11))
+		mustBe(t, repo.ConsumeTOTPStep(ctx, user.ID, 11), validations.ErrConditionFailed)
+		mustBe(t, repo.ConsumeTOTPStep(ctx, user.ID, 9), validations.ErrConditionFailed)
+
+		// Cada código de recuperación sirve una sola vez
+		mustNoError(t, repo.ConsumeRecoveryCode(ctx, user.ID, "hash-a"))
+		mustBe(t, repo.ConsumeRecoveryCode(ctx, user.ID, "hash-a"), validations.ErrConditionFailed)
+		mustBe(t, repo.ConsumeRecoveryCode(ctx, user.ID, "hash-unknown"), validations.ErrConditionFailed)
+
+		stored, err := repo.GetUserByID(ctx, user.ID)
+		mustNoError(t, err)
+		mfa := stored.MFA
+		if !stored.IsMFAEnabled() || mfa.TOTPSecret != "encrypted" || mfa.TOTPLastStep != 11 ||
+			len(mfa.RecoveryCodes) != 1 || mfa.RecoveryCodes[0] != "hash-b" || mfa.EnabledAt == nil || !mfa.EnabledAt.Equal(enabledAt) {
+			t.Fatalf("unexpected MFA: %+v", mfa)
+		}
+	})
+}
+
+func mustNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func mustBe(t *testing.T, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Fatalf("error = %v, want %v", err, target)
+	}
+}
@@ -0,0 +1,123 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// TestWebAuthnCredentialRepository verifica el contrato de repositories.WebAuthnCredentialRepository.
+func TestWebAuthnCredentialRepository(t *testing.T, newRepo func(t *testing.T) repositories.WebAuthnCredentialRepository) {
+	ctx := context.Background()
+	newCredential := func(userID string, createdAt time.Time) *models.WebAuthnCredential {
+		return &models.WebAuthnCredential{
+			ID:              uuid.New().String(),
+			UserID:          userID,
+			Name:            "Passkey",
+			PublicKey:       []byte{0xa5, 0x01, 0x02},
+			AttestationType: "none",
+			Transports:      []string{"internal", "hybrid"},
+			BackupEligible:  true,
+			CreatedAt:       createdAt.UTC().Truncate(time.Millisecond),
+		}
+	}
+
+	t.Run("CreateGetAndList", func(t *testing.T) {
+		repo := newRepo(t)
+		userID := uuid.New().String()
+
+		older := newCredential(userID, time.Now().Add(-time.Hour))
+		newer := newCredential(userID, time.Now())
+		foreign := newCredential(uuid.New().String(), time.Now())
+		for _, c := range []*models.WebAuthnCredential{newer, older, foreign} {
+			mustNoError(t, repo.CreateCredential(ctx, c))
+		}
+		mustBe(t, repo.CreateCredential(ctx, older), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.GetCredential(ctx, older.ID)
+		mustNoError(t, err)
+		if stored.UserID != userID || string(stored.PublicKey) != string(older.PublicKey) || len(stored.Transports) != 2 || !stored.BackupEligible {
+			t.Fatalf("unexpected credential: %+v", stored)
+		}
+
+		_, err = repo.GetCredential(ctx, uuid.New().String())
+		mustBe(t, err, validations.ErrDocumentNotFound)
+
+		credentials, err := repo.ListCredentialsByUser(ctx, userID)
+		mustNoError(t, err)
+		if len(credentials) != 2 || credentials[0].ID != older.ID || credentials[1].ID != newer.ID {
+			t.Fatalf("credentials = %+v, want [older, newer] without foreign credentials", credentials)
+		}
+	})
+
+	t.Run("UpdateUsageRequiresIncreasingCounter", func(t *testing.T) {
+		repo := newRepo(t)
+		credential := newCredential(uuid.New().String(), time.Now())
+		mustNoError(t, repo.CreateCredential(ctx, credential))
+
+		mustNoError(t, repo.UpdateCredentialUsage(ctx, credential.ID, 5, true, time.Now()))
+
+		// Un contador igual o menor indica una credencial clonada (o un login repetido)
+		mustBe(t, repo.UpdateCredentialUsage(ctx, credential.ID, 5, true, time.Now()), validations.ErrConditionFailed)
+		mustBe(t, repo.UpdateCredentialUsage(ctx, credential.ID, 3, true, time.Now()), validations.ErrConditionFailed)
+		mustBe(t, repo.UpdateCredentialUsage(ctx, uuid.New().String(), 1, false, time.Now()), validations.ErrConditionFailed)
+
+		stored, err := repo.GetCredential(ctx, credential.ID)
+		mustNoError(t, err)
+		if stored.SignCount != 5 || !stored.BackupState || stored.LastUsedAt.IsZero() {
+			t.Fatalf("usage not recorded: %+v", stored)
+		}
+
+		// Los autenticadores sin contador siempre informan cero
+		mustNoError(t, repo.UpdateCredentialUsage(ctx, credential.ID, 0, true, time.Now()))
+	})
+
+	t.Run("DeleteOnlyOwnCredential", func(t *testing.T) {
+		repo := newRepo(t)
+		credential := newCredential(uuid.New().String(), time.Now())
+		mustNoError(t, repo.CreateCredential(ctx, credential))
+
+		mustBe(t, repo.DeleteCredential(ctx, uuid.New().String(), credential.ID), validations.ErrDocumentNotFound)
+		mustNoError(t, repo.DeleteCredential(ctx, credential.UserID, credential.ID))
+		mustBe(t, repo.DeleteCredential(ctx, credential.UserID, credential.ID), validations.ErrDocumentNotFound)
+
+		_, err := repo.GetCredential(ctx, credential.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+}
+
+// TestWebAuthnChallengeRepository verifica el contrato de repositories.WebAuthnChallengeRepository.
+func TestWebAuthnChallengeRepository(t *testing.T, newRepo func(t *testing.T) repositories.WebAuthnChallengeRepository) {
+	ctx := context.Background()
+
+	t.Run("ConsumeOnce", func(t *testing.T) {
+		repo := newRepo(t)
+		challenge := models.NewWebAuthnChallenge(uuid.New().String(), uuid.New().String(), models.WEBAUTHN_CEREMONY_REGISTRATION, `{"challenge":"abc"}`, time.Now().Add(time.Minute))
+		mustNoError(t, repo.CreateChallenge(ctx, challenge))
+		mustBe(t, repo.CreateChallenge(ctx, challenge), validations.ErrDocumentAlreadyExists)
+
+		stored, err := repo.ConsumeChallenge(ctx, challenge.ID)
+		mustNoError(t, err)
+		if stored.UserID != challenge.UserID || stored.Ceremony != challenge.Ceremony || stored.SessionData != challenge.SessionData {
+			t.Fatalf("unexpected challenge: %+v", stored)
+		}
+
+		_, err = repo.ConsumeChallenge(ctx, challenge.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+
+	t.Run("ExpiredChallenge", func(t *testing.T) {
+		repo := newRepo(t)
+		challenge := models.NewWebAuthnChallenge(uuid.New().String(), "", models.WEBAUTHN_CEREMONY_LOGIN, "{}", time.Now().Add(-time.Second))
+		mustNoError(t, repo.CreateChallenge(ctx, challenge))
+
+		_, err := repo.ConsumeChallenge(ctx, challenge.ID)
+		mustBe(t, err, validations.ErrDocumentNotFound)
+	})
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getRevokedTokensTableName retorna el nombre de la tabla de la denylist desde variables de entorno
+func getRevokedTokensTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_REVOKED_TOKENS")
+	if tableName == "" {
+		return "revoked_tokens" // nombre por defecto
+	}
+	return tableName
+}
+
+// RevokedTokenRepository define los métodos para la denylist de access tokens en DynamoDB.
+type RevokedTokenRepository interface {
+	RevokeToken(ctx context.Context, token *models.RevokedToken) error
+	ConsumeToken(ctx context.Context, token *models.RevokedToken) error
+	ReleaseToken(ctx context.Context, jti string) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// revokedTokenRepository implementa la interfaz RevokedTokenRepository usando DynamoDB.
+type revokedTokenRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewRevokedTokenRepository crea una nueva instancia de revokedTokenRepository.
+func NewRevokedTokenRepository(client *dynamodb.Client) RevokedTokenRepository {
+	return &revokedTokenRepository{
+		dynamoClient: client,
+	}
+}
+
+// RevokeToken agrega un jti a la denylist
+func (r *revokedTokenRepository) RevokeToken(ctx context.Context, token *models.RevokedToken) error {
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(getRevokedTokensTableName()),
+		Item:      item,
+	})
+
+	return err
+}
+
+// ConsumeToken agrega un jti a la denylist solo si no estaba. Si ya estaba retorna
+// validations.ErrConditionFailed: de dos usos simultáneos de un token de un solo uso, solo uno lo consume.
+func (r *revokedTokenRepository) ConsumeToken(ctx context.Context, token *models.RevokedToken) error {
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getRevokedTokensTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(jti)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// ReleaseToken quita un jti de la denylist, para que un token consumido con ConsumeToken pueda
+// volver a usarse (por ejemplo, si el uso falló por un error del usuario).
+func (r *revokedTokenRepository) ReleaseToken(ctx context.Context, jti string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getRevokedTokensTableName()),
+		Key: map[string]types.AttributeValue{
+			"jti": &types.AttributeValueMemberS{Value: jti},
+		},
+	})
+
+	return err
+}
+
+// IsTokenRevoked indica si un jti está en la denylist.
+// El TTL de DynamoDB no elimina los items de inmediato, por eso también se compara la expiración.
+func (r *revokedTokenRepository) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getRevokedTokensTableName()),
+		Key: map[string]types.AttributeValue{
+			"jti": &types.AttributeValueMemberS{Value: jti},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if result.Item == nil {
+		return false, nil
+	}
+
+	var token models.RevokedToken
+	if err := attributevalue.UnmarshalMap(result.Item, &token); err != nil {
+		return false, err
+	}
+
+	return time.Now().Before(token.ExpiresAt), nil
+}
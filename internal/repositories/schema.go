@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"myproject/internal/db"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// TableSchemas retorna la definición de todas las tablas usadas por los repositorios,
+// con los nombres configurados por variables de entorno. La usa db.EnsureTables para aprovisionarlas.
+func TableSchemas() []db.TableSchema {
+	return []db.TableSchema{
+		{
+			Name:         getUsersTableName(),
+			PartitionKey: "user_id",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: usersEmailIndex, PartitionKey: "email_normalized"},
+			},
+		},
+		{
+			Name:         getRefreshTokensTableName(),
+			PartitionKey: "token_hash",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: refreshTokensFamilyIndex, PartitionKey: "family_id"},
+			},
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getRevokedTokensTableName(),
+			PartitionKey: "jti",
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getSessionsTableName(),
+			PartitionKey: "session_id",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: sessionsUserIndex, PartitionKey: "user_id"},
+			},
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getPasswordResetsTableName(),
+			PartitionKey: "token_hash",
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getLoginAttemptsTableName(),
+			PartitionKey: "attempt_key",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: loginAttemptsEmailIndex, PartitionKey: "email"},
+			},
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getWebAuthnCredentialsTableName(),
+			PartitionKey: "credential_id",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: webAuthnCredentialsUserIndex, PartitionKey: "user_id"},
+			},
+		},
+		{
+			Name:         getWebAuthnChallengesTableName(),
+			PartitionKey: "challenge_id",
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getEmailLoginsTableName(),
+			PartitionKey: "login_key",
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getOAuthClientsTableName(),
+			PartitionKey: "client_id",
+		},
+		{
+			Name:         getAuthorizationCodesTableName(),
+			PartitionKey: "code_hash",
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getIdentitiesTableName(),
+			PartitionKey: "identity_id",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: identitiesUserIndex, PartitionKey: "user_id"},
+			},
+		},
+		{
+			Name:         getFederatedStatesTableName(),
+			PartitionKey: "state_hash",
+			TTLAttribute: "ttl",
+		},
+		{
+			Name:         getOrganizationsTableName(),
+			PartitionKey: "organization_id",
+		},
+		{
+			Name:         getMembershipsTableName(),
+			PartitionKey: "membership_id",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: membershipsUserIndex, PartitionKey: "user_id"},
+			},
+		},
+		{
+			Name:         getInvitationsTableName(),
+			PartitionKey: "invitation_id",
+			TTLAttribute: "ttl",
+			GlobalIndexes: []db.GlobalIndex{
+				{Name: invitationsOrganizationIndex, PartitionKey: "organization_id"},
+			},
+		},
+	}
+}
+
+// Provision crea las tablas e índices faltantes y prepara los datos existentes para ellos.
+// Es idempotente.
+func Provision(ctx context.Context, client *dynamodb.Client) error {
+	if err := db.EnsureTables(ctx, client, TableSchemas()); err != nil {
+		return err
+	}
+
+	updated, err := BackfillUserEmails(ctx, client)
+	if err != nil {
+		return err
+	}
+	if updated > 0 {
+		log.Printf("Backfilled email index for %d user(s)", updated)
+	}
+
+	return nil
+}
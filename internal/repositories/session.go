@@ -0,0 +1,185 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// sessionsUserIndex es el GSI (partition key: user_id) usado para listar las sesiones de un usuario.
+const sessionsUserIndex = "user_id-index"
+
+// getSessionsTableName retorna el nombre de la tabla de sesiones desde variables de entorno
+func getSessionsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_SESSIONS")
+	if tableName == "" {
+		return "sessions" // nombre por defecto
+	}
+	return tableName
+}
+
+// SessionRepository define los métodos para interactuar con las sesiones activas en DynamoDB.
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionByID(ctx context.Context, id string) (*models.Session, error)
+	ListSessionsByUser(ctx context.Context, userID string) ([]models.Session, error)
+	TouchSession(ctx context.Context, id, ip string, lastUsedAt, expiresAt time.Time) error
+	DeleteSession(ctx context.Context, id string) error
+	DeleteSessionsByUser(ctx context.Context, userID string) error
+}
+
+// sessionRepository implementa la interfaz SessionRepository usando DynamoDB.
+type sessionRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewSessionRepository crea una nueva instancia de sessionRepository.
+func NewSessionRepository(client *dynamodb.Client) SessionRepository {
+	return &sessionRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateSession guarda una nueva sesión
+func (r *sessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	item, err := attributevalue.MarshalMap(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getSessionsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(session_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetSessionByID obtiene una sesión por su ID
+func (r *sessionRepository) GetSessionByID(ctx context.Context, id string) (*models.Session, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getSessionsTableName()),
+		Key: map[string]types.AttributeValue{
+			"session_id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var session models.Session
+	if err := attributevalue.UnmarshalMap(result.Item, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ListSessionsByUser lista las sesiones vigentes de un usuario, de la más reciente a la más antigua
+func (r *sessionRepository) ListSessionsByUser(ctx context.Context, userID string) ([]models.Session, error) {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:              aws.String(getSessionsTableName()),
+		IndexName:              aws.String(sessionsUserIndex),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+
+	sessions := []models.Session{}
+	now := time.Now()
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []models.Session
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+
+		// El TTL de DynamoDB puede tardar en eliminar los items vencidos
+		for _, session := range items {
+			if now.Before(session.ExpiresAt) {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+// TouchSession registra un nuevo uso de la sesión y extiende su expiración
+func (r *sessionRepository) TouchSession(ctx context.Context, id, ip string, lastUsedAt, expiresAt time.Time) error {
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":ip":           ip,
+		":last_used_at": lastUsedAt,
+		":expires_at":   expiresAt,
+		":ttl":          expiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getSessionsTableName()),
+		Key: map[string]types.AttributeValue{
+			"session_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String("SET ip = :ip, last_used_at = :last_used_at, expires_at = :expires_at, #ttl = :ttl"),
+		ConditionExpression:       aws.String("attribute_exists(session_id)"),
+		ExpressionAttributeNames:  map[string]string{"#ttl": "ttl"}, // "ttl" es palabra reservada
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
+
+// DeleteSession elimina una sesión
+func (r *sessionRepository) DeleteSession(ctx context.Context, id string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getSessionsTableName()),
+		Key: map[string]types.AttributeValue{
+			"session_id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+
+	return err
+}
+
+// DeleteSessionsByUser elimina todas las sesiones de un usuario
+func (r *sessionRepository) DeleteSessionsByUser(ctx context.Context, userID string) error {
+	sessions, err := r.ListSessionsByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := r.DeleteSession(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
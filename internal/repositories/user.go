@@ -2,9 +2,15 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"myproject/internal/models"
 	"myproject/pkg/validations"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -21,12 +27,49 @@ func getUsersTableName() string {
 	return tableName
 }
 
+// usersEmailIndex es el GSI (partition key: email_normalized) usado para buscar usuarios por email.
+// Los items de reserva de email no tienen ese atributo, por lo que no aparecen en el índice.
+const usersEmailIndex = "email_normalized-index"
+
+// emailReservationPrefix es el prefijo de la clave de los items que reservan un email en la tabla
+// de usuarios. Garantizan la unicidad del email: se escriben en la misma transacción que el usuario.
+const emailReservationPrefix = "EMAIL#"
+
+// emailBackfillMarkerKey es la clave del item que BackfillUserEmails escribe al terminar. Sin él,
+// puede haber usuarios sin reserva de email y CreateUser no detectaría un email duplicado.
+const emailBackfillMarkerKey = "MIGRATION#email_reservations"
+
+// emailReservation es el item que reserva un email para un usuario.
+type emailReservation struct {
+	Key       string    `dynamodbav:"user_id"` // EMAIL#<email normalizado>
+	OwnerID   string    `dynamodbav:"owner_id"`
+	CreatedAt time.Time `dynamodbav:"created_at"`
+}
+
+// emailReservationKey retorna la clave del item de reserva de un email
+func emailReservationKey(email string) string {
+	return emailReservationPrefix + validations.NormalizeEmail(email)
+}
+
 // UserRepository define los métodos para interactuar con el almacenamiento de usuarios en DynamoDB.
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByID(ctx context.Context, id string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	UpdateUser(ctx context.Context, id string, user *models.User) error
+	UpdateEmail(ctx context.Context, id, newEmail string) error
+	UpdateLastSession(ctx context.Context, id string, lastSession time.Time) error
+	UpdatePassword(ctx context.Context, id, hashedPassword string) error
+	UpdateStatus(ctx context.Context, id string, status int32) error
+	UpdateRole(ctx context.Context, id, role string) error
+	UpdateActiveOrganization(ctx context.Context, id, organizationID string) error
+	MarkVerificationSent(ctx context.Context, id string, sentAt time.Time) error
+	MarkEmailVerified(ctx context.Context, id, email string, verifiedAt time.Time) error
+	PatchProfile(ctx context.Context, id string, patch models.ProfilePatch, expectedVersion int64) (*models.User, error)
+	IncrementTokenVersion(ctx context.Context, id string) error
+	UpdateMFA(ctx context.Context, id string, mfa models.MFAInfo) error
+	ConsumeTOTPStep(ctx context.Context, id string, step int64) error
+	ConsumeRecoveryCode(ctx context.Context, id, codeHash string) error
 }
 
 // userRepository implementa la interfaz UserRepository usando DynamoDB.
@@ -41,19 +84,50 @@ func NewUserRepository(client *dynamodb.Client) UserRepository {
 	}
 }
 
-// CreateUser crea un nuevo usuario en DynamoDB
+// CreateUser crea un nuevo usuario en DynamoDB junto con la reserva de su email, en una
+// única transacción. Si el ID o el email ya existen retorna validations.ErrDocumentAlreadyExists.
 func (r *userRepository) CreateUser(ctx context.Context, user *models.User) error {
+	user.Version = 1
+	user.EmailNormalized = validations.NormalizeEmail(user.ContactInfo.Email.Address)
+
 	// Convertir el modelo a atributos de DynamoDB
 	item, err := attributevalue.MarshalMap(user)
 	if err != nil {
 		return err
 	}
 
-	// Realizar la operación PutItem
-	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(getUsersTableName()),
-		Item:      item,
+	reservation, err := attributevalue.MarshalMap(emailReservation{
+		Key:       emailReservationKey(user.ContactInfo.Email.Address),
+		OwnerID:   user.ID,
+		CreatedAt: time.Now(),
 	})
+	if err != nil {
+		return err
+	}
+
+	// Ambas escrituras fallan si el item ya existe: dos registros concurrentes con el
+	// mismo email no pueden completarse los dos
+	_, err = r.dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName:           aws.String(getUsersTableName()),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(user_id)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName:           aws.String(getUsersTableName()),
+					Item:                reservation,
+					ConditionExpression: aws.String("attribute_not_exists(user_id)"),
+				},
+			},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
 
 	return err
 }
@@ -84,21 +158,17 @@ func (r *userRepository) GetUserByID(ctx context.Context, id string) (*models.Us
 	return &user, nil
 }
 
-// GetUserByEmail obtiene un usuario por su email usando scan temporal
-// TODO: Implementar GSI para mejor performance en producción
+// GetUserByEmail obtiene un usuario por su email usando el índice email_normalized-index.
+// El índice es eventualmente consistente: un usuario recién creado puede tardar unos instantes en aparecer.
 func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	// Por ahora usamos Scan ya que no tenemos GSI configurado
-	// En producción debería usarse un GSI para mejor performance
-	result, err := r.dynamoClient.Scan(ctx, &dynamodb.ScanInput{
-		TableName:        aws.String(getUsersTableName()),
-		FilterExpression: aws.String("contact_info.#email.#address = :email"),
-		ExpressionAttributeNames: map[string]string{
-			"#email":   "email",
-			"#address": "address",
-		},
+	result, err := r.dynamoClient.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(getUsersTableName()),
+		IndexName:              aws.String(usersEmailIndex),
+		KeyConditionExpression: aws.String("email_normalized = :email"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":email": &types.AttributeValueMemberS{Value: email},
+			":email": &types.AttributeValueMemberS{Value: validations.NormalizeEmail(email)},
 		},
+		Limit: aws.Int32(1), // la reserva de email garantiza un único usuario por email
 	})
 
 	if err != nil {
@@ -118,19 +188,513 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 	return &user, nil
 }
 
-// UpdateUser actualiza un usuario existente
+// UpdateUser reemplaza el usuario completo si no fue modificado desde que se leyó (user.Version).
+// Si otra escritura lo modificó antes retorna validations.ErrVersionConflict.
+// Para cambios puntuales usar los métodos Update*/PatchProfile, y UpdateEmail para el email.
 func (r *userRepository) UpdateUser(ctx context.Context, id string, user *models.User) error {
+	expectedVersion := user.Version
+	condition, values := versionCondition(expectedVersion)
+
+	user.EmailNormalized = validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	user.Version = expectedVersion + 1
+
 	// Convertir el modelo a atributos de DynamoDB
 	item, err := attributevalue.MarshalMap(user)
 	if err != nil {
+		user.Version = expectedVersion
 		return err
 	}
 
-	// Realizar la operación PutItem (actualización completa)
+	// Realizar la operación PutItem (actualización completa) condicionada a la versión leída
 	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(getUsersTableName()),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeNames:  map[string]string{"#version": "version"},
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		user.Version = expectedVersion
+		if isConditionalCheckFailed(err) {
+			return validations.ErrVersionConflict
+		}
+		return err
+	}
+
+	return nil
+}
+
+// UpdateLastSession registra la fecha del último inicio de sesión
+func (r *userRepository) UpdateLastSession(ctx context.Context, id string, lastSession time.Time) error {
+	return r.updateFields(ctx, id, map[string]interface{}{"last_session": lastSession})
+}
+
+// UpdatePassword reemplaza el hash de la contraseña
+func (r *userRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) error {
+	return r.updateFields(ctx, id, map[string]interface{}{
+		"password":   hashedPassword,
+		"updated_at": time.Now(),
+	})
+}
+
+// UpdateStatus cambia el estado del usuario (activo, inactivo, baneado)
+func (r *userRepository) UpdateStatus(ctx context.Context, id string, status int32) error {
+	return r.updateFields(ctx, id, map[string]interface{}{
+		"status":     status,
+		"updated_at": time.Now(),
+	})
+}
+
+// UpdateRole cambia el rol global del usuario
+func (r *userRepository) UpdateRole(ctx context.Context, id, role string) error {
+	return r.updateFields(ctx, id, map[string]interface{}{
+		"role":       role,
+		"updated_at": time.Now(),
+	})
+}
+
+// UpdateActiveOrganization cambia la organización para la que se emiten los tokens del usuario
+func (r *userRepository) UpdateActiveOrganization(ctx context.Context, id, organizationID string) error {
+	return r.updateFields(ctx, id, map[string]interface{}{
+		"active_organization_id": organizationID,
+		"updated_at":             time.Now(),
+	})
+}
+
+// MarkVerificationSent registra el envío del link de activación del email
+func (r *userRepository) MarkVerificationSent(ctx context.Context, id string, sentAt time.Time) error {
+	return r.updateFields(ctx, id, map[string]interface{}{
+		"contact_info.email.is_sent_for_verify": true,
+		"contact_info.email.sent_at":            sentAt,
+	})
+}
+
+// MarkEmailVerified marca el email del usuario como verificado, solo si sigue siendo `email` (el que
+// se verificó). Si el usuario no existe o cambió de email retorna validations.ErrConditionFailed.
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id, email string, verifiedAt time.Time) error {
+	input, err := buildFieldsUpdate(id, map[string]interface{}{
+		"contact_info.email.is_verified": true,
+		"contact_info.email.verified_at": verifiedAt,
+	})
+	if err != nil {
+		return err
+	}
+	input.ConditionExpression = aws.String("#contactinfo.#email.#address = :email")
+	input.ExpressionAttributeNames["#address"] = "address"
+	input.ExpressionAttributeValues[":email"] = &types.AttributeValueMemberS{Value: email}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, input)
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// PatchProfile modifica solo los campos presentes en el patch si la versión del usuario es
+// expectedVersion, y retorna el usuario actualizado. Si la versión no coincide retorna
+// validations.ErrVersionConflict.
+func (r *userRepository) PatchProfile(ctx context.Context, id string, patch models.ProfilePatch, expectedVersion int64) (*models.User, error) {
+	fields := map[string]interface{}{"updated_at": time.Now()}
+	if patch.Name != nil {
+		fields["personal_info.name"] = *patch.Name
+	}
+	if patch.LastName != nil {
+		fields["personal_info.last_name"] = *patch.LastName
+	}
+	if patch.BirthDate != nil {
+		fields["personal_info.birth_date"] = *patch.BirthDate
+	}
+
+	input, err := buildFieldsUpdate(id, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	condition, values := versionCondition(expectedVersion)
+	input.ConditionExpression = aws.String(condition)
+	for k, v := range values {
+		input.ExpressionAttributeValues[k] = v
+	}
+	input.ReturnValues = types.ReturnValueAllNew
+
+	result, err := r.dynamoClient.UpdateItem(ctx, input)
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			if _, getErr := r.GetUserByID(ctx, id); errors.Is(getErr, validations.ErrDocumentNotFound) {
+				return nil, validations.ErrDocumentNotFound
+			}
+			return nil, validations.ErrVersionConflict
+		}
+		return nil, err
+	}
+
+	var user models.User
+	if err := attributevalue.UnmarshalMap(result.Attributes, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// updateFields actualiza los campos indicados (rutas de atributos) e incrementa la versión,
+// de modo que un UpdateUser posterior con datos leídos antes falle en lugar de pisar el cambio.
+func (r *userRepository) updateFields(ctx context.Context, id string, fields map[string]interface{}) error {
+	input, err := buildFieldsUpdate(id, fields)
+	if err != nil {
+		return err
+	}
+	input.ConditionExpression = aws.String("attribute_exists(user_id)")
+
+	_, err = r.dynamoClient.UpdateItem(ctx, input)
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
+
+// buildFieldsUpdate arma un UpdateItem "SET ... ADD version :one" para los campos indicados.
+// Cada segmento de la ruta se referencia con un alias porque varios nombres son palabras
+// reservadas de DynamoDB (name, status, password...).
+func buildFieldsUpdate(id string, fields map[string]interface{}) (*dynamodb.UpdateItemInput, error) {
+	paths := make([]string, 0, len(fields))
+	for path := range fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths) // expresiones deterministas
+
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{
+		":one": &types.AttributeValueMemberN{Value: "1"},
+	}
+	sets := make([]string, 0, len(paths))
+
+	for i, path := range paths {
+		segments := strings.Split(path, ".")
+		for j, segment := range segments {
+			alias := "#" + strings.ReplaceAll(segment, "_", "")
+			names[alias] = segment
+			segments[j] = alias
+		}
+
+		value, err := attributevalue.Marshal(fields[path])
+		if err != nil {
+			return nil, err
+		}
+		placeholder := fmt.Sprintf(":v%d", i)
+		values[placeholder] = value
+		sets = append(sets, strings.Join(segments, ".")+" = "+placeholder)
+	}
+
+	names["#version"] = "version"
+	return &dynamodb.UpdateItemInput{
+		TableName: aws.String(getUsersTableName()),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ") + " ADD #version :one"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}, nil
+}
+
+// versionCondition retorna la condición de bloqueo optimista para la versión esperada.
+// Los usuarios creados antes de existir el atributo version se consideran en la versión 0.
+// La condición usa el alias #version, que debe declararse en ExpressionAttributeNames.
+func versionCondition(expectedVersion int64) (string, map[string]types.AttributeValue) {
+	values := map[string]types.AttributeValue{
+		":expected_version": &types.AttributeValueMemberN{Value: strconv.FormatInt(expectedVersion, 10)},
+	}
+
+	if expectedVersion == 0 {
+		return "attribute_exists(user_id) AND (attribute_not_exists(#version) OR #version = :expected_version)", values
+	}
+	return "#version = :expected_version", values
+}
+
+// UpdateEmail cambia el email del usuario moviendo la reserva en una única transacción:
+// reserva el nuevo email, libera el anterior y actualiza el usuario (que vuelve a quedar sin verificar).
+// Retorna validations.ErrDocumentAlreadyExists si el nuevo email pertenece a otro usuario y
+// validations.ErrConditionFailed si el email del usuario cambió de forma concurrente.
+func (r *userRepository) UpdateEmail(ctx context.Context, id, newEmail string) error {
+	user, err := r.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	oldEmail := user.ContactInfo.Email.Address
+	newEmail = validations.NormalizeEmail(newEmail)
+	if oldEmail == newEmail {
+		return nil
+	}
+
+	reservation, err := attributevalue.MarshalMap(emailReservation{
+		Key:       emailReservationKey(newEmail),
+		OwnerID:   id,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":old_email": oldEmail,
+		":new_email": newEmail,
+		":details":   models.EmailDetails{Address: newEmail},
+		":owner":     id,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				// 0. Reservar el nuevo email
+				Put: &types.Put{
+					TableName:           aws.String(getUsersTableName()),
+					Item:                reservation,
+					ConditionExpression: aws.String("attribute_not_exists(user_id)"),
+				},
+			},
+			{
+				// 1. Actualizar el usuario solo si su email sigue siendo el leído
+				Update: &types.Update{
+					TableName: aws.String(getUsersTableName()),
+					Key: map[string]types.AttributeValue{
+						"user_id": &types.AttributeValueMemberS{Value: id},
+					},
+					UpdateExpression:    aws.String("SET contact_info.#email = :details, email_normalized = :new_email ADD #version :one"),
+					ConditionExpression: aws.String("contact_info.#email.#address = :old_email"),
+					ExpressionAttributeNames: map[string]string{
+						"#email":   "email",
+						"#address": "address",
+						"#version": "version",
+					},
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":details":   values[":details"],
+						":old_email": values[":old_email"],
+						":new_email": values[":new_email"],
+						":one":       &types.AttributeValueMemberN{Value: "1"},
+					},
+				},
+			},
+			{
+				// 2. Liberar el email anterior (los usuarios creados antes de las reservas no tienen item)
+				Delete: &types.Delete{
+					TableName: aws.String(getUsersTableName()),
+					Key: map[string]types.AttributeValue{
+						"user_id": &types.AttributeValueMemberS{Value: emailReservationKey(oldEmail)},
+					},
+					ConditionExpression: aws.String("attribute_not_exists(user_id) OR owner_id = :owner"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":owner": values[":owner"],
+					},
+				},
+			},
+		},
+	})
+	if isTransactionItemConditionFailed(err, 0) {
+		return validations.ErrDocumentAlreadyExists
+	}
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// IncrementTokenVersion incrementa atómicamente la versión de tokens del usuario
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id string) error {
+	_, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(getUsersTableName()),
-		Item:      item,
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:         aws.String("ADD token_version :one, #version :one"),
+		ConditionExpression:      aws.String("attribute_exists(user_id)"),
+		ExpressionAttributeNames: map[string]string{"#version": "version"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+		},
 	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
 
 	return err
 }
+
+// UpdateMFA reemplaza la configuración del segundo factor
+func (r *userRepository) UpdateMFA(ctx context.Context, id string, mfa models.MFAInfo) error {
+	return r.updateFields(ctx, id, map[string]interface{}{
+		"mfa":        mfa,
+		"updated_at": time.Now(),
+	})
+}
+
+// ConsumeTOTPStep registra el paso de tiempo de un código TOTP aceptado. Si ese paso (o uno
+// posterior) ya se usó retorna validations.ErrConditionFailed: cada código sirve una sola vez.
+func (r *userRepository) ConsumeTOTPStep(ctx context.Context, id string, step int64) error {
+	_, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getUsersTableName()),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:         aws.String("SET mfa.totp_last_step = :step ADD #version :one"),
+		ConditionExpression:      aws.String("attribute_exists(user_id) AND mfa.totp_last_step < :step"),
+		ExpressionAttributeNames: map[string]string{"#version": "version"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":step": &types.AttributeValueMemberN{Value: strconv.FormatInt(step, 10)},
+			":one":  &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// ConsumeRecoveryCode elimina un código de recuperación (por su hash). Si no existe o ya se
+// usó retorna validations.ErrConditionFailed.
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, id, codeHash string) error {
+	_, err := r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getUsersTableName()),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:         aws.String("DELETE mfa.recovery_codes :codes ADD #version :one"),
+		ConditionExpression:      aws.String("contains(mfa.recovery_codes, :code)"),
+		ExpressionAttributeNames: map[string]string{"#version": "version"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":codes": &types.AttributeValueMemberSS{Value: []string{codeHash}},
+			":code":  &types.AttributeValueMemberS{Value: codeHash},
+			":one":   &types.AttributeValueMemberN{Value: "1"},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// BackfillUserEmails prepara los usuarios creados antes del índice por email y de las reservas:
+// completa email_normalized (para que GetUserByEmail los encuentre) y crea la reserva de su email.
+// Es idempotente. Retorna la cantidad de usuarios actualizados, o un error con los IDs de los
+// usuarios cuyo email ya reservó otro; en ese caso no escribe la marca de completado.
+func BackfillUserEmails(ctx context.Context, client *dynamodb.Client) (int, error) {
+	paginator := dynamodb.NewScanPaginator(client, &dynamodb.ScanInput{
+		TableName:        aws.String(getUsersTableName()),
+		FilterExpression: aws.String("attribute_not_exists(email_normalized) AND attribute_exists(contact_info.#email.#address)"),
+		ExpressionAttributeNames: map[string]string{
+			"#email":   "email",
+			"#address": "address",
+		},
+	})
+
+	updated := 0
+	var conflicts []string // usuarios cuyo email ya está reservado por otro usuario
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return updated, err
+		}
+
+		var users []models.User
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &users); err != nil {
+			return updated, err
+		}
+
+		for _, user := range users {
+			email := validations.NormalizeEmail(user.ContactInfo.Email.Address)
+
+			// 1. Reservar el email. Si ya está reservado por otro usuario se informa y se sigue
+			reservation, err := attributevalue.MarshalMap(emailReservation{
+				Key:       emailReservationKey(email),
+				OwnerID:   user.ID,
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return updated, err
+			}
+
+			_, err = client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:           aws.String(getUsersTableName()),
+				Item:                reservation,
+				ConditionExpression: aws.String("attribute_not_exists(user_id) OR owner_id = :owner"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":owner": &types.AttributeValueMemberS{Value: user.ID},
+				},
+			})
+			if isConditionalCheckFailed(err) {
+				conflicts = append(conflicts, user.ID)
+				continue
+			}
+			if err != nil {
+				return updated, err
+			}
+
+			// 2. Completar la clave del índice
+			_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String(getUsersTableName()),
+				Key: map[string]types.AttributeValue{
+					"user_id": &types.AttributeValueMemberS{Value: user.ID},
+				},
+				UpdateExpression:    aws.String("SET email_normalized = :email"),
+				ConditionExpression: aws.String("attribute_exists(user_id)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":email": &types.AttributeValueMemberS{Value: email},
+				},
+			})
+			if isConditionalCheckFailed(err) {
+				continue
+			}
+			if err != nil {
+				return updated, err
+			}
+			updated++
+		}
+	}
+
+	// Con emails duplicados no se puede garantizar la unicidad: no se marca el backfill como
+	// completo hasta que se resuelvan y se vuelva a ejecutar
+	if len(conflicts) > 0 {
+		return updated, fmt.Errorf("%d usuario(s) con un email ya reservado por otro usuario, resolvé los duplicados y volvé a ejecutar: %s", len(conflicts), strings.Join(conflicts, ", "))
+	}
+
+	// Registrar que todos los usuarios tienen su reserva (ver CheckUserEmailsBackfilled)
+	_, err := client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(getUsersTableName()),
+		Item: map[string]types.AttributeValue{
+			"user_id":      &types.AttributeValueMemberS{Value: emailBackfillMarkerKey},
+			"completed_at": &types.AttributeValueMemberS{Value: time.Now().UTC().Format(time.RFC3339)},
+		},
+	})
+	if err != nil {
+		return updated, err
+	}
+
+	return updated, nil
+}
+
+// CheckUserEmailsBackfilled verifica que BackfillUserEmails se haya ejecutado sobre la tabla de
+// usuarios. La unicidad del email depende de las reservas, así que la API no inicia sin ellas.
+func CheckUserEmailsBackfilled(ctx context.Context, client *dynamodb.Client) error {
+	result, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getUsersTableName()),
+		Key: map[string]types.AttributeValue{
+			"user_id": &types.AttributeValueMemberS{Value: emailBackfillMarkerKey},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	if result.Item == nil {
+		return errors.New("faltan las reservas de email de los usuarios existentes: ejecutá go run cmd/provision/main.go (o iniciá con DYNAMODB_AUTO_PROVISION=true)")
+	}
+	return nil
+}
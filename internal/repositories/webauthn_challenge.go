@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// getWebAuthnChallengesTableName retorna el nombre de la tabla de ceremonias WebAuthn desde variables de entorno
+func getWebAuthnChallengesTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_WEBAUTHN_CHALLENGES")
+	if tableName == "" {
+		return "webauthn_challenges" // nombre por defecto
+	}
+	return tableName
+}
+
+// WebAuthnChallengeRepository define los métodos para guardar el estado de las ceremonias WebAuthn en curso.
+type WebAuthnChallengeRepository interface {
+	CreateChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error
+	ConsumeChallenge(ctx context.Context, id string) (*models.WebAuthnChallenge, error)
+}
+
+// webAuthnChallengeRepository implementa la interfaz WebAuthnChallengeRepository usando DynamoDB.
+type webAuthnChallengeRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewWebAuthnChallengeRepository crea una nueva instancia de webAuthnChallengeRepository.
+func NewWebAuthnChallengeRepository(client *dynamodb.Client) WebAuthnChallengeRepository {
+	return &webAuthnChallengeRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateChallenge guarda el estado de una nueva ceremonia
+func (r *webAuthnChallengeRepository) CreateChallenge(ctx context.Context, challenge *models.WebAuthnChallenge) error {
+	item, err := attributevalue.MarshalMap(challenge)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getWebAuthnChallengesTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(challenge_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// ConsumeChallenge elimina la ceremonia y la retorna, de modo que solo pueda completarse una vez
+// aun con peticiones concurrentes. Si no existe o ya venció retorna ErrDocumentNotFound.
+func (r *webAuthnChallengeRepository) ConsumeChallenge(ctx context.Context, id string) (*models.WebAuthnChallenge, error) {
+	result, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getWebAuthnChallengesTableName()),
+		Key: map[string]types.AttributeValue{
+			"challenge_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Attributes == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var challenge models.WebAuthnChallenge
+	if err := attributevalue.UnmarshalMap(result.Attributes, &challenge); err != nil {
+		return nil, err
+	}
+
+	// El TTL de DynamoDB no elimina los items de inmediato
+	if challenge.IsExpired() {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	return &challenge, nil
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// webAuthnCredentialsUserIndex es el GSI (partition key: user_id) usado para listar las passkeys de un usuario.
+const webAuthnCredentialsUserIndex = "user_id-index"
+
+// getWebAuthnCredentialsTableName retorna el nombre de la tabla de passkeys desde variables de entorno
+func getWebAuthnCredentialsTableName() string {
+	tableName := os.Getenv("DYNAMODB_TABLE_WEBAUTHN_CREDENTIALS")
+	if tableName == "" {
+		return "webauthn_credentials" // nombre por defecto
+	}
+	return tableName
+}
+
+// WebAuthnCredentialRepository define los métodos para persistir las passkeys de los usuarios.
+type WebAuthnCredentialRepository interface {
+	CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	GetCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error)
+	ListCredentialsByUser(ctx context.Context, userID string) ([]models.WebAuthnCredential, error)
+	UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error
+	DeleteCredential(ctx context.Context, userID, id string) error
+}
+
+// webAuthnCredentialRepository implementa la interfaz WebAuthnCredentialRepository usando DynamoDB.
+type webAuthnCredentialRepository struct {
+	dynamoClient *dynamodb.Client
+}
+
+// NewWebAuthnCredentialRepository crea una nueva instancia de webAuthnCredentialRepository.
+func NewWebAuthnCredentialRepository(client *dynamodb.Client) WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{
+		dynamoClient: client,
+	}
+}
+
+// CreateCredential guarda una nueva passkey. Un credential ID ya registrado retorna ErrDocumentAlreadyExists.
+func (r *webAuthnCredentialRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	item, err := attributevalue.MarshalMap(credential)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.dynamoClient.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(getWebAuthnCredentialsTableName()),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(credential_id)"),
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentAlreadyExists
+	}
+
+	return err
+}
+
+// GetCredential obtiene una passkey por su credential ID
+func (r *webAuthnCredentialRepository) GetCredential(ctx context.Context, id string) (*models.WebAuthnCredential, error) {
+	result, err := r.dynamoClient.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(getWebAuthnCredentialsTableName()),
+		Key: map[string]types.AttributeValue{
+			"credential_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Item == nil {
+		return nil, validations.ErrDocumentNotFound
+	}
+
+	var credential models.WebAuthnCredential
+	if err := attributevalue.UnmarshalMap(result.Item, &credential); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// ListCredentialsByUser lista las passkeys de un usuario, de la más antigua a la más reciente
+func (r *webAuthnCredentialRepository) ListCredentialsByUser(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	paginator := dynamodb.NewQueryPaginator(r.dynamoClient, &dynamodb.QueryInput{
+		TableName:              aws.String(getWebAuthnCredentialsTableName()),
+		IndexName:              aws.String(webAuthnCredentialsUserIndex),
+		KeyConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+
+	credentials := []models.WebAuthnCredential{}
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var items []models.WebAuthnCredential
+		if err := attributevalue.UnmarshalListOfMaps(page.Items, &items); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, items...)
+	}
+
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].CreatedAt.Before(credentials[j].CreatedAt) })
+	return credentials, nil
+}
+
+// UpdateCredentialUsage registra un login con la passkey. Si el autenticador informa un contador
+// de firmas (distinto de cero), solo se acepta si avanza respecto del guardado: de lo contrario,
+// o si la passkey no existe, retorna validations.ErrConditionFailed. Así dos logins concurrentes
+// no pueden aceptar el mismo contador.
+func (r *webAuthnCredentialRepository) UpdateCredentialUsage(ctx context.Context, id string, signCount uint32, backupState bool, usedAt time.Time) error {
+	values, err := attributevalue.MarshalMap(map[string]interface{}{
+		":sign_count":   signCount,
+		":backup_state": backupState,
+		":last_used_at": usedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	condition := "attribute_exists(credential_id)"
+	if signCount > 0 {
+		condition += " AND sign_count < :sign_count"
+	}
+
+	_, err = r.dynamoClient.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(getWebAuthnCredentialsTableName()),
+		Key: map[string]types.AttributeValue{
+			"credential_id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String("SET sign_count = :sign_count, backup_state = :backup_state, last_used_at = :last_used_at"),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrConditionFailed
+	}
+
+	return err
+}
+
+// DeleteCredential elimina una passkey del usuario. Si no existe o pertenece a otro usuario
+// retorna ErrDocumentNotFound.
+func (r *webAuthnCredentialRepository) DeleteCredential(ctx context.Context, userID, id string) error {
+	_, err := r.dynamoClient.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(getWebAuthnCredentialsTableName()),
+		Key: map[string]types.AttributeValue{
+			"credential_id": &types.AttributeValueMemberS{Value: id},
+		},
+		ConditionExpression: aws.String("user_id = :user_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":user_id": &types.AttributeValueMemberS{Value: userID},
+		},
+	})
+	if isConditionalCheckFailed(err) {
+		return validations.ErrDocumentNotFound
+	}
+
+	return err
+}
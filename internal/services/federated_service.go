@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/oidc"
+	"myproject/pkg/request"
+	security "myproject/pkg/session"
+	"myproject/pkg/validations"
+)
+
+// FEDERATED_STATE_DURATION es el tiempo que tiene el usuario para iniciar sesión en el proveedor y volver
+const FEDERATED_STATE_DURATION = 10 * time.Minute
+
+// providerIDPattern restringe los IDs de proveedor a valores seguros para rutas y variables de entorno
+var providerIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// FederationConfig es la configuración del login con proveedores de identidad externos.
+type FederationConfig struct {
+	Providers []oidc.ProviderConfig
+	// RedirectURL es la página del frontend que recibe al usuario al volver del proveedor; se le agrega /{provider}
+	RedirectURL string
+	// AutoRegister crea la cuenta cuando el email verificado por el proveedor no tiene usuario
+	AutoRegister bool
+}
+
+// LoadFederationConfig lee los proveedores desde variables de entorno:
+//   - OIDC_PROVIDERS: IDs de los proveedores separados por coma (por ejemplo google,corp).
+//   - OIDC_<ID>_ISSUER, OIDC_<ID>_CLIENT_ID, OIDC_<ID>_CLIENT_SECRET: datos del cliente registrado en el proveedor.
+//   - OIDC_<ID>_SCOPES (separados por espacio, por defecto "openid email profile") y OIDC_<ID>_NAME.
+//   - OIDC_REDIRECT_URL: por defecto APP_URL + /auth/callback.
+//   - OIDC_AUTO_REGISTER: "true" para crear la cuenta en el primer login.
+func LoadFederationConfig() FederationConfig {
+	config := FederationConfig{
+		RedirectURL:  os.Getenv("OIDC_REDIRECT_URL"),
+		AutoRegister: os.Getenv("OIDC_AUTO_REGISTER") == "true",
+	}
+	if config.RedirectURL == "" {
+		config.RedirectURL = getAppURL() + "/auth/callback"
+	}
+
+	for _, id := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+		config.Providers = append(config.Providers, oidc.ProviderConfig{
+			ID:           id,
+			Name:         os.Getenv(prefix + "NAME"),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		})
+	}
+
+	return config
+}
+
+// IdentityProvider es un proveedor externo configurado.
+type IdentityProvider struct {
+	ID   string
+	Name string
+}
+
+// FederatedAuthorization es la URL del proveedor a la que se redirige al usuario y el state
+// con el que vuelve al frontend. Binding ata el login al navegador que lo inició: se le entrega en
+// una cookie HttpOnly y se exige en FinishLogin, para que nadie complete en otro navegador un login
+// que inició él (login CSRF).
+type FederatedAuthorization struct {
+	AuthorizationURL string
+	State            string
+	Binding          string
+}
+
+// FederatedService encapsula el login con proveedores de identidad externos (OpenID Connect)
+// y las cuentas externas vinculadas a cada usuario.
+type FederatedService interface {
+	ListProviders() []IdentityProvider
+	BeginLogin(ctx context.Context, providerID string) (*FederatedAuthorization, error)
+	FinishLogin(ctx context.Context, providerID, state, binding, code string, client request.ClientInfo) (*LoginResult, error)
+	ListIdentities(ctx context.Context, userID string) ([]models.Identity, error)
+	UnlinkIdentity(ctx context.Context, userID, providerID string) error
+}
+
+type federatedService struct {
+	providers      map[string]*oidc.Provider
+	providerOrder  []string
+	redirectURL    string
+	autoRegister   bool
+	userRepo       repositories.UserRepository
+	identityRepo   repositories.IdentityRepository
+	stateRepo      repositories.FederatedStateRepository
+	sessionService SessionService
+}
+
+// NewFederatedService crea una nueva instancia de FederatedService. Retorna error si algún
+// proveedor está mal configurado (ver LoadFederationConfig).
+func NewFederatedService(
+	userRepo repositories.UserRepository,
+	identityRepo repositories.IdentityRepository,
+	stateRepo repositories.FederatedStateRepository,
+	sessionService SessionService,
+	config FederationConfig,
+) (FederatedService, error) {
+	service := &federatedService{
+		providers:      map[string]*oidc.Provider{},
+		redirectURL:    strings.TrimSuffix(config.RedirectURL, "/"),
+		autoRegister:   config.AutoRegister,
+		userRepo:       userRepo,
+		identityRepo:   identityRepo,
+		stateRepo:      stateRepo,
+		sessionService: sessionService,
+	}
+
+	for _, providerConfig := range config.Providers {
+		if !providerIDPattern.MatchString(providerConfig.ID) {
+			return nil, fmt.Errorf("invalid provider id %q", providerConfig.ID)
+		}
+		if _, exists := service.providers[providerConfig.ID]; exists {
+			return nil, fmt.Errorf("duplicate provider %q", providerConfig.ID)
+		}
+
+		provider, err := oidc.NewProvider(providerConfig, nil)
+		if err != nil {
+			return nil, fmt.Errorf("provider %q: %w", providerConfig.ID, err)
+		}
+		service.providers[providerConfig.ID] = provider
+		service.providerOrder = append(service.providerOrder, providerConfig.ID)
+	}
+
+	return service, nil
+}
+
+// ListProviders lista los proveedores configurados, en el orden de la configuración
+func (s *federatedService) ListProviders() []IdentityProvider {
+	providers := []IdentityProvider{}
+	for _, id := range s.providerOrder {
+		providers = append(providers, IdentityProvider{ID: id, Name: s.providers[id].Name()})
+	}
+	return providers
+}
+
+// BeginLogin inicia el login con el proveedor: guarda el state, el binding del navegador, el nonce
+// y el code_verifier de PKCE y retorna la URL de autorización del proveedor.
+func (s *federatedService) BeginLogin(ctx context.Context, providerID string) (*FederatedAuthorization, error) {
+	provider, ok := s.providers[providerID]
+	if !ok {
+		return nil, validations.ErrIdentityProviderNotFound
+	}
+
+	state, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256([]byte(codeVerifier))
+	codeChallenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	authURL, err := provider.AuthCodeURL(ctx, s.redirectURI(providerID), state, nonce, codeChallenge)
+	if err != nil {
+		log.Printf("BeginLogin: proveedor %s: %v", providerID, err)
+		return nil, validations.ErrIdentityProviderUnavailable
+	}
+
+	savedState := models.NewFederatedState(security.HashToken(state), security.HashToken(binding), providerID, nonce, codeVerifier, time.Now().Add(FEDERATED_STATE_DURATION))
+	if err := s.stateRepo.CreateState(ctx, savedState); err != nil {
+		return nil, err
+	}
+
+	return &FederatedAuthorization{AuthorizationURL: authURL, State: state, Binding: binding}, nil
+}
+
+// FinishLogin completa el login con el código con el que volvió el usuario: canjea el código,
+// verifica el ID token y continúa el login del usuario vinculado, igual que Login (incluido el
+// segundo factor). El state sirve una sola vez y solo en el navegador que inició el login, que
+// presenta el binding retornado por BeginLogin.
+func (s *federatedService) FinishLogin(ctx context.Context, providerID, state, binding, code string, client request.ClientInfo) (*LoginResult, error) {
+	provider, ok := s.providers[providerID]
+	if !ok {
+		return nil, validations.ErrIdentityProviderNotFound
+	}
+
+	// 1. Consumir el state: tiene que haberse emitido para este proveedor y este navegador, y no estar vencido
+	savedState, err := s.stateRepo.ConsumeState(ctx, security.HashToken(state))
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrFederatedStateInvalid
+		}
+		return nil, err
+	}
+	if savedState.Provider != providerID {
+		return nil, validations.ErrFederatedStateInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(security.HashToken(binding)), []byte(savedState.BindingHash)) != 1 {
+		return nil, validations.ErrFederatedStateInvalid
+	}
+
+	// 2. Canjear el código con el code_verifier del state
+	tokenResp, err := provider.Exchange(ctx, code, s.redirectURI(providerID), savedState.CodeVerifier)
+	if err != nil {
+		log.Printf("FinishLogin: proveedor %s: %v", providerID, err)
+		if errors.Is(err, oidc.ErrTokenExchange) || errors.Is(err, oidc.ErrMissingIDToken) {
+			return nil, validations.ErrFederatedLoginFailed
+		}
+		return nil, validations.ErrIdentityProviderUnavailable
+	}
+
+	// 3. Verificar firma, issuer, audiencia, vigencia y nonce del ID token
+	claims, err := provider.VerifyIDToken(ctx, tokenResp.IDToken, savedState.Nonce)
+	if err != nil {
+		log.Printf("FinishLogin: ID token de %s rechazado: %v", providerID, err)
+		return nil, validations.ErrFederatedLoginFailed
+	}
+
+	// 4. Buscar (o vincular) el usuario
+	user, err := s.findOrLinkUser(ctx, providerID, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. Abrir la sesión o pedir el segundo factor
+	return s.sessionService.CompleteLogin(ctx, user, client)
+}
+
+// ListIdentities lista las cuentas externas vinculadas al usuario
+func (s *federatedService) ListIdentities(ctx context.Context, userID string) ([]models.Identity, error) {
+	return s.identityRepo.ListIdentitiesByUser(ctx, userID)
+}
+
+// UnlinkIdentity desvincula las cuentas del proveedor del usuario. El usuario puede seguir
+// ingresando con su contraseña, passkeys o por email.
+func (s *federatedService) UnlinkIdentity(ctx context.Context, userID, providerID string) error {
+	identities, err := s.identityRepo.ListIdentitiesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	unlinked := false
+	for _, identity := range identities {
+		if identity.Provider != providerID {
+			continue
+		}
+		if err := s.identityRepo.DeleteIdentity(ctx, userID, identity.ID); err != nil && !errors.Is(err, validations.ErrDocumentNotFound) {
+			return err
+		}
+		unlinked = true
+	}
+
+	if !unlinked {
+		return validations.ErrIdentityNotFound
+	}
+	return nil
+}
+
+// findOrLinkUser retorna el usuario vinculado a la identidad. La primera vez la vincula al usuario
+// con el mismo email, solo si el proveedor lo informa como verificado y la cuenta local también
+// lo está: de lo contrario quien registró el email sin confirmarlo obtendría acceso a la cuenta.
+// Sin usuario, y con el auto-registro habilitado, crea la cuenta.
+func (s *federatedService) findOrLinkUser(ctx context.Context, providerID string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	key := models.IdentityKey(providerID, claims.Subject)
+	identity, err := s.identityRepo.GetIdentity(ctx, key)
+	if err == nil {
+		return s.getLinkedUser(ctx, identity)
+	}
+	if !errors.Is(err, validations.ErrDocumentNotFound) {
+		return nil, err
+	}
+
+	email := validations.NormalizeEmail(claims.Email)
+	if !claims.EmailVerified || !validations.IsValidEmail(email) {
+		return nil, validations.ErrFederatedEmailNotVerified
+	}
+
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if !user.IsUserVerified() {
+			return nil, validations.ErrEmailNotVerified
+		}
+	case errors.Is(err, validations.ErrDocumentNotFound) && s.autoRegister:
+		if user, err = s.registerUser(ctx, email, claims); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, validations.ErrDocumentNotFound):
+		return nil, validations.ErrFederatedAccountNotFound
+	default:
+		return nil, err
+	}
+
+	if err := s.identityRepo.CreateIdentity(ctx, models.NewIdentity(user.ID, providerID, claims.Subject, email)); err != nil {
+		if !errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return nil, err
+		}
+
+		// Otro callback vinculó la misma identidad al mismo tiempo
+		identity, err := s.identityRepo.GetIdentity(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return s.getLinkedUser(ctx, identity)
+	}
+
+	return user, nil
+}
+
+// getLinkedUser retorna el usuario de la identidad; si ya no existe, la identidad no sirve para ingresar
+func (s *federatedService) getLinkedUser(ctx context.Context, identity *models.Identity) (*models.User, error) {
+	user, err := s.userRepo.GetUserByID(ctx, identity.UserID)
+	if errors.Is(err, validations.ErrDocumentNotFound) {
+		return nil, validations.ErrFederatedAccountNotFound
+	}
+	return user, err
+}
+
+// registerUser crea la cuenta con los datos del ID token, sin contraseña: solo puede ingresar con
+// el proveedor o por email hasta que defina una con forgot-password.
+func (s *federatedService) registerUser(ctx context.Context, email string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	name := strings.TrimSpace(claims.GivenName)
+	if name == "" {
+		name = strings.TrimSpace(claims.Name)
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:           generateUserID(),
+		PersonalInfo: models.PersonalInfo{Name: name, LastName: strings.TrimSpace(claims.FamilyName)},
+		ContactInfo: models.ContactInfo{
+			Email: models.EmailDetails{
+				Address:    email,
+				IsVerified: true,
+				VerifiedAt: now,
+			},
+		},
+		CreatedAt: now,
+		Status:    models.USER_STATUS_ACTIVE,
+	}
+
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		// Otro login registró el mismo email al mismo tiempo
+		if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return s.userRepo.GetUserByEmail(ctx, email)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// redirectURI retorna la redirect_uri registrada en el proveedor
+func (s *federatedService) redirectURI(providerID string) string {
+	return s.redirectURL + "/" + providerID
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/memory"
+	"myproject/pkg/oidc"
+	"myproject/pkg/oidc/oidctest"
+	"myproject/pkg/validations"
+)
+
+const testFederatedRedirectURL = "https://app.example.com/auth/callback"
+
+type federatedTestEnv struct {
+	*testEnv
+	idp          *oidctest.Server
+	identityRepo repositories.IdentityRepository
+	service      FederatedService
+}
+
+func newFederatedTestEnv(t *testing.T, autoRegister bool) *federatedTestEnv {
+	t.Helper()
+	idp := oidctest.NewServer("login-api", "idp-secret")
+	t.Cleanup(idp.Close)
+
+	env := &federatedTestEnv{testEnv: newTestEnv(t), idp: idp, identityRepo: memory.NewIdentityRepository()}
+	service, err := NewFederatedService(env.userRepo, env.identityRepo, memory.NewFederatedStateRepository(), env.sessions, FederationConfig{
+		Providers: []oidc.ProviderConfig{{
+			ID:           "corp",
+			Name:         "Corporate SSO",
+			Issuer:       idp.Issuer(),
+			ClientID:     idp.ClientID,
+			ClientSecret: idp.ClientSecret,
+		}},
+		RedirectURL:  testFederatedRedirectURL,
+		AutoRegister: autoRegister,
+	})
+	if err != nil {
+		t.Fatalf("new federated service: %v", err)
+	}
+	env.service = service
+	return env
+}
+
+// signIn inicia el login, "aprueba" en el proveedor con la identidad y completa el callback
+func (env *federatedTestEnv) signIn(t *testing.T, identity oidctest.Identity) (*LoginResult, error) {
+	t.Helper()
+	ctx := context.Background()
+
+	authorization, err := env.service.BeginLogin(ctx, "corp")
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+
+	code, state, err := env.idp.Authorize(authorization.AuthorizationURL, identity)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if state != authorization.State {
+		t.Fatalf("state = %q, want %q", state, authorization.State)
+	}
+
+	return env.service.FinishLogin(ctx, "corp", state, authorization.Binding, code, oauthClientInfo)
+}
+
+var corpIdentity = oidctest.Identity{
+	Subject:       "00u1a2b3c4",
+	Email:         "Juan@Example.com",
+	EmailVerified: true,
+	GivenName:     "Juan",
+	FamilyName:    "Pérez",
+}
+
+func TestFederatedLoginAutoRegister(t *testing.T) {
+	env := newFederatedTestEnv(t, true)
+	ctx := context.Background()
+
+	authorization, err := env.service.BeginLogin(ctx, "corp")
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	authURL, _ := url.Parse(authorization.AuthorizationURL)
+	query := authURL.Query()
+	if !strings.HasPrefix(authorization.AuthorizationURL, env.idp.Issuer()+"/authorize?") ||
+		query.Get("redirect_uri") != testFederatedRedirectURL+"/corp" || query.Get("nonce") == "" || query.Get("code_challenge") == "" {
+		t.Fatalf("unexpected authorization url: %s", authorization.AuthorizationURL)
+	}
+
+	result, err := env.signIn(t, corpIdentity)
+	if err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+	if result.Tokens == nil {
+		t.Fatal("expected tokens")
+	}
+
+	user, err := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if err != nil {
+		t.Fatalf("user not registered: %v", err)
+	}
+	if !user.IsUserVerified() || user.Password != "" || user.PersonalInfo.Name != "Juan" || user.PersonalInfo.LastName != "Pérez" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// El segundo login usa la identidad vinculada aunque cambie el email en el proveedor
+	changed := corpIdentity
+	changed.Email = "otro@example.com"
+	result, err = env.signIn(t, changed)
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	claims, _ := env.sessions.ValidateAccessToken(ctx, result.Tokens.AccessToken)
+	if claims.Subject != user.ID {
+		t.Fatalf("logged in as %s, want %s", claims.Subject, user.ID)
+	}
+
+	identities, err := env.service.ListIdentities(ctx, user.ID)
+	if err != nil || len(identities) != 1 || identities[0].Provider != "corp" || identities[0].Subject != corpIdentity.Subject {
+		t.Fatalf("identities = %+v, err = %v", identities, err)
+	}
+}
+
+func TestFederatedLoginLinksVerifiedAccount(t *testing.T) {
+	env := newFederatedTestEnv(t, false)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	// Sin email verificado en la cuenta local no se vincula: quien la registró no probó ser el dueño
+	if _, err := env.signIn(t, corpIdentity); !errors.Is(err, validations.ErrEmailNotVerified) {
+		t.Fatalf("unverified local account: error = %v, want ErrEmailNotVerified", err)
+	}
+
+	user.ContactInfo.Email.IsVerified = true
+	if err := env.userRepo.UpdateUser(ctx, user.ID, user); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+
+	// Ni si el proveedor no verificó el email
+	unverified := corpIdentity
+	unverified.EmailVerified = false
+	if _, err := env.signIn(t, unverified); !errors.Is(err, validations.ErrFederatedEmailNotVerified) {
+		t.Fatalf("unverified provider email: error = %v, want ErrFederatedEmailNotVerified", err)
+	}
+
+	result, err := env.signIn(t, corpIdentity)
+	if err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+	claims, _ := env.sessions.ValidateAccessToken(ctx, result.Tokens.AccessToken)
+	if claims.Subject != user.ID {
+		t.Fatalf("logged in as %s, want %s", claims.Subject, user.ID)
+	}
+
+	// Sin auto-registro, un email desconocido no crea la cuenta
+	stranger := oidctest.Identity{Subject: "00u9z8y7", Email: "nadie@example.com", EmailVerified: true}
+	if _, err := env.signIn(t, stranger); !errors.Is(err, validations.ErrFederatedAccountNotFound) {
+		t.Fatalf("unknown email: error = %v, want ErrFederatedAccountNotFound", err)
+	}
+
+	// La identidad se desvincula una sola vez
+	if err := env.service.UnlinkIdentity(ctx, user.ID, "corp"); err != nil {
+		t.Fatalf("unlink: %v", err)
+	}
+	if err := env.service.UnlinkIdentity(ctx, user.ID, "corp"); !errors.Is(err, validations.ErrIdentityNotFound) {
+		t.Fatalf("second unlink: error = %v, want ErrIdentityNotFound", err)
+	}
+}
+
+func TestFederatedLoginRequiresMFA(t *testing.T) {
+	env := newFederatedTestEnv(t, true)
+	ctx := context.Background()
+
+	if _, err := env.signIn(t, corpIdentity); err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	user.MFA.TOTPEnabled = true
+	if err := env.userRepo.UpdateUser(ctx, user.ID, user); err != nil {
+		t.Fatalf("update user: %v", err)
+	}
+
+	result, err := env.signIn(t, corpIdentity)
+	if err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+	if result.Tokens != nil || result.ChallengeToken == "" {
+		t.Fatalf("expected an MFA challenge, got %+v", result)
+	}
+}
+
+func TestFederatedLoginStateIsSingleUse(t *testing.T) {
+	env := newFederatedTestEnv(t, true)
+	ctx := context.Background()
+
+	if _, err := env.service.BeginLogin(ctx, "otro"); !errors.Is(err, validations.ErrIdentityProviderNotFound) {
+		t.Fatalf("unknown provider: error = %v, want ErrIdentityProviderNotFound", err)
+	}
+
+	authorization, _ := env.service.BeginLogin(ctx, "corp")
+	code, state, _ := env.idp.Authorize(authorization.AuthorizationURL, corpIdentity)
+
+	if _, err := env.service.FinishLogin(ctx, "corp", "state-inventado", authorization.Binding, code, oauthClientInfo); !errors.Is(err, validations.ErrFederatedStateInvalid) {
+		t.Fatalf("unknown state: error = %v, want ErrFederatedStateInvalid", err)
+	}
+	if _, err := env.service.FinishLogin(ctx, "corp", state, authorization.Binding, code, oauthClientInfo); err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+	if _, err := env.service.FinishLogin(ctx, "corp", state, authorization.Binding, code, oauthClientInfo); !errors.Is(err, validations.ErrFederatedStateInvalid) {
+		t.Fatalf("reused state: error = %v, want ErrFederatedStateInvalid", err)
+	}
+
+	// Un código que el proveedor no emitió (o ya canjeado) no inicia sesión
+	authorization, _ = env.service.BeginLogin(ctx, "corp")
+	if _, err := env.service.FinishLogin(ctx, "corp", authorization.State, authorization.Binding, code, oauthClientInfo); !errors.Is(err, validations.ErrFederatedLoginFailed) {
+		t.Fatalf("used code: error = %v, want ErrFederatedLoginFailed", err)
+	}
+}
+
+func TestFederatedLoginIsBoundToTheBrowser(t *testing.T) {
+	env := newFederatedTestEnv(t, true)
+	ctx := context.Background()
+
+	// El atacante inicia un login y lleva al usuario a completarlo: su navegador tiene otro binding (o ninguno)
+	attacker, _ := env.service.BeginLogin(ctx, "corp")
+	victim, _ := env.service.BeginLogin(ctx, "corp")
+	for name, binding := range map[string]string{"another browser": victim.Binding, "without binding": ""} {
+		code, state, _ := env.idp.Authorize(attacker.AuthorizationURL, corpIdentity)
+		if _, err := env.service.FinishLogin(ctx, "corp", state, binding, code, oauthClientInfo); !errors.Is(err, validations.ErrFederatedStateInvalid) {
+			t.Fatalf("%s: error = %v, want ErrFederatedStateInvalid", name, err)
+		}
+		attacker, _ = env.service.BeginLogin(ctx, "corp")
+	}
+
+	code, state, _ := env.idp.Authorize(victim.AuthorizationURL, corpIdentity)
+	if _, err := env.service.FinishLogin(ctx, "corp", state, victim.Binding, code, oauthClientInfo); err != nil {
+		t.Fatalf("finish login: %v", err)
+	}
+}
+
+func TestNewFederatedServiceValidatesProviders(t *testing.T) {
+	env := newTestEnv(t)
+	tests := []FederationConfig{
+		{Providers: []oidc.ProviderConfig{{ID: "Corp/1", Issuer: "https://idp.example.com", ClientID: "x"}}},
+		{Providers: []oidc.ProviderConfig{{ID: "corp", ClientID: "x"}}},
+		{Providers: []oidc.ProviderConfig{
+			{ID: "corp", Issuer: "https://idp.example.com", ClientID: "x"},
+			{ID: "corp", Issuer: "https://other.example.com", ClientID: "y"},
+		}},
+	}
+	for _, config := range tests {
+		if _, err := NewFederatedService(env.userRepo, memory.NewIdentityRepository(), memory.NewFederatedStateRepository(), env.sessions, config); err == nil {
+			t.Fatalf("config %+v accepted", config)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/authz"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/rbac"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// INVITATION_DURATION es la vigencia en horas del link de invitación
+const INVITATION_DURATION = 72
+
+// InvitationService encapsula las invitaciones a las organizaciones: enviarlas, reenviarlas,
+// revocarlas y aceptarlas. Solo invitan los miembros con rbac.PERMISSION_MEMBERS_INVITE, y nunca
+// con un rol superior al propio (un ADMINISTRATIVE no puede invitar a un OWNER). Además, cada acción
+// se evalúa con las políticas de pkg/authz sobre el sujeto del access token del contexto.
+type InvitationService interface {
+	Invite(ctx context.Context, organizationID, inviterID, email, role string) (*models.Invitation, error)
+	ListInvitations(ctx context.Context, organizationID, actorID string) ([]models.Invitation, error)
+	ResendInvitation(ctx context.Context, organizationID, actorID, invitationID string) (*models.Invitation, error)
+	RevokeInvitation(ctx context.Context, organizationID, actorID, invitationID string) error
+	AcceptInvitation(ctx context.Context, req request.AcceptInvitationRequest) (*AcceptedInvitation, error)
+}
+
+// AcceptedInvitation es el resultado de aceptar una invitación: la organización, el rol con el que
+// el usuario quedó como miembro y si la cuenta se creó al aceptarla.
+type AcceptedInvitation struct {
+	Organization models.Organization
+	Role         string
+	Registered   bool
+}
+
+type invitationService struct {
+	userRepo         repositories.UserRepository
+	invitationRepo   repositories.InvitationRepository
+	organizationRepo repositories.OrganizationRepository
+	membershipRepo   repositories.MembershipRepository
+	notifier         Notifier
+}
+
+// NewInvitationService crea una nueva instancia de InvitationService.
+func NewInvitationService(userRepo repositories.UserRepository, invitationRepo repositories.InvitationRepository, organizationRepo repositories.OrganizationRepository, membershipRepo repositories.MembershipRepository, notifier Notifier) InvitationService {
+	return &invitationService{
+		userRepo:         userRepo,
+		invitationRepo:   invitationRepo,
+		organizationRepo: organizationRepo,
+		membershipRepo:   membershipRepo,
+		notifier:         notifier,
+	}
+}
+
+// Invite invita al email a la organización con el rol indicado y le envía el link.
+func (s *invitationService) Invite(ctx context.Context, organizationID, inviterID, email, role string) (*models.Invitation, error) {
+	email = validations.NormalizeEmail(email)
+	if !validations.IsValidEmail(email) {
+		return nil, validations.ErrInvalidEmail
+	}
+	if !rbac.IsValidRole(role) {
+		return nil, validations.ErrInvalidRole
+	}
+
+	membership, err := s.authorize(ctx, organizationID, inviterID, "invitations:create")
+	if err != nil {
+		return nil, err
+	}
+	if !rbac.CanAssignRole(membership.Role, role) {
+		return nil, validations.ErrRoleNotAssignable
+	}
+
+	// 1. No invitar a quien ya es miembro ni duplicar una invitación vigente
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if _, err := s.membershipRepo.GetMembership(ctx, organizationID, user.ID); err == nil {
+			return nil, validations.ErrAlreadyMember
+		} else if !errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, err
+		}
+	case !errors.Is(err, validations.ErrDocumentNotFound):
+		return nil, err
+	}
+
+	pending, err := s.invitationRepo.ListInvitationsByOrganization(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	for _, invitation := range pending {
+		if invitation.Email == email {
+			return nil, validations.ErrInvitationPending
+		}
+	}
+
+	// 2. Guardar la invitación y enviar el link
+	invitation := models.NewInvitation(organizationID, email, role, inviterID, time.Now().Add(INVITATION_DURATION*time.Hour))
+	if err := s.invitationRepo.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	// Un fallo en el envío no deshace la invitación: se puede reenviar
+	if err := s.send(ctx, invitation); err != nil {
+		log.Printf("Invite: error enviando la invitación %s: %v", invitation.ID, err)
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations lista las invitaciones vigentes de la organización.
+func (s *invitationService) ListInvitations(ctx context.Context, organizationID, actorID string) ([]models.Invitation, error) {
+	if _, err := s.authorize(ctx, organizationID, actorID, "invitations:read"); err != nil {
+		return nil, err
+	}
+
+	return s.invitationRepo.ListInvitationsByOrganization(ctx, organizationID)
+}
+
+// ResendInvitation reenvía la invitación con un link nuevo y extiende su vigencia. Los links
+// enviados antes dejan de servir.
+func (s *invitationService) ResendInvitation(ctx context.Context, organizationID, actorID, invitationID string) (*models.Invitation, error) {
+	invitation, err := s.getManagedInvitation(ctx, organizationID, actorID, invitationID, "invitations:update")
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.TokenID = uuid.New().String()
+	invitation.ExpiresAt = time.Now().Add(INVITATION_DURATION * time.Hour)
+	invitation.TTL = invitation.ExpiresAt.Unix()
+	if err := s.invitationRepo.RenewInvitation(ctx, organizationID, invitation.ID, invitation.TokenID, invitation.ExpiresAt); err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.send(ctx, invitation); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// RevokeInvitation elimina la invitación: su link deja de servir.
+func (s *invitationService) RevokeInvitation(ctx context.Context, organizationID, actorID, invitationID string) error {
+	if _, err := s.getManagedInvitation(ctx, organizationID, actorID, invitationID, "invitations:delete"); err != nil {
+		return err
+	}
+
+	err := s.invitationRepo.DeleteInvitation(ctx, organizationID, invitationID)
+	if errors.Is(err, validations.ErrDocumentNotFound) {
+		return validations.ErrInvitationNotFound
+	}
+
+	return err
+}
+
+// AcceptInvitation agrega al destinatario de la invitación a la organización. Si el email no
+// tiene cuenta la crea con el nombre, apellido y contraseña de la petición; sin ellos retorna
+// validations.ErrRegistrationRequired para que el frontend muestre el registro. El link acredita
+// que el usuario es dueño del email, por lo que queda verificado.
+func (s *invitationService) AcceptInvitation(ctx context.Context, req request.AcceptInvitationRequest) (*AcceptedInvitation, error) {
+	// 1. Validar el token y la invitación sin consumirla, para que un registro inválido pueda corregirse
+	claims, err := tokens.ParseInvitationToken(req.Token)
+	if err != nil {
+		return nil, validations.ErrInvitationInvalid
+	}
+
+	invitation, err := s.invitationRepo.GetInvitation(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvitationInvalid
+		}
+		return nil, err
+	}
+	if invitation.TokenID != claims.ID || invitation.IsExpired() {
+		return nil, validations.ErrInvitationInvalid
+	}
+
+	organization, err := s.organizationRepo.GetOrganization(ctx, invitation.OrganizationID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvitationInvalid
+		}
+		return nil, err
+	}
+
+	// 2. Buscar la cuenta del email o preparar la nueva
+	user, err := s.userRepo.GetUserByEmail(ctx, invitation.Email)
+	registered := errors.Is(err, validations.ErrDocumentNotFound)
+	switch {
+	case registered:
+		if strings.TrimSpace(req.Name) == "" && strings.TrimSpace(req.LastName) == "" && req.Password == "" {
+			return nil, validations.ErrRegistrationRequired
+		}
+		if user, err = models.NewUser(req.Name, req.LastName, invitation.Email, req.Password, ""); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case !user.IsActive():
+		return nil, validations.ErrUserInactive
+	default:
+		if _, err := s.membershipRepo.GetMembership(ctx, organization.ID, user.ID); err == nil {
+			return nil, validations.ErrAlreadyMember
+		} else if !errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, err
+		}
+	}
+
+	// 3. Consumir la invitación: cada link se acepta una sola vez
+	consumed, err := s.invitationRepo.ConsumeInvitation(ctx, invitation.ID, claims.ID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvitationInvalid
+		}
+		return nil, err
+	}
+
+	// 4. Crear la cuenta y la membresía. Si algo falla, la invitación se restaura para poder
+	// reintentar con el mismo link
+	if err := s.join(ctx, user, registered, organization.ID, invitation.Role); err != nil {
+		s.restoreInvitation(ctx, consumed)
+		return nil, err
+	}
+
+	return &AcceptedInvitation{Organization: *organization, Role: invitation.Role, Registered: registered}, nil
+}
+
+// join crea la cuenta (si registered) o marca el email como verificado y agrega al usuario como
+// miembro de la organización. La organización pasa a ser la activa de la cuenta nueva recién
+// cuando la membresía existe.
+func (s *invitationService) join(ctx context.Context, user *models.User, registered bool, organizationID, role string) error {
+	if !user.ContactInfo.Email.IsVerified {
+		verifiedAt := time.Now()
+		if !registered {
+			if err := s.userRepo.MarkEmailVerified(ctx, user.ID, user.ContactInfo.Email.Address, verifiedAt); err != nil {
+				if errors.Is(err, validations.ErrConditionFailed) {
+					return validations.ErrInvitationInvalid
+				}
+				return err
+			}
+		}
+		user.ContactInfo.Email.IsVerified = true
+		user.ContactInfo.Email.VerifiedAt = verifiedAt
+	}
+	if registered {
+		if err := s.userRepo.CreateUser(ctx, user); err != nil {
+			if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+				return ErrUserAlreadyExists
+			}
+			return err
+		}
+	}
+
+	if err := s.membershipRepo.CreateMembership(ctx, models.NewMembership(organizationID, user.ID, role)); err != nil {
+		if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return validations.ErrAlreadyMember
+		}
+		return err
+	}
+
+	// Ya es miembro: si no se puede activar la organización, el usuario puede elegirla con switch-org
+	if registered {
+		if err := s.userRepo.UpdateActiveOrganization(ctx, user.ID, organizationID); err != nil {
+			log.Printf("AcceptInvitation: error activando la organización %s de %s: %v", organizationID, user.ID, err)
+			return nil
+		}
+		user.ActiveOrganizationID = organizationID
+	}
+	return nil
+}
+
+// restoreInvitation vuelve a guardar una invitación consumida cuya aceptación falló. Un error no
+// cambia la respuesta al cliente, solo se registra: el administrador puede reenviarla.
+func (s *invitationService) restoreInvitation(ctx context.Context, invitation *models.Invitation) {
+	if err := s.invitationRepo.CreateInvitation(ctx, invitation); err != nil {
+		log.Printf("AcceptInvitation: error restaurando la invitación %s: %v", invitation.ID, err)
+	}
+}
+
+// authorize verifica que el actor pueda realizar la acción sobre las invitaciones de la
+// organización y retorna su membresía, con el rol vigente.
+func (s *invitationService) authorize(ctx context.Context, organizationID, actorID, action string) (*models.Membership, error) {
+	membership, err := s.inviterMembership(ctx, organizationID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authz.Authorize(ctx, action, invitationResource(organizationID, nil)); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// inviterMembership verifica que el actor sea miembro de la organización con permiso para invitar
+// y retorna su membresía.
+func (s *invitationService) inviterMembership(ctx context.Context, organizationID, actorID string) (*models.Membership, error) {
+	if organizationID == "" {
+		return nil, validations.ErrOrganizationRequired
+	}
+
+	membership, err := s.membershipRepo.GetMembership(ctx, organizationID, actorID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrCompanyNotFound
+		}
+		return nil, err
+	}
+
+	if !rbac.HasPermission(membership.Role, rbac.PERMISSION_MEMBERS_INVITE) {
+		return nil, validations.ErrForbidden
+	}
+
+	return membership, nil
+}
+
+// getManagedInvitation retorna una invitación de la organización sobre la que el actor puede
+// realizar la acción (reenviarla o revocarla): las de un rol superior al suyo no.
+func (s *invitationService) getManagedInvitation(ctx context.Context, organizationID, actorID, invitationID, action string) (*models.Invitation, error) {
+	membership, err := s.inviterMembership(ctx, organizationID, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.invitationRepo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if invitation.OrganizationID != organizationID {
+		return nil, validations.ErrInvitationNotFound
+	}
+	if err := authz.Authorize(ctx, action, invitationResource(organizationID, invitation)); err != nil {
+		return nil, err
+	}
+
+	if !rbac.CanAssignRole(membership.Role, invitation.Role) {
+		return nil, validations.ErrRoleNotAssignable
+	}
+
+	return invitation, nil
+}
+
+// invitationResource arma el recurso evaluado por las políticas: las invitaciones de la organización
+// o, si se indica, una en particular, cuyo dueño es quien la envió.
+func invitationResource(organizationID string, invitation *models.Invitation) authz.Resource {
+	resource := authz.Resource{
+		Type:       "invitation",
+		Attributes: map[string]interface{}{"organization_id": organizationID},
+	}
+	if invitation != nil {
+		resource.ID = invitation.ID
+		resource.Attributes["owner_id"] = invitation.InvitedBy
+	}
+	return resource
+}
+
+// send envía el link de la invitación a su destinatario
+func (s *invitationService) send(ctx context.Context, invitation *models.Invitation) error {
+	organization, err := s.organizationRepo.GetOrganization(ctx, invitation.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	inviterName := ""
+	if inviter, err := s.userRepo.GetUserByID(ctx, invitation.InvitedBy); err == nil {
+		inviterName = strings.TrimSpace(inviter.PersonalInfo.Name + " " + inviter.PersonalInfo.LastName)
+	}
+
+	token, err := tokens.GenerateInvitationToken(invitation)
+	if err != nil {
+		return err
+	}
+
+	link := getAppURL() + "/accept-invitation?token=" + url.QueryEscape(token)
+	return s.notifier.SendInvitation(ctx, invitation.Email, inviterName, organization.Name, invitation.Role, link)
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myproject/internal/repositories/memory"
+	"myproject/pkg/consts"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+)
+
+// newInvitationTestOrganization registra al dueño con su empresa y retorna los IDs de ambos
+func newInvitationTestOrganization(t *testing.T, env *testEnv) (organizationID, ownerID string) {
+	t.Helper()
+	ctx := context.Background()
+	err := env.sessions.Register(ctx, request.RegisterUserRequest{
+		Name: "Juan", LastName: "Pérez", Email: "owner@example.com", Password: testPassword, CompanyName: "La Espiga",
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	owner, _ := env.userRepo.GetUserByEmail(ctx, "owner@example.com")
+	return owner.ActiveOrganizationID, owner.ID
+}
+
+// memberContext retorna un contexto con los claims del access token de un miembro de la organización
+func memberContext(organizationID, userID, role string) context.Context {
+	claims := &tokens.Claims{OrganizationID: organizationID, Role: role}
+	claims.Subject = userID
+	return context.WithValue(context.Background(), "claims", claims)
+}
+
+func TestAcceptInvitation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	organizationID, ownerID := newInvitationTestOrganization(t, env)
+
+	// Un usuario nuevo se registra al aceptar
+	if _, err := env.invitations.Invite(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, " Ana@Example.com ", consts.ROLE_ADMINISTRATIVE); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	token := env.magicLinkToken(t, "ana@example.com")
+
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token}); !errors.Is(err, validations.ErrRegistrationRequired) {
+		t.Fatalf("without registration: error = %v, want ErrRegistrationRequired", err)
+	}
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token, Name: "Ana", LastName: "Gómez", Password: "corta"}); !errors.Is(err, validations.ErrPasswordChars) {
+		t.Fatalf("invalid password: error = %v, want ErrPasswordChars", err)
+	}
+
+	accepted, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token, Name: "Ana", LastName: "Gómez", Password: testPassword})
+	if err != nil || !accepted.Registered || accepted.Organization.ID != organizationID || accepted.Role != consts.ROLE_ADMINISTRATIVE {
+		t.Fatalf("accepted = %+v, err = %v", accepted, err)
+	}
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token}); !errors.Is(err, validations.ErrInvitationInvalid) {
+		t.Fatalf("reused link: error = %v, want ErrInvitationInvalid", err)
+	}
+
+	// La cuenta queda verificada y con la organización activa
+	tokens := env.login(t, "ana@example.com")
+	claims, err := env.sessions.ValidateAccessToken(ctx, tokens.AccessToken)
+	if err != nil || claims.OrganizationID != organizationID || claims.Role != consts.ROLE_ADMINISTRATIVE {
+		t.Fatalf("claims = %+v, err = %v", claims, err)
+	}
+	admin, _ := env.userRepo.GetUserByEmail(ctx, "ana@example.com")
+	if !admin.IsUserVerified() {
+		t.Fatal("email not verified after accepting the invitation")
+	}
+
+	// Un usuario existente solo necesita el token
+	env.register(t, "juan@example.com")
+	if _, err := env.invitations.Invite(memberContext(organizationID, admin.ID, consts.ROLE_ADMINISTRATIVE), organizationID, admin.ID, "juan@example.com", consts.ROLE_EMPLOYEE); err != nil {
+		t.Fatalf("invite existing user: %v", err)
+	}
+	accepted, err = env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: env.magicLinkToken(t, "juan@example.com")})
+	if err != nil || accepted.Registered || accepted.Role != consts.ROLE_EMPLOYEE {
+		t.Fatalf("accepted = %+v, err = %v", accepted, err)
+	}
+	employee, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if _, err := env.organizations.GetMembership(ctx, organizationID, employee.ID); err != nil {
+		t.Fatalf("membership not created: %v", err)
+	}
+}
+
+func TestAcceptInvitationFailureKeepsInvitation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	memberships := &failingMembershipRepository{MembershipRepository: memory.NewMembershipRepository()}
+	env.useMembershipRepository(memberships)
+	organizationID, ownerID := newInvitationTestOrganization(t, env)
+
+	if _, err := env.invitations.Invite(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, "ana@example.com", consts.ROLE_EMPLOYEE); err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+	token := env.magicLinkToken(t, "ana@example.com")
+	accept := request.AcceptInvitationRequest{Token: token, Name: "Ana", LastName: "Gómez", Password: testPassword}
+
+	// Si la membresía no se guarda, el link sigue sirviendo
+	memberships.failures = 1
+	if _, err := env.invitations.AcceptInvitation(ctx, accept); err == nil {
+		t.Fatal("accept succeeded without the membership")
+	}
+	invitations, err := env.invitations.ListInvitations(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID)
+	if err != nil || len(invitations) != 1 {
+		t.Fatalf("invitations = %+v, err = %v", invitations, err)
+	}
+
+	// La cuenta ya se creó: el reintento la usa y completa la membresía
+	accepted, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token})
+	if err != nil || accepted.Registered || accepted.Organization.ID != organizationID {
+		t.Fatalf("retry: accepted = %+v, err = %v", accepted, err)
+	}
+	user, _ := env.userRepo.GetUserByEmail(ctx, "ana@example.com")
+	if _, err := env.organizations.GetMembership(ctx, organizationID, user.ID); err != nil {
+		t.Fatalf("membership not created: %v", err)
+	}
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token}); !errors.Is(err, validations.ErrInvitationInvalid) {
+		t.Fatalf("reused link: error = %v, want ErrInvitationInvalid", err)
+	}
+}
+
+func TestInviteRoleChecks(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	organizationID, ownerID := newInvitationTestOrganization(t, env)
+
+	for email, role := range map[string]string{"admin@example.com": consts.ROLE_ADMINISTRATIVE, "juan@example.com": consts.ROLE_EMPLOYEE} {
+		if _, err := env.invitations.Invite(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, email, role); err != nil {
+			t.Fatalf("invite %s: %v", email, err)
+		}
+		token := env.magicLinkToken(t, email)
+		if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: token, Name: "Juan", LastName: "Pérez", Password: testPassword}); err != nil {
+			t.Fatalf("accept %s: %v", email, err)
+		}
+	}
+	admin, _ := env.userRepo.GetUserByEmail(ctx, "admin@example.com")
+	employee, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	tests := []struct {
+		name    string
+		actorID string
+		orgID   string
+		email   string
+		role    string
+		want    error
+	}{
+		{"invalid email", ownerID, organizationID, "no-es-un-email", consts.ROLE_EMPLOYEE, validations.ErrInvalidEmail},
+		{"unknown role", ownerID, organizationID, "nuevo@example.com", "SUPERUSER", validations.ErrInvalidRole},
+		{"without organization", ownerID, "", "nuevo@example.com", consts.ROLE_EMPLOYEE, validations.ErrOrganizationRequired},
+		{"not a member", ownerID, "otra", "nuevo@example.com", consts.ROLE_EMPLOYEE, validations.ErrCompanyNotFound},
+		{"without permission", employee.ID, organizationID, "nuevo@example.com", consts.ROLE_CLIENT, validations.ErrForbidden},
+		{"admin invites an owner", admin.ID, organizationID, "nuevo@example.com", consts.ROLE_OWNER, validations.ErrRoleNotAssignable},
+		{"already a member", admin.ID, organizationID, "juan@example.com", consts.ROLE_CLIENT, validations.ErrAlreadyMember},
+		{"allowed", admin.ID, organizationID, "nuevo@example.com", consts.ROLE_ADMINISTRATIVE, nil},
+		{"already invited", ownerID, organizationID, "NUEVO@example.com", consts.ROLE_EMPLOYEE, validations.ErrInvitationPending},
+	}
+	roles := map[string]string{ownerID: consts.ROLE_OWNER, admin.ID: consts.ROLE_ADMINISTRATIVE, employee.ID: consts.ROLE_EMPLOYEE}
+	for _, tt := range tests {
+		_, err := env.invitations.Invite(memberContext(tt.orgID, tt.actorID, roles[tt.actorID]), tt.orgID, tt.actorID, tt.email, tt.role)
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestResendAndRevokeInvitation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	organizationID, ownerID := newInvitationTestOrganization(t, env)
+
+	if _, err := env.invitations.Invite(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, "admin@example.com", consts.ROLE_ADMINISTRATIVE); err != nil {
+		t.Fatalf("invite admin: %v", err)
+	}
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{
+		Token: env.magicLinkToken(t, "admin@example.com"), Name: "Ana", LastName: "Gómez", Password: testPassword,
+	}); err != nil {
+		t.Fatalf("accept admin: %v", err)
+	}
+	admin, _ := env.userRepo.GetUserByEmail(ctx, "admin@example.com")
+
+	invitation, err := env.invitations.Invite(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, "socio@example.com", consts.ROLE_OWNER)
+	if err != nil {
+		t.Fatalf("invite owner: %v", err)
+	}
+	firstToken := env.magicLinkToken(t, "socio@example.com")
+
+	// Un ADMINISTRATIVE no gestiona invitaciones de un rol superior
+	if _, err := env.invitations.ResendInvitation(memberContext(organizationID, admin.ID, consts.ROLE_ADMINISTRATIVE), organizationID, admin.ID, invitation.ID); !errors.Is(err, validations.ErrRoleNotAssignable) {
+		t.Fatalf("admin resend: error = %v, want ErrRoleNotAssignable", err)
+	}
+	if err := env.invitations.RevokeInvitation(memberContext(organizationID, admin.ID, consts.ROLE_ADMINISTRATIVE), organizationID, admin.ID, invitation.ID); !errors.Is(err, validations.ErrRoleNotAssignable) {
+		t.Fatalf("admin revoke: error = %v, want ErrRoleNotAssignable", err)
+	}
+
+	// El reenvío invalida el link anterior
+	resent, err := env.invitations.ResendInvitation(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, invitation.ID)
+	if err != nil || resent.ExpiresAt.Before(invitation.ExpiresAt) {
+		t.Fatalf("resent = %+v, err = %v", resent, err)
+	}
+	secondToken := env.magicLinkToken(t, "socio@example.com")
+	if secondToken == firstToken {
+		t.Fatal("resend did not issue a new link")
+	}
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: firstToken}); !errors.Is(err, validations.ErrInvitationInvalid) {
+		t.Fatalf("previous link: error = %v, want ErrInvitationInvalid", err)
+	}
+
+	invitations, err := env.invitations.ListInvitations(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID)
+	if err != nil || len(invitations) != 1 || invitations[0].ID != invitation.ID {
+		t.Fatalf("invitations = %+v, err = %v", invitations, err)
+	}
+
+	// La revocación invalida el link vigente
+	if err := env.invitations.RevokeInvitation(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, invitation.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if err := env.invitations.RevokeInvitation(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, invitation.ID); !errors.Is(err, validations.ErrInvitationNotFound) {
+		t.Fatalf("revoke twice: error = %v, want ErrInvitationNotFound", err)
+	}
+	if _, err := env.invitations.AcceptInvitation(ctx, request.AcceptInvitationRequest{Token: secondToken}); !errors.Is(err, validations.ErrInvitationInvalid) {
+		t.Fatalf("revoked link: error = %v, want ErrInvitationInvalid", err)
+	}
+	if invitations, _ := env.invitations.ListInvitations(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID); len(invitations) != 0 {
+		t.Fatalf("invitations after revoke = %+v", invitations)
+	}
+}
+
+func TestInvitationsFollowAuthorizationPolicies(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	organizationID, ownerID := newInvitationTestOrganization(t, env)
+	invitation, err := env.invitations.Invite(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID, "ana@example.com", consts.ROLE_EMPLOYEE)
+	if err != nil {
+		t.Fatalf("invite: %v", err)
+	}
+
+	// Sin access token, o con uno de otra organización, las políticas deniegan aunque sea miembro
+	for name, actorCtx := range map[string]context.Context{
+		"without token":      ctx,
+		"other organization": memberContext("otra", ownerID, consts.ROLE_OWNER),
+	} {
+		if _, err := env.invitations.ListInvitations(actorCtx, organizationID, ownerID); !errors.Is(err, validations.ErrForbidden) {
+			t.Fatalf("list %s: error = %v, want ErrForbidden", name, err)
+		}
+		if err := env.invitations.RevokeInvitation(actorCtx, organizationID, ownerID, invitation.ID); !errors.Is(err, validations.ErrForbidden) {
+			t.Fatalf("revoke %s: error = %v, want ErrForbidden", name, err)
+		}
+	}
+
+	if invitations, err := env.invitations.ListInvitations(memberContext(organizationID, ownerID, consts.ROLE_OWNER), organizationID, ownerID); err != nil || len(invitations) != 1 {
+		t.Fatalf("list: %v, %d invitations", err, len(invitations))
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/validations"
+)
+
+// Valores por defecto de la política de bloqueo por intentos fallidos
+const (
+	DEFAULT_LOGIN_BACKOFF_AFTER            = 3  // fallos a partir de los cuales se exige esperar entre intentos
+	DEFAULT_LOGIN_BACKOFF_BASE_SECONDS     = 1  // espera tras el primer fallo con backoff; se duplica en cada fallo
+	DEFAULT_LOGIN_BACKOFF_MAX_SECONDS      = 60 // tope de la espera progresiva
+	DEFAULT_LOGIN_MAX_FAILURES_PER_IP      = 10 // fallos de un email desde una IP hasta el bloqueo
+	DEFAULT_LOGIN_MAX_FAILURES_PER_ACCOUNT = 50 // fallos de un email desde cualquier IP hasta el bloqueo
+	DEFAULT_LOGIN_LOCKOUT_MINUTES          = 15 // duración del bloqueo temporal
+	DEFAULT_LOGIN_ATTEMPT_WINDOW_MINUTES   = 60 // tiempo sin fallos tras el cual se olvidan los anteriores
+)
+
+// LockoutPolicy define los umbrales de la espera progresiva y del bloqueo temporal.
+type LockoutPolicy struct {
+	BackoffAfter          int
+	BackoffBase           time.Duration
+	BackoffMax            time.Duration
+	MaxFailuresPerIP      int
+	MaxFailuresPerAccount int
+	LockoutDuration       time.Duration
+	Window                time.Duration
+}
+
+// LoadLockoutPolicy lee la política desde variables de entorno, con los valores por defecto.
+func LoadLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		BackoffAfter:          getEnvInt("LOGIN_BACKOFF_AFTER", DEFAULT_LOGIN_BACKOFF_AFTER),
+		BackoffBase:           time.Duration(getEnvInt("LOGIN_BACKOFF_BASE_SECONDS", DEFAULT_LOGIN_BACKOFF_BASE_SECONDS)) * time.Second,
+		BackoffMax:            time.Duration(getEnvInt("LOGIN_BACKOFF_MAX_SECONDS", DEFAULT_LOGIN_BACKOFF_MAX_SECONDS)) * time.Second,
+		MaxFailuresPerIP:      getEnvInt("LOGIN_MAX_FAILURES_PER_IP", DEFAULT_LOGIN_MAX_FAILURES_PER_IP),
+		MaxFailuresPerAccount: getEnvInt("LOGIN_MAX_FAILURES_PER_ACCOUNT", DEFAULT_LOGIN_MAX_FAILURES_PER_ACCOUNT),
+		LockoutDuration:       time.Duration(getEnvInt("LOGIN_LOCKOUT_MINUTES", DEFAULT_LOGIN_LOCKOUT_MINUTES)) * time.Minute,
+		Window:                time.Duration(getEnvInt("LOGIN_ATTEMPT_WINDOW_MINUTES", DEFAULT_LOGIN_ATTEMPT_WINDOW_MINUTES)) * time.Minute,
+	}
+}
+
+// getEnvInt lee un entero positivo desde variables de entorno, o retorna el valor por defecto
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// lockedUntil calcula hasta cuándo la clave no puede volver a intentar: el bloqueo temporal si
+// alcanzó maxFailures o, con progressive, una espera que se duplica con cada fallo desde BackoffAfter.
+func (p LockoutPolicy) lockedUntil(attempt *models.LoginAttempt, maxFailures int, progressive bool) time.Time {
+	switch {
+	case attempt.Failures >= maxFailures:
+		return attempt.LastFailureAt.Add(p.LockoutDuration)
+	case progressive && attempt.Failures >= p.BackoffAfter:
+		delay := p.BackoffMax
+		if exponent := attempt.Failures - p.BackoffAfter; exponent < 30 {
+			delay = min(p.BackoffBase<<exponent, p.BackoffMax)
+		}
+		return attempt.LastFailureAt.Add(delay)
+	default:
+		return time.Time{}
+	}
+}
+
+// expiresAt es el fin de la ventana de conteo tras un fallo. Nunca es anterior al fin del bloqueo.
+func (p LockoutPolicy) expiresAt(failedAt time.Time) time.Time {
+	return failedAt.Add(max(p.Window, p.LockoutDuration))
+}
+
+// LockoutService lleva la cuenta de los logins fallidos por cuenta y por email + IP.
+type LockoutService interface {
+	CheckLogin(ctx context.Context, email, ip string) error
+	RegisterFailure(ctx context.Context, email, ip string) error
+	ResetFailures(ctx context.Context, email, ip string) error
+	UnlockUser(ctx context.Context, userID string) error
+}
+
+type lockoutService struct {
+	userRepo         repositories.UserRepository
+	loginAttemptRepo repositories.LoginAttemptRepository
+	policy           LockoutPolicy
+}
+
+// NewLockoutService crea una nueva instancia de LockoutService.
+func NewLockoutService(userRepo repositories.UserRepository, loginAttemptRepo repositories.LoginAttemptRepository, policy LockoutPolicy) LockoutService {
+	return &lockoutService{
+		userRepo:         userRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		policy:           policy,
+	}
+}
+
+// CheckLogin indica si el email puede intentar iniciar sesión desde la IP. Si está en espera o
+// bloqueado retorna validations.ErrAccountLocked envuelto en un RetryAfterError.
+// Se aplica igual a emails que no existen, para no revelar qué cuentas están registradas.
+func (s *lockoutService) CheckLogin(ctx context.Context, email, ip string) error {
+	var until time.Time
+
+	// El contador de la cuenta solo aplica el bloqueo (con un umbral más alto): con la espera
+	// progresiva, unos pocos fallos desde cualquier IP bastarían para impedirle el acceso al usuario.
+	checks := []struct {
+		key         string
+		maxFailures int
+		progressive bool
+	}{
+		{models.EmailIPAttemptKey(email, ip), s.policy.MaxFailuresPerIP, true},
+		{models.AccountAttemptKey(email), s.policy.MaxFailuresPerAccount, false},
+	}
+	for _, check := range checks {
+		attempt, err := s.loginAttemptRepo.GetLoginAttempt(ctx, check.key)
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if lockedUntil := s.policy.lockedUntil(attempt, check.maxFailures, check.progressive); lockedUntil.After(until) {
+			until = lockedUntil
+		}
+	}
+
+	if wait := time.Until(until); wait > 0 {
+		return validations.NewRetryAfterError(validations.ErrAccountLocked, wait)
+	}
+
+	return nil
+}
+
+// RegisterFailure suma un login fallido a la cuenta y a la combinación email + IP.
+func (s *lockoutService) RegisterFailure(ctx context.Context, email, ip string) error {
+	now := time.Now()
+	expiresAt := s.policy.expiresAt(now)
+
+	for _, key := range []string{models.EmailIPAttemptKey(email, ip), models.AccountAttemptKey(email)} {
+		if _, err := s.loginAttemptRepo.RegisterFailure(ctx, key, email, now, expiresAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResetFailures reinicia los contadores tras un login correcto.
+func (s *lockoutService) ResetFailures(ctx context.Context, email, ip string) error {
+	for _, key := range []string{models.EmailIPAttemptKey(email, ip), models.AccountAttemptKey(email)} {
+		if err := s.loginAttemptRepo.DeleteLoginAttempt(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UnlockUser levanta el bloqueo de un usuario desde cualquier IP (operación de administración).
+func (s *lockoutService) UnlockUser(ctx context.Context, userID string) error {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return s.loginAttemptRepo.DeleteLoginAttemptsByEmail(ctx, validations.NormalizeEmail(user.ContactInfo.Email.Address))
+}
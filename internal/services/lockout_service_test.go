@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+)
+
+// testLockoutPolicy usa esperas largas para que los tests no dependan del reloj
+var testLockoutPolicy = LockoutPolicy{
+	BackoffAfter:          3,
+	BackoffBase:           time.Minute,
+	BackoffMax:            10 * time.Minute,
+	MaxFailuresPerIP:      5,
+	MaxFailuresPerAccount: 8,
+	LockoutDuration:       15 * time.Minute,
+	Window:                time.Hour,
+}
+
+func (env *testEnv) failLogin(t *testing.T, email, ip string) {
+	t.Helper()
+	_, err := env.sessions.Login(context.Background(), email, "incorrecta", request.ClientInfo{IP: ip})
+	if !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("failed login: error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func mustBeLocked(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var retryErr *validations.RetryAfterError
+	if !errors.Is(err, validations.ErrAccountLocked) || !errors.As(err, &retryErr) {
+		t.Fatalf("error = %v, want ErrAccountLocked with Retry-After", err)
+	}
+	return retryErr.RetryAfter
+}
+
+func TestLockoutPolicyDelays(t *testing.T) {
+	p := testLockoutPolicy
+	now := time.Now()
+	delay := func(failures, maxFailures int, progressive bool) time.Duration {
+		until := p.lockedUntil(&models.LoginAttempt{Failures: failures, LastFailureAt: now}, maxFailures, progressive)
+		if until.IsZero() {
+			return 0
+		}
+		return until.Sub(now)
+	}
+
+	cases := []struct {
+		failures    int
+		progressive bool
+		want        time.Duration
+	}{
+		{2, true, 0},
+		{3, true, time.Minute},
+		{4, true, 2 * time.Minute},
+		{5, true, 15 * time.Minute}, // MaxFailuresPerIP: bloqueo
+		{3, false, 0},               // sin espera progresiva
+		{8, false, 15 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := delay(c.failures, 5, c.progressive); got != c.want {
+			t.Errorf("failures=%d progressive=%v: delay = %v, want %v", c.failures, c.progressive, got, c.want)
+		}
+	}
+
+	// La espera progresiva nunca supera BackoffMax
+	if got := delay(40, 100, true); got != p.BackoffMax {
+		t.Errorf("delay = %v, want BackoffMax", got)
+	}
+}
+
+func TestLoginProgressiveBackoff(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+
+	for i := 0; i < testLockoutPolicy.BackoffAfter; i++ {
+		env.failLogin(t, "juan@example.com", "10.0.0.1")
+	}
+
+	// Ni siquiera la contraseña correcta se acepta durante la espera
+	_, err := env.sessions.Login(ctx, "juan@example.com", testPassword, request.ClientInfo{IP: "10.0.0.1"})
+	if wait := mustBeLocked(t, err); wait <= 0 || wait > testLockoutPolicy.BackoffBase {
+		t.Fatalf("retry after = %v", wait)
+	}
+
+	// Desde otra IP la cuenta sigue accesible
+	if _, err := env.sessions.Login(ctx, "juan@example.com", testPassword, request.ClientInfo{IP: "10.0.0.2"}); err != nil {
+		t.Fatalf("login from another IP: %v", err)
+	}
+}
+
+func TestLoginBackoffAcrossIPv6Addresses(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+
+	// Cambiar de dirección dentro del mismo /64 no reinicia la espera
+	for i := 1; i <= testLockoutPolicy.BackoffAfter; i++ {
+		env.failLogin(t, "juan@example.com", fmt.Sprintf("2001:db8:1:2::%x", i))
+	}
+	_, err := env.sessions.Login(context.Background(), "juan@example.com", testPassword, request.ClientInfo{IP: "2001:db8:1:2:ffff::1"})
+	mustBeLocked(t, err)
+
+	// Otra red IPv6 sigue accediendo
+	if _, err := env.sessions.Login(context.Background(), "juan@example.com", testPassword, request.ClientInfo{IP: "2001:db8:1:3::1"}); err != nil {
+		t.Fatalf("login from another network: %v", err)
+	}
+}
+
+func TestLoginAccountLockout(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+
+	// Fallos distribuidos entre IPs: ninguna alcanza la espera, pero sí el umbral de la cuenta
+	for i := 0; i < testLockoutPolicy.MaxFailuresPerAccount; i++ {
+		env.failLogin(t, "juan@example.com", fmt.Sprintf("10.0.1.%d", i))
+	}
+
+	_, err := env.sessions.Login(context.Background(), "juan@example.com", testPassword, request.ClientInfo{IP: "10.0.2.1"})
+	if wait := mustBeLocked(t, err); wait <= testLockoutPolicy.LockoutDuration-time.Minute {
+		t.Fatalf("retry after = %v, want about %v", wait, testLockoutPolicy.LockoutDuration)
+	}
+
+	// Los emails inexistentes se comportan igual, sin revelar si la cuenta existe
+	for i := 0; i < testLockoutPolicy.BackoffAfter; i++ {
+		env.failLogin(t, "nadie@example.com", "10.0.0.1")
+	}
+	_, err = env.sessions.Login(context.Background(), "nadie@example.com", testPassword, request.ClientInfo{IP: "10.0.0.1"})
+	mustBeLocked(t, err)
+}
+
+func TestLoginSuccessResetsFailures(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+
+	for round := 0; round < 3; round++ {
+		for i := 0; i < testLockoutPolicy.BackoffAfter-1; i++ {
+			env.failLogin(t, "juan@example.com", "127.0.0.1") // misma IP que env.login
+		}
+		env.login(t, "juan@example.com")
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+
+	// Se registran los fallos directamente para llegar al bloqueo sin esperar entre intentos
+	for i := 0; i < testLockoutPolicy.MaxFailuresPerIP; i++ {
+		if err := env.lockout.RegisterFailure(ctx, "juan@example.com", "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := env.sessions.Login(ctx, "juan@example.com", testPassword, request.ClientInfo{IP: "10.0.0.1"})
+	mustBeLocked(t, err)
+
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if err := env.lockout.UnlockUser(ctx, user.ID); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := env.sessions.Login(ctx, "juan@example.com", testPassword, request.ClientInfo{IP: "10.0.0.1"}); err != nil {
+		t.Fatalf("login after unlock: %v", err)
+	}
+
+	if err := env.lockout.UnlockUser(ctx, "no-existe"); !errors.Is(err, validations.ErrDocumentNotFound) {
+		t.Fatalf("unknown user: error = %v", err)
+	}
+}
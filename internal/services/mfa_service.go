@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	tokens "myproject/pkg/jwt"
+	security "myproject/pkg/session"
+	"myproject/pkg/totp"
+	"myproject/pkg/validations"
+)
+
+// MFA_CHALLENGE_DURATION es la vigencia del challenge entre la contraseña y el segundo factor
+const MFA_CHALLENGE_DURATION = 5 * time.Minute
+
+// RECOVERY_CODES_COUNT es la cantidad de códigos de recuperación generados al habilitar TOTP
+const RECOVERY_CODES_COUNT = 10
+
+// TOTPSetup contiene el secreto a cargar en la app de autenticación
+type TOTPSetup struct {
+	Secret string
+	URI    string
+}
+
+// MFAService encapsula el alta y la verificación del segundo factor (TOTP y códigos de recuperación).
+type MFAService interface {
+	SetupTOTP(ctx context.Context, userID string) (*TOTPSetup, error)
+	VerifyTOTP(ctx context.Context, userID, code string) ([]string, error)
+	VerifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error
+}
+
+type mfaService struct {
+	userRepo repositories.UserRepository
+}
+
+// NewMFAService crea una nueva instancia de MFAService.
+func NewMFAService(userRepo repositories.UserRepository) MFAService {
+	return &mfaService{
+		userRepo: userRepo,
+	}
+}
+
+// getMFAIssuer retorna el nombre que muestran las apps de autenticación
+func getMFAIssuer() string {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		return tokens.DEFAULT_ISSUER
+	}
+	return issuer
+}
+
+// SetupTOTP genera un secreto nuevo y lo guarda cifrado como pendiente hasta que el usuario
+// confirme un código con VerifyTOTP. Volver a llamarlo reemplaza el secreto pendiente.
+func (s *mfaService) SetupTOTP(ctx context.Context, userID string) (*TOTPSetup, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsMFAEnabled() {
+		return nil, validations.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := security.EncryptSecret(secret, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	mfa := user.MFA
+	mfa.TOTPPendingSecret = encrypted
+	if err := s.userRepo.UpdateMFA(ctx, user.ID, mfa); err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    totp.KeyURI(getMFAIssuer(), user.ContactInfo.Email.Address, secret),
+	}, nil
+}
+
+// VerifyTOTP confirma el alta con un código de la app y habilita MFA. Retorna los códigos de
+// recuperación: es la única vez que se muestran, solo se guarda su hash.
+func (s *mfaService) VerifyTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.IsMFAEnabled() {
+		return nil, validations.ErrMFAAlreadyEnabled
+	}
+	if user.MFA.TOTPPendingSecret == "" {
+		return nil, validations.ErrMFASetupNotStarted
+	}
+
+	secret, err := security.DecryptSecret(user.MFA.TOTPPendingSecret, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return nil, validations.ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	mfa := models.MFAInfo{
+		TOTPEnabled:   true,
+		TOTPSecret:    user.MFA.TOTPPendingSecret,
+		TOTPLastStep:  step,
+		RecoveryCodes: hashes,
+		EnabledAt:     &now,
+	}
+	if err := s.userRepo.UpdateMFA(ctx, user.ID, mfa); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifySecondFactor valida un código TOTP o, si no se envía, un código de recuperación.
+// Ambos son de un solo uso. Si no son válidos retorna validations.ErrInvalidMFACode.
+func (s *mfaService) VerifySecondFactor(ctx context.Context, user *models.User, code, recoveryCode string) error {
+	if !user.IsMFAEnabled() {
+		return validations.ErrInvalidMFACode
+	}
+
+	var err error
+	switch {
+	case code != "":
+		secret, decryptErr := security.DecryptSecret(user.MFA.TOTPSecret, user.ID)
+		if decryptErr != nil {
+			return decryptErr
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now())
+		if !ok {
+			return validations.ErrInvalidMFACode
+		}
+		err = s.userRepo.ConsumeTOTPStep(ctx, user.ID, step)
+
+	case recoveryCode != "":
+		err = s.userRepo.ConsumeRecoveryCode(ctx, user.ID, security.HashToken(normalizeRecoveryCode(recoveryCode)))
+
+	default:
+		return validations.ErrInvalidMFACode
+	}
+
+	if errors.Is(err, validations.ErrConditionFailed) {
+		return validations.ErrInvalidMFACode
+	}
+	return err
+}
+
+// generateRecoveryCodes genera los códigos de recuperación (xxxx-xxxx-xxxx) y sus hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, RECOVERY_CODES_COUNT)
+	hashes := make([]string, RECOVERY_CODES_COUNT)
+
+	for i := range codes {
+		buf := make([]byte, 6)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(buf)
+
+		codes[i] = raw[0:4] + "-" + raw[4:8] + "-" + raw[8:12]
+		hashes[i] = security.HashToken(raw)
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode ignora mayúsculas, espacios y guiones al comparar códigos de recuperación
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(strings.TrimSpace(code)))
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myproject/pkg/request"
+	"myproject/pkg/totp"
+	"myproject/pkg/validations"
+)
+
+// enableTOTP registra un usuario con TOTP habilitado y retorna su secreto y sus códigos de recuperación
+func (env *testEnv) enableTOTP(t *testing.T, email string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	env.register(t, email)
+	user, _ := env.userRepo.GetUserByEmail(ctx, email)
+
+	if _, err := env.mfa.VerifyTOTP(ctx, user.ID, "000000"); !errors.Is(err, validations.ErrMFASetupNotStarted) {
+		t.Fatalf("verify before setup: error = %v", err)
+	}
+
+	setup, err := env.mfa.SetupTOTP(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	if !strings.HasPrefix(setup.URI, "otpauth://totp/") || !strings.Contains(setup.URI, "secret="+setup.Secret) {
+		t.Fatalf("unexpected uri: %s", setup.URI)
+	}
+
+	code, _ := totp.Code(setup.Secret, totp.Step(time.Now())-1)
+	codes, err := env.mfa.VerifyTOTP(ctx, user.ID, code)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if len(codes) != RECOVERY_CODES_COUNT {
+		t.Fatalf("got %d recovery codes", len(codes))
+	}
+
+	return setup.Secret, codes
+}
+
+func (env *testEnv) challenge(t *testing.T, email string) string {
+	t.Helper()
+	result, err := env.sessions.Login(context.Background(), email, testPassword, request.ClientInfo{IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if result.Tokens != nil || result.ChallengeToken == "" {
+		t.Fatalf("expected an MFA challenge, got %+v", result)
+	}
+	return result.ChallengeToken
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	secret, codes := env.enableTOTP(t, "juan@example.com")
+
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if !user.IsMFAEnabled() || user.MFA.TOTPPendingSecret != "" {
+		t.Fatalf("unexpected MFA state: %+v", user.MFA)
+	}
+
+	// Ni el secreto ni los códigos de recuperación se guardan en claro
+	if strings.Contains(user.MFA.TOTPSecret, secret) {
+		t.Fatal("TOTP secret stored in plain text")
+	}
+	for _, hash := range user.MFA.RecoveryCodes {
+		for _, code := range codes {
+			if strings.Contains(hash, strings.ReplaceAll(code, "-", "")) {
+				t.Fatal("recovery code stored in plain text")
+			}
+		}
+	}
+
+	if _, err := env.mfa.SetupTOTP(ctx, user.ID); !errors.Is(err, validations.ErrMFAAlreadyEnabled) {
+		t.Fatalf("setup when enabled: error = %v", err)
+	}
+}
+
+func TestLoginWithTOTP(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	client := request.ClientInfo{IP: "127.0.0.1"}
+	secret, _ := env.enableTOTP(t, "juan@example.com")
+
+	challenge := env.challenge(t, "juan@example.com")
+
+	// El código usado al confirmar el alta no sirve de nuevo
+	used, _ := totp.Code(secret, totp.Step(time.Now())-1)
+	if _, err := env.sessions.CompleteMFAChallenge(ctx, challenge, used, "", client); !errors.Is(err, validations.ErrInvalidMFACode) {
+		t.Fatalf("replayed code: error = %v", err)
+	}
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	pair, err := env.sessions.CompleteMFAChallenge(ctx, challenge, code, "", client)
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	if _, err := env.sessions.ValidateAccessToken(ctx, pair.AccessToken); err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+
+	// El challenge es de un solo uso
+	next, _ := totp.Code(secret, totp.Step(time.Now())+1)
+	if _, err := env.sessions.CompleteMFAChallenge(ctx, challenge, next, "", client); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("reused challenge: error = %v", err)
+	}
+
+	// Un access token no sirve como challenge
+	if _, err := env.sessions.CompleteMFAChallenge(ctx, pair.AccessToken, next, "", client); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("access token as challenge: error = %v", err)
+	}
+}
+
+func TestLoginWithRecoveryCode(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	client := request.ClientInfo{IP: "127.0.0.1"}
+	_, codes := env.enableTOTP(t, "juan@example.com")
+
+	// Se aceptan mayúsculas y sin guiones
+	typed := strings.ToUpper(strings.ReplaceAll(codes[0], "-", " "))
+	if _, err := env.sessions.CompleteMFAChallenge(ctx, env.challenge(t, "juan@example.com"), "", typed, client); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+
+	_, err := env.sessions.CompleteMFAChallenge(ctx, env.challenge(t, "juan@example.com"), "", codes[0], client)
+	if !errors.Is(err, validations.ErrInvalidMFACode) {
+		t.Fatalf("reused recovery code: error = %v", err)
+	}
+}
+
+func TestMFAChallengeFailuresLockout(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	client := request.ClientInfo{IP: "127.0.0.1"}
+	secret, _ := env.enableTOTP(t, "juan@example.com")
+
+	challenge := env.challenge(t, "juan@example.com")
+	for i := 0; i < testLockoutPolicy.BackoffAfter; i++ {
+		if _, err := env.sessions.CompleteMFAChallenge(ctx, challenge, "000000", "", client); !errors.Is(err, validations.ErrInvalidMFACode) {
+			t.Fatalf("wrong code: error = %v", err)
+		}
+	}
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	_, err := env.sessions.CompleteMFAChallenge(ctx, challenge, code, "", client)
+	mustBeLocked(t, err)
+}
+
+func TestMFAChallengeIsConsumedOnce(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	client := request.ClientInfo{IP: "127.0.0.1"}
+	_, codes := env.enableTOTP(t, "juan@example.com")
+
+	// Usos simultáneos del mismo challenge, cada uno con un código de recuperación válido distinto
+	challenge := env.challenge(t, "juan@example.com")
+	attempts := codes[:5]
+	errs := make([]error, len(attempts))
+	var wg sync.WaitGroup
+	for i, code := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = env.sessions.CompleteMFAChallenge(ctx, challenge, "", code, client)
+		}()
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, validations.ErrInvalidToken):
+			t.Fatalf("concurrent challenge: error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d sessions opened with one challenge, want 1", succeeded)
+	}
+
+	// Solo se gastó el código de recuperación del intento que abrió la sesión
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if remaining := len(user.MFA.RecoveryCodes); remaining != RECOVERY_CODES_COUNT-1 {
+		t.Fatalf("recovery codes left = %d, want %d", remaining, RECOVERY_CODES_COUNT-1)
+	}
+}
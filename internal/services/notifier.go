@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/pkg/mail"
+)
+
+// Notifier envía al usuario los mensajes generados por la lógica de negocio
+// (links de restablecimiento, verificación, etc.).
+type Notifier interface {
+	SendPasswordReset(ctx context.Context, user *models.User, link string) error
+	SendEmailVerification(ctx context.Context, user *models.User, link string) error
+	// SendMagicLink y SendEmailOTP reciben la dirección y no el usuario porque, con el
+	// auto-registro habilitado, el destinatario puede no tener cuenta todavía.
+	SendMagicLink(ctx context.Context, email, name, link string, expiresIn time.Duration) error
+	SendEmailOTP(ctx context.Context, email, name, code string, expiresIn time.Duration) error
+	// SendInvitation envía el link de una invitación a una organización; el destinatario puede no tener cuenta.
+	SendInvitation(ctx context.Context, email, inviterName, organizationName, role, link string) error
+}
+
+// mailNotifier renderiza los templates de pkg/mail y encola los emails para su envío.
+type mailNotifier struct {
+	queue *mail.Queue
+}
+
+// NewMailNotifier crea un Notifier que envía los mensajes por email a través de la cola.
+func NewMailNotifier(queue *mail.Queue) Notifier {
+	return &mailNotifier{
+		queue: queue,
+	}
+}
+
+func (n *mailNotifier) SendPasswordReset(ctx context.Context, user *models.User, link string) error {
+	return n.send(ctx, user.ContactInfo.Email.Address, mail.TemplatePasswordReset, mail.TemplateData{
+		Name:           user.PersonalInfo.Name,
+		Link:           link,
+		ExpiresInHours: RESET_TOKEN_DURATION,
+	})
+}
+
+func (n *mailNotifier) SendEmailVerification(ctx context.Context, user *models.User, link string) error {
+	return n.send(ctx, user.ContactInfo.Email.Address, mail.TemplateEmailVerification, mail.TemplateData{
+		Name:           user.PersonalInfo.Name,
+		Link:           link,
+		ExpiresInHours: VERIFICATION_DURATION,
+	})
+}
+
+func (n *mailNotifier) SendMagicLink(ctx context.Context, email, name, link string, expiresIn time.Duration) error {
+	return n.send(ctx, email, mail.TemplateMagicLink, mail.TemplateData{
+		Name:             name,
+		Link:             link,
+		ExpiresInMinutes: int(expiresIn.Minutes()),
+	})
+}
+
+func (n *mailNotifier) SendEmailOTP(ctx context.Context, email, name, code string, expiresIn time.Duration) error {
+	return n.send(ctx, email, mail.TemplateEmailOTP, mail.TemplateData{
+		Name:             name,
+		Code:             code,
+		ExpiresInMinutes: int(expiresIn.Minutes()),
+	})
+}
+
+func (n *mailNotifier) SendInvitation(ctx context.Context, email, inviterName, organizationName, role, link string) error {
+	return n.send(ctx, email, mail.TemplateInvitation, mail.TemplateData{
+		InviterName:      inviterName,
+		OrganizationName: organizationName,
+		Role:             role,
+		Link:             link,
+		ExpiresInHours:   INVITATION_DURATION,
+	})
+}
+
+// send renderiza el template en el idioma configurado y lo encola para la dirección `to`.
+func (n *mailNotifier) send(ctx context.Context, to string, tpl mail.Template, data mail.TemplateData) error {
+	content, err := mail.Render(tpl, mail.GetDefaultLanguage(), data)
+	if err != nil {
+		return err
+	}
+
+	return n.queue.Enqueue(ctx, mail.NewMessage(to, content))
+}
+
+// getAppURL retorna la URL del frontend usada para armar los links enviados por email
+func getAppURL() string {
+	appURL := os.Getenv("APP_URL")
+	if appURL == "" {
+		return "http://localhost:3000" // valor por defecto para desarrollo
+	}
+	return appURL
+}
+
+// GetAPIURL retorna la URL pública de esta API, usada en links que apuntan directo a un endpoint
+func GetAPIURL() string {
+	apiURL := os.Getenv("API_URL")
+	if apiURL == "" {
+		return "http://localhost:9000" // valor por defecto para desarrollo
+	}
+	return apiURL
+}
@@ -0,0 +1,662 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	security "myproject/pkg/session"
+	"myproject/pkg/validations"
+
+	"github.com/google/uuid"
+)
+
+// OAUTH_CODE_DURATION es la vigencia de un código de autorización
+const OAUTH_CODE_DURATION = 5 * time.Minute
+
+// OIDC_ID_TOKEN_DURATION es la vigencia del ID token
+const OIDC_ID_TOKEN_DURATION = time.Hour
+
+// OAUTH_CODE_CHALLENGE_S256 es el único método de PKCE aceptado ("plain" no protege el código)
+const OAUTH_CODE_CHALLENGE_S256 = "S256"
+
+// OAuthService encapsula el servidor de autorización OAuth 2.0: registro de clientes,
+// /oauth/authorize (authorization code con PKCE) y /oauth/token. Con el scope openid actúa además
+// como proveedor OpenID Connect: ID token, /userinfo y cierre de sesión.
+type OAuthService interface {
+	RegisterClient(ctx context.Context, req request.CreateOAuthClientRequest) (*models.OAuthClient, string, error)
+	ListClients(ctx context.Context) ([]models.OAuthClient, error)
+	DeleteClient(ctx context.Context, clientID string) error
+	ValidateAuthorizeRequest(ctx context.Context, req request.AuthorizeRequest) (*Authorization, error)
+	IssueAuthorizationCode(ctx context.Context, authorization *Authorization, user *models.User) (string, error)
+	Token(ctx context.Context, req request.OAuthTokenRequest, client request.ClientInfo) (*OAuthToken, error)
+	UserInfo(ctx context.Context, claims *tokens.Claims) (*models.User, error)
+	EndSession(ctx context.Context, req request.EndSessionRequest) (string, error)
+}
+
+// Authorization es una solicitud de /oauth/authorize ya validada.
+type Authorization struct {
+	Client              *models.OAuthClient
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Nonce               string
+}
+
+// ErrorRedirectURL retorna la redirect_uri del cliente con el error en la query, como pide el estándar
+// para los errores que ocurren después de validar el cliente y la redirect_uri.
+func (a *Authorization) ErrorRedirectURL(err *validations.OAuthError) string {
+	params := url.Values{"error": {err.Code}}
+	if err.Description != "" {
+		params.Set("error_description", err.Description)
+	}
+	return a.redirectURL(params)
+}
+
+// redirectURL agrega los parámetros (y el state) a la redirect_uri
+func (a *Authorization) redirectURL(params url.Values) string {
+	return appendQuery(a.RedirectURI, params, a.State)
+}
+
+// appendQuery agrega los parámetros y el state, si lo hay, a la query de la URI
+func appendQuery(uri string, params url.Values, state string) string {
+	if state != "" {
+		params.Set("state", state)
+	}
+	if len(params) == 0 {
+		return uri
+	}
+
+	separator := "?"
+	if strings.Contains(uri, "?") {
+		separator = "&"
+	}
+	return uri + separator + params.Encode()
+}
+
+// OAuthToken es la respuesta de /oauth/token. RefreshToken está vacío si el cliente no tiene el grant
+// refresh_token e IDToken si no se concedió el scope openid.
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	Scope        string
+	ExpiresIn    int
+}
+
+type oauthService struct {
+	clientRepo     repositories.OAuthClientRepository
+	codeRepo       repositories.AuthorizationCodeRepository
+	userRepo       repositories.UserRepository
+	sessionService SessionService
+}
+
+// NewOAuthService crea una nueva instancia de OAuthService.
+func NewOAuthService(
+	clientRepo repositories.OAuthClientRepository,
+	codeRepo repositories.AuthorizationCodeRepository,
+	userRepo repositories.UserRepository,
+	sessionService SessionService,
+) OAuthService {
+	return &oauthService{
+		clientRepo:     clientRepo,
+		codeRepo:       codeRepo,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+	}
+}
+
+// RegisterClient registra un cliente y retorna su secreto en texto plano, que solo se muestra
+// esta vez. Los clientes públicos no tienen secreto y no pueden usar client_credentials.
+func (s *oauthService) RegisterClient(ctx context.Context, req request.CreateOAuthClientRequest) (*models.OAuthClient, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "", validations.ErrOAuthClientNameRequired
+	}
+
+	grantTypes := req.GrantTypes
+	if len(grantTypes) == 0 {
+		grantTypes = []string{models.OAUTH_GRANT_AUTHORIZATION_CODE, models.OAUTH_GRANT_REFRESH_TOKEN}
+	}
+	for _, grantType := range grantTypes {
+		switch grantType {
+		case models.OAUTH_GRANT_AUTHORIZATION_CODE, models.OAUTH_GRANT_REFRESH_TOKEN:
+		case models.OAUTH_GRANT_CLIENT_CREDENTIALS:
+			if req.Public {
+				return nil, "", validations.ErrOAuthGrantTypeInvalid
+			}
+		default:
+			return nil, "", validations.ErrOAuthGrantTypeInvalid
+		}
+	}
+
+	// Sin redirect_uri no hay a dónde enviar el código
+	if slices.Contains(grantTypes, models.OAUTH_GRANT_AUTHORIZATION_CODE) && len(req.RedirectURIs) == 0 {
+		return nil, "", validations.ErrOAuthRedirectURIInvalid
+	}
+	for _, uri := range slices.Concat(req.RedirectURIs, req.PostLogoutRedirectURIs) {
+		if !isValidRedirectURI(uri) {
+			return nil, "", validations.ErrOAuthRedirectURIInvalid
+		}
+	}
+
+	for _, scope := range req.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n\"\\") {
+			return nil, "", validations.ErrOAuthScopeInvalid
+		}
+	}
+
+	client := &models.OAuthClient{
+		ID:           uuid.New().String(),
+		Name:         name,
+		RedirectURIs: slices.Clone(req.RedirectURIs),
+		Scopes:       slices.Clone(req.Scopes),
+		GrantTypes:   slices.Clone(grantTypes),
+		CreatedAt:    time.Now(),
+
+		PostLogoutRedirectURIs: slices.Clone(req.PostLogoutRedirectURIs),
+	}
+
+	secret := ""
+	if !req.Public {
+		var err error
+		if secret, err = security.GenerateRandomToken(32); err != nil {
+			return nil, "", err
+		}
+		client.SecretHash = security.HashToken(secret)
+	}
+
+	if err := s.clientRepo.CreateClient(ctx, client); err != nil {
+		return nil, "", err
+	}
+
+	return client, secret, nil
+}
+
+// ListClients lista los clientes registrados.
+func (s *oauthService) ListClients(ctx context.Context) ([]models.OAuthClient, error) {
+	return s.clientRepo.ListClients(ctx)
+}
+
+// DeleteClient elimina un cliente. Los tokens ya emitidos siguen vigentes hasta expirar,
+// pero sus refresh tokens ya no pueden canjearse.
+func (s *oauthService) DeleteClient(ctx context.Context, clientID string) error {
+	if err := s.clientRepo.DeleteClient(ctx, clientID); err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return validations.ErrOAuthClientNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ValidateAuthorizeRequest valida los parámetros de /oauth/authorize.
+// Si el cliente no existe o la redirect_uri no está registrada retorna ErrOAuthClientNotFound o
+// ErrOAuthRedirectURIInvalid y no debe redirigirse. El resto de los errores son *validations.OAuthError
+// y se retornan junto con la Authorization, para informarlos en la redirect_uri (ErrorRedirectURL).
+func (s *oauthService) ValidateAuthorizeRequest(ctx context.Context, req request.AuthorizeRequest) (*Authorization, error) {
+	// 1. Cliente y redirect_uri: sin ellos no hay a dónde redirigir de forma segura
+	client, err := s.clientRepo.GetClient(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrOAuthClientNotFound
+		}
+		return nil, err
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !client.HasRedirectURI(redirectURI) {
+		return nil, validations.ErrOAuthRedirectURIInvalid
+	}
+
+	authorization := &Authorization{
+		Client:              client,
+		RedirectURI:         redirectURI,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+		Nonce:               req.Nonce,
+	}
+
+	// 2. Tipo de respuesta y grant del cliente
+	if req.ResponseType != "code" {
+		return authorization, validations.NewOAuthError(validations.OAUTH_UNSUPPORTED_RESPONSE_TYPE, "only response_type=code is supported")
+	}
+	if !client.AllowsGrant(models.OAUTH_GRANT_AUTHORIZATION_CODE) {
+		return authorization, validations.NewOAuthError(validations.OAUTH_UNAUTHORIZED_CLIENT, "the client cannot use the authorization code grant")
+	}
+
+	// 3. PKCE es obligatorio para todos los clientes
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != OAUTH_CODE_CHALLENGE_S256 {
+		return authorization, validations.NewOAuthError(validations.OAUTH_INVALID_REQUEST, "code_challenge with code_challenge_method=S256 is required")
+	}
+	if len(req.CodeChallenge) < 43 || len(req.CodeChallenge) > 128 {
+		return authorization, validations.NewOAuthError(validations.OAUTH_INVALID_REQUEST, "invalid code_challenge")
+	}
+
+	// 4. Scopes: sin scope se conceden todos los del cliente
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !client.AllowsScopes(scopes) {
+		return authorization, validations.NewOAuthError(validations.OAUTH_INVALID_SCOPE, "the requested scope is not allowed for the client")
+	}
+	// Sin una clave asimétrica no se emiten ID tokens: se rechaza openid antes de emitir el código
+	if slices.Contains(scopes, models.OAUTH_SCOPE_OPENID) {
+		keySet, err := tokens.GetKeySet()
+		if err != nil {
+			return authorization, err
+		}
+		if !keySet.CanSignIDTokens() {
+			return authorization, validations.NewOAuthError(validations.OAUTH_INVALID_SCOPE, "the openid scope requires an asymmetric signing key")
+		}
+	}
+	authorization.Scope = strings.Join(scopes, " ")
+
+	return authorization, nil
+}
+
+// IssueAuthorizationCode emite el código de un solo uso para el usuario que aprobó la solicitud
+// y retorna la redirect_uri con el código y el state. Se llama apenas el usuario se autentica,
+// por lo que ese momento es el auth_time del ID token.
+func (s *oauthService) IssueAuthorizationCode(ctx context.Context, authorization *Authorization, user *models.User) (string, error) {
+	code, err := security.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(OAUTH_CODE_DURATION)
+	authorizationCode := &models.AuthorizationCode{
+		CodeHash:            security.HashToken(code),
+		ClientID:            authorization.Client.ID,
+		UserID:              user.ID,
+		RedirectURI:         authorization.RedirectURI,
+		Scope:               authorization.Scope,
+		CodeChallenge:       authorization.CodeChallenge,
+		CodeChallengeMethod: authorization.CodeChallengeMethod,
+		Nonce:               authorization.Nonce,
+		AuthTime:            now,
+		CreatedAt:           now,
+		ExpiresAt:           expiresAt,
+		TTL:                 expiresAt.Unix(),
+	}
+
+	if err := s.codeRepo.CreateAuthorizationCode(ctx, authorizationCode); err != nil {
+		return "", err
+	}
+
+	return authorization.redirectURL(url.Values{"code": {code}}), nil
+}
+
+// Token implementa /oauth/token. Los errores son *validations.OAuthError salvo los inesperados.
+func (s *oauthService) Token(ctx context.Context, req request.OAuthTokenRequest, client request.ClientInfo) (*OAuthToken, error) {
+	oauthClient, err := s.authenticateClient(ctx, req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case models.OAUTH_GRANT_AUTHORIZATION_CODE, models.OAUTH_GRANT_REFRESH_TOKEN, models.OAUTH_GRANT_CLIENT_CREDENTIALS:
+		if !oauthClient.AllowsGrant(req.GrantType) {
+			return nil, validations.NewOAuthError(validations.OAUTH_UNAUTHORIZED_CLIENT, "the client cannot use this grant type")
+		}
+	default:
+		return nil, validations.NewOAuthError(validations.OAUTH_UNSUPPORTED_GRANT_TYPE, "unsupported grant_type")
+	}
+
+	switch req.GrantType {
+	case models.OAUTH_GRANT_AUTHORIZATION_CODE:
+		return s.exchangeAuthorizationCode(ctx, oauthClient, req, client)
+	case models.OAUTH_GRANT_REFRESH_TOKEN:
+		return s.refreshToken(ctx, oauthClient, req, client)
+	default:
+		return s.clientCredentials(oauthClient, req)
+	}
+}
+
+// exchangeAuthorizationCode canjea un código de autorización verificando el code_verifier de PKCE.
+// Si el código ya se había canjeado, revoca la sesión abierta con él (RFC 6749 §4.1.2): la
+// reutilización indica que el código se filtró.
+func (s *oauthService) exchangeAuthorizationCode(ctx context.Context, oauthClient *models.OAuthClient, req request.OAuthTokenRequest, client request.ClientInfo) (*OAuthToken, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, validations.NewOAuthError(validations.OAUTH_INVALID_REQUEST, "code and code_verifier are required")
+	}
+
+	// 1. El código debe estar vigente y haberse emitido para este cliente y redirect_uri
+	codeHash := security.HashToken(req.Code)
+	code, err := s.codeRepo.GetAuthorizationCode(ctx, codeHash)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, invalidGrant("invalid authorization code")
+		}
+		return nil, err
+	}
+
+	if code.ClientID != oauthClient.ID {
+		return nil, invalidGrant("invalid authorization code")
+	}
+	if code.UsedAt != nil {
+		s.revokeCodeSession(ctx, code)
+		return nil, invalidGrant("invalid authorization code")
+	}
+	if !code.IsUsable() {
+		return nil, invalidGrant("invalid authorization code")
+	}
+	if req.RedirectURI != code.RedirectURI {
+		return nil, invalidGrant("redirect_uri does not match the authorization request")
+	}
+
+	// 2. PKCE: el verifier debe corresponder al challenge enviado en /oauth/authorize
+	if !verifyCodeChallenge(req.CodeVerifier, code.CodeChallenge) {
+		return nil, invalidGrant("invalid code_verifier")
+	}
+
+	// 3. Marcarlo usado: de dos canjes simultáneos solo uno obtiene tokens
+	if err := s.codeRepo.MarkAuthorizationCodeUsed(ctx, codeHash); err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return nil, invalidGrant("invalid authorization code")
+		}
+		return nil, err
+	}
+
+	// 4. Abrir la sesión del usuario para el cliente
+	user, err := s.userRepo.GetUserByID(ctx, code.UserID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, invalidGrant("invalid authorization code")
+		}
+		return nil, err
+	}
+
+	grant := tokens.Grant{ClientID: oauthClient.ID, Scope: code.Scope, AuthTime: code.AuthTime}
+	newTokens, err := s.sessionService.StartOAuthSession(ctx, user, client, grant)
+	if err != nil {
+		if errors.Is(err, validations.ErrUserInactive) {
+			return nil, invalidGrant("the user is not active")
+		}
+		return nil, err
+	}
+
+	// 5. Recordar la sesión para revocarla si el código se reutiliza
+	if err := s.codeRepo.SetAuthorizationCodeSession(ctx, codeHash, newTokens.SessionID); err != nil {
+		return nil, err
+	}
+
+	return s.newOAuthToken(oauthClient, user, newTokens, grant, code.Nonce)
+}
+
+// revokeCodeSession revoca la sesión abierta al canjear el código. Un fallo no cambia la respuesta:
+// el código reutilizado se rechaza igual.
+func (s *oauthService) revokeCodeSession(ctx context.Context, code *models.AuthorizationCode) {
+	if code.SessionID == "" {
+		return
+	}
+
+	err := s.sessionService.RevokeSession(ctx, code.UserID, code.SessionID)
+	if err != nil && !errors.Is(err, validations.ErrSessionNotFound) {
+		log.Printf("exchangeAuthorizationCode: error revocando la sesión %s del código reutilizado: %v", code.SessionID, err)
+	}
+}
+
+// refreshToken renueva los tokens de una sesión abierta por el cliente. Conserva los scopes
+// concedidos: el parámetro scope, si se envía, debe ser el mismo.
+func (s *oauthService) refreshToken(ctx context.Context, oauthClient *models.OAuthClient, req request.OAuthTokenRequest, client request.ClientInfo) (*OAuthToken, error) {
+	if req.RefreshToken == "" {
+		return nil, validations.NewOAuthError(validations.OAUTH_INVALID_REQUEST, "refresh_token is required")
+	}
+
+	claims, err := tokens.ParseRefreshToken(req.RefreshToken)
+	if err != nil || claims.ClientID != oauthClient.ID {
+		return nil, invalidGrant("invalid refresh token")
+	}
+	if req.Scope != "" && !sameScopes(req.Scope, claims.Scope) {
+		return nil, validations.NewOAuthError(validations.OAUTH_INVALID_SCOPE, "the scope cannot change on refresh")
+	}
+
+	newTokens, err := s.sessionService.RefreshOAuthToken(ctx, req.RefreshToken, oauthClient.ID, client)
+	if err != nil {
+		switch {
+		case errors.Is(err, validations.ErrInvalidToken), errors.Is(err, validations.ErrRefreshTokenReused):
+			return nil, invalidGrant("invalid refresh token")
+		case errors.Is(err, validations.ErrUserInactive):
+			return nil, invalidGrant("the user is not active")
+		}
+		return nil, err
+	}
+
+	// El ID token renovado conserva el auth_time del login original y no lleva nonce
+	grant := tokens.Grant{ClientID: oauthClient.ID, Scope: claims.Scope}
+	if claims.AuthTime != nil {
+		grant.AuthTime = claims.AuthTime.Time
+	}
+
+	var user *models.User
+	if hasScope(claims.Scope, models.OAUTH_SCOPE_OPENID) {
+		if user, err = s.userRepo.GetUserByID(ctx, claims.Subject); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.newOAuthToken(oauthClient, user, newTokens, grant, "")
+}
+
+// clientCredentials emite un access token a nombre del propio cliente (sin usuario ni refresh token).
+func (s *oauthService) clientCredentials(oauthClient *models.OAuthClient, req request.OAuthTokenRequest) (*OAuthToken, error) {
+	if oauthClient.IsPublic() {
+		return nil, validations.NewOAuthError(validations.OAUTH_UNAUTHORIZED_CLIENT, "public clients cannot use client_credentials")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 {
+		scopes = oauthClient.Scopes
+	}
+	if !oauthClient.AllowsScopes(scopes) {
+		return nil, validations.NewOAuthError(validations.OAUTH_INVALID_SCOPE, "the requested scope is not allowed for the client")
+	}
+	scope := strings.Join(scopes, " ")
+
+	accessToken, err := tokens.GenerateClientCredentialsToken(oauthClient.ID, scope, time.Hour*ACCESS_DURATION)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OAuthToken{
+		AccessToken: accessToken,
+		Scope:       scope,
+		ExpiresIn:   ACCESS_DURATION * 3600,
+	}, nil
+}
+
+// UserInfo retorna el usuario del access token para /userinfo. El token debe tener el scope openid;
+// los datos que se informan dependen de los demás scopes concedidos (ver tokens.NewUserClaims).
+func (s *oauthService) UserInfo(ctx context.Context, claims *tokens.Claims) (*models.User, error) {
+	if claims.IsClientToken() || !hasScope(claims.Scope, models.OAUTH_SCOPE_OPENID) {
+		return nil, validations.ErrInsufficientScope
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if !user.IsActive() {
+		return nil, validations.ErrUserInactive
+	}
+
+	return user, nil
+}
+
+// EndSession implementa el cierre de sesión de OpenID Connect (RP-Initiated Logout). Con id_token_hint
+// revoca la sesión del ID token. Retorna la post_logout_redirect_uri con el state, o vacío si no se
+// pidió redirección; la URI debe estar registrada para el cliente del ID token (o el client_id).
+func (s *oauthService) EndSession(ctx context.Context, req request.EndSessionRequest) (string, error) {
+	clientID := req.ClientID
+
+	// 1. Cerrar la sesión identificada por el ID token
+	if req.IDTokenHint != "" {
+		claims, err := tokens.ParseIDTokenHint(req.IDTokenHint)
+		if err != nil {
+			return "", validations.ErrInvalidToken
+		}
+		if clientID != "" && clientID != claims.Audience[0] {
+			return "", validations.ErrInvalidToken
+		}
+		clientID = claims.Audience[0]
+
+		if claims.SessionID != "" {
+			// Una sesión que ya no existe (cerrada antes o vencida) no es un error
+			err := s.sessionService.RevokeSession(ctx, claims.Subject, claims.SessionID)
+			if err != nil && !errors.Is(err, validations.ErrSessionNotFound) {
+				return "", err
+			}
+		}
+	}
+
+	// 2. Volver a la aplicación solo a una URI registrada
+	if req.PostLogoutRedirectURI == "" {
+		return "", nil
+	}
+	if clientID == "" {
+		return "", validations.ErrOAuthRedirectURIInvalid
+	}
+
+	client, err := s.clientRepo.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return "", validations.ErrOAuthClientNotFound
+		}
+		return "", err
+	}
+	if !client.HasPostLogoutRedirectURI(req.PostLogoutRedirectURI) {
+		return "", validations.ErrOAuthRedirectURIInvalid
+	}
+
+	return appendQuery(req.PostLogoutRedirectURI, url.Values{}, req.State), nil
+}
+
+// authenticateClient identifica al cliente. Los confidenciales deben enviar su secreto; los públicos no tienen.
+func (s *oauthService) authenticateClient(ctx context.Context, clientID, clientSecret string) (*models.OAuthClient, error) {
+	invalidClient := validations.NewOAuthError(validations.OAUTH_INVALID_CLIENT, "client authentication failed")
+	if clientID == "" {
+		return nil, invalidClient
+	}
+
+	client, err := s.clientRepo.GetClient(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, invalidClient
+		}
+		return nil, err
+	}
+
+	if client.IsPublic() {
+		if clientSecret != "" {
+			return nil, invalidClient
+		}
+		return client, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(security.HashToken(clientSecret))) != 1 {
+		return nil, invalidClient
+	}
+	return client, nil
+}
+
+// newOAuthToken arma la respuesta. El refresh token solo se entrega si el cliente tiene ese grant,
+// y el ID token si se concedió el scope openid.
+func (s *oauthService) newOAuthToken(oauthClient *models.OAuthClient, user *models.User, newTokens *tokens.Tokens, grant tokens.Grant, nonce string) (*OAuthToken, error) {
+	token := &OAuthToken{
+		AccessToken: newTokens.AccessToken,
+		Scope:       grant.Scope,
+		ExpiresIn:   ACCESS_DURATION * 3600,
+	}
+	if oauthClient.AllowsGrant(models.OAUTH_GRANT_REFRESH_TOKEN) {
+		token.RefreshToken = newTokens.RefreshToken
+	}
+
+	if hasScope(grant.Scope, models.OAUTH_SCOPE_OPENID) {
+		idToken, err := tokens.GenerateIDToken(user, tokens.IDTokenParams{
+			ClientID:  oauthClient.ID,
+			SessionID: newTokens.SessionID,
+			Nonce:     nonce,
+			Scope:     grant.Scope,
+			AuthTime:  grant.AuthTime,
+		}, OIDC_ID_TOKEN_DURATION)
+		if err != nil {
+			return nil, err
+		}
+		token.IDToken = idToken
+	}
+
+	return token, nil
+}
+
+// hasScope indica si la lista de scopes (separados por espacio) incluye el scope
+func hasScope(scopes, scope string) bool {
+	return slices.Contains(strings.Fields(scopes), scope)
+}
+
+// invalidGrant crea el error invalid_grant de /oauth/token
+func invalidGrant(description string) *validations.OAuthError {
+	return validations.NewOAuthError(validations.OAUTH_INVALID_GRANT, description)
+}
+
+// verifyCodeChallenge compara BASE64URL(SHA256(verifier)) con el challenge (RFC 7636, S256)
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// sameScopes indica si las dos listas de scopes (separados por espacio) tienen los mismos elementos
+func sameScopes(a, b string) bool {
+	first, second := strings.Fields(a), strings.Fields(b)
+	slices.Sort(first)
+	slices.Sort(second)
+	return slices.Equal(slices.Compact(first), slices.Compact(second))
+}
+
+// isValidRedirectURI acepta URIs absolutas sin fragmento. http solo se permite para localhost;
+// los esquemas propios de apps móviles (com.example.app:/callback) se aceptan.
+func isValidRedirectURI(uri string) bool {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme == "" || parsed.Fragment != "" || strings.Contains(uri, "#") {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+		return parsed.Host != ""
+	case "http":
+		host := parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	case "javascript", "data", "file":
+		return false
+	default:
+		return true
+	}
+}
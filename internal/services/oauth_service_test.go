@@ -0,0 +1,567 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories/memory"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	"myproject/pkg/totp"
+	"myproject/pkg/validations"
+)
+
+const (
+	testRedirectURI  = "https://app.example.com/callback"
+	testCodeVerifier = "dBjftJeZ4CVP-mJ92K9f8n3yMTb1ZoG5ulMvV4z9-2qWqA"
+)
+
+var oauthClientInfo = request.ClientInfo{UserAgent: "test", IP: "127.0.0.1"}
+
+func newOAuthTestService(env *testEnv) OAuthService {
+	return NewOAuthService(memory.NewOAuthClientRepository(), memory.NewAuthorizationCodeRepository(), env.userRepo, env.sessions)
+}
+
+// codeChallenge calcula el code_challenge S256 del verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// registerOAuthClient registra un cliente y retorna su ID y secreto (vacío si es público)
+func registerOAuthClient(t *testing.T, service OAuthService, req request.CreateOAuthClientRequest) (string, string) {
+	t.Helper()
+	client, secret, err := service.RegisterClient(context.Background(), req)
+	if err != nil {
+		t.Fatalf("register client: %v", err)
+	}
+	return client.ID, secret
+}
+
+// authorize ejecuta /oauth/authorize para el usuario con el scope profile y retorna el código de la redirección
+func authorize(t *testing.T, env *testEnv, service OAuthService, clientID, email string) string {
+	t.Helper()
+	return authorizeScope(t, env, service, clientID, email, "profile", "")
+}
+
+// authorizeScope es authorize con el scope y el nonce indicados
+func authorizeScope(t *testing.T, env *testEnv, service OAuthService, clientID, email, scope, nonce string) string {
+	t.Helper()
+	ctx := context.Background()
+	authorization, err := service.ValidateAuthorizeRequest(ctx, request.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         testRedirectURI,
+		Scope:               scope,
+		State:               "xyz",
+		CodeChallenge:       codeChallenge(testCodeVerifier),
+		CodeChallengeMethod: OAUTH_CODE_CHALLENGE_S256,
+		Nonce:               nonce,
+	})
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+
+	user, err := env.sessions.Authenticate(ctx, email, testPassword, "", oauthClientInfo)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+
+	redirect, err := service.IssueAuthorizationCode(ctx, authorization, user)
+	if err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+
+	parsed, _ := url.Parse(redirect)
+	if parsed.Host != "app.example.com" || parsed.Query().Get("state") != "xyz" || parsed.Query().Get("code") == "" {
+		t.Fatalf("unexpected redirect: %s", redirect)
+	}
+	return parsed.Query().Get("code")
+}
+
+// mustOAuthError verifica que err sea un *validations.OAuthError con el código esperado
+func mustOAuthError(t *testing.T, err error, code string) {
+	t.Helper()
+	var oauthErr *validations.OAuthError
+	if !errors.As(err, &oauthErr) || oauthErr.Code != code {
+		t.Fatalf("error = %v, want OAuth error %s", err, code)
+	}
+}
+
+var publicClientRequest = request.CreateOAuthClientRequest{
+	Name:         "SPA",
+	RedirectURIs: []string{testRedirectURI},
+	Scopes:       []string{"profile", "email"},
+	Public:       true,
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	clientID, secret := registerOAuthClient(t, service, publicClientRequest)
+	if secret != "" {
+		t.Fatal("public client got a secret")
+	}
+
+	code := authorize(t, env, service, clientID, "juan@example.com")
+	token, err := service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_AUTHORIZATION_CODE,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if token.AccessToken == "" || token.RefreshToken == "" || token.Scope != "profile" || token.ExpiresIn <= 0 {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+
+	claims, err := env.sessions.ValidateAccessToken(ctx, token.AccessToken)
+	if err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+	if claims.ClientID != clientID || claims.Scope != "profile" {
+		t.Fatalf("unexpected claims: client_id = %q, scope = %q", claims.ClientID, claims.Scope)
+	}
+
+	// El refresh token se renueva en /oauth/token y conserva el scope
+	refreshed, err := service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_REFRESH_TOKEN,
+		RefreshToken: token.RefreshToken,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if refreshed.Scope != "profile" || refreshed.RefreshToken == token.RefreshToken {
+		t.Fatalf("unexpected refreshed token: %+v", refreshed)
+	}
+
+	// ...pero no en /auth/refresh-token
+	if _, err := env.sessions.RefreshToken(ctx, refreshed.RefreshToken, oauthClientInfo); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("/auth/refresh-token: error = %v, want ErrInvalidToken", err)
+	}
+
+	// El código sirve una sola vez, y reutilizarlo revoca la sesión abierta con él
+	_, err = service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_AUTHORIZATION_CODE,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	_, err = service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_REFRESH_TOKEN,
+		RefreshToken: refreshed.RefreshToken,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+}
+
+func TestOAuthPKCEVerifierMismatch(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	clientID, _ := registerOAuthClient(t, service, publicClientRequest)
+
+	code := authorize(t, env, service, clientID, "juan@example.com")
+	tokenReq := request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_AUTHORIZATION_CODE,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: "otro-verifier-de-al-menos-cuarenta-y-tres-caracteres",
+		ClientID:     clientID,
+	}
+	_, err := service.Token(ctx, tokenReq, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	// Otra redirect_uri tampoco sirve
+	tokenReq.CodeVerifier = testCodeVerifier
+	tokenReq.RedirectURI = "https://evil.example.com/callback"
+	_, err = service.Token(ctx, tokenReq, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	// Ni omitirla, porque se envió en /oauth/authorize (RFC 6749 §4.1.3)
+	tokenReq.RedirectURI = ""
+	_, err = service.Token(ctx, tokenReq, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	// Ni otro cliente
+	otherID, _ := registerOAuthClient(t, service, publicClientRequest)
+	tokenReq.RedirectURI = testRedirectURI
+	tokenReq.ClientID = otherID
+	_, err = service.Token(ctx, tokenReq, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	// El código sigue siendo válido para su cliente con el verifier correcto
+	tokenReq.ClientID = clientID
+	if _, err := service.Token(ctx, tokenReq, oauthClientInfo); err != nil {
+		t.Fatalf("token: %v", err)
+	}
+}
+
+func TestOAuthRefreshTokenClientMismatch(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	clientID, _ := registerOAuthClient(t, service, publicClientRequest)
+	otherID, _ := registerOAuthClient(t, service, publicClientRequest)
+
+	code := authorize(t, env, service, clientID, "juan@example.com")
+	token, err := service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_AUTHORIZATION_CODE,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+
+	_, err = service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_REFRESH_TOKEN,
+		RefreshToken: token.RefreshToken,
+		ClientID:     otherID,
+	}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	// Un refresh token propio (de /auth/login) no se acepta en /oauth/token
+	own := env.login(t, "juan@example.com")
+	_, err = service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_REFRESH_TOKEN,
+		RefreshToken: own.RefreshToken,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+}
+
+func TestOAuthClientCredentials(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	clientID, secret := registerOAuthClient(t, service, request.CreateOAuthClientRequest{
+		Name:       "Backend",
+		Scopes:     []string{"reports:read", "reports:write"},
+		GrantTypes: []string{models.OAUTH_GRANT_CLIENT_CREDENTIALS},
+	})
+	if secret == "" {
+		t.Fatal("confidential client without secret")
+	}
+
+	token, err := service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_CLIENT_CREDENTIALS,
+		Scope:        "reports:read",
+		ClientID:     clientID,
+		ClientSecret: secret,
+	}, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	if token.RefreshToken != "" || token.Scope != "reports:read" {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+
+	claims, err := env.sessions.ValidateAccessToken(ctx, token.AccessToken)
+	if err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+	if !claims.IsClientToken() || claims.Subject != clientID {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	// Secreto incorrecto, scope no permitido y grant no habilitado
+	_, err = service.Token(ctx, request.OAuthTokenRequest{GrantType: models.OAUTH_GRANT_CLIENT_CREDENTIALS, ClientID: clientID, ClientSecret: "wrong"}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_CLIENT)
+	_, err = service.Token(ctx, request.OAuthTokenRequest{GrantType: models.OAUTH_GRANT_CLIENT_CREDENTIALS, Scope: "admin", ClientID: clientID, ClientSecret: secret}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_SCOPE)
+	_, err = service.Token(ctx, request.OAuthTokenRequest{GrantType: models.OAUTH_GRANT_REFRESH_TOKEN, RefreshToken: "x", ClientID: clientID, ClientSecret: secret}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_UNAUTHORIZED_CLIENT)
+	_, err = service.Token(ctx, request.OAuthTokenRequest{GrantType: "password", ClientID: clientID, ClientSecret: secret}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_UNSUPPORTED_GRANT_TYPE)
+}
+
+func TestOAuthRegisterClientValidation(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		req  request.CreateOAuthClientRequest
+		want error
+	}{
+		{"without name", request.CreateOAuthClientRequest{RedirectURIs: []string{testRedirectURI}}, validations.ErrOAuthClientNameRequired},
+		{"without redirect uri", request.CreateOAuthClientRequest{Name: "App"}, validations.ErrOAuthRedirectURIInvalid},
+		{"http redirect uri", request.CreateOAuthClientRequest{Name: "App", RedirectURIs: []string{"http://app.example.com/cb"}}, validations.ErrOAuthRedirectURIInvalid},
+		{"redirect uri with fragment", request.CreateOAuthClientRequest{Name: "App", RedirectURIs: []string{testRedirectURI + "#x"}}, validations.ErrOAuthRedirectURIInvalid},
+		{"relative redirect uri", request.CreateOAuthClientRequest{Name: "App", RedirectURIs: []string{"/callback"}}, validations.ErrOAuthRedirectURIInvalid},
+		{"public client credentials", request.CreateOAuthClientRequest{Name: "App", Public: true, GrantTypes: []string{models.OAUTH_GRANT_CLIENT_CREDENTIALS}}, validations.ErrOAuthGrantTypeInvalid},
+		{"unknown grant", request.CreateOAuthClientRequest{Name: "App", GrantTypes: []string{"password"}}, validations.ErrOAuthGrantTypeInvalid},
+		{"scope with spaces", request.CreateOAuthClientRequest{Name: "App", RedirectURIs: []string{testRedirectURI}, Scopes: []string{"a b"}}, validations.ErrOAuthScopeInvalid},
+	}
+	for _, tc := range cases {
+		if _, _, err := service.RegisterClient(ctx, tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%s: error = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// localhost y los esquemas de apps móviles se aceptan
+	registerOAuthClient(t, service, request.CreateOAuthClientRequest{
+		Name:         "Mobile",
+		Public:       true,
+		RedirectURIs: []string{"http://localhost:3000/callback", "com.example.app:/oauth"},
+	})
+}
+
+func TestOAuthAuthorizeRequestValidation(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	clientID, _ := registerOAuthClient(t, service, publicClientRequest)
+	valid := request.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            clientID,
+		RedirectURI:         testRedirectURI,
+		CodeChallenge:       codeChallenge(testCodeVerifier),
+		CodeChallengeMethod: OAUTH_CODE_CHALLENGE_S256,
+	}
+
+	// Sin scope se conceden todos los del cliente; la redirect_uri única puede omitirse
+	req := valid
+	req.RedirectURI = ""
+	authorization, err := service.ValidateAuthorizeRequest(ctx, req)
+	if err != nil || authorization.Scope != "profile email" || authorization.RedirectURI != testRedirectURI {
+		t.Fatalf("authorization = %+v, err = %v", authorization, err)
+	}
+
+	// Errores que no se redirigen
+	req = valid
+	req.ClientID = "unknown"
+	if _, err := service.ValidateAuthorizeRequest(ctx, req); !errors.Is(err, validations.ErrOAuthClientNotFound) {
+		t.Fatalf("unknown client: error = %v", err)
+	}
+	req = valid
+	req.RedirectURI = "https://evil.example.com/callback"
+	if _, err := service.ValidateAuthorizeRequest(ctx, req); !errors.Is(err, validations.ErrOAuthRedirectURIInvalid) {
+		t.Fatalf("unregistered redirect uri: error = %v", err)
+	}
+
+	// Errores que se informan en la redirect_uri
+	cases := []struct {
+		name   string
+		modify func(*request.AuthorizeRequest)
+		code   string
+	}{
+		{"token response type", func(r *request.AuthorizeRequest) { r.ResponseType = "token" }, validations.OAUTH_UNSUPPORTED_RESPONSE_TYPE},
+		{"without pkce", func(r *request.AuthorizeRequest) { r.CodeChallenge = "" }, validations.OAUTH_INVALID_REQUEST},
+		{"plain pkce", func(r *request.AuthorizeRequest) { r.CodeChallengeMethod = "plain" }, validations.OAUTH_INVALID_REQUEST},
+		{"scope not allowed", func(r *request.AuthorizeRequest) { r.Scope = "profile admin" }, validations.OAUTH_INVALID_SCOPE},
+	}
+	for _, tc := range cases {
+		req := valid
+		req.State = "abc"
+		tc.modify(&req)
+		authorization, err := service.ValidateAuthorizeRequest(ctx, req)
+		mustOAuthError(t, err, tc.code)
+
+		redirect, _ := url.Parse(authorization.ErrorRedirectURL(err.(*validations.OAuthError)))
+		if redirect.Query().Get("error") != tc.code || redirect.Query().Get("state") != "abc" {
+			t.Fatalf("%s: unexpected redirect %s", tc.name, redirect)
+		}
+	}
+}
+
+func TestAuthenticateRequiresMFACode(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	secret, _ := env.enableTOTP(t, "juan@example.com")
+
+	if _, err := env.sessions.Authenticate(ctx, "juan@example.com", testPassword, "", oauthClientInfo); !errors.Is(err, validations.ErrMFARequired) {
+		t.Fatalf("without code: error = %v, want ErrMFARequired", err)
+	}
+	if _, err := env.sessions.Authenticate(ctx, "juan@example.com", testPassword, "000000", oauthClientInfo); !errors.Is(err, validations.ErrInvalidMFACode) {
+		t.Fatalf("wrong code: error = %v, want ErrInvalidMFACode", err)
+	}
+	if _, err := env.sessions.Authenticate(ctx, "juan@example.com", "incorrecta", "", oauthClientInfo); !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("wrong password: error = %v, want ErrInvalidCredentials", err)
+	}
+
+	code, _ := totp.Code(secret, totp.Step(time.Now()))
+	user, err := env.sessions.Authenticate(ctx, "juan@example.com", testPassword, code, oauthClientInfo)
+	if err != nil || user.ContactInfo.Email.Address != "juan@example.com" {
+		t.Fatalf("authenticate: user = %+v, err = %v", user, err)
+	}
+}
+
+var oidcClientRequest = request.CreateOAuthClientRequest{
+	Name:                   "Integración",
+	RedirectURIs:           []string{testRedirectURI},
+	Scopes:                 []string{"openid", "profile", "email"},
+	Public:                 true,
+	PostLogoutRedirectURIs: []string{"https://app.example.com/logged-out"},
+}
+
+// oidcLogin completa el flujo authorization code con el scope indicado y retorna los tokens
+func oidcLogin(t *testing.T, env *testEnv, service OAuthService, clientID, scope, nonce string) *OAuthToken {
+	t.Helper()
+	code := authorizeScope(t, env, service, clientID, "juan@example.com", scope, nonce)
+	token, err := service.Token(context.Background(), request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_AUTHORIZATION_CODE,
+		Code:         code,
+		RedirectURI:  testRedirectURI,
+		CodeVerifier: testCodeVerifier,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("token: %v", err)
+	}
+	return token
+}
+
+func TestOIDCIDToken(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	clientID, _ := registerOAuthClient(t, service, oidcClientRequest)
+
+	before := time.Now().Add(-time.Second)
+	token := oidcLogin(t, env, service, clientID, "openid email", "n-0S6_WzA2Mj")
+	if token.IDToken == "" {
+		t.Fatal("missing id_token")
+	}
+
+	claims, err := tokens.ParseIDTokenHint(token.IDToken)
+	if err != nil {
+		t.Fatalf("parse id token: %v", err)
+	}
+	if claims.Audience[0] != clientID || claims.Nonce != "n-0S6_WzA2Mj" || claims.SessionID == "" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if claims.AuthTime == nil || claims.AuthTime.Time.Before(before.Truncate(time.Second)) {
+		t.Fatalf("unexpected auth_time: %v", claims.AuthTime)
+	}
+	// email sí (y sin verificar), el nombre no porque no se pidió profile
+	if claims.Email != "juan@example.com" || claims.EmailVerified == nil || *claims.EmailVerified || claims.Name != "" {
+		t.Fatalf("unexpected user claims: %+v", claims.UserClaims)
+	}
+
+	// Un ID token no sirve como access token
+	if _, err := env.sessions.ValidateAccessToken(ctx, token.IDToken); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("id token as access token: error = %v, want ErrInvalidToken", err)
+	}
+
+	// Al renovar se emite un ID token nuevo con el mismo auth_time y sin nonce
+	refreshed, err := service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_REFRESH_TOKEN,
+		RefreshToken: token.RefreshToken,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	refreshedClaims, err := tokens.ParseIDTokenHint(refreshed.IDToken)
+	if err != nil {
+		t.Fatalf("parse refreshed id token: %v", err)
+	}
+	if refreshedClaims.Nonce != "" || !refreshedClaims.AuthTime.Equal(claims.AuthTime.Time) || refreshedClaims.SessionID != claims.SessionID {
+		t.Fatalf("unexpected refreshed claims: %+v", refreshedClaims)
+	}
+
+	// Sin openid no hay ID token
+	if token := oidcLogin(t, env, service, clientID, "profile", ""); token.IDToken != "" {
+		t.Fatal("id_token issued without the openid scope")
+	}
+}
+
+func TestOIDCUserInfo(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	clientID, _ := registerOAuthClient(t, service, oidcClientRequest)
+
+	token := oidcLogin(t, env, service, clientID, "openid profile", "")
+	claims, err := env.sessions.ValidateAccessToken(ctx, token.AccessToken)
+	if err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+
+	user, err := service.UserInfo(ctx, claims)
+	if err != nil {
+		t.Fatalf("userinfo: %v", err)
+	}
+	info := tokens.NewUserClaims(user, claims.Scope)
+	if info.Name != "Juan Pérez" || info.GivenName != "Juan" || info.FamilyName != "Pérez" || info.Email != "" || info.EmailVerified != nil {
+		t.Fatalf("unexpected userinfo: %+v", info)
+	}
+
+	// Sin el scope openid, /userinfo no responde
+	withoutOpenID := oidcLogin(t, env, service, clientID, "profile email", "")
+	claims, _ = env.sessions.ValidateAccessToken(ctx, withoutOpenID.AccessToken)
+	if _, err := service.UserInfo(ctx, claims); !errors.Is(err, validations.ErrInsufficientScope) {
+		t.Fatalf("without openid: error = %v, want ErrInsufficientScope", err)
+	}
+
+	// Ni con un token propio de la API
+	own := env.login(t, "juan@example.com")
+	claims, _ = env.sessions.ValidateAccessToken(ctx, own.AccessToken)
+	if _, err := service.UserInfo(ctx, claims); !errors.Is(err, validations.ErrInsufficientScope) {
+		t.Fatalf("first-party token: error = %v, want ErrInsufficientScope", err)
+	}
+}
+
+func TestOIDCEndSession(t *testing.T) {
+	env := newTestEnv(t)
+	service := newOAuthTestService(env)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	clientID, _ := registerOAuthClient(t, service, oidcClientRequest)
+	token := oidcLogin(t, env, service, clientID, "openid", "")
+
+	// La URI de retorno debe estar registrada
+	_, err := service.EndSession(ctx, request.EndSessionRequest{IDTokenHint: token.IDToken, PostLogoutRedirectURI: "https://evil.example.com/"})
+	if !errors.Is(err, validations.ErrOAuthRedirectURIInvalid) {
+		t.Fatalf("unregistered uri: error = %v, want ErrOAuthRedirectURIInvalid", err)
+	}
+	if _, err := service.EndSession(ctx, request.EndSessionRequest{IDTokenHint: token.AccessToken}); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("access token as hint: error = %v, want ErrInvalidToken", err)
+	}
+
+	redirect, err := service.EndSession(ctx, request.EndSessionRequest{
+		IDTokenHint:           token.IDToken,
+		PostLogoutRedirectURI: "https://app.example.com/logged-out",
+		State:                 "abc",
+	})
+	if err != nil || redirect != "https://app.example.com/logged-out?state=abc" {
+		t.Fatalf("redirect = %q, err = %v", redirect, err)
+	}
+
+	// La sesión quedó cerrada: el refresh token ya no sirve
+	_, err = service.Token(ctx, request.OAuthTokenRequest{
+		GrantType:    models.OAUTH_GRANT_REFRESH_TOKEN,
+		RefreshToken: token.RefreshToken,
+		ClientID:     clientID,
+	}, oauthClientInfo)
+	mustOAuthError(t, err, validations.OAUTH_INVALID_GRANT)
+
+	// Cerrar de nuevo la misma sesión no es un error
+	if _, err := service.EndSession(ctx, request.EndSessionRequest{IDTokenHint: token.IDToken}); err != nil {
+		t.Fatalf("second end session: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/consts"
+	"myproject/pkg/validations"
+)
+
+// OrganizationService encapsula la lógica de las organizaciones (empresas) y sus miembros.
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, organization *models.Organization) error
+	ListOrganizations(ctx context.Context, userID string) ([]UserOrganization, error)
+	GetMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error)
+}
+
+// UserOrganization es una organización a la que pertenece el usuario, con su rol en ella.
+type UserOrganization struct {
+	Organization models.Organization
+	Role         string
+}
+
+type organizationService struct {
+	organizationRepo repositories.OrganizationRepository
+	membershipRepo   repositories.MembershipRepository
+}
+
+// NewOrganizationService crea una nueva instancia de OrganizationService.
+func NewOrganizationService(organizationRepo repositories.OrganizationRepository, membershipRepo repositories.MembershipRepository) OrganizationService {
+	return &organizationService{
+		organizationRepo: organizationRepo,
+		membershipRepo:   membershipRepo,
+	}
+}
+
+// CreateOrganization guarda la organización y agrega a su dueño como miembro con ROLE_OWNER.
+func (s *organizationService) CreateOrganization(ctx context.Context, organization *models.Organization) error {
+	if err := s.organizationRepo.CreateOrganization(ctx, organization); err != nil {
+		return err
+	}
+
+	// Sin la membresía del dueño la organización quedaría inaccesible: se elimina
+	if err := s.membershipRepo.CreateMembership(ctx, models.NewMembership(organization.ID, organization.OwnerID, consts.ROLE_OWNER)); err != nil {
+		if deleteErr := s.organizationRepo.DeleteOrganization(ctx, organization.ID); deleteErr != nil {
+			log.Printf("CreateOrganization: error eliminando la organización %s sin dueño: %v", organization.ID, deleteErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListOrganizations lista las organizaciones del usuario, de la más antigua a la más reciente.
+func (s *organizationService) ListOrganizations(ctx context.Context, userID string) ([]UserOrganization, error) {
+	memberships, err := s.membershipRepo.ListMembershipsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations := make([]UserOrganization, 0, len(memberships))
+	for _, membership := range memberships {
+		organization, err := s.organizationRepo.GetOrganization(ctx, membership.OrganizationID)
+		if err != nil {
+			// Una membresía sin organización (por ejemplo, si falló el alta) no se lista
+			if errors.Is(err, validations.ErrDocumentNotFound) {
+				log.Printf("ListOrganizations: la organización %s de %s no existe", membership.OrganizationID, userID)
+				continue
+			}
+			return nil, err
+		}
+		organizations = append(organizations, UserOrganization{Organization: *organization, Role: membership.Role})
+	}
+
+	return organizations, nil
+}
+
+// GetMembership retorna la membresía del usuario en la organización. Si no es miembro retorna
+// validations.ErrCompanyNotFound, sin distinguir una organización ajena de una inexistente.
+func (s *organizationService) GetMembership(ctx context.Context, organizationID, userID string) (*models.Membership, error) {
+	membership, err := s.membershipRepo.GetMembership(ctx, organizationID, userID)
+	if errors.Is(err, validations.ErrDocumentNotFound) {
+		return nil, validations.ErrCompanyNotFound
+	}
+
+	return membership, err
+}
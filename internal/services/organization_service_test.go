@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/memory"
+	"myproject/pkg/consts"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+)
+
+func TestRegisterWithCompanyCreatesOrganization(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	err := env.sessions.Register(ctx, request.RegisterUserRequest{
+		Name: "Juan", LastName: "Pérez", Email: "juan@example.com", Password: testPassword, CompanyName: " La Espiga ",
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	organizations, err := env.organizations.ListOrganizations(ctx, user.ID)
+	if err != nil || len(organizations) != 1 {
+		t.Fatalf("organizations = %+v, err = %v", organizations, err)
+	}
+	organization := organizations[0]
+	if organization.Organization.Name != "La Espiga" || organization.Organization.OwnerID != user.ID || organization.Role != consts.ROLE_OWNER {
+		t.Fatalf("unexpected organization: %+v", organization)
+	}
+
+	// El login emite tokens para la organización, con el rol de la membresía y no el global
+	tokens := env.login(t, "juan@example.com")
+	claims, err := env.sessions.ValidateAccessToken(ctx, tokens.AccessToken)
+	if err != nil || claims.OrganizationID != organization.Organization.ID || claims.Role != consts.ROLE_OWNER {
+		t.Fatalf("claims = %+v, err = %v", claims, err)
+	}
+
+	refreshed, err := env.sessions.RefreshToken(ctx, tokens.RefreshToken, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	claims, _ = env.sessions.ValidateAccessToken(ctx, refreshed.AccessToken)
+	if claims.OrganizationID != organization.Organization.ID {
+		t.Fatalf("refreshed token org = %q, want %q", claims.OrganizationID, organization.Organization.ID)
+	}
+
+	// Un nombre de empresa inválido no deja el usuario registrado a medias
+	err = env.sessions.Register(ctx, request.RegisterUserRequest{
+		Name: "Ana", LastName: "Gómez", Email: "ana@example.com", Password: testPassword, CompanyName: "<script>",
+	})
+	if !errors.Is(err, validations.ErrInvalidCompanyName) {
+		t.Fatalf("invalid company: error = %v, want ErrInvalidCompanyName", err)
+	}
+	if _, err := env.userRepo.GetUserByEmail(ctx, "ana@example.com"); !errors.Is(err, validations.ErrDocumentNotFound) {
+		t.Fatalf("user registered with an invalid company: %v", err)
+	}
+}
+
+// failingMembershipRepository simula que las próximas `failures` membresías no se pueden guardar
+type failingMembershipRepository struct {
+	repositories.MembershipRepository
+	failures int
+}
+
+func (r *failingMembershipRepository) CreateMembership(ctx context.Context, membership *models.Membership) error {
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("membership store unavailable")
+	}
+	return r.MembershipRepository.CreateMembership(ctx, membership)
+}
+
+// useMembershipRepository reconstruye los servicios del entorno sobre membershipRepo
+func (env *testEnv) useMembershipRepository(membershipRepo repositories.MembershipRepository) {
+	organizationRepo := memory.NewOrganizationRepository()
+	env.organizations = NewOrganizationService(organizationRepo, membershipRepo)
+	env.invitations = NewInvitationService(env.userRepo, memory.NewInvitationRepository(), organizationRepo, membershipRepo, env.notifier)
+	env.sessions = NewSessionService(env.userRepo, env.refreshTokenRepo, memory.NewRevokedTokenRepository(), env.sessionRepo, env.verification, env.lockout, env.mfa, env.organizations)
+}
+
+func TestCreateOrganizationWithoutOwnerMembership(t *testing.T) {
+	ctx := context.Background()
+	organizationRepo := memory.NewOrganizationRepository()
+	service := NewOrganizationService(organizationRepo, &failingMembershipRepository{MembershipRepository: memory.NewMembershipRepository(), failures: 1})
+
+	organization, _ := models.NewOrganization("La Espiga", "user-1")
+	if err := service.CreateOrganization(ctx, organization); err == nil {
+		t.Fatal("organization created without the owner membership")
+	}
+	if _, err := organizationRepo.GetOrganization(ctx, organization.ID); !errors.Is(err, validations.ErrDocumentNotFound) {
+		t.Fatalf("organization without owner left behind: %v", err)
+	}
+}
+
+func TestRegisterWithCompanyFailure(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.useMembershipRepository(&failingMembershipRepository{MembershipRepository: memory.NewMembershipRepository(), failures: 1})
+
+	err := env.sessions.Register(ctx, request.RegisterUserRequest{
+		Name: "Juan", LastName: "Pérez", Email: "juan@example.com", Password: testPassword, CompanyName: "La Espiga",
+	})
+	if err == nil {
+		t.Fatal("register succeeded without the membership")
+	}
+
+	// El usuario queda sin organización activa y puede iniciar sesión con su rol global
+	user, err := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if err != nil {
+		t.Fatalf("user not stored: %v", err)
+	}
+	if user.ActiveOrganizationID != "" {
+		t.Fatalf("active organization = %q, want none", user.ActiveOrganizationID)
+	}
+	claims, err := env.sessions.ValidateAccessToken(ctx, env.login(t, "juan@example.com").AccessToken)
+	if err != nil || claims.OrganizationID != "" {
+		t.Fatalf("claims = %+v, err = %v", claims, err)
+	}
+}
+
+func TestSwitchOrganization(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	env.register(t, "ana@example.com")
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	other, _ := env.userRepo.GetUserByEmail(ctx, "ana@example.com")
+
+	organization, _ := models.NewOrganization("La Espiga", user.ID)
+	foreign, _ := models.NewOrganization("El Molino", other.ID)
+	for _, org := range []*models.Organization{organization, foreign} {
+		if err := env.organizations.CreateOrganization(ctx, org); err != nil {
+			t.Fatalf("create organization: %v", err)
+		}
+	}
+
+	// Sin organización activa los tokens llevan el rol global
+	tokens := env.login(t, "juan@example.com")
+	claims, _ := env.sessions.ValidateAccessToken(ctx, tokens.AccessToken)
+	if claims.OrganizationID != "" || claims.Role != consts.ROLE_CLIENT {
+		t.Fatalf("unscoped claims = %+v", claims)
+	}
+
+	if _, err := env.sessions.SwitchOrganization(ctx, claims, foreign.ID, oauthClientInfo); !errors.Is(err, validations.ErrCompanyNotFound) {
+		t.Fatalf("foreign organization: error = %v, want ErrCompanyNotFound", err)
+	}
+
+	switched, err := env.sessions.SwitchOrganization(ctx, claims, organization.ID, oauthClientInfo)
+	if err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	switchedClaims, err := env.sessions.ValidateAccessToken(ctx, switched.AccessToken)
+	if err != nil || switchedClaims.OrganizationID != organization.ID || switchedClaims.Role != consts.ROLE_OWNER {
+		t.Fatalf("switched claims = %+v, err = %v", switchedClaims, err)
+	}
+
+	// La sesión anterior queda cerrada
+	if _, err := env.sessions.ValidateAccessToken(ctx, tokens.AccessToken); err == nil {
+		t.Fatal("previous access token still valid")
+	}
+	if _, err := env.sessions.RefreshToken(ctx, tokens.RefreshToken, oauthClientInfo); err == nil {
+		t.Fatal("previous refresh token still valid")
+	}
+
+	// Y la organización queda activa para los próximos logins
+	tokens = env.login(t, "juan@example.com")
+	claims, _ = env.sessions.ValidateAccessToken(ctx, tokens.AccessToken)
+	if claims.OrganizationID != organization.ID {
+		t.Fatalf("next login org = %q, want %q", claims.OrganizationID, organization.ID)
+	}
+}
+
+func TestRegisterWithCompanySendsVerification(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	err := env.sessions.Register(ctx, request.RegisterUserRequest{
+		Name: "Juan", LastName: "Pérez", Email: "juan@example.com", Password: testPassword, CompanyName: "La Espiga",
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// El envío queda registrado junto con la organización activa
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	email := user.ContactInfo.Email
+	if !email.IsSentForVerify || email.SentAt.IsZero() || user.ActiveOrganizationID == "" {
+		t.Fatalf("unexpected user: active organization = %q, email = %+v", user.ActiveOrganizationID, email)
+	}
+	if len(env.notifier.links["juan@example.com"]) != 1 {
+		t.Fatal("verification email not sent")
+	}
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	security "myproject/pkg/session"
+	"myproject/pkg/validations"
+)
+
+// RESET_TOKEN_DURATION es la vigencia en horas del link de restablecimiento
+const RESET_TOKEN_DURATION = 1
+
+// RESET_TOKEN_BYTES es la entropía del token de restablecimiento
+const RESET_TOKEN_BYTES = 32
+
+// PasswordService encapsula la lógica de recuperación de contraseña.
+type PasswordService interface {
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token, password string) error
+}
+
+type passwordService struct {
+	userRepo          repositories.UserRepository
+	passwordResetRepo repositories.PasswordResetRepository
+	sessionService    SessionService
+	notifier          Notifier
+}
+
+// NewPasswordService crea una nueva instancia de PasswordService.
+func NewPasswordService(
+	userRepo repositories.UserRepository,
+	passwordResetRepo repositories.PasswordResetRepository,
+	sessionService SessionService,
+	notifier Notifier,
+) PasswordService {
+	return &passwordService{
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		sessionService:    sessionService,
+		notifier:          notifier,
+	}
+}
+
+// ForgotPassword genera un token de un solo uso y envía el link de restablecimiento.
+// Nunca informa si el email existe: ante un email desconocido o un fallo de envío responde igual.
+func (s *passwordService) ForgotPassword(ctx context.Context, email string) error {
+	// 1. Buscar usuario por email
+	user, err := s.userRepo.GetUserByEmail(ctx, validations.NormalizeEmail(email))
+	if err != nil {
+		if !errors.Is(err, validations.ErrDocumentNotFound) {
+			log.Printf("ForgotPassword: error buscando usuario: %v", err)
+		}
+		return nil
+	}
+
+	// 2. Generar el token y guardar solo su hash
+	token, err := security.GenerateRandomToken(RESET_TOKEN_BYTES)
+	if err != nil {
+		return err
+	}
+
+	reset := models.NewPasswordReset(security.HashToken(token), user.ID, time.Now().Add(time.Hour*RESET_TOKEN_DURATION))
+	if err := s.passwordResetRepo.CreatePasswordReset(ctx, reset); err != nil {
+		return err
+	}
+
+	// 3. Enviar el link
+	link := getAppURL() + "/reset-password?link=" + url.QueryEscape(token)
+	if err := s.notifier.SendPasswordReset(ctx, user, link); err != nil {
+		log.Printf("ForgotPassword: error enviando email a %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword valida el token, cambia la contraseña y cierra todas las sesiones del usuario.
+func (s *passwordService) ResetPassword(ctx context.Context, token, password string) error {
+	// 1. Validar el token
+	tokenHash := security.HashToken(token)
+	reset, err := s.passwordResetRepo.GetPasswordReset(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return validations.ErrInvalidToken
+		}
+		return err
+	}
+
+	if !reset.IsUsable() {
+		return validations.ErrInvalidToken
+	}
+
+	// 2. Validar la nueva contraseña antes de consumir el token
+	hashedPassword, err := security.ValidateAndHashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	// 3. Consumir el token (falla si otra petición lo usó al mismo tiempo)
+	if err := s.passwordResetRepo.MarkPasswordResetUsed(ctx, tokenHash); err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return validations.ErrInvalidToken
+		}
+		return err
+	}
+
+	// 4. Actualizar la contraseña
+	if err := s.userRepo.UpdatePassword(ctx, reset.UserID, *hashedPassword); err != nil {
+		return err
+	}
+
+	// 5. Cerrar todas las sesiones abiertas con la contraseña anterior
+	return s.sessionService.LogoutAll(ctx, reset.UserID)
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"os"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	security "myproject/pkg/session"
+	"myproject/pkg/validations"
+)
+
+// Valores por defecto de la política de login sin contraseña
+const (
+	DEFAULT_MAGIC_LINK_TTL_MINUTES      = 15 // vigencia del magic link
+	DEFAULT_EMAIL_OTP_TTL_MINUTES       = 10 // vigencia del código enviado por email
+	DEFAULT_EMAIL_LOGIN_MAX_ATTEMPTS    = 5  // intentos de canje por link o código
+	DEFAULT_PASSWORDLESS_RESEND_SECONDS = 60 // tiempo mínimo entre dos envíos al mismo email
+)
+
+// EMAIL_OTP_DIGITS es la cantidad de dígitos del código enviado por email
+const EMAIL_OTP_DIGITS = 6
+
+// PasswordlessPolicy define la vigencia, los intentos y el reenvío de los magic links y códigos por email.
+type PasswordlessPolicy struct {
+	MagicLinkTTL time.Duration
+	EmailOTPTTL  time.Duration
+	MaxAttempts  int
+	ResendAfter  time.Duration
+	// AutoRegister crea la cuenta al canjear un link o código enviado a un email sin usuario
+	AutoRegister bool
+}
+
+// LoadPasswordlessPolicy lee la política desde variables de entorno, con los valores por defecto.
+func LoadPasswordlessPolicy() PasswordlessPolicy {
+	return PasswordlessPolicy{
+		MagicLinkTTL: time.Duration(getEnvInt("MAGIC_LINK_TTL_MINUTES", DEFAULT_MAGIC_LINK_TTL_MINUTES)) * time.Minute,
+		EmailOTPTTL:  time.Duration(getEnvInt("EMAIL_OTP_TTL_MINUTES", DEFAULT_EMAIL_OTP_TTL_MINUTES)) * time.Minute,
+		MaxAttempts:  getEnvInt("EMAIL_LOGIN_MAX_ATTEMPTS", DEFAULT_EMAIL_LOGIN_MAX_ATTEMPTS),
+		ResendAfter:  time.Duration(getEnvInt("PASSWORDLESS_RESEND_SECONDS", DEFAULT_PASSWORDLESS_RESEND_SECONDS)) * time.Second,
+		AutoRegister: os.Getenv("PASSWORDLESS_AUTO_REGISTER") == "true",
+	}
+}
+
+// PasswordlessService encapsula el login sin contraseña: magic links y códigos de un solo uso por email.
+type PasswordlessService interface {
+	RequestMagicLink(ctx context.Context, email string) error
+	RedeemMagicLink(ctx context.Context, token string, client request.ClientInfo) (*LoginResult, error)
+	RequestEmailOTP(ctx context.Context, email string) error
+	VerifyEmailOTP(ctx context.Context, email, code string, client request.ClientInfo) (*LoginResult, error)
+}
+
+type passwordlessService struct {
+	userRepo       repositories.UserRepository
+	emailLoginRepo repositories.EmailLoginRepository
+	sessionService SessionService
+	lockoutService LockoutService
+	notifier       Notifier
+	policy         PasswordlessPolicy
+}
+
+// NewPasswordlessService crea una nueva instancia de PasswordlessService.
+func NewPasswordlessService(
+	userRepo repositories.UserRepository,
+	emailLoginRepo repositories.EmailLoginRepository,
+	sessionService SessionService,
+	lockoutService LockoutService,
+	notifier Notifier,
+	policy PasswordlessPolicy,
+) PasswordlessService {
+	return &passwordlessService{
+		userRepo:       userRepo,
+		emailLoginRepo: emailLoginRepo,
+		sessionService: sessionService,
+		lockoutService: lockoutService,
+		notifier:       notifier,
+		policy:         policy,
+	}
+}
+
+// RequestMagicLink envía un link firmado de un solo uso. No informa si el email tiene cuenta.
+func (s *passwordlessService) RequestMagicLink(ctx context.Context, email string) error {
+	email = validations.NormalizeEmail(email)
+	if !validations.IsValidEmail(email) {
+		return validations.ErrInvalidEmail
+	}
+
+	user, send, err := s.prepareSend(ctx, models.EMAIL_LOGIN_MAGIC_LINK, email)
+	if err != nil || !send {
+		return err
+	}
+
+	token, err := tokens.GenerateJWTEmail(email, s.policy.MagicLinkTTL)
+	if err != nil {
+		return err
+	}
+
+	login := models.NewEmailLogin(models.EMAIL_LOGIN_MAGIC_LINK, email, security.HashToken(token), time.Now().Add(s.policy.MagicLinkTTL))
+	if err := s.emailLoginRepo.SaveEmailLogin(ctx, login); err != nil {
+		return err
+	}
+
+	link := getAppURL() + "/magic-link?token=" + url.QueryEscape(token)
+	return s.notifier.SendMagicLink(ctx, email, recipientName(user), link, s.policy.MagicLinkTTL)
+}
+
+// RedeemMagicLink canjea el link por una sesión (o por el challenge MFA, igual que Login).
+func (s *passwordlessService) RedeemMagicLink(ctx context.Context, token string, client request.ClientInfo) (*LoginResult, error) {
+	claims, err := tokens.ParseEmailToken(token)
+	if err != nil {
+		return nil, validations.ErrInvalidToken
+	}
+
+	return s.redeem(ctx, models.EMAIL_LOGIN_MAGIC_LINK, validations.NormalizeEmail(claims.Email), security.HashToken(token), client, validations.ErrInvalidToken)
+}
+
+// RequestEmailOTP envía un código numérico de un solo uso. No informa si el email tiene cuenta.
+func (s *passwordlessService) RequestEmailOTP(ctx context.Context, email string) error {
+	email = validations.NormalizeEmail(email)
+	if !validations.IsValidEmail(email) {
+		return validations.ErrInvalidEmail
+	}
+
+	user, send, err := s.prepareSend(ctx, models.EMAIL_LOGIN_OTP, email)
+	if err != nil || !send {
+		return err
+	}
+
+	code, err := generateEmailOTP()
+	if err != nil {
+		return err
+	}
+
+	login := models.NewEmailLogin(models.EMAIL_LOGIN_OTP, email, security.HashToken(code), time.Now().Add(s.policy.EmailOTPTTL))
+	if err := s.emailLoginRepo.SaveEmailLogin(ctx, login); err != nil {
+		return err
+	}
+
+	return s.notifier.SendEmailOTP(ctx, email, recipientName(user), code, s.policy.EmailOTPTTL)
+}
+
+// VerifyEmailOTP canjea el código por una sesión (o por el challenge MFA, igual que Login).
+func (s *passwordlessService) VerifyEmailOTP(ctx context.Context, email, code string, client request.ClientInfo) (*LoginResult, error) {
+	return s.redeem(ctx, models.EMAIL_LOGIN_OTP, validations.NormalizeEmail(email), security.HashToken(code), client, validations.ErrInvalidEmailOTP)
+}
+
+// prepareSend decide si corresponde enviar un nuevo link o código: no se envía a emails sin cuenta
+// (salvo con auto-registro), a cuentas inactivas ni antes de que pase el tiempo mínimo entre envíos.
+// El usuario retornado es nil si el email todavía no tiene cuenta.
+func (s *passwordlessService) prepareSend(ctx context.Context, method, email string) (*models.User, bool, error) {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, validations.ErrDocumentNotFound):
+		if !s.policy.AutoRegister {
+			return nil, false, nil
+		}
+		user = nil
+	case err != nil:
+		return nil, false, err
+	case !user.IsActive():
+		return nil, false, nil
+	}
+
+	previous, err := s.emailLoginRepo.GetEmailLogin(ctx, models.EmailLoginKey(method, email))
+	if err != nil && !errors.Is(err, validations.ErrDocumentNotFound) {
+		return nil, false, err
+	}
+	if err == nil && time.Since(previous.CreatedAt) < s.policy.ResendAfter {
+		return nil, false, nil
+	}
+
+	return user, true, nil
+}
+
+// redeem canjea el secreto de un login por email y continúa el login. Cada intento se cuenta antes
+// de comparar, de forma atómica, para que peticiones concurrentes no superen el máximo.
+// Los secretos incorrectos cuentan además para el bloqueo igual que las contraseñas.
+func (s *passwordlessService) redeem(ctx context.Context, method, email, secretHash string, client request.ClientInfo, invalidErr error) (*LoginResult, error) {
+	if err := s.lockoutService.CheckLogin(ctx, email, client.IP); err != nil {
+		return nil, err
+	}
+
+	// 1. Contar el intento; falla si no hay un link o código vigente o se agotaron los intentos
+	key := models.EmailLoginKey(method, email)
+	login, err := s.emailLoginRepo.RegisterEmailLoginAttempt(ctx, key, s.policy.MaxAttempts)
+	if err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return nil, invalidErr
+		}
+		return nil, err
+	}
+
+	// 2. Comparar en tiempo constante
+	if subtle.ConstantTimeCompare([]byte(login.SecretHash), []byte(secretHash)) != 1 {
+		if err := s.lockoutService.RegisterFailure(ctx, email, client.IP); err != nil {
+			log.Printf("redeem: error registrando intento fallido de %s: %v", email, err)
+		}
+		return nil, invalidErr
+	}
+
+	// 3. Marcarlo usado: de dos canjes simultáneos solo uno continúa
+	if err := s.emailLoginRepo.MarkEmailLoginUsed(ctx, key, secretHash); err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return nil, invalidErr
+		}
+		return nil, err
+	}
+
+	// 4. Recibir el secreto en el email prueba que la dirección es del usuario
+	user, err := s.findOrRegisterUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, invalidErr
+		}
+		return nil, err
+	}
+
+	// 5. Abrir la sesión o pedir el segundo factor
+	return s.sessionService.CompleteLogin(ctx, user, client)
+}
+
+// findOrRegisterUser busca al usuario del email y lo marca como verificado. Si no existe y el
+// auto-registro está habilitado, crea la cuenta sin contraseña; si no, retorna ErrDocumentNotFound.
+func (s *passwordlessService) findOrRegisterUser(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
+	if err == nil {
+		if !user.ContactInfo.Email.IsVerified {
+			verifiedAt := time.Now()
+			if err := s.userRepo.MarkEmailVerified(ctx, user.ID, email, verifiedAt); err != nil {
+				// El usuario cambió de email mientras tanto: el secreto ya no prueba nada
+				if errors.Is(err, validations.ErrConditionFailed) {
+					return nil, validations.ErrDocumentNotFound
+				}
+				return nil, err
+			}
+			user.ContactInfo.Email.IsVerified = true
+			user.ContactInfo.Email.VerifiedAt = verifiedAt
+		}
+		return user, nil
+	}
+	if !errors.Is(err, validations.ErrDocumentNotFound) || !s.policy.AutoRegister {
+		return nil, err
+	}
+
+	// Sin contraseña: solo puede ingresar por email hasta que defina una con forgot-password
+	now := time.Now()
+	user = &models.User{
+		ID: generateUserID(),
+		ContactInfo: models.ContactInfo{
+			Email: models.EmailDetails{
+				Address:    email,
+				IsVerified: true,
+				VerifiedAt: now,
+			},
+		},
+		CreatedAt: now,
+		Status:    models.USER_STATUS_ACTIVE,
+	}
+
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		// Otro canje registró el mismo email al mismo tiempo
+		if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return s.userRepo.GetUserByEmail(ctx, email)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// recipientName retorna el nombre para el saludo del email, vacío si el email aún no tiene cuenta
+func recipientName(user *models.User) string {
+	if user == nil {
+		return ""
+	}
+	return user.PersonalInfo.Name
+}
+
+// generateEmailOTP genera un código numérico aleatorio de EMAIL_OTP_DIGITS dígitos
+func generateEmailOTP() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < EMAIL_OTP_DIGITS; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", EMAIL_OTP_DIGITS, n), nil
+}
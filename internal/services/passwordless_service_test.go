@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories/memory"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+)
+
+// testPasswordlessPolicy desactiva la espera entre envíos para poder pedir varios códigos seguidos
+var testPasswordlessPolicy = PasswordlessPolicy{
+	MagicLinkTTL: 15 * time.Minute,
+	EmailOTPTTL:  10 * time.Minute,
+	MaxAttempts:  3,
+}
+
+var passwordlessClient = request.ClientInfo{UserAgent: "test", IP: "127.0.0.1"}
+
+func newPasswordlessTestService(env *testEnv, policy PasswordlessPolicy) PasswordlessService {
+	return NewPasswordlessService(env.userRepo, memory.NewEmailLoginRepository(), env.sessions, env.lockout, env.notifier, policy)
+}
+
+// lastSent retorna el último link o código enviado al email
+func (env *testEnv) lastSent(t *testing.T, email string) string {
+	t.Helper()
+	env.notifier.mu.Lock()
+	defer env.notifier.mu.Unlock()
+	sent := env.notifier.links[email]
+	if len(sent) == 0 {
+		t.Fatalf("nothing sent to %s", email)
+	}
+	return sent[len(sent)-1]
+}
+
+// magicLinkToken extrae el token del último magic link enviado al email
+func (env *testEnv) magicLinkToken(t *testing.T, email string) string {
+	t.Helper()
+	link, err := url.Parse(env.lastSent(t, email))
+	if err != nil {
+		t.Fatalf("invalid link: %v", err)
+	}
+	return link.Query().Get("token")
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	if err := service.RequestMagicLink(ctx, " Juan@Example.com "); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	token := env.magicLinkToken(t, "juan@example.com")
+
+	result, err := service.RedeemMagicLink(ctx, token, passwordlessClient)
+	if err != nil || result.Tokens == nil {
+		t.Fatalf("redeem: result = %+v, err = %v", result, err)
+	}
+	if _, err := env.sessions.ValidateAccessToken(ctx, result.Tokens.AccessToken); err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+
+	// Recibir el link verifica el email
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if !user.IsUserVerified() {
+		t.Fatal("email not marked as verified")
+	}
+
+	// El link sirve una sola vez
+	if _, err := service.RedeemMagicLink(ctx, token, passwordlessClient); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("second redeem: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestMagicLinkSupersededByNewLink(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	service.RequestMagicLink(ctx, "juan@example.com")
+	first := env.magicLinkToken(t, "juan@example.com")
+	service.RequestMagicLink(ctx, "juan@example.com")
+	second := env.magicLinkToken(t, "juan@example.com")
+
+	if _, err := service.RedeemMagicLink(ctx, first, passwordlessClient); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("old link: error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := service.RedeemMagicLink(ctx, second, passwordlessClient); err != nil {
+		t.Fatalf("new link: %v", err)
+	}
+	if _, err := service.RedeemMagicLink(ctx, "not-a-token", passwordlessClient); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("garbage token: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestEmailOTPLogin(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	if err := service.RequestEmailOTP(ctx, "juan@example.com"); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	code := env.lastSent(t, "juan@example.com")
+	if len(code) != EMAIL_OTP_DIGITS {
+		t.Fatalf("code = %q, want %d digits", code, EMAIL_OTP_DIGITS)
+	}
+
+	result, err := service.VerifyEmailOTP(ctx, "JUAN@example.com", code, passwordlessClient)
+	if err != nil || result.Tokens == nil {
+		t.Fatalf("verify: result = %+v, err = %v", result, err)
+	}
+
+	if _, err := service.VerifyEmailOTP(ctx, "juan@example.com", code, passwordlessClient); !errors.Is(err, validations.ErrInvalidEmailOTP) {
+		t.Fatalf("reused code: error = %v, want ErrInvalidEmailOTP", err)
+	}
+}
+
+func TestEmailOTPAttemptLimit(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	service.RequestEmailOTP(ctx, "juan@example.com")
+	code := env.lastSent(t, "juan@example.com")
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+
+	// Se usa una IP distinta en cada intento para que actúe el límite del código y no el bloqueo
+	for i := 0; i < testPasswordlessPolicy.MaxAttempts; i++ {
+		client := request.ClientInfo{IP: fmt.Sprintf("10.0.0.%d", i+1)}
+		if _, err := service.VerifyEmailOTP(ctx, "juan@example.com", wrong, client); !errors.Is(err, validations.ErrInvalidEmailOTP) {
+			t.Fatalf("attempt %d: error = %v, want ErrInvalidEmailOTP", i+1, err)
+		}
+	}
+
+	// Agotados los intentos, ni el código correcto sirve
+	if _, err := service.VerifyEmailOTP(ctx, "juan@example.com", code, request.ClientInfo{IP: "10.0.1.1"}); !errors.Is(err, validations.ErrInvalidEmailOTP) {
+		t.Fatalf("after max attempts: error = %v, want ErrInvalidEmailOTP", err)
+	}
+}
+
+func TestEmailLoginResendCooldown(t *testing.T) {
+	env := newTestEnv(t)
+	policy := testPasswordlessPolicy
+	policy.ResendAfter = time.Hour
+	service := newPasswordlessTestService(env, policy)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+	verification := len(env.notifier.links["juan@example.com"])
+
+	for i := 0; i < 3; i++ {
+		if err := service.RequestEmailOTP(ctx, "juan@example.com"); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if sent := len(env.notifier.links["juan@example.com"]) - verification; sent != 1 {
+		t.Fatalf("sent %d codes during the cooldown, want 1", sent)
+	}
+}
+
+func TestEmailLoginUnknownEmail(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+
+	// Sin auto-registro no se envía nada, pero tampoco se informa que el email no existe
+	if err := service.RequestMagicLink(ctx, "nadie@example.com"); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if err := service.RequestEmailOTP(ctx, "nadie@example.com"); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if len(env.notifier.links["nadie@example.com"]) != 0 {
+		t.Fatal("email sent to an unknown address")
+	}
+
+	if err := service.RequestEmailOTP(ctx, "no-es-un-email"); !errors.Is(err, validations.ErrInvalidEmail) {
+		t.Fatalf("invalid email: error = %v, want ErrInvalidEmail", err)
+	}
+}
+
+func TestEmailLoginAutoRegister(t *testing.T) {
+	env := newTestEnv(t)
+	policy := testPasswordlessPolicy
+	policy.AutoRegister = true
+	service := newPasswordlessTestService(env, policy)
+	ctx := context.Background()
+
+	if err := service.RequestEmailOTP(ctx, "nueva@example.com"); err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if _, err := env.userRepo.GetUserByEmail(ctx, "nueva@example.com"); !errors.Is(err, validations.ErrDocumentNotFound) {
+		t.Fatalf("user created before redeeming the code: error = %v", err)
+	}
+
+	result, err := service.VerifyEmailOTP(ctx, "nueva@example.com", env.lastSent(t, "nueva@example.com"), passwordlessClient)
+	if err != nil || result.Tokens == nil {
+		t.Fatalf("verify: result = %+v, err = %v", result, err)
+	}
+
+	user, err := env.userRepo.GetUserByEmail(ctx, "nueva@example.com")
+	if err != nil {
+		t.Fatalf("user not registered: %v", err)
+	}
+	if !user.IsActive() || !user.IsUserVerified() || user.Password != "" {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+
+	// Sin contraseña no puede ingresar por /auth/login
+	if _, err := env.sessions.Login(ctx, "nueva@example.com", "", passwordlessClient); !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("password login: error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestEmailLoginRequiresMFA(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+	env.enableTOTP(t, "juan@example.com")
+
+	service.RequestMagicLink(ctx, "juan@example.com")
+	result, err := service.RedeemMagicLink(ctx, env.magicLinkToken(t, "juan@example.com"), passwordlessClient)
+	if err != nil {
+		t.Fatalf("redeem: %v", err)
+	}
+	if result.Tokens != nil || result.ChallengeToken == "" {
+		t.Fatalf("expected an MFA challenge, got %+v", result)
+	}
+}
+
+func TestEmailLoginInactiveUser(t *testing.T) {
+	env := newTestEnv(t)
+	service := newPasswordlessTestService(env, testPasswordlessPolicy)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	service.RequestEmailOTP(ctx, "juan@example.com")
+	code := env.lastSent(t, "juan@example.com")
+
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	env.userRepo.UpdateStatus(ctx, user.ID, models.USER_STATUS_BANNED)
+
+	if _, err := service.VerifyEmailOTP(ctx, "juan@example.com", code, passwordlessClient); !errors.Is(err, validations.ErrUserInactive) {
+		t.Fatalf("error = %v, want ErrUserInactive", err)
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"myproject/internal/repositories"
 	tokens "myproject/pkg/jwt"
 	"myproject/pkg/request"
+	security "myproject/pkg/session"
 	"myproject/pkg/validations"
 
 	"github.com/google/uuid"
@@ -27,30 +29,68 @@ var (
 // SessionService encapsula la lógica de negocio para las sesiones.
 type SessionService interface {
 	Register(ctx context.Context, req request.RegisterUserRequest) error
-	Login(ctx context.Context, email, password string) (*tokens.Tokens, error)
-	RefreshToken(ctx context.Context, token string) (*tokens.Tokens, error)
+	Login(ctx context.Context, email, password string, client request.ClientInfo) (*LoginResult, error)
+	CompleteLogin(ctx context.Context, user *models.User, client request.ClientInfo) (*LoginResult, error)
+	CompleteMFAChallenge(ctx context.Context, challengeToken, code, recoveryCode string, client request.ClientInfo) (*tokens.Tokens, error)
+	IssueSession(ctx context.Context, user *models.User, client request.ClientInfo) (*tokens.Tokens, error)
+	Authenticate(ctx context.Context, email, password, mfaCode string, client request.ClientInfo) (*models.User, error)
+	StartOAuthSession(ctx context.Context, user *models.User, client request.ClientInfo, grant tokens.Grant) (*tokens.Tokens, error)
+	RefreshToken(ctx context.Context, token string, client request.ClientInfo) (*tokens.Tokens, error)
+	RefreshOAuthToken(ctx context.Context, token, clientID string, client request.ClientInfo) (*tokens.Tokens, error)
+	ValidateAccessToken(ctx context.Context, token string) (*tokens.Claims, error)
+	Logout(ctx context.Context, claims *tokens.Claims, refreshToken string) error
+	LogoutAll(ctx context.Context, userID string) error
+	ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID string) error
+	SwitchOrganization(ctx context.Context, claims *tokens.Claims, organizationID string, client request.ClientInfo) (*tokens.Tokens, error)
+}
+
+// LoginResult es el resultado de Login: los tokens de la sesión o, si el usuario tiene MFA
+// habilitado, el token del challenge que se completa en CompleteMFAChallenge.
+type LoginResult struct {
+	Tokens         *tokens.Tokens
+	ChallengeToken string
 }
 
 type sessionService struct {
-	userRepo repositories.UserRepository
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	revokedTokenRepo repositories.RevokedTokenRepository
+	sessionRepo      repositories.SessionRepository
+
+	verificationService VerificationService
+	lockoutService      LockoutService
+	mfaService          MFAService
+	organizationService OrganizationService
 }
 
 // NewSessionService crea una nueva instancia de SessionService.
-func NewSessionService(userRepo repositories.UserRepository) SessionService {
+func NewSessionService(
+	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	revokedTokenRepo repositories.RevokedTokenRepository,
+	sessionRepo repositories.SessionRepository,
+	verificationService VerificationService,
+	lockoutService LockoutService,
+	mfaService MFAService,
+	organizationService OrganizationService,
+) SessionService {
 	return &sessionService{
-		userRepo: userRepo,
+		userRepo:            userRepo,
+		refreshTokenRepo:    refreshTokenRepo,
+		revokedTokenRepo:    revokedTokenRepo,
+		sessionRepo:         sessionRepo,
+		verificationService: verificationService,
+		lockoutService:      lockoutService,
+		mfaService:          mfaService,
+		organizationService: organizationService,
 	}
 }
 
-// Register maneja la lógica de registro simple de usuarios.
+// Register maneja la lógica de registro simple de usuarios. Si se indica el nombre de la empresa,
+// crea la organización con el usuario como OWNER y sus logins emiten tokens para ella.
 func (s *sessionService) Register(ctx context.Context, req request.RegisterUserRequest) error {
-	// 1. Validar si el usuario ya existe
-	existingUser, _ := s.userRepo.GetUserByEmail(ctx, req.Email)
-	if existingUser != nil {
-		return ErrUserAlreadyExists
-	}
-
-	// 2. Crear el usuario
+	// 1. Crear el usuario. La unicidad del email la garantiza CreateUser de forma atómica
 	user := &models.User{
 		ID: "", // Se generará en NewUser
 		PersonalInfo: models.PersonalInfo{
@@ -59,11 +99,11 @@ func (s *sessionService) Register(ctx context.Context, req request.RegisterUserR
 		},
 		ContactInfo: models.ContactInfo{
 			Email: models.EmailDetails{
-				Address: strings.ToLower(req.Email),
+				Address: validations.NormalizeEmail(req.Email),
 			},
 		},
 		CreatedAt: time.Now(),
-		Status:    1, // active
+		Status:    models.USER_STATUS_ACTIVE,
 	}
 
 	// 4. Hash de la contraseña
@@ -76,105 +116,601 @@ func (s *sessionService) Register(ctx context.Context, req request.RegisterUserR
 	// 5. Generar ID único
 	user.ID = generateUserID()
 
-	// 6. Guardar el usuario en DynamoDB
+	// 6. Validar la empresa antes de crear el usuario, para no dejarlo registrado a medias
+	var organization *models.Organization
+	if strings.TrimSpace(req.CompanyName) != "" {
+		organization, err = models.NewOrganization(req.CompanyName, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 7. Guardar el usuario en DynamoDB
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return ErrUserAlreadyExists
+		}
 		return err
 	}
 
+	// 8. Enviar el link de verificación. Un fallo no impide el registro: el usuario puede pedir el reenvío.
+	// Va antes de la empresa porque la cuenta ya existe aunque ese paso falle
+	if err := s.verificationService.SendVerification(ctx, user); err != nil {
+		log.Printf("Register: error enviando verificación a %s: %v", user.ID, err)
+	}
+
+	// 9. Crear la empresa y su membresía. Recién entonces pasa a ser la organización activa, para
+	// que un fallo a mitad de camino no deje al usuario apuntando a una organización inexistente
+	if organization != nil {
+		if err := s.organizationService.CreateOrganization(ctx, organization); err != nil {
+			return err
+		}
+		if err := s.userRepo.UpdateActiveOrganization(ctx, user.ID, organization.ID); err != nil {
+			return err
+		}
+		user.ActiveOrganizationID = organization.ID
+	}
+
 	return nil
 }
 
 // Login maneja la autenticación de usuarios.
-func (s *sessionService) Login(ctx context.Context, email, password string) (*tokens.Tokens, error) {
-	emailLower := strings.ToLower(email)
+func (s *sessionService) Login(ctx context.Context, email, password string, client request.ClientInfo) (*LoginResult, error) {
+	user, err := s.verifyPassword(ctx, email, password, client)
+	if err != nil {
+		return nil, err
+	}
 
-	// 1. Buscar usuario por email
-	user, err := s.userRepo.GetUserByEmail(ctx, emailLower)
+	// 5. Abrir la sesión o pedir el segundo factor
+	return s.CompleteLogin(ctx, user, client)
+}
+
+// verifyPassword valida el primer factor de Login: bloqueo, usuario activo y contraseña.
+// Los emails inexistentes y las contraseñas incorrectas cuentan como intento fallido.
+func (s *sessionService) verifyPassword(ctx context.Context, email, password string, client request.ClientInfo) (*models.User, error) {
+	email = validations.NormalizeEmail(email)
+
+	// 1. Rechazar el intento si la cuenta o el email desde esta IP acumulan demasiados fallos
+	if err := s.lockoutService.CheckLogin(ctx, email, client.IP); err != nil {
+		return nil, err
+	}
+
+	// 2. Buscar usuario por email
+	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
+		s.registerLoginFailure(ctx, email, client.IP)
 		return nil, validations.ErrInvalidCredentials
 	}
 
-	// 2. Verificar que el usuario esté activo
-	if !user.IsUserVerified() {
+	// 3. Verificar que el usuario esté activo
+	if !user.IsActive() {
 		return nil, validations.ErrUserInactive
 	}
 
-	// 3. Verificar contraseña
+	// 4. Verificar contraseña
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		s.registerLoginFailure(ctx, email, client.IP)
 		return nil, validations.ErrInvalidCredentials
 	}
 
-	// 4. Generar tokens
-	accessToken, err := tokens.GenerateJWT(user, ACCESS_DURATION)
+	return user, nil
+}
+
+// Authenticate valida las credenciales en un solo paso, sin abrir sesión: lo usa la pantalla de
+// /oauth/authorize, que no puede seguir el challenge de Login. Con MFA habilitado el código TOTP
+// (o de recuperación) es obligatorio; si no viene retorna validations.ErrMFARequired.
+func (s *sessionService) Authenticate(ctx context.Context, email, password, mfaCode string, client request.ClientInfo) (*models.User, error) {
+	user, err := s.verifyPassword(ctx, email, password, client)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := tokens.GenerateJWT(user, REFRESH_DURATION)
+	if requireEmailVerification() && !user.IsUserVerified() {
+		return nil, validations.ErrEmailNotVerified
+	}
+
+	email = validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	if user.IsMFAEnabled() {
+		if mfaCode == "" {
+			return nil, validations.ErrMFARequired
+		}
+		if err := s.mfaService.VerifySecondFactor(ctx, user, mfaCode, ""); err != nil {
+			if errors.Is(err, validations.ErrInvalidMFACode) {
+				s.registerLoginFailure(ctx, email, client.IP)
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.lockoutService.ResetFailures(ctx, email, client.IP); err != nil {
+		log.Printf("Authenticate: error reiniciando los intentos fallidos de %s: %v", user.ID, err)
+	}
+
+	return user, nil
+}
+
+// StartOAuthSession abre la sesión de un usuario autenticado en /oauth/authorize para un cliente
+// OAuth. Los tokens llevan el client_id y los scopes concedidos; la sesión aparece en /sessions
+// como cualquier otra y el usuario puede revocarla.
+func (s *sessionService) StartOAuthSession(ctx context.Context, user *models.User, client request.ClientInfo, grant tokens.Grant) (*tokens.Tokens, error) {
+	if !user.IsActive() {
+		return nil, validations.ErrUserInactive
+	}
+
+	return s.startSession(ctx, user, client, grant)
+}
+
+// CompleteLogin continúa un login cuyo primer factor ya se validó (contraseña, magic link o código
+// por email): con MFA habilitado retorna el challenge; si no, reinicia los fallos y abre la sesión.
+func (s *sessionService) CompleteLogin(ctx context.Context, user *models.User, client request.ClientInfo) (*LoginResult, error) {
+	if !user.IsActive() {
+		return nil, validations.ErrUserInactive
+	}
+
+	// Se verifica después del primer factor para no revelar el estado de cuentas ajenas
+	if requireEmailVerification() && !user.IsUserVerified() {
+		return nil, validations.ErrEmailNotVerified
+	}
+
+	// Con MFA habilitado la sesión se abre recién al validar el segundo factor.
+	// Los contadores de fallos no se reinician hasta entonces.
+	if user.IsMFAEnabled() {
+		challenge, err := tokens.GenerateMFAChallengeToken(user, MFA_CHALLENGE_DURATION)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{ChallengeToken: challenge}, nil
+	}
+
+	email := validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	if err := s.lockoutService.ResetFailures(ctx, email, client.IP); err != nil {
+		log.Printf("CompleteLogin: error reiniciando los intentos fallidos de %s: %v", user.ID, err)
+	}
+
+	newTokens, err := s.startSession(ctx, user, client, tokens.Grant{})
 	if err != nil {
 		return nil, err
 	}
 
-	// 5. Actualizar última sesión (async)
-	go func() {
-		updatedUser := *user
-		updatedUser.LastSession = time.Now()
-		s.userRepo.UpdateUser(context.Background(), user.ID, &updatedUser)
-	}()
+	return &LoginResult{Tokens: newTokens}, nil
+}
 
-	return &tokens.Tokens{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+// CompleteMFAChallenge completa un login con MFA: valida el challenge emitido por Login y el
+// código TOTP (o de recuperación), y abre la sesión. El challenge sirve una sola vez: se consume
+// antes de verificar el código y solo se libera si el código es incorrecto, para reintentarlo.
+func (s *sessionService) CompleteMFAChallenge(ctx context.Context, challengeToken, code, recoveryCode string, client request.ClientInfo) (*tokens.Tokens, error) {
+	// 1. Validar el challenge: vigente y emitido para la versión de tokens actual
+	claims, err := tokens.ParseMFAChallengeToken(challengeToken)
+	if err != nil {
+		return nil, validations.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, validations.ErrInvalidToken
+	}
+
+	if !user.IsActive() {
+		return nil, validations.ErrUserInactive
+	}
+
+	if claims.Version != user.TokenVersion {
+		return nil, validations.ErrInvalidToken
+	}
+
+	// 2. Los códigos fallidos cuentan para el bloqueo igual que las contraseñas incorrectas
+	email := validations.NormalizeEmail(user.ContactInfo.Email.Address)
+	if err := s.lockoutService.CheckLogin(ctx, email, client.IP); err != nil {
+		return nil, err
+	}
+
+	// 3. Consumir el challenge con una escritura condicional: de dos usos simultáneos solo uno sigue
+	if err := s.revokedTokenRepo.ConsumeToken(ctx, models.NewRevokedToken(claims.ID, user.ID, claims.ExpiresAt.Time)); err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return nil, validations.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	// 4. Verificar el segundo factor. Un código incorrecto libera el challenge para reintentarlo
+	if err := s.mfaService.VerifySecondFactor(ctx, user, code, recoveryCode); err != nil {
+		if errors.Is(err, validations.ErrInvalidMFACode) {
+			s.registerLoginFailure(ctx, email, client.IP)
+			if releaseErr := s.revokedTokenRepo.ReleaseToken(ctx, claims.ID); releaseErr != nil {
+				log.Printf("CompleteMFAChallenge: error liberando el challenge de %s: %v", user.ID, releaseErr)
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.lockoutService.ResetFailures(ctx, email, client.IP); err != nil {
+		log.Printf("CompleteMFAChallenge: error reiniciando los intentos fallidos de %s: %v", user.ID, err)
+	}
+
+	// 5. Abrir la sesión
+	return s.startSession(ctx, user, client, tokens.Grant{})
+}
+
+// IssueSession abre una sesión para un usuario que ya se autenticó por otro medio (por ejemplo,
+// con una passkey). Aplica las mismas reglas de estado que Login, pero no pide contraseña ni MFA.
+func (s *sessionService) IssueSession(ctx context.Context, user *models.User, client request.ClientInfo) (*tokens.Tokens, error) {
+	if !user.IsActive() {
+		return nil, validations.ErrUserInactive
+	}
+
+	if requireEmailVerification() && !user.IsUserVerified() {
+		return nil, validations.ErrEmailNotVerified
+	}
+
+	return s.startSession(ctx, user, client, tokens.Grant{})
+}
+
+// startSession emite los tokens de una nueva sesión, iniciando una nueva familia de refresh tokens.
+// Las sesiones propias de la API se abren para la organización activa del usuario.
+func (s *sessionService) startSession(ctx context.Context, user *models.User, client request.ClientInfo, grant tokens.Grant) (*tokens.Tokens, error) {
+	if grant.ClientID == "" {
+		var err error
+		if grant, err = s.organizationGrant(ctx, user.ID, user.ActiveOrganizationID, grant); err != nil {
+			return nil, err
+		}
+	}
+
+	sessionID := uuid.New().String()
+	newTokens, record, err := s.generateTokens(user, sessionID, client, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.CreateRefreshToken(ctx, record); err != nil {
+		return nil, err
+	}
+
+	// Registrar la sesión del dispositivo
+	if err := s.sessionRepo.CreateSession(ctx, models.NewSession(sessionID, user.ID, client, record.ExpiresAt)); err != nil {
+		return nil, err
+	}
+
+	// Registrar el último inicio de sesión. Solo modifica ese campo, sin pisar otros cambios
+	if err := s.userRepo.UpdateLastSession(ctx, user.ID, time.Now()); err != nil {
+		log.Printf("startSession: error actualizando last_session de %s: %v", user.ID, err)
+	}
+
+	return newTokens, nil
+}
+
+// registerLoginFailure cuenta un login fallido. Un error no cambia la respuesta al cliente, solo se registra.
+func (s *sessionService) registerLoginFailure(ctx context.Context, email, ip string) {
+	if err := s.lockoutService.RegisterFailure(ctx, email, ip); err != nil {
+		log.Printf("Login: error registrando intento fallido de %s: %v", email, err)
+	}
 }
 
 // RefreshToken maneja la renovación de tokens.
-func (s *sessionService) RefreshToken(ctx context.Context, token string) (*tokens.Tokens, error) {
-	// 1. Obtener claims del token
-	claimsMap, err := tokens.GetClaims(token)
+// Cada refresh token solo puede usarse una vez: se rota por uno nuevo de la misma familia
+// y, si se presenta uno ya rotado, se revoca la familia completa.
+// Los refresh tokens emitidos a clientes OAuth no se aceptan acá: se renuevan en /oauth/token.
+func (s *sessionService) RefreshToken(ctx context.Context, token string, client request.ClientInfo) (*tokens.Tokens, error) {
+	return s.refresh(ctx, token, "", client)
+}
+
+// RefreshOAuthToken renueva un refresh token emitido al cliente OAuth clientID, con las mismas
+// reglas de rotación que RefreshToken. Conserva los scopes concedidos.
+func (s *sessionService) RefreshOAuthToken(ctx context.Context, token, clientID string, client request.ClientInfo) (*tokens.Tokens, error) {
+	if clientID == "" {
+		return nil, validations.ErrInvalidToken
+	}
+	return s.refresh(ctx, token, clientID, client)
+}
+
+// refresh rota el refresh token si fue emitido al cliente clientID (vacío para las sesiones propias).
+func (s *sessionService) refresh(ctx context.Context, token, clientID string, client request.ClientInfo) (*tokens.Tokens, error) {
+	// 1. Validar el token: debe ser de tipo refresh, del emisor y audiencia esperados, y del mismo cliente
+	claims, err := tokens.ParseRefreshToken(token)
 	if err != nil {
 		return nil, validations.ErrInvalidToken
 	}
 
+	if claims.ClientID != clientID {
+		return nil, validations.ErrInvalidToken
+	}
+
 	// 2. Extraer el ID del usuario del token
-	userID, ok := (*claimsMap)["id"].(string)
-	if !ok || userID == "" {
+	userID := claims.Subject
+
+	// 3. Buscar el token persistido
+	tokenHash := security.HashToken(token)
+	stored, err := s.refreshTokenRepo.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if stored.UserID != userID || stored.Revoked || stored.IsExpired() {
 		return nil, validations.ErrInvalidToken
 	}
 
-	// 3. Buscar usuario en la base de datos
+	// 4. Un token ya rotado que vuelve a presentarse indica robo: se revoca toda la familia
+	if stored.IsRotated() {
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, err
+		}
+		return nil, validations.ErrRefreshTokenReused
+	}
+
+	// 5. Buscar usuario en la base de datos
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, validations.ErrInvalidToken
 	}
 
-	// 4. Verificar que el usuario esté activo
-	if !user.IsUserVerified() {
+	// 6. Verificar que el usuario esté activo y que no haya cerrado todas sus sesiones
+	if !user.IsActive() {
 		return nil, validations.ErrUserInactive
 	}
 
-	// 5. Generar nuevos tokens
-	accessToken, err := tokens.GenerateJWT(user, ACCESS_DURATION)
+	if claims.Version != user.TokenVersion {
+		return nil, validations.ErrInvalidToken
+	}
+
+	// 7. Generar nuevos tokens y rotar el refresh token dentro de la misma familia
+	grant := tokens.Grant{ClientID: claims.ClientID, Scope: claims.Scope}
+	if claims.AuthTime != nil {
+		grant.AuthTime = claims.AuthTime.Time
+	}
+
+	// La organización se conserva con el rol vigente; si el usuario dejó de ser miembro, los tokens ya no la llevan
+	grant, err = s.organizationGrant(ctx, user.ID, claims.OrganizationID, grant)
+	if err != nil {
+		return nil, err
+	}
+	newTokens, record, err := s.generateTokens(user, stored.FamilyID, client, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshTokenRepo.RotateRefreshToken(ctx, tokenHash, record); err != nil {
+		// Otra petición rotó el mismo token al mismo tiempo
+		if errors.Is(err, validations.ErrConditionFailed) {
+			if err := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+				return nil, err
+			}
+			return nil, validations.ErrRefreshTokenReused
+		}
+		return nil, err
+	}
+
+	// 8. Registrar el uso de la sesión
+	if err := s.touchSession(ctx, stored.FamilyID, user.ID, client, record.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateLastSession(ctx, user.ID, time.Now()); err != nil {
+		log.Printf("RefreshToken: error actualizando last_session de %s: %v", user.ID, err)
+	}
+
+	return newTokens, nil
+}
+
+// ValidateAccessToken valida un access token y verifica que no haya sido revocado,
+// ya sea individualmente (logout) o por un cierre de todas las sesiones (logout-all).
+func (s *sessionService) ValidateAccessToken(ctx context.Context, token string) (*tokens.Claims, error) {
+	claims, err := tokens.ParseAccessToken(token)
+	if err != nil {
+		return nil, validations.ErrInvalidToken
+	}
+
+	revoked, err := s.revokedTokenRepo.IsTokenRevoked(ctx, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, validations.ErrInvalidToken
+	}
+
+	// Los tokens de client_credentials no representan a un usuario
+	if claims.IsClientToken() {
+		return claims, nil
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, validations.ErrInvalidToken
+	}
+
+	if claims.Version != user.TokenVersion {
+		return nil, validations.ErrInvalidToken
+	}
+
+	// El rol vigente es el del usuario (o el de su membresía en la organización del token):
+	// un cambio de rol rige en la API sin esperar a que venza el token
+	claims.Role = user.GetRole()
+	if claims.OrganizationID != "" {
+		membership, err := s.organizationService.GetMembership(ctx, claims.OrganizationID, user.ID)
+		if err != nil {
+			return nil, validations.ErrInvalidToken
+		}
+		claims.Role = membership.Role
+	}
+
+	return claims, nil
+}
+
+// Logout cierra la sesión actual: agrega el access token a la denylist hasta su expiración
+// y revoca la familia de refresh tokens de la sesión.
+func (s *sessionService) Logout(ctx context.Context, claims *tokens.Claims, refreshToken string) error {
+	// 1. Identificar la sesión: por el claim "sid" o, en tokens anteriores, por el refresh token recibido
+	sessionID := claims.SessionID
+	if refreshToken != "" {
+		stored, err := s.refreshTokenRepo.GetRefreshToken(ctx, security.HashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, validations.ErrDocumentNotFound) {
+				return validations.ErrInvalidToken
+			}
+			return err
+		}
+
+		if stored.UserID != claims.Subject {
+			return validations.ErrInvalidToken
+		}
+		sessionID = stored.FamilyID
+	}
+
+	// 2. Revocar la sesión antes de la denylist, para no dejarla viva ante un error
+	if sessionID != "" {
+		if err := s.revokeSession(ctx, sessionID); err != nil {
+			return err
+		}
+	}
+
+	// 3. Denylist del access token
+	return s.revokedTokenRepo.RevokeToken(ctx, models.NewRevokedToken(claims.ID, claims.Subject, claims.ExpiresAt.Time))
+}
+
+// LogoutAll invalida todos los tokens del usuario incrementando su TokenVersion
+// y elimina sus sesiones activas.
+func (s *sessionService) LogoutAll(ctx context.Context, userID string) error {
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.DeleteSessionsByUser(ctx, userID)
+}
+
+// ListSessions lista las sesiones activas del usuario marcando la actual.
+func (s *sessionService) ListSessions(ctx context.Context, userID, currentSessionID string) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.ListSessionsByUser(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := tokens.GenerateJWT(user, REFRESH_DURATION)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession cierra una sesión del usuario (por ejemplo, la de un dispositivo perdido).
+// Los access tokens ya emitidos para esa sesión siguen vigentes hasta su expiración.
+func (s *sessionService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	session, err := s.sessionRepo.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return validations.ErrSessionNotFound
+		}
+		return err
+	}
+
+	// No revelamos si la sesión existe cuando pertenece a otro usuario
+	if session.UserID != userID {
+		return validations.ErrSessionNotFound
+	}
+
+	return s.revokeSession(ctx, sessionID)
+}
+
+// SwitchOrganization cierra la sesión actual y abre una nueva cuyos tokens están emitidos para
+// la organización indicada, con el rol del usuario en ella. La organización queda como activa
+// para los próximos logins. Si el usuario no es miembro retorna validations.ErrCompanyNotFound.
+func (s *sessionService) SwitchOrganization(ctx context.Context, claims *tokens.Claims, organizationID string, client request.ClientInfo) (*tokens.Tokens, error) {
+	// Los tokens de clientes OAuth no se emiten para una organización
+	if claims.ClientID != "" {
+		return nil, validations.ErrInvalidToken
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, claims.Subject)
 	if err != nil {
+		return nil, validations.ErrInvalidToken
+	}
+
+	if !user.IsActive() {
+		return nil, validations.ErrUserInactive
+	}
+
+	if _, err := s.organizationService.GetMembership(ctx, organizationID, user.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateActiveOrganization(ctx, user.ID, organizationID); err != nil {
 		return nil, err
 	}
+	user.ActiveOrganizationID = organizationID
+
+	if err := s.Logout(ctx, claims, ""); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user, client, tokens.Grant{})
+}
+
+// organizationGrant agrega al grant la organización y el rol del usuario en ella. Si organizationID
+// es vacío o el usuario ya no es miembro, retorna el grant sin organización.
+func (s *sessionService) organizationGrant(ctx context.Context, userID, organizationID string, grant tokens.Grant) (tokens.Grant, error) {
+	if organizationID == "" {
+		return grant, nil
+	}
+
+	membership, err := s.organizationService.GetMembership(ctx, organizationID, userID)
+	if errors.Is(err, validations.ErrCompanyNotFound) {
+		return grant, nil
+	}
+	if err != nil {
+		return grant, err
+	}
+
+	grant.OrganizationID = membership.OrganizationID
+	grant.Role = membership.Role
+	return grant, nil
+}
+
+// revokeSession revoca la familia de refresh tokens de la sesión y elimina su registro.
+func (s *sessionService) revokeSession(ctx context.Context, sessionID string) error {
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, sessionID); err != nil {
+		return err
+	}
+
+	return s.sessionRepo.DeleteSession(ctx, sessionID)
+}
+
+// touchSession actualiza el último uso de la sesión. Las familias creadas antes de
+// existir el registro de sesiones no tienen sesión, así que se crea en ese momento.
+func (s *sessionService) touchSession(ctx context.Context, sessionID, userID string, client request.ClientInfo, expiresAt time.Time) error {
+	err := s.sessionRepo.TouchSession(ctx, sessionID, client.IP, time.Now(), expiresAt)
+	if errors.Is(err, validations.ErrDocumentNotFound) {
+		return s.sessionRepo.CreateSession(ctx, models.NewSession(sessionID, userID, client, expiresAt))
+	}
+
+	return err
+}
+
+// generateTokens genera el par de tokens y el registro a persistir del refresh token.
+// La familia de refresh tokens coincide con el ID de la sesión (claim "sid").
+func (s *sessionService) generateTokens(user *models.User, familyID string, client request.ClientInfo, grant tokens.Grant) (*tokens.Tokens, *models.RefreshToken, error) {
+	accessToken, err := tokens.GenerateGrantJWT(user, familyID, tokens.TokenTypeAccess, ACCESS_DURATION, grant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, err := tokens.GenerateGrantJWT(user, familyID, tokens.TokenTypeRefresh, REFRESH_DURATION, grant)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	// 6. Actualizar última sesión (async)
-	go func() {
-		updatedUser := *user
-		updatedUser.LastSession = time.Now()
-		s.userRepo.UpdateUser(context.Background(), user.ID, &updatedUser)
-	}()
+	record := models.NewRefreshToken(
+		security.HashToken(refreshToken),
+		familyID,
+		user.ID,
+		client.UserAgent,
+		time.Now().Add(time.Hour*REFRESH_DURATION),
+	)
 
 	return &tokens.Tokens{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-	}, nil
+		SessionID:    familyID,
+	}, record, nil
 }
 
 // generateUserID genera un ID único para el usuario usando UUID v4
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/internal/repositories/memory"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+)
+
+const testPassword = "Secreta#123"
+
+func TestMain(m *testing.M) {
+	// Las claves de firma se cargan una sola vez desde el entorno. Los ID tokens exigen una clave
+	// asimétrica; JWT_SECRET queda para verificar, como en una instalación migrada
+	keyFile, err := writeTestSigningKey()
+	if err != nil {
+		log.Fatalf("signing key: %v", err)
+	}
+	os.Setenv("JWT_PRIVATE_KEY_FILE", keyFile)
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Setenv("MFA_ENCRYPTION_KEY", "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=")
+
+	code := m.Run()
+	os.RemoveAll(filepath.Dir(keyFile))
+	os.Exit(code)
+}
+
+// writeTestSigningKey escribe una clave Ed25519 en PEM en un directorio temporal
+func writeTestSigningKey() (string, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", "jwt-keys")
+	if err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, "test.pem")
+	return file, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600)
+}
+
+// fakeNotifier guarda los links (y códigos) en lugar de enviarlos
+type fakeNotifier struct {
+	mu    sync.Mutex
+	links map[string][]string // email -> links o códigos
+}
+
+func newFakeNotifier() *fakeNotifier {
+	return &fakeNotifier{links: map[string][]string{}}
+}
+
+func (n *fakeNotifier) SendPasswordReset(ctx context.Context, user *models.User, link string) error {
+	return n.record(user.ContactInfo.Email.Address, link)
+}
+
+func (n *fakeNotifier) SendEmailVerification(ctx context.Context, user *models.User, link string) error {
+	return n.record(user.ContactInfo.Email.Address, link)
+}
+
+func (n *fakeNotifier) SendMagicLink(ctx context.Context, email, name, link string, expiresIn time.Duration) error {
+	return n.record(email, link)
+}
+
+func (n *fakeNotifier) SendEmailOTP(ctx context.Context, email, name, code string, expiresIn time.Duration) error {
+	return n.record(email, code)
+}
+
+func (n *fakeNotifier) SendInvitation(ctx context.Context, email, inviterName, organizationName, role, link string) error {
+	return n.record(email, link)
+}
+
+func (n *fakeNotifier) record(email, link string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.links[email] = append(n.links[email], link)
+	return nil
+}
+
+type testEnv struct {
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	sessionRepo      repositories.SessionRepository
+	notifier         *fakeNotifier
+	verification     VerificationService
+	lockout          LockoutService
+	mfa              MFAService
+	organizations    OrganizationService
+	invitations      InvitationService
+	sessions         SessionService
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	env := &testEnv{
+		userRepo:         memory.NewUserRepository(),
+		refreshTokenRepo: memory.NewRefreshTokenRepository(),
+		sessionRepo:      memory.NewSessionRepository(),
+		notifier:         newFakeNotifier(),
+	}
+	env.verification = NewVerificationService(env.userRepo, env.notifier)
+	env.lockout = NewLockoutService(env.userRepo, memory.NewLoginAttemptRepository(), testLockoutPolicy)
+	env.mfa = NewMFAService(env.userRepo)
+	organizationRepo := memory.NewOrganizationRepository()
+	membershipRepo := memory.NewMembershipRepository()
+	env.organizations = NewOrganizationService(organizationRepo, membershipRepo)
+	env.invitations = NewInvitationService(env.userRepo, memory.NewInvitationRepository(), organizationRepo, membershipRepo, env.notifier)
+	env.sessions = NewSessionService(env.userRepo, env.refreshTokenRepo, memory.NewRevokedTokenRepository(), env.sessionRepo, env.verification, env.lockout, env.mfa, env.organizations)
+	return env
+}
+
+func (env *testEnv) register(t *testing.T, email string) {
+	t.Helper()
+	err := env.sessions.Register(context.Background(), request.RegisterUserRequest{
+		Name: "Juan", LastName: "Pérez", Email: email, Password: testPassword,
+	})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+}
+
+func (env *testEnv) login(t *testing.T, email string) *tokens.Tokens {
+	t.Helper()
+	result, err := env.sessions.Login(context.Background(), email, testPassword, request.ClientInfo{UserAgent: "test", IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if result.Tokens == nil {
+		t.Fatal("login: expected tokens, got an MFA challenge")
+	}
+	return result.Tokens
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "Juan@Example.com")
+
+	user, err := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if err != nil {
+		t.Fatalf("user not stored: %v", err)
+	}
+	if user.Password == testPassword {
+		t.Fatal("password stored in plain text")
+	}
+	if len(env.notifier.links["juan@example.com"]) != 1 {
+		t.Fatal("verification email not sent")
+	}
+
+	pair := env.login(t, " JUAN@example.com ")
+	claims, err := env.sessions.ValidateAccessToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatalf("access token rejected: %v", err)
+	}
+	if claims.Subject != user.ID || claims.SessionID == "" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	user, _ = env.userRepo.GetUserByID(ctx, user.ID)
+	if user.LastSession.IsZero() {
+		t.Fatal("last session not recorded")
+	}
+
+	sessions, err := env.sessions.ListSessions(ctx, user.ID, claims.SessionID)
+	if err != nil || len(sessions) != 1 || !sessions[0].Current {
+		t.Fatalf("sessions = %+v, err = %v", sessions, err)
+	}
+}
+
+func TestRegisterDuplicateEmail(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+
+	err := env.sessions.Register(context.Background(), request.RegisterUserRequest{
+		Name: "Otro", LastName: "Usuario", Email: "JUAN@example.com", Password: testPassword,
+	})
+	if !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("error = %v, want ErrUserAlreadyExists", err)
+	}
+}
+
+func TestLoginInvalidCredentials(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+
+	_, err := env.sessions.Login(ctx, "juan@example.com", "incorrecta", request.ClientInfo{})
+	if !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("wrong password: error = %v", err)
+	}
+
+	_, err = env.sessions.Login(ctx, "nadie@example.com", testPassword, request.ClientInfo{})
+	if !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("unknown email: error = %v", err)
+	}
+}
+
+func TestLoginInactiveUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if err := env.userRepo.UpdateStatus(ctx, user.ID, models.USER_STATUS_BANNED); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := env.sessions.Login(ctx, "juan@example.com", testPassword, request.ClientInfo{})
+	if !errors.Is(err, validations.ErrUserInactive) {
+		t.Fatalf("error = %v, want ErrUserInactive", err)
+	}
+}
+
+func TestRefreshTokenRotationAndReuse(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+	client := request.ClientInfo{UserAgent: "test"}
+
+	first := env.login(t, "juan@example.com")
+	second, err := env.sessions.RefreshToken(ctx, first.RefreshToken, client)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+
+	// Reutilizar el refresh token rotado revoca toda la familia
+	_, err = env.sessions.RefreshToken(ctx, first.RefreshToken, client)
+	if !errors.Is(err, validations.ErrRefreshTokenReused) {
+		t.Fatalf("reuse: error = %v, want ErrRefreshTokenReused", err)
+	}
+
+	_, err = env.sessions.RefreshToken(ctx, second.RefreshToken, client)
+	if !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("token of revoked family: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestRefreshRejectsAccessToken(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+
+	pair := env.login(t, "juan@example.com")
+	_, err := env.sessions.RefreshToken(context.Background(), pair.AccessToken, request.ClientInfo{})
+	if !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+
+	pair := env.login(t, "juan@example.com")
+	claims, err := env.sessions.ValidateAccessToken(ctx, pair.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := env.sessions.Logout(ctx, claims, ""); err != nil {
+		t.Fatalf("logout: %v", err)
+	}
+
+	if _, err := env.sessions.ValidateAccessToken(ctx, pair.AccessToken); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("access token after logout: error = %v", err)
+	}
+	if _, err := env.sessions.RefreshToken(ctx, pair.RefreshToken, request.ClientInfo{}); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("refresh token after logout: error = %v", err)
+	}
+}
+
+func TestLogoutAll(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+
+	laptop := env.login(t, "juan@example.com")
+	phone := env.login(t, "juan@example.com")
+
+	claims, _ := env.sessions.ValidateAccessToken(ctx, laptop.AccessToken)
+	if err := env.sessions.LogoutAll(ctx, claims.Subject); err != nil {
+		t.Fatalf("logout all: %v", err)
+	}
+
+	for _, pair := range []*tokens.Tokens{laptop, phone} {
+		if _, err := env.sessions.ValidateAccessToken(ctx, pair.AccessToken); !errors.Is(err, validations.ErrInvalidToken) {
+			t.Fatalf("access token after logout-all: error = %v", err)
+		}
+		if _, err := env.sessions.RefreshToken(ctx, pair.RefreshToken, request.ClientInfo{}); !errors.Is(err, validations.ErrInvalidToken) {
+			t.Fatalf("refresh token after logout-all: error = %v", err)
+		}
+	}
+
+	sessions, _ := env.sessions.ListSessions(ctx, claims.Subject, "")
+	if len(sessions) != 0 {
+		t.Fatalf("%d sessions left after logout-all", len(sessions))
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	env.register(t, "ana@example.com")
+	ctx := context.Background()
+
+	pair := env.login(t, "juan@example.com")
+	claims, _ := env.sessions.ValidateAccessToken(ctx, pair.AccessToken)
+
+	other := env.login(t, "ana@example.com")
+	otherClaims, _ := env.sessions.ValidateAccessToken(ctx, other.AccessToken)
+
+	// No se puede cerrar la sesión de otro usuario
+	if err := env.sessions.RevokeSession(ctx, claims.Subject, otherClaims.SessionID); !errors.Is(err, validations.ErrSessionNotFound) {
+		t.Fatalf("revoke foreign session: error = %v", err)
+	}
+
+	if err := env.sessions.RevokeSession(ctx, claims.Subject, claims.SessionID); err != nil {
+		t.Fatalf("revoke session: %v", err)
+	}
+	if _, err := env.sessions.RefreshToken(ctx, pair.RefreshToken, request.ClientInfo{}); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("refresh token of revoked session: error = %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	"myproject/pkg/rbac"
+	"myproject/pkg/validations"
+)
+
+// UserService encapsula la lógica del perfil del usuario autenticado.
+type UserService interface {
+	GetProfile(ctx context.Context, userID string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, patch models.ProfilePatch, expectedVersion int64) (*models.User, error)
+	AssignRole(ctx context.Context, actorID, userID, role string) error
+	SetRole(ctx context.Context, userID, role string) error
+}
+
+type userService struct {
+	userRepo repositories.UserRepository
+}
+
+// NewUserService crea una nueva instancia de UserService.
+func NewUserService(userRepo repositories.UserRepository) UserService {
+	return &userService{
+		userRepo: userRepo,
+	}
+}
+
+// GetProfile retorna el usuario
+func (s *userService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	return s.userRepo.GetUserByID(ctx, userID)
+}
+
+// UpdateProfile valida y aplica un cambio parcial del perfil. expectedVersion es la versión del
+// usuario que vio el cliente: si cambió desde entonces retorna validations.ErrVersionConflict.
+func (s *userService) UpdateProfile(ctx context.Context, userID string, patch models.ProfilePatch, expectedVersion int64) (*models.User, error) {
+	if patch.IsEmpty() {
+		return nil, validations.ErrInvalidRequest
+	}
+
+	if patch.Name != nil {
+		name, err := validations.ValidateName(*patch.Name, "Nombre")
+		if err != nil {
+			return nil, validations.ErrInvalidName
+		}
+		patch.Name = &name
+	}
+
+	if patch.LastName != nil {
+		lastName, err := validations.ValidateName(*patch.LastName, "Apellido")
+		if err != nil {
+			return nil, validations.ErrInvalidLastName
+		}
+		patch.LastName = &lastName
+	}
+
+	return s.userRepo.PatchProfile(ctx, userID, patch, expectedVersion)
+}
+
+// AssignRole cambia el rol global de otro usuario en nombre de actorID. Se decide con el rol global
+// del actor y no con el del token, que puede ser el de una organización. Nadie cambia su propio rol
+// ni asigna un rol, o modifica a un usuario, de mayor jerarquía que el propio (ver rbac.CanAssignRole).
+func (s *userService) AssignRole(ctx context.Context, actorID, userID, role string) error {
+	if !rbac.IsValidRole(role) {
+		return validations.ErrInvalidRole
+	}
+
+	if actorID == userID {
+		return validations.ErrCannotChangeOwnRole
+	}
+
+	actor, err := s.userRepo.GetUserByID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	actorRole := actor.GetRole()
+	if !rbac.HasPermission(actorRole, rbac.PERMISSION_USERS_MANAGE) {
+		return validations.ErrForbidden
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !rbac.CanAssignRole(actorRole, role) || !rbac.CanAssignRole(actorRole, user.GetRole()) {
+		return validations.ErrRoleNotAssignable
+	}
+
+	return s.userRepo.UpdateRole(ctx, userID, role)
+}
+
+// SetRole cambia el rol de un usuario sin restricciones de jerarquía. Es para administración
+// (por ejemplo, designar al primer OWNER) y no debe exponerse a los usuarios.
+func (s *userService) SetRole(ctx context.Context, userID, role string) error {
+	if !rbac.IsValidRole(role) {
+		return validations.ErrInvalidRole
+	}
+
+	return s.userRepo.UpdateRole(ctx, userID, role)
+}
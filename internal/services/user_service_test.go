@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myproject/pkg/consts"
+	"myproject/pkg/validations"
+)
+
+func TestAssignRole(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	service := NewUserService(env.userRepo)
+
+	for _, email := range []string{"owner@example.com", "admin@example.com", "juan@example.com"} {
+		env.register(t, email)
+	}
+	owner, _ := env.userRepo.GetUserByEmail(ctx, "owner@example.com")
+	admin, _ := env.userRepo.GetUserByEmail(ctx, "admin@example.com")
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	if user.GetRole() != consts.ROLE_CLIENT {
+		t.Fatalf("default role = %q, want %q", user.GetRole(), consts.ROLE_CLIENT)
+	}
+
+	if err := service.SetRole(ctx, owner.ID, consts.ROLE_OWNER); err != nil {
+		t.Fatalf("set owner: %v", err)
+	}
+	if err := service.AssignRole(ctx, owner.ID, admin.ID, consts.ROLE_ADMINISTRATIVE); err != nil {
+		t.Fatalf("assign admin: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		actorID string
+		userID  string
+		role    string
+		want    error
+	}{
+		{"unknown role", admin.ID, user.ID, "SUPERUSER", validations.ErrInvalidRole},
+		{"own role", admin.ID, admin.ID, consts.ROLE_OWNER, validations.ErrCannotChangeOwnRole},
+		{"without permission", user.ID, admin.ID, consts.ROLE_CLIENT, validations.ErrForbidden},
+		{"promote above own role", admin.ID, user.ID, consts.ROLE_OWNER, validations.ErrRoleNotAssignable},
+		{"demote a higher role", admin.ID, owner.ID, consts.ROLE_CLIENT, validations.ErrRoleNotAssignable},
+		{"missing user", admin.ID, "missing", consts.ROLE_EMPLOYEE, validations.ErrDocumentNotFound},
+		{"allowed", admin.ID, user.ID, consts.ROLE_EMPLOYEE, nil},
+	}
+	for _, tt := range tests {
+		err := service.AssignRole(ctx, tt.actorID, tt.userID, tt.role)
+		if !errors.Is(err, tt.want) {
+			t.Fatalf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// El access token lleva el rol y la API usa el vigente aunque cambie después de emitirlo
+	tokens := env.login(t, "juan@example.com")
+	claims, err := env.sessions.ValidateAccessToken(ctx, tokens.AccessToken)
+	if err != nil || claims.Role != consts.ROLE_EMPLOYEE {
+		t.Fatalf("role claim = %+v, err = %v", claims, err)
+	}
+
+	if err := service.AssignRole(ctx, owner.ID, user.ID, consts.ROLE_CLIENT); err != nil {
+		t.Fatalf("demote: %v", err)
+	}
+	claims, err = env.sessions.ValidateAccessToken(ctx, tokens.AccessToken)
+	if err != nil || claims.Role != consts.ROLE_CLIENT {
+		t.Fatalf("role after demotion = %+v, err = %v", claims, err)
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/validations"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// VERIFICATION_DURATION es la vigencia en horas del link de activación
+const VERIFICATION_DURATION = 48
+
+// VERIFICATION_RESEND_COOLDOWN es el tiempo mínimo entre dos envíos del email de verificación
+const VERIFICATION_RESEND_COOLDOWN = 2 * time.Minute
+
+// VerificationService encapsula la lógica de verificación de email y activación de cuenta.
+type VerificationService interface {
+	SendVerification(ctx context.Context, user *models.User) error
+	ActivateAccount(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email string) error
+	ChangeEmail(ctx context.Context, userID, password, newEmail string) error
+}
+
+type verificationService struct {
+	userRepo repositories.UserRepository
+	notifier Notifier
+}
+
+// NewVerificationService crea una nueva instancia de VerificationService.
+func NewVerificationService(userRepo repositories.UserRepository, notifier Notifier) VerificationService {
+	return &verificationService{
+		userRepo: userRepo,
+		notifier: notifier,
+	}
+}
+
+// requireEmailVerification indica si Login debe rechazar usuarios con el email sin verificar
+func requireEmailVerification() bool {
+	return os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true"
+}
+
+// SendVerification envía el link de activación y registra el envío en EmailDetails.
+func (s *verificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := tokens.GenerateEmailVerificationToken(user, VERIFICATION_DURATION)
+	if err != nil {
+		return err
+	}
+
+	link := GetAPIURL() + "/auth/activate?link=" + url.QueryEscape(token)
+	if err := s.notifier.SendEmailVerification(ctx, user, link); err != nil {
+		return err
+	}
+
+	// Solo se escriben los campos del envío, para no pisar cambios concurrentes del usuario
+	sentAt := time.Now()
+	if err := s.userRepo.MarkVerificationSent(ctx, user.ID, sentAt); err != nil {
+		return err
+	}
+	user.ContactInfo.Email.IsSentForVerify = true
+	user.ContactInfo.Email.SentAt = sentAt
+	return nil
+}
+
+// ActivateAccount marca el email como verificado. Es idempotente: un link ya usado no da error.
+func (s *verificationService) ActivateAccount(ctx context.Context, token string) error {
+	// 1. Validar el token
+	claims, err := tokens.ParseEmailVerificationToken(token)
+	if err != nil {
+		return validations.ErrInvalidToken
+	}
+
+	// 2. Buscar al usuario y comprobar que el email no haya cambiado desde el envío
+	user, err := s.userRepo.GetUserByID(ctx, claims.Subject)
+	if err != nil {
+		return validations.ErrInvalidToken
+	}
+
+	if user.ContactInfo.Email.Address != claims.Email {
+		return validations.ErrInvalidToken
+	}
+
+	if user.ContactInfo.Email.IsVerified {
+		return nil
+	}
+
+	// 3. Activar, solo si el email sigue siendo el del link
+	if err := s.userRepo.MarkEmailVerified(ctx, user.ID, claims.Email, time.Now()); err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return validations.ErrInvalidToken
+		}
+		return err
+	}
+	return nil
+}
+
+// ResendVerification reenvía el link de activación respetando un tiempo mínimo entre envíos.
+// No informa si el email existe, si ya está verificado o si se envió hace poco: responder distinto
+// durante la espera revelaría qué emails tienen una cuenta sin verificar.
+func (s *verificationService) ResendVerification(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetUserByEmail(ctx, validations.NormalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if user.ContactInfo.Email.IsVerified {
+		return nil
+	}
+
+	emailDetails := user.ContactInfo.Email
+	if emailDetails.IsSentForVerify {
+		if time.Since(emailDetails.SentAt) < VERIFICATION_RESEND_COOLDOWN {
+			return nil
+		}
+	}
+
+	if err := s.SendVerification(ctx, user); err != nil {
+		log.Printf("ResendVerification: error enviando email a %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+// ChangeEmail cambia el email del usuario tras confirmar su contraseña. La reserva del email se
+// mueve de forma atómica y el nuevo email queda sin verificar hasta que se use el link enviado.
+func (s *verificationService) ChangeEmail(ctx context.Context, userID, password, newEmail string) error {
+	newEmail = validations.NormalizeEmail(newEmail)
+	if !validations.IsValidEmail(newEmail) {
+		return validations.ErrInvalidEmail
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return validations.ErrInvalidCredentials
+	}
+
+	if err := s.userRepo.UpdateEmail(ctx, userID, newEmail); err != nil {
+		if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return ErrUserAlreadyExists
+		}
+		return err
+	}
+
+	// Releer el usuario para enviar la verificación con el email nuevo
+	user, err = s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.SendVerification(ctx, user); err != nil {
+		log.Printf("ChangeEmail: error enviando verificación a %s: %v", user.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"myproject/pkg/consts"
+)
+
+func TestResendVerificationDoesNotRevealAccounts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	// El registro ya envió el link: dentro de la espera responde igual que para un email inexistente
+	for _, email := range []string{"juan@example.com", "nadie@example.com"} {
+		if err := env.verification.ResendVerification(ctx, email); err != nil {
+			t.Fatalf("resend to %s: %v", email, err)
+		}
+	}
+	if links := env.notifier.links["juan@example.com"]; len(links) != 1 {
+		t.Fatalf("verification emails = %d, want 1 during the cooldown", len(links))
+	}
+	if links := env.notifier.links["nadie@example.com"]; len(links) != 0 {
+		t.Fatalf("email sent to an unknown address: %v", links)
+	}
+}
+
+func TestVerificationDoesNotOverwriteConcurrentChanges(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.register(t, "juan@example.com")
+
+	// El usuario leído antes de un cambio concurrente no impide registrar el envío
+	stale, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	if err := env.userRepo.UpdateRole(ctx, stale.ID, consts.ROLE_ADMINISTRATIVE); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.verification.SendVerification(ctx, stale); err != nil {
+		t.Fatalf("send verification with a stale user: %v", err)
+	}
+
+	link, err := url.Parse(env.lastSent(t, "juan@example.com"))
+	if err != nil {
+		t.Fatalf("invalid link: %v", err)
+	}
+	if err := env.verification.ActivateAccount(ctx, link.Query().Get("link")); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+
+	user, _ := env.userRepo.GetUserByID(ctx, stale.ID)
+	if !user.IsUserVerified() || user.ContactInfo.Email.SentAt.IsZero() || user.Role != consts.ROLE_ADMINISTRATIVE {
+		t.Fatalf("unexpected user: role = %s, email = %+v", user.Role, user.ContactInfo.Email)
+	}
+}
@@ -0,0 +1,392 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+)
+
+// WEBAUTHN_CHALLENGE_DURATION es la vigencia de una ceremonia WebAuthn entre las opciones y la respuesta
+const WEBAUTHN_CHALLENGE_DURATION = 5 * time.Minute
+
+// WebAuthnCeremony son las opciones a pasar a navigator.credentials.create/get y el ID
+// con el que el cliente completa la ceremonia.
+type WebAuthnCeremony struct {
+	ChallengeID string
+	Options     interface{}
+}
+
+// WebAuthnService encapsula el registro de passkeys y el login con ellas.
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID string) (*WebAuthnCeremony, error)
+	FinishRegistration(ctx context.Context, userID, challengeID, name string, credential []byte) (*models.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context) (*WebAuthnCeremony, error)
+	FinishLogin(ctx context.Context, challengeID string, credential []byte, client request.ClientInfo) (*tokens.Tokens, error)
+	ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, userID, credentialID string) error
+}
+
+type webAuthnService struct {
+	webAuthn       *webauthn.WebAuthn
+	userRepo       repositories.UserRepository
+	credentialRepo repositories.WebAuthnCredentialRepository
+	challengeRepo  repositories.WebAuthnChallengeRepository
+	sessionService SessionService
+}
+
+// NewWebAuthnService crea una nueva instancia de WebAuthnService. Retorna error si la
+// configuración del relying party es inválida (ver LoadWebAuthnConfig).
+func NewWebAuthnService(
+	userRepo repositories.UserRepository,
+	credentialRepo repositories.WebAuthnCredentialRepository,
+	challengeRepo repositories.WebAuthnChallengeRepository,
+	sessionService SessionService,
+	config *webauthn.Config,
+) (WebAuthnService, error) {
+	webAuthn, err := webauthn.New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webAuthnService{
+		webAuthn:       webAuthn,
+		userRepo:       userRepo,
+		credentialRepo: credentialRepo,
+		challengeRepo:  challengeRepo,
+		sessionService: sessionService,
+	}, nil
+}
+
+// LoadWebAuthnConfig arma la configuración del relying party desde variables de entorno:
+//   - WEBAUTHN_RP_ORIGINS: orígenes permitidos separados por coma (por defecto APP_URL).
+//   - WEBAUTHN_RP_ID: dominio de las passkeys (por defecto el host del primer origen).
+//   - WEBAUTHN_RP_NAME: nombre que muestra el navegador (por defecto el emisor de los tokens).
+func LoadWebAuthnConfig() *webauthn.Config {
+	origins := []string{}
+	for _, origin := range strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	if len(origins) == 0 {
+		origins = []string{getAppURL()}
+	}
+
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		if parsed, err := url.Parse(origins[0]); err == nil {
+			rpID = parsed.Hostname()
+		}
+	}
+
+	rpName := os.Getenv("WEBAUTHN_RP_NAME")
+	if rpName == "" {
+		rpName = tokens.DEFAULT_ISSUER
+	}
+
+	return &webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: rpName,
+		RPOrigins:     origins,
+		// Las passkeys son residentes y exigen verificación del usuario (biometría o PIN):
+		// por eso el login con passkey no pide contraseña ni segundo factor
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		},
+		AttestationPreference: protocol.PreferNoAttestation,
+	}
+}
+
+// BeginRegistration inicia el alta de una passkey para el usuario autenticado.
+// Las passkeys ya registradas se excluyen para no duplicarlas en el mismo autenticador.
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userID string) (*WebAuthnCeremony, error) {
+	user, err := s.loadWebAuthnUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(webauthn.Credentials(user.credentials).CredentialDescriptors()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeID, err := s.saveChallenge(ctx, user.user.ID, models.WEBAUTHN_CEREMONY_REGISTRATION, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnCeremony{ChallengeID: challengeID, Options: creation}, nil
+}
+
+// FinishRegistration verifica la respuesta del autenticador y guarda la passkey.
+// credential es el JSON de la PublicKeyCredential retornada por navigator.credentials.create.
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userID, challengeID, name string, credential []byte) (*models.WebAuthnCredential, error) {
+	session, err := s.consumeChallenge(ctx, challengeID, userID, models.WEBAUTHN_CEREMONY_REGISTRATION)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(credential))
+	if err != nil {
+		return nil, validations.ErrWebAuthnVerification
+	}
+
+	user, err := s.loadWebAuthnUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, validations.ErrWebAuthnVerification
+	}
+
+	passkey := newWebAuthnCredential(userID, name, created)
+	if err := s.credentialRepo.CreateCredential(ctx, passkey); err != nil {
+		if errors.Is(err, validations.ErrDocumentAlreadyExists) {
+			return nil, validations.ErrPasskeyAlreadyRegistered
+		}
+		return nil, err
+	}
+
+	return passkey, nil
+}
+
+// BeginLogin inicia un login con passkey. No se pide el email: el autenticador elige la
+// credencial (discoverable credential) y así no se revela qué cuentas existen.
+func (s *webAuthnService) BeginLogin(ctx context.Context) (*WebAuthnCeremony, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeID, err := s.saveChallenge(ctx, "", models.WEBAUTHN_CEREMONY_LOGIN, session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnCeremony{ChallengeID: challengeID, Options: assertion}, nil
+}
+
+// FinishLogin verifica la firma del autenticador y abre una sesión con los mismos tokens que Login.
+// credential es el JSON de la PublicKeyCredential retornada por navigator.credentials.get.
+func (s *webAuthnService) FinishLogin(ctx context.Context, challengeID string, credential []byte, client request.ClientInfo) (*tokens.Tokens, error) {
+	// 1. La ceremonia se consume antes de verificar: una respuesta fallida no puede reintentarse
+	session, err := s.consumeChallenge(ctx, challengeID, "", models.WEBAUTHN_CEREMONY_LOGIN)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(credential))
+	if err != nil {
+		return nil, validations.ErrInvalidCredentials
+	}
+
+	// 2. Verificar la firma con la passkey del usuario indicado por el autenticador (user handle)
+	var owner *webAuthnUser
+	findUser := func(rawID, userHandle []byte) (webauthn.User, error) {
+		user, err := s.loadWebAuthnUser(ctx, string(userHandle))
+		if err != nil {
+			return nil, err
+		}
+		owner = user
+		return user, nil
+	}
+
+	verified, err := s.webAuthn.ValidateDiscoverableLogin(findUser, *session, parsed)
+	if err != nil {
+		return nil, validations.ErrInvalidCredentials
+	}
+
+	// 3. Un contador de firmas que no avanza indica un autenticador clonado
+	if verified.Authenticator.CloneWarning {
+		log.Printf("FinishLogin: posible passkey clonada del usuario %s", owner.user.ID)
+		return nil, validations.ErrInvalidCredentials
+	}
+
+	credentialID := base64.RawURLEncoding.EncodeToString(verified.ID)
+	err = s.credentialRepo.UpdateCredentialUsage(ctx, credentialID, verified.Authenticator.SignCount, verified.Flags.BackupState, time.Now())
+	if err != nil {
+		if errors.Is(err, validations.ErrConditionFailed) {
+			return nil, validations.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	// 4. Abrir la sesión
+	return s.sessionService.IssueSession(ctx, owner.user, client)
+}
+
+// ListCredentials lista las passkeys del usuario.
+func (s *webAuthnService) ListCredentials(ctx context.Context, userID string) ([]models.WebAuthnCredential, error) {
+	return s.credentialRepo.ListCredentialsByUser(ctx, userID)
+}
+
+// DeleteCredential elimina una passkey del usuario.
+func (s *webAuthnService) DeleteCredential(ctx context.Context, userID, credentialID string) error {
+	err := s.credentialRepo.DeleteCredential(ctx, userID, credentialID)
+	if errors.Is(err, validations.ErrDocumentNotFound) {
+		return validations.ErrPasskeyNotFound
+	}
+	return err
+}
+
+// saveChallenge guarda el estado de la ceremonia y retorna su ID
+func (s *webAuthnService) saveChallenge(ctx context.Context, userID, ceremony string, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := models.NewWebAuthnChallenge(uuid.New().String(), userID, ceremony, string(data), time.Now().Add(WEBAUTHN_CHALLENGE_DURATION))
+	if err := s.challengeRepo.CreateChallenge(ctx, challenge); err != nil {
+		return "", err
+	}
+
+	return challenge.ID, nil
+}
+
+// consumeChallenge obtiene (y elimina) la ceremonia, verificando que sea del tipo y usuario esperados
+func (s *webAuthnService) consumeChallenge(ctx context.Context, challengeID, userID, ceremony string) (*webauthn.SessionData, error) {
+	challenge, err := s.challengeRepo.ConsumeChallenge(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, validations.ErrDocumentNotFound) {
+			return nil, validations.ErrWebAuthnChallengeInvalid
+		}
+		return nil, err
+	}
+
+	if challenge.Ceremony != ceremony || challenge.UserID != userID {
+		return nil, validations.ErrWebAuthnChallengeInvalid
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(challenge.SessionData), &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// loadWebAuthnUser obtiene el usuario junto con sus passkeys
+func (s *webAuthnService) loadWebAuthnUser(ctx context.Context, userID string) (*webAuthnUser, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.credentialRepo.ListCredentialsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, credential := range stored {
+		converted, err := toWebAuthnCredential(credential)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, converted)
+	}
+
+	return &webAuthnUser{user: user, credentials: credentials}, nil
+}
+
+// webAuthnUser adapta models.User a la interfaz webauthn.User. El user handle es el ID del usuario.
+type webAuthnUser struct {
+	user        *models.User
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return []byte(u.user.ID)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.ContactInfo.Email.Address
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return strings.TrimSpace(u.user.PersonalInfo.Name + " " + u.user.PersonalInfo.LastName)
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+// newWebAuthnCredential convierte la credencial verificada por la librería en el modelo a persistir
+func newWebAuthnCredential(userID, name string, credential *webauthn.Credential) *models.WebAuthnCredential {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, transport := range credential.Transport {
+		transports = append(transports, string(transport))
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = "Passkey"
+	}
+
+	return &models.WebAuthnCredential{
+		ID:              base64.RawURLEncoding.EncodeToString(credential.ID),
+		UserID:          userID,
+		Name:            name,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       credential.Authenticator.SignCount,
+		Transports:      transports,
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// toWebAuthnCredential convierte una passkey guardada al formato que verifica la librería
+func toWebAuthnCredential(credential models.WebAuthnCredential) (webauthn.Credential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(credential.ID)
+	if err != nil {
+		return webauthn.Credential{}, err
+	}
+
+	transports := make([]protocol.AuthenticatorTransport, 0, len(credential.Transports))
+	for _, transport := range credential.Transports {
+		transports = append(transports, protocol.AuthenticatorTransport(transport))
+	}
+
+	return webauthn.Credential{
+		ID:              id,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			BackupEligible: credential.BackupEligible,
+			BackupState:    credential.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:    credential.AAGUID,
+			SignCount: credential.SignCount,
+		},
+	}, nil
+}
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"myproject/internal/models"
+	"myproject/internal/repositories/memory"
+	"myproject/pkg/request"
+	"myproject/pkg/validations"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+)
+
+// Origen y RP ID por defecto de LoadWebAuthnConfig sin variables de entorno (APP_URL de desarrollo)
+const (
+	testWebAuthnOrigin = "http://localhost:3000"
+	testWebAuthnRPID   = "localhost"
+)
+
+// Flags de authenticatorData (WebAuthn §6.1)
+const (
+	flagUserPresent    byte = 0x01
+	flagUserVerified   byte = 0x04
+	flagBackupEligible byte = 0x08
+	flagBackupState    byte = 0x10
+	flagAttestedData   byte = 0x40
+)
+
+// softAuthenticator es un autenticador de plataforma por software: genera una passkey ECDSA P-256
+// con attestation "none" y firma las aserciones como lo haría un navegador.
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	rand.Read(credentialID)
+	return &softAuthenticator{key: key, credentialID: credentialID, origin: testWebAuthnOrigin}
+}
+
+// create responde a navigator.credentials.create con las opciones de BeginRegistration
+func (a *softAuthenticator) create(t *testing.T, ceremony *WebAuthnCeremony) []byte {
+	t.Helper()
+	options := ceremony.Options.(*protocol.CredentialCreation).Response
+	a.userHandle = options.User.ID.(protocol.URLEncodedBase64)
+
+	publicKey, err := webauthncbor.Marshal(webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{
+			KeyType:   int64(webauthncose.EllipticKey),
+			Algorithm: int64(webauthncose.AlgES256),
+		},
+		Curve:  int64(webauthncose.P256),
+		XCoord: a.key.X.FillBytes(make([]byte, 32)),
+		YCoord: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// attestedCredentialData: AAGUID (cero) | largo del credential ID | credential ID | clave COSE
+	attested := make([]byte, 16, 16+2+len(a.credentialID)+len(publicKey))
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	authData := append(a.authenticatorData(flagAttestedData), attested...)
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credentialJSON(t, map[string]interface{}{
+		"clientDataJSON":    encode(a.clientData(t, "webauthn.create", challengeOf(ceremony))),
+		"attestationObject": encode(attestation),
+		"transports":        []string{"internal", "hybrid"},
+	})
+}
+
+// get responde a navigator.credentials.get con las opciones de BeginLogin
+func (a *softAuthenticator) get(t *testing.T, ceremony *WebAuthnCeremony) []byte {
+	t.Helper()
+	a.signCount++
+	authData := a.authenticatorData(0)
+	clientData := a.clientData(t, "webauthn.get", challengeOf(ceremony))
+
+	hash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), hash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return a.credentialJSON(t, map[string]interface{}{
+		"clientDataJSON":    encode(clientData),
+		"authenticatorData": encode(authData),
+		"signature":         encode(signature),
+		"userHandle":        encode(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) authenticatorData(extraFlags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testWebAuthnRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flagUserPresent|flagUserVerified|flagBackupEligible|flagBackupState|extraFlags)
+	return binary.BigEndian.AppendUint32(data, a.signCount)
+}
+
+func (a *softAuthenticator) clientData(t *testing.T, ceremonyType string, challenge protocol.URLEncodedBase64) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": challenge.String(),
+		"origin":    a.origin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func (a *softAuthenticator) credentialJSON(t *testing.T, response map[string]interface{}) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       encode(a.credentialID),
+		"rawId":    encode(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// challengeOf retorna el challenge de las opciones de registro o de login
+func challengeOf(ceremony *WebAuthnCeremony) protocol.URLEncodedBase64 {
+	switch options := ceremony.Options.(type) {
+	case *protocol.CredentialCreation:
+		return options.Response.Challenge
+	case *protocol.CredentialAssertion:
+		return options.Response.Challenge
+	}
+	return nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// newWebAuthnTestService crea el servicio de passkeys sobre los repositorios del entorno de pruebas
+func newWebAuthnTestService(t *testing.T, env *testEnv) WebAuthnService {
+	t.Helper()
+	service, err := NewWebAuthnService(env.userRepo, memory.NewWebAuthnCredentialRepository(), memory.NewWebAuthnChallengeRepository(), env.sessions, LoadWebAuthnConfig())
+	if err != nil {
+		t.Fatalf("webauthn service: %v", err)
+	}
+	return service
+}
+
+// registerPasskey registra una passkey del autenticador para el usuario
+func registerPasskey(t *testing.T, service WebAuthnService, userID string, authenticator *softAuthenticator) *models.WebAuthnCredential {
+	t.Helper()
+	ctx := context.Background()
+	ceremony, err := service.BeginRegistration(ctx, userID)
+	if err != nil {
+		t.Fatalf("begin registration: %v", err)
+	}
+
+	credential, err := service.FinishRegistration(ctx, userID, ceremony.ChallengeID, "Laptop", authenticator.create(t, ceremony))
+	if err != nil {
+		t.Fatalf("finish registration: %v", err)
+	}
+	return credential
+}
+
+func beginPasskeyLogin(t *testing.T, service WebAuthnService) *WebAuthnCeremony {
+	t.Helper()
+	ceremony, err := service.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("begin login: %v", err)
+	}
+	return ceremony
+}
+
+func TestWebAuthnRegisterAndLogin(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	service := newWebAuthnTestService(t, env)
+	authenticator := newSoftAuthenticator(t)
+
+	credential := registerPasskey(t, service, user.ID, authenticator)
+	if credential.Name != "Laptop" || len(credential.Transports) != 2 || !credential.BackupEligible {
+		t.Fatalf("unexpected credential: %+v", credential)
+	}
+
+	credentials, err := service.ListCredentials(ctx, user.ID)
+	if err != nil || len(credentials) != 1 || credentials[0].ID != encode(authenticator.credentialID) {
+		t.Fatalf("credentials = %+v, err = %v", credentials, err)
+	}
+
+	// Cada login firma con un contador mayor y emite los mismos tokens que Login
+	for i := 0; i < 2; i++ {
+		ceremony := beginPasskeyLogin(t, service)
+		pair, err := service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.get(t, ceremony), request.ClientInfo{UserAgent: "test", IP: "127.0.0.1"})
+		if err != nil {
+			t.Fatalf("login %d: %v", i, err)
+		}
+
+		claims, err := env.sessions.ValidateAccessToken(ctx, pair.AccessToken)
+		if err != nil || claims.Subject != user.ID || claims.SessionID == "" {
+			t.Fatalf("login %d: claims = %+v, err = %v", i, claims, err)
+		}
+		if _, err := env.sessions.RefreshToken(ctx, pair.RefreshToken, request.ClientInfo{}); err != nil {
+			t.Fatalf("login %d: refresh token rejected: %v", i, err)
+		}
+	}
+
+	credentials, _ = service.ListCredentials(ctx, user.ID)
+	if credentials[0].SignCount != 2 || credentials[0].LastUsedAt.IsZero() {
+		t.Fatalf("usage not recorded: %+v", credentials[0])
+	}
+
+	if err := service.DeleteCredential(ctx, "otro-usuario", credentials[0].ID); !errors.Is(err, validations.ErrPasskeyNotFound) {
+		t.Fatalf("delete foreign passkey: error = %v", err)
+	}
+	if err := service.DeleteCredential(ctx, user.ID, credentials[0].ID); err != nil {
+		t.Fatalf("delete passkey: %v", err)
+	}
+
+	ceremony := beginPasskeyLogin(t, service)
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.get(t, ceremony), request.ClientInfo{}); !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("login with deleted passkey: error = %v", err)
+	}
+}
+
+func TestWebAuthnChallengeIsSingleUse(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	env.register(t, "ana@example.com")
+	ctx := context.Background()
+	juan, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+	ana, _ := env.userRepo.GetUserByEmail(ctx, "ana@example.com")
+
+	service := newWebAuthnTestService(t, env)
+	authenticator := newSoftAuthenticator(t)
+
+	// La ceremonia de registro de un usuario no sirve para otro
+	ceremony, err := service.BeginRegistration(ctx, juan.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.FinishRegistration(ctx, ana.ID, ceremony.ChallengeID, "", authenticator.create(t, ceremony)); !errors.Is(err, validations.ErrWebAuthnChallengeInvalid) {
+		t.Fatalf("registration with foreign challenge: error = %v", err)
+	}
+
+	registerPasskey(t, service, juan.ID, authenticator)
+
+	// Registrar otra vez la misma credencial
+	ceremony, _ = service.BeginRegistration(ctx, juan.ID)
+	if _, err := service.FinishRegistration(ctx, juan.ID, ceremony.ChallengeID, "", authenticator.create(t, ceremony)); !errors.Is(err, validations.ErrPasskeyAlreadyRegistered) {
+		t.Fatalf("duplicate passkey: error = %v", err)
+	}
+
+	// Una ceremonia de registro no sirve para login
+	ceremony, _ = service.BeginRegistration(ctx, juan.ID)
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.get(t, ceremony), request.ClientInfo{}); !errors.Is(err, validations.ErrWebAuthnChallengeInvalid) {
+		t.Fatalf("login with registration challenge: error = %v", err)
+	}
+
+	// La ceremonia de login se consume al usarla
+	ceremony = beginPasskeyLogin(t, service)
+	assertion := authenticator.get(t, ceremony)
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, assertion, request.ClientInfo{}); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, assertion, request.ClientInfo{}); !errors.Is(err, validations.ErrWebAuthnChallengeInvalid) {
+		t.Fatalf("replayed assertion: error = %v", err)
+	}
+}
+
+func TestWebAuthnRejectsInvalidAssertions(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	service := newWebAuthnTestService(t, env)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user.ID, authenticator)
+
+	tests := []struct {
+		name   string
+		tamper func(a *softAuthenticator)
+	}{
+		// Un sitio de phishing obtiene una firma para su propio origen
+		{"wrong origin", func(a *softAuthenticator) { a.origin = "https://evil.example.com" }},
+		// Otra clave para el mismo credential ID: la firma no verifica con la clave registrada
+		{"wrong key", func(a *softAuthenticator) { a.key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader) }},
+		// Una passkey que nunca se registró
+		{"unknown credential", func(a *softAuthenticator) { a.credentialID = []byte("no-registrada") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forged := *authenticator
+			tt.tamper(&forged)
+
+			ceremony := beginPasskeyLogin(t, service)
+			_, err := service.FinishLogin(ctx, ceremony.ChallengeID, forged.get(t, ceremony), request.ClientInfo{})
+			if !errors.Is(err, validations.ErrInvalidCredentials) {
+				t.Fatalf("error = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+}
+
+func TestWebAuthnRejectsClonedAuthenticator(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	service := newWebAuthnTestService(t, env)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user.ID, authenticator)
+
+	// El clon copia la clave y el contador antes de que el original vuelva a usarse
+	clone := *authenticator
+
+	ceremony := beginPasskeyLogin(t, service)
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.get(t, ceremony), request.ClientInfo{}); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	ceremony = beginPasskeyLogin(t, service)
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, clone.get(t, ceremony), request.ClientInfo{}); !errors.Is(err, validations.ErrInvalidCredentials) {
+		t.Fatalf("cloned authenticator: error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestWebAuthnLoginInactiveUser(t *testing.T) {
+	env := newTestEnv(t)
+	env.register(t, "juan@example.com")
+	ctx := context.Background()
+	user, _ := env.userRepo.GetUserByEmail(ctx, "juan@example.com")
+
+	service := newWebAuthnTestService(t, env)
+	authenticator := newSoftAuthenticator(t)
+	registerPasskey(t, service, user.ID, authenticator)
+
+	if err := env.userRepo.UpdateStatus(ctx, user.ID, models.USER_STATUS_BANNED); err != nil {
+		t.Fatal(err)
+	}
+
+	ceremony := beginPasskeyLogin(t, service)
+	if _, err := service.FinishLogin(ctx, ceremony.ChallengeID, authenticator.get(t, ceremony), request.ClientInfo{}); !errors.Is(err, validations.ErrUserInactive) {
+		t.Fatalf("error = %v, want ErrUserInactive", err)
+	}
+}
@@ -0,0 +1,241 @@
+// Package authz es un motor de autorización por atributos (ABAC). Evalúa políticas declarativas
+// (ver Policy) sobre el sujeto (los claims del access token que dejó AuthMiddleware), la acción y
+// los atributos del recurso. Complementa a pkg/rbac para reglas que dependen del recurso, como
+// "un EMPLOYEE solo lee los clientes que tiene asignados".
+package authz
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/validations"
+)
+
+// Modos del log de decisiones (AUTHZ_DECISION_LOG)
+const (
+	DECISION_LOG_OFF  = "off"
+	DECISION_LOG_DENY = "deny" // por defecto: solo las denegaciones, para depurarlas
+	DECISION_LOG_ALL  = "all"
+)
+
+// defaultPolicies son las políticas usadas si no se configura AUTHZ_POLICY_FILE
+//
+//go:embed policies.yaml
+var defaultPolicies []byte
+
+// Subject es quien realiza la acción, tomado de los claims del access token.
+type Subject struct {
+	ID             string
+	Role           string
+	OrganizationID string
+	ClientID       string
+	Email          string
+	Scopes         []string
+}
+
+// SubjectFromClaims arma el sujeto a partir de los claims de un access token.
+func SubjectFromClaims(claims *tokens.Claims) Subject {
+	if claims == nil {
+		return Subject{}
+	}
+	return Subject{
+		ID:             claims.Subject,
+		Role:           claims.Role,
+		OrganizationID: claims.OrganizationID,
+		ClientID:       claims.ClientID,
+		Email:          claims.Email,
+		Scopes:         strings.Fields(claims.Scope),
+	}
+}
+
+// SubjectFromContext retorna el sujeto de la petición. Sin access token (rutas públicas) es anónimo:
+// todos sus atributos están vacíos.
+func SubjectFromContext(ctx context.Context) Subject {
+	claims, _ := ctx.Value("claims").(*tokens.Claims)
+	return SubjectFromClaims(claims)
+}
+
+// Resource es el recurso sobre el que se actúa. Attributes son los datos usados por las
+// condiciones (resource.<nombre>), por ejemplo organization_id u owner_id.
+type Resource struct {
+	Type       string
+	ID         string
+	Attributes map[string]interface{}
+}
+
+// Decision es el resultado de evaluar una acción, con lo necesario para entender una denegación.
+type Decision struct {
+	Allowed  bool
+	Action   string
+	Subject  Subject
+	Resource Resource
+	// PolicyID es la política que decidió; vacío si ninguna aplicó (denegación por defecto)
+	PolicyID string
+	Reason   string
+}
+
+// Engine evalúa las políticas. Es seguro para uso concurrente.
+type Engine struct {
+	policies    []Policy
+	decisionLog string
+	logf        func(format string, args ...interface{})
+}
+
+// NewEngine crea un Engine con las políticas indicadas y el modo de log de decisiones.
+func NewEngine(policies []Policy, decisionLog string) (*Engine, error) {
+	if err := validatePolicies(policies); err != nil {
+		return nil, err
+	}
+	switch decisionLog {
+	case DECISION_LOG_OFF, DECISION_LOG_DENY, DECISION_LOG_ALL:
+	default:
+		return nil, fmt.Errorf("AUTHZ_DECISION_LOG inválido: %q", decisionLog)
+	}
+
+	return &Engine{
+		policies:    policies,
+		decisionLog: decisionLog,
+		logf:        log.Printf,
+	}, nil
+}
+
+// Evaluate decide si el sujeto puede realizar la acción sobre el recurso. Se deniega si alguna
+// política deny aplica o si ninguna allow aplica.
+func (e *Engine) Evaluate(subject Subject, action string, resource Resource) Decision {
+	decision := Decision{Action: action, Subject: subject, Resource: resource}
+
+	var allowedBy string
+	for _, policy := range e.policies {
+		if !policy.appliesTo(subject, action, resource) {
+			continue
+		}
+		if policy.Effect == EFFECT_DENY {
+			decision.PolicyID = policy.ID
+			decision.Reason = "denegado por la política " + policy.ID
+			return decision
+		}
+		if allowedBy == "" {
+			allowedBy = policy.ID
+		}
+	}
+
+	if allowedBy == "" {
+		decision.Reason = "ninguna política permite la acción"
+		return decision
+	}
+
+	decision.Allowed = true
+	decision.PolicyID = allowedBy
+	decision.Reason = "permitido por la política " + allowedBy
+	return decision
+}
+
+// Authorize evalúa la acción para el sujeto de la petición. Si se deniega retorna
+// validations.ErrForbidden; el motivo queda en el log de decisiones y no se expone al cliente.
+func (e *Engine) Authorize(ctx context.Context, action string, resource Resource) error {
+	decision := e.Evaluate(SubjectFromContext(ctx), action, resource)
+	e.logDecision(decision)
+
+	if !decision.Allowed {
+		return validations.ErrForbidden
+	}
+	return nil
+}
+
+// logDecision registra la decisión según el modo configurado
+func (e *Engine) logDecision(decision Decision) {
+	if e.decisionLog == DECISION_LOG_OFF || decision.Allowed && e.decisionLog != DECISION_LOG_ALL {
+		return
+	}
+
+	result := "DENY"
+	if decision.Allowed {
+		result = "ALLOW"
+	}
+	e.logf("authz %s: action=%s resource=%s/%s subject=%s role=%s org=%s: %s",
+		result, decision.Action, decision.Resource.Type, decision.Resource.ID,
+		decision.Subject.ID, decision.Subject.Role, decision.Subject.OrganizationID, decision.Reason)
+}
+
+// appliesTo indica si la política aplica a la acción y el recurso y se cumplen sus condiciones
+func (p *Policy) appliesTo(subject Subject, action string, resource Resource) bool {
+	if !matchesAny(p.Actions, action) {
+		return false
+	}
+	if len(p.Resources) > 0 && !matchesAny(p.Resources, resource.Type) {
+		return false
+	}
+
+	for _, condition := range p.Conditions {
+		if !condition.holds(subject, action, resource) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny indica si value coincide con algún patrón. "*" reemplaza cualquier texto, por
+// ejemplo "clients:*" o "*:read".
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+//----------- MOTOR GLOBAL -----------\\
+
+var (
+	engine     *Engine
+	engineErr  error
+	engineOnce sync.Once
+)
+
+// Init carga el motor global desde variables de entorno. Se llama al iniciar la aplicación
+// para fallar rápido ante un archivo de políticas inválido.
+func Init() error {
+	engineOnce.Do(func() {
+		engine, engineErr = LoadEngineFromEnv()
+	})
+	return engineErr
+}
+
+// LoadEngineFromEnv crea un Engine con las políticas de AUTHZ_POLICY_FILE (o las incluidas por
+// defecto, ver policies.yaml) y el modo de AUTHZ_DECISION_LOG.
+func LoadEngineFromEnv() (*Engine, error) {
+	decisionLog := os.Getenv("AUTHZ_DECISION_LOG")
+	if decisionLog == "" {
+		decisionLog = DECISION_LOG_DENY
+	}
+
+	var policies []Policy
+	var err error
+	if path := os.Getenv("AUTHZ_POLICY_FILE"); path != "" {
+		policies, err = LoadPolicies(path)
+	} else {
+		policies, err = ParsePolicies(defaultPolicies, "yaml")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEngine(policies, decisionLog)
+}
+
+// Authorize evalúa la acción con el motor global. Si el motor no pudo cargarse, deniega.
+func Authorize(ctx context.Context, action string, resource Resource) error {
+	if err := Init(); err != nil {
+		log.Printf("authz: motor no disponible: %v", err)
+		return validations.ErrForbidden
+	}
+	return engine.Authorize(ctx, action, resource)
+}
@@ -0,0 +1,146 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"myproject/pkg/consts"
+	tokens "myproject/pkg/jwt"
+	"myproject/pkg/validations"
+)
+
+func newTestEngine(t *testing.T, decisionLog string) *Engine {
+	t.Helper()
+	policies, err := ParsePolicies(defaultPolicies, "yaml")
+	if err != nil {
+		t.Fatalf("default policies: %v", err)
+	}
+	engine, err := NewEngine(policies, decisionLog)
+	if err != nil {
+		t.Fatalf("new engine: %v", err)
+	}
+	return engine
+}
+
+func TestDefaultPolicies(t *testing.T) {
+	engine := newTestEngine(t, DECISION_LOG_OFF)
+
+	owner := Subject{ID: "u1", Role: consts.ROLE_OWNER, OrganizationID: "org1"}
+	employee := Subject{ID: "u2", Role: consts.ROLE_EMPLOYEE, OrganizationID: "org1"}
+	client := Subject{ID: "u3", Role: consts.ROLE_CLIENT, OrganizationID: "org1"}
+	ownClient := Resource{Type: "client", ID: "c1", Attributes: map[string]interface{}{"organization_id": "org1", "owner_id": "u3"}}
+	otherOrg := Resource{Type: "client", ID: "c2", Attributes: map[string]interface{}{"organization_id": "org2", "owner_id": "u3"}}
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		action   string
+		resource Resource
+		want     bool
+	}{
+		{"owner updates", owner, "clients:update", ownClient, true},
+		{"owner in another organization", owner, "clients:read", otherOrg, false},
+		{"employee reads an assigned one", employee, "clients:read", Resource{Type: "client", Attributes: map[string]interface{}{"organization_id": "org1", "owner_id": "u2"}}, true},
+		{"employee reads another's", employee, "clients:read", ownClient, false},
+		{"employee reads one without owner", employee, "clients:read", Resource{Type: "client", Attributes: map[string]interface{}{"organization_id": "org1"}}, false},
+		{"employee updates", employee, "clients:update", ownClient, false},
+		{"client updates its own", client, "clients:update", ownClient, true},
+		{"client updates its own in another organization", client, "clients:update", otherOrg, false},
+		{"client reads another's", client, "clients:read", Resource{Type: "client", Attributes: map[string]interface{}{"organization_id": "org1", "owner_id": "u9"}}, false},
+		{"anonymous", Subject{}, "clients:read", ownClient, false},
+		{"owner of a resource without organization", Subject{ID: "u3", Role: consts.ROLE_CLIENT}, "clients:update", ownClient, false},
+	}
+	for _, tt := range tests {
+		if got := engine.Evaluate(tt.subject, tt.action, tt.resource); got.Allowed != tt.want {
+			t.Errorf("%s: allowed = %v, want %v (%s)", tt.name, got.Allowed, tt.want, got.Reason)
+		}
+	}
+}
+
+func TestEvaluateDenyOverrides(t *testing.T) {
+	policies, err := ParsePolicies([]byte(`{"policies": [
+		{"id": "read-all", "effect": "allow", "actions": ["reports:*"]},
+		{"id": "no-clients", "effect": "deny", "actions": ["*"], "resources": ["report"],
+		 "conditions": [{"attribute": "subject.scopes", "operator": "contains", "value": "readonly"},
+		                {"attribute": "resource.level", "operator": "in", "value": [2, 3]}]}
+	]}`), "json")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	engine, _ := NewEngine(policies, DECISION_LOG_OFF)
+
+	subject := Subject{ID: "u1", Scopes: []string{"openid", "readonly"}}
+	decision := engine.Evaluate(subject, "reports:read", Resource{Type: "report", Attributes: map[string]interface{}{"level": 2}})
+	if decision.Allowed || decision.PolicyID != "no-clients" {
+		t.Fatalf("decision = %+v, want denied by no-clients", decision)
+	}
+
+	decision = engine.Evaluate(subject, "reports:read", Resource{Type: "report", Attributes: map[string]interface{}{"level": 1}})
+	if !decision.Allowed || decision.PolicyID != "read-all" {
+		t.Fatalf("decision = %+v, want allowed by read-all", decision)
+	}
+
+	decision = engine.Evaluate(subject, "users:read", Resource{Type: "user"})
+	if decision.Allowed || decision.PolicyID != "" {
+		t.Fatalf("decision = %+v, want default deny", decision)
+	}
+}
+
+func TestParsePoliciesValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{"missing id", `{effect: allow, actions: [a]}`, "sin id"},
+		{"invalid effect", `{id: p, effect: maybe, actions: [a]}`, "effect"},
+		{"missing actions", `{id: p, effect: allow}`, "actions"},
+		{"invalid pattern", `{id: p, effect: allow, actions: ["clients:[read"]}`, "patrón"},
+		{"unknown operator", `{id: p, effect: allow, actions: [a], conditions: [{attribute: subject.role, operator: gt, value: 1}]}`, "operador"},
+		{"invalid attribute", `{id: p, effect: allow, actions: [a], conditions: [{attribute: role, operator: eq, value: OWNER}]}`, "atributo"},
+		{"in without list", `{id: p, effect: allow, actions: [a], conditions: [{attribute: subject.role, operator: in, value: OWNER}]}`, "lista"},
+		{"value and value_from", `{id: p, effect: allow, actions: [a], conditions: [{attribute: subject.id, operator: eq, value: x, value_from: resource.owner_id}]}`, "value"},
+	}
+	for _, tt := range tests {
+		_, err := ParsePolicies([]byte("policies: ["+tt.policy+"]"), "yaml")
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+
+	duplicated := `policies: [{id: p, effect: allow, actions: [a]}, {id: p, effect: deny, actions: [b]}]`
+	if _, err := ParsePolicies([]byte(duplicated), "yaml"); err == nil {
+		t.Error("duplicated id: expected an error")
+	}
+	if _, err := ParsePolicies([]byte(`policies: []`), "toml"); err == nil {
+		t.Error("unsupported format: expected an error")
+	}
+}
+
+func TestAuthorizeLogsDenials(t *testing.T) {
+	engine := newTestEngine(t, DECISION_LOG_DENY)
+	var logged []string
+	engine.logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+
+	claims := &tokens.Claims{Role: consts.ROLE_EMPLOYEE, OrganizationID: "org1"}
+	claims.Subject = "u2"
+	ctx := context.WithValue(context.Background(), "claims", claims)
+	assigned := Resource{Type: "client", ID: "c1", Attributes: map[string]interface{}{"organization_id": "org1", "owner_id": "u2"}}
+	resource := Resource{Type: "client", ID: "c2", Attributes: map[string]interface{}{"organization_id": "org1", "owner_id": "u9"}}
+
+	if err := engine.Authorize(ctx, "clients:read", assigned); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := engine.Authorize(ctx, "clients:delete", resource); !errors.Is(err, validations.ErrForbidden) {
+		t.Fatalf("delete: error = %v, want ErrForbidden", err)
+	}
+
+	if len(logged) != 1 || !strings.Contains(logged[0], "DENY") || !strings.Contains(logged[0], "action=clients:delete") || !strings.Contains(logged[0], "subject=u2") {
+		t.Fatalf("decision log = %q, want only the denial", logged)
+	}
+}
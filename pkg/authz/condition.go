@@ -0,0 +1,151 @@
+package authz
+
+import (
+	"fmt"
+	"strings"
+)
+
+// holds indica si la condición se cumple. Un atributo inexistente nunca es igual a nada ni está
+// en ninguna lista, de modo que una condición sobre un dato faltante no concede permisos. Si lo
+// que falta es el valor de comparación (ValueFrom), ne y not_in se cumplen: un recurso de una
+// organización es "de otra organización" para un sujeto sin organización, y las denegaciones
+// como deny-other-organization fallan cerradas.
+func (c *Condition) holds(subject Subject, action string, resource Resource) bool {
+	value, found := resolve(c.Attribute, subject, action, resource)
+
+	if c.Operator == OPERATOR_EXISTS {
+		return found && !isEmpty(value)
+	}
+
+	expected := c.Value
+	if c.ValueFrom != "" {
+		var expectedFound bool
+		expected, expectedFound = resolve(c.ValueFrom, subject, action, resource)
+		if !expectedFound {
+			return found && (c.Operator == OPERATOR_NOT_EQUALS || c.Operator == OPERATOR_NOT_IN)
+		}
+	}
+
+	switch c.Operator {
+	case OPERATOR_EQUALS:
+		return found && equals(value, expected)
+	case OPERATOR_NOT_EQUALS:
+		return found && !equals(value, expected)
+	case OPERATOR_IN:
+		return found && contains(expected, value)
+	case OPERATOR_NOT_IN:
+		return found && !contains(expected, value)
+	case OPERATOR_CONTAINS:
+		return found && contains(value, expected)
+	}
+	return false
+}
+
+// resolve retorna el valor de un atributo: "action", "subject.<campo>" o "resource.<campo>"
+// (type, id o un atributo del recurso). Los valores vacíos del sujeto cuentan como inexistentes.
+func resolve(path string, subject Subject, action string, resource Resource) (interface{}, bool) {
+	if path == "action" {
+		return action, true
+	}
+
+	if name, ok := strings.CutPrefix(path, "subject."); ok {
+		var value interface{}
+		switch name {
+		case "id":
+			value = subject.ID
+		case "role":
+			value = subject.Role
+		case "organization_id":
+			value = subject.OrganizationID
+		case "client_id":
+			value = subject.ClientID
+		case "email":
+			value = subject.Email
+		case "scopes":
+			return toList(subject.Scopes), true
+		default:
+			return nil, false
+		}
+		return value, value != ""
+	}
+
+	if name, ok := strings.CutPrefix(path, "resource."); ok {
+		switch name {
+		case "type":
+			return resource.Type, resource.Type != ""
+		case "id":
+			return resource.ID, resource.ID != ""
+		}
+		value, found := resource.Attributes[name]
+		return value, found && value != nil
+	}
+
+	return nil, false
+}
+
+// equals compara dos valores escalares. Los números se comparan por valor, sin importar si
+// vienen de YAML (int) o de JSON (float64).
+func equals(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return x == y
+		}
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// contains indica si list (una lista) tiene un elemento igual a value
+func contains(list, value interface{}) bool {
+	items, ok := asList(list)
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if equals(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// asList convierte las listas de los atributos y de las políticas a []interface{}
+func asList(value interface{}) ([]interface{}, bool) {
+	switch list := value.(type) {
+	case []interface{}:
+		return list, true
+	case []string:
+		return toList(list), true
+	}
+	return nil, false
+}
+
+func toList(values []string) []interface{} {
+	list := make([]interface{}, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int:
+		return float64(number), true
+	case int32:
+		return float64(number), true
+	case int64:
+		return float64(number), true
+	case float32:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
+
+func isEmpty(value interface{}) bool {
+	if list, ok := asList(value); ok {
+		return len(list) == 0
+	}
+	return value == nil || value == ""
+}
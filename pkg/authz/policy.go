@@ -0,0 +1,164 @@
+package authz
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Effect es el resultado que aplica una política cuando se cumple.
+type Effect string
+
+const (
+	EFFECT_ALLOW Effect = "allow"
+	EFFECT_DENY  Effect = "deny"
+)
+
+// Operadores de las condiciones
+const (
+	OPERATOR_EQUALS     = "eq"
+	OPERATOR_NOT_EQUALS = "ne"
+	OPERATOR_IN         = "in"
+	OPERATOR_NOT_IN     = "not_in"
+	OPERATOR_CONTAINS   = "contains" // el atributo es una lista (por ejemplo subject.scopes) que contiene el valor
+	OPERATOR_EXISTS     = "exists"   // el atributo tiene un valor no vacío
+)
+
+// Policy es una regla declarativa: si la acción y el tipo de recurso coinciden y se cumplen todas
+// las condiciones, la política permite o deniega. Una denegación prevalece sobre cualquier permiso.
+type Policy struct {
+	ID          string `json:"id" yaml:"id"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Effect      Effect `json:"effect" yaml:"effect"`
+	// Actions son las acciones a las que aplica: "clients:read", "clients:*", "*:read" o "*"
+	Actions []string `json:"actions" yaml:"actions"`
+	// Resources son los tipos de recurso a los que aplica; vacío o "*" aplica a todos
+	Resources  []string    `json:"resources,omitempty" yaml:"resources,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+}
+
+// Condition compara un atributo ("subject.role", "resource.owner_id", "action") con un valor
+// literal (Value) o con otro atributo (ValueFrom), por ejemplo resource.owner_id == subject.id.
+type Condition struct {
+	Attribute string      `json:"attribute" yaml:"attribute"`
+	Operator  string      `json:"operator" yaml:"operator"`
+	Value     interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	ValueFrom string      `json:"value_from,omitempty" yaml:"value_from,omitempty"`
+}
+
+// policyFile es el formato del archivo de políticas
+type policyFile struct {
+	Policies []Policy `json:"policies" yaml:"policies"`
+}
+
+// LoadPolicies lee las políticas de un archivo YAML (.yaml, .yml) o JSON (.json).
+func LoadPolicies(path string) ([]Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	policies, err := ParsePolicies(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policies, nil
+}
+
+// ParsePolicies decodifica y valida las políticas en formato "yaml" o "json".
+func ParsePolicies(data []byte, format string) ([]Policy, error) {
+	var file policyFile
+	switch format {
+	case "yaml", "yml":
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+	case "json":
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("formato de políticas no soportado: %q", format)
+	}
+
+	if err := validatePolicies(file.Policies); err != nil {
+		return nil, err
+	}
+	return file.Policies, nil
+}
+
+// validatePolicies rechaza políticas incompletas o ambiguas, para fallar al iniciar y no al evaluar.
+func validatePolicies(policies []Policy) error {
+	ids := map[string]bool{}
+	for _, policy := range policies {
+		if policy.ID == "" {
+			return fmt.Errorf("política sin id")
+		}
+		if ids[policy.ID] {
+			return fmt.Errorf("política %q duplicada", policy.ID)
+		}
+		ids[policy.ID] = true
+
+		if policy.Effect != EFFECT_ALLOW && policy.Effect != EFFECT_DENY {
+			return fmt.Errorf("política %q: effect debe ser %q o %q", policy.ID, EFFECT_ALLOW, EFFECT_DENY)
+		}
+		if len(policy.Actions) == 0 {
+			return fmt.Errorf("política %q: falta actions", policy.ID)
+		}
+		for _, pattern := range slices.Concat(policy.Actions, policy.Resources) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("política %q: patrón inválido %q", policy.ID, pattern)
+			}
+		}
+
+		for _, condition := range policy.Conditions {
+			if err := validateCondition(condition); err != nil {
+				return fmt.Errorf("política %q: %w", policy.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCondition(condition Condition) error {
+	if !isAttributePath(condition.Attribute) {
+		return fmt.Errorf("atributo inválido %q", condition.Attribute)
+	}
+
+	hasValue := condition.Value != nil || condition.ValueFrom != ""
+	switch condition.Operator {
+	case OPERATOR_EXISTS:
+		if hasValue {
+			return fmt.Errorf("%s no lleva value ni value_from", condition.Operator)
+		}
+	case OPERATOR_EQUALS, OPERATOR_NOT_EQUALS, OPERATOR_CONTAINS:
+		if condition.Value != nil && condition.ValueFrom != "" || !hasValue {
+			return fmt.Errorf("%s %s requiere value o value_from", condition.Attribute, condition.Operator)
+		}
+	case OPERATOR_IN, OPERATOR_NOT_IN:
+		if _, ok := condition.Value.([]interface{}); !ok && condition.ValueFrom == "" {
+			return fmt.Errorf("%s %s requiere una lista en value o value_from", condition.Attribute, condition.Operator)
+		}
+	default:
+		return fmt.Errorf("operador desconocido %q", condition.Operator)
+	}
+
+	if condition.ValueFrom != "" && !isAttributePath(condition.ValueFrom) {
+		return fmt.Errorf("atributo inválido %q", condition.ValueFrom)
+	}
+	return nil
+}
+
+// isAttributePath indica si path referencia un atributo del sujeto, del recurso o la acción
+func isAttributePath(path string) bool {
+	return path == "action" ||
+		strings.HasPrefix(path, "subject.") && len(path) > len("subject.") ||
+		strings.HasPrefix(path, "resource.") && len(path) > len("resource.")
+}
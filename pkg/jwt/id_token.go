@@ -0,0 +1,123 @@
+package tokens
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// UserClaims son los claims estándar de OpenID Connect del usuario. Se incluyen según los scopes
+// concedidos: profile agrega el nombre y email agrega el email y si está verificado.
+type UserClaims struct {
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	Birthdate     string `json:"birthdate,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+}
+
+// NewUserClaims arma los claims del usuario para los scopes (separados por espacio).
+func NewUserClaims(user *models.User, scope string) UserClaims {
+	scopes := strings.Fields(scope)
+	claims := UserClaims{}
+
+	if slices.Contains(scopes, models.OAUTH_SCOPE_PROFILE) {
+		claims.GivenName = user.PersonalInfo.Name
+		claims.FamilyName = user.PersonalInfo.LastName
+		claims.Name = strings.TrimSpace(user.PersonalInfo.Name + " " + user.PersonalInfo.LastName)
+		if user.PersonalInfo.BirthDate != nil {
+			claims.Birthdate = user.PersonalInfo.BirthDate.Format(time.DateOnly)
+		}
+	}
+
+	if slices.Contains(scopes, models.OAUTH_SCOPE_EMAIL) {
+		verified := user.ContactInfo.Email.IsVerified
+		claims.Email = user.ContactInfo.Email.Address
+		claims.EmailVerified = &verified
+	}
+
+	return claims
+}
+
+// IDTokenClaims son los claims del ID token (OpenID Connect Core, sección 2). La audiencia es el
+// client_id, por lo que un ID token no sirve como access token de la API.
+type IDTokenClaims struct {
+	Type      string           `json:"typ"`
+	Nonce     string           `json:"nonce,omitempty"`
+	AuthTime  *jwt.NumericDate `json:"auth_time,omitempty"`
+	SessionID string           `json:"sid,omitempty"`
+	UserClaims
+	jwt.RegisteredClaims
+}
+
+// IDTokenParams son los datos del login que viajan en el ID token.
+type IDTokenParams struct {
+	ClientID  string
+	SessionID string
+	Nonce     string
+	Scope     string
+	AuthTime  time.Time
+}
+
+// GenerateIDToken genera el ID token del usuario para el cliente OAuth.
+func GenerateIDToken(user *models.User, params IDTokenParams, duration time.Duration) (string, error) {
+	claims := &IDTokenClaims{
+		Type:             TokenTypeID,
+		Nonce:            params.Nonce,
+		SessionID:        params.SessionID,
+		UserClaims:       NewUserClaims(user, params.Scope),
+		RegisteredClaims: newRegisteredClaimsFor(user.ID, duration),
+	}
+	claims.Audience = jwt.ClaimStrings{params.ClientID}
+	if !params.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(params.AuthTime)
+	}
+
+	keySet, err := GetKeySet()
+	if err != nil {
+		return "", err
+	}
+	if !keySet.CanSignIDTokens() {
+		return "", validations.ErrIDTokenSigningKeyRequired
+	}
+	return keySet.Sign(claims)
+}
+
+// ParseIDTokenHint valida el id_token_hint del cierre de sesión: firma, emisor y tipo. No exige
+// que esté vigente, porque el cliente puede pedir el cierre después de que expire.
+func ParseIDTokenHint(tokenString string) (*IDTokenClaims, error) {
+	keySet, err := GetKeySet()
+	if err != nil {
+		return nil, err
+	}
+
+	// Un ID token firmado con HMAC nunca lo emitimos nosotros (ver GenerateIDToken)
+	algorithms := keySet.IDTokenAlgorithms()
+	if len(algorithms) == 0 {
+		return nil, validations.ErrInvalidToken
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(algorithms),
+		jwt.WithoutClaimsValidation(),
+	)
+
+	claims := &IDTokenClaims{}
+	token, err := parser.ParseWithClaims(tokenString, claims, keySet.keyFunc)
+	if err != nil || !token.Valid {
+		return nil, validations.ErrInvalidToken
+	}
+
+	// Sin la validación de claims, el emisor se verifica acá
+	if claims.Type != TokenTypeID || claims.Issuer != getIssuer() || claims.Subject == "" || len(claims.Audience) != 1 {
+		return nil, validations.ErrInvalidToken
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,141 @@
+package tokens
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"sort"
+)
+
+// JWK representa una clave pública en formato JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519) y EC
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"` // solo EC
+}
+
+// JWKS es el documento publicado en /.well-known/jwks.json.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK retorna la representación pública de la clave. Las claves HMAC no tienen representación pública.
+func (k *Key) JWK() (*JWK, error) {
+	jwk := &JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Algorithm}
+
+	switch pub := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	return jwk, nil
+}
+
+// PublicKey decodifica la clave pública del JWK (RSA, EC P-256/P-384/P-521 u OKP Ed25519).
+// La usan los clientes de proveedores externos para verificar sus tokens.
+func (j *JWK) PublicKey() (interface{}, error) {
+	switch j.KeyType {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(j.N)
+		e, errE := base64.RawURLEncoding.DecodeString(j.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(j.X)
+		y, errY := base64.RawURLEncoding.DecodeString(j.Y)
+		if errX != nil || errY != nil {
+			return nil, ErrUnsupportedKey
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrUnsupportedKey
+		}
+		return key, nil
+
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if j.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+// Thumbprint calcula el thumbprint SHA-256 de la clave pública (RFC 7638).
+func (k *Key) Thumbprint() (string, error) {
+	jwk, err := k.JWK()
+	if err != nil {
+		return "", err
+	}
+
+	// RFC 7638 exige solo los miembros requeridos, en orden lexicográfico
+	var members map[string]string
+	if jwk.KeyType == "RSA" {
+		members = map[string]string{"e": jwk.E, "kty": jwk.KeyType, "n": jwk.N}
+	} else {
+		members = map[string]string{"crv": jwk.Curve, "kty": jwk.KeyType, "x": jwk.X}
+	}
+
+	data, err := json.Marshal(members) // encoding/json ordena las claves del mapa
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// JWKS retorna todas las claves públicas de verificación, incluidas las que ya no firman.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range ks.keys {
+		if k.IsSymmetric() {
+			continue
+		}
+		jwk, err := k.JWK()
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, *jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
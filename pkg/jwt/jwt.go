@@ -1,114 +1,306 @@
 package tokens
 
 import (
-	"log"
 	"myproject/internal/models"
 	"myproject/pkg/validations"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// Tipos de token (claim "typ"). Impiden usar un refresh token como access token y viceversa.
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+	TokenTypeEmail   = "email"
+	// TokenTypeEmailVerification es el token del link de activación de cuenta
+	TokenTypeEmailVerification = "email_verification"
+	// TokenTypeMFAChallenge es el token que entrega Login cuando falta el segundo factor
+	TokenTypeMFAChallenge = "mfa_challenge"
+	// TokenTypeID es el ID token de OpenID Connect que reciben los clientes OAuth con el scope openid
+	TokenTypeID = "id"
+	// TokenTypeInvitation es el token del link de invitación a una organización
+	TokenTypeInvitation = "invitation"
+)
+
+const DEFAULT_ISSUER = "login-dynamodb-api"
+const DEFAULT_LEEWAY_SECONDS = 30
+
 type Tokens struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+	// SessionID es la sesión a la que pertenecen los tokens (claim "sid"); no se envía al cliente
+	SessionID string `json:"-"`
 }
 
-func GenerateJWT(user *models.User, duration int) (string, error) {
-	return generateTokenByClaims(jwt.MapClaims{
-		"id":            user.ID,
-		"personal_info": user.PersonalInfo,
-		"contact_info":  user.ContactInfo,
-		"created_at":    user.CreatedAt,
-		"updated_at":    user.UpdatedAt,
-		"deleted_at":    user.DeletedAt,
-		"last_session":  user.LastSession,
-		"iat":           time.Now().Unix(),
-		"exp":           time.Now().Add(time.Hour * time.Duration(duration)).Unix(),
-	})
+// Claims es el conjunto de claims de todos los tokens emitidos por la API.
+// iss, aud, sub, jti, iat y exp viajan en RegisteredClaims.
+type Claims struct {
+	Type         string               `json:"typ"`
+	PersonalInfo *models.PersonalInfo `json:"personal_info,omitempty"`
+	Email        string               `json:"email,omitempty"`
+	// Role es el rol del usuario (consts.ROLE_*): en la organización de OrganizationID o, sin
+	// organización, su rol global. Solo lo llevan los access tokens
+	Role string `json:"role,omitempty"`
+	// OrganizationID es la organización (tenant) para la que se emitió el token
+	OrganizationID string `json:"org_id,omitempty"`
+	// Version es la TokenVersion del usuario al emitir el token (ver "cerrar todas las sesiones")
+	Version int `json:"ver"`
+	// SessionID identifica la sesión (familia de refresh tokens) que originó el token
+	SessionID string `json:"sid,omitempty"`
+	// ClientID y Scope solo están en los tokens emitidos a clientes OAuth (ver Grant)
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// AuthTime es el momento en que el usuario se autenticó en /oauth/authorize (claim auth_time del ID token)
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	jwt.RegisteredClaims
 }
 
-func GenerateJWTEmail(email string, duration int) (string, error) {
-	return generateTokenByClaims(jwt.MapClaims{
-		"email": email,
-		"iat":   time.Now().Unix(),
-		"exp":   time.Now().Add(time.Hour * time.Duration(duration)).Unix(),
-	})
+// Grant identifica al cliente OAuth y los scopes (separados por espacio) para los que se emite un
+// token, y cuándo se autenticó el usuario; en los logins propios de la API esos campos son vacíos.
+// OrganizationID y Role son la organización para la que se emite y el rol del usuario en ella.
+type Grant struct {
+	ClientID       string
+	Scope          string
+	AuthTime       time.Time
+	OrganizationID string
+	Role           string
 }
 
-func generateTokenByClaims(claims jwt.MapClaims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+// IsClientToken indica si el token se emitió a un cliente OAuth en nombre propio (client_credentials),
+// sin un usuario detrás.
+func (c *Claims) IsClientToken() bool {
+	return c.ClientID != "" && c.Subject == c.ClientID
+}
 
-	// Firmar el token
-	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-	if err != nil {
-		log.Fatal(err)
+// getIssuer retorna el emisor de los tokens desde variables de entorno
+func getIssuer() string {
+	issuer := os.Getenv("JWT_ISSUER")
+	if issuer == "" {
+		return DEFAULT_ISSUER
 	}
-	return tokenString, nil
+	return issuer
 }
 
-//-----------------------------------------\\
+// Issuer retorna el emisor de los tokens (claim "iss"). Para OpenID Connect debe ser la URL pública
+// de la API, ya que los clientes buscan la configuración en <issuer>/.well-known/openid-configuration.
+func Issuer() string {
+	return getIssuer()
+}
 
-func GetClaims(tokenString string) (*jwt.MapClaims, error) {
-	claims := &jwt.MapClaims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-	if err != nil {
-		return nil, err
-	}
-	if !token.Valid {
-		return nil, validations.ErrInvalidToken
+// getAudience retorna la audiencia de los tokens desde variables de entorno
+func getAudience() string {
+	audience := os.Getenv("JWT_AUDIENCE")
+	if audience == "" {
+		return getIssuer()
 	}
-	return claims, nil
+	return audience
 }
 
-func GetTokenInHeader(r *http.Request) (string, error) {
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		return "", nil
+// getLeeway retorna la tolerancia de reloj usada al validar exp, nbf e iat
+func getLeeway() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("JWT_LEEWAY_SECONDS"))
+	if err != nil || seconds < 0 {
+		seconds = DEFAULT_LEEWAY_SECONDS
 	}
+	return time.Duration(seconds) * time.Second
+}
 
-	token := strings.TrimPrefix(authHeader, "Bearer ")
+// newRegisteredClaims arma los claims estándar para un token de `duration` horas.
+func newRegisteredClaims(subject string, duration int) jwt.RegisteredClaims {
+	return newRegisteredClaimsFor(subject, time.Hour*time.Duration(duration))
+}
 
-	return token, nil
+// newRegisteredClaimsFor arma los claims estándar para un token válido durante `duration`.
+func newRegisteredClaimsFor(subject string, duration time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		Issuer:    getIssuer(),
+		Audience:  jwt.ClaimStrings{getAudience()},
+		Subject:   subject,
+		ID:        uuid.New().String(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+	}
 }
 
-func GetFieldOfHeaderToken(r *http.Request, field string) (string, error) {
-	token, err := GetTokenInHeader(r)
-	if err != nil {
-		return "", err
+// GenerateJWT genera un token de tipo `tokenType` (access o refresh) para una sesión del usuario.
+func GenerateJWT(user *models.User, sessionID string, tokenType string, duration int) (string, error) {
+	return GenerateGrantJWT(user, sessionID, tokenType, duration, Grant{})
+}
+
+// GenerateGrantJWT es GenerateJWT para una sesión abierta por un cliente OAuth: el token lleva
+// el client_id y los scopes concedidos.
+func GenerateGrantJWT(user *models.User, sessionID string, tokenType string, duration int, grant Grant) (string, error) {
+	claims := &Claims{
+		Type:             tokenType,
+		Version:          user.TokenVersion,
+		SessionID:        sessionID,
+		ClientID:         grant.ClientID,
+		Scope:            grant.Scope,
+		OrganizationID:   grant.OrganizationID,
+		RegisteredClaims: newRegisteredClaims(user.ID, duration),
 	}
+	if !grant.AuthTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(grant.AuthTime)
+	}
+
+	// Solo el access token lleva datos de perfil para el frontend
+	if tokenType == TokenTypeAccess {
+		claims.PersonalInfo = &user.PersonalInfo
+		claims.Email = user.ContactInfo.Email.Address
+		claims.Role = user.GetRole()
+		if grant.OrganizationID != "" {
+			claims.Role = grant.Role
+		}
+	}
+
+	return generateTokenByClaims(claims)
+}
+
+// GenerateClientCredentialsToken genera el access token de un cliente OAuth que actúa en nombre
+// propio (grant client_credentials). El subject es el client_id.
+func GenerateClientCredentialsToken(clientID, scope string, duration time.Duration) (string, error) {
+	return generateTokenByClaims(&Claims{
+		Type:             TokenTypeAccess,
+		ClientID:         clientID,
+		Scope:            scope,
+		RegisteredClaims: newRegisteredClaimsFor(clientID, duration),
+	})
+}
+
+// GenerateJWTEmail genera un token firmado para una dirección de email, que puede no pertenecer
+// a ningún usuario todavía (por ejemplo, el del magic link). No lleva subject.
+func GenerateJWTEmail(email string, duration time.Duration) (string, error) {
+	return generateTokenByClaims(&Claims{
+		Type:             TokenTypeEmail,
+		Email:            email,
+		RegisteredClaims: newRegisteredClaimsFor("", duration),
+	})
+}
+
+// GenerateEmailVerificationToken genera el token del link de activación.
+// Incluye el email para que el link deje de servir si el usuario cambia de dirección.
+func GenerateEmailVerificationToken(user *models.User, duration int) (string, error) {
+	return generateTokenByClaims(&Claims{
+		Type:             TokenTypeEmailVerification,
+		Email:            user.ContactInfo.Email.Address,
+		RegisteredClaims: newRegisteredClaims(user.ID, duration),
+	})
+}
+
+// GenerateMFAChallengeToken genera el token que acredita que el usuario ya validó su contraseña
+// y solo le falta el segundo factor. Lleva la versión de tokens para que logout-all lo invalide.
+func GenerateMFAChallengeToken(user *models.User, duration time.Duration) (string, error) {
+	return generateTokenByClaims(&Claims{
+		Type:             TokenTypeMFAChallenge,
+		Version:          user.TokenVersion,
+		RegisteredClaims: newRegisteredClaimsFor(user.ID, duration),
+	})
+}
+
+// GenerateInvitationToken genera el token del link de una invitación. El subject es la invitación
+// y el jti su TokenID, de modo que reenviarla invalida los links anteriores.
+func GenerateInvitationToken(invitation *models.Invitation) (string, error) {
+	registeredClaims := newRegisteredClaimsFor(invitation.ID, time.Until(invitation.ExpiresAt))
+	registeredClaims.ID = invitation.TokenID
+
+	return generateTokenByClaims(&Claims{
+		Type:             TokenTypeInvitation,
+		Email:            invitation.Email,
+		OrganizationID:   invitation.OrganizationID,
+		RegisteredClaims: registeredClaims,
+	})
+}
 
-	value, err := GetFieldInToken(token, field)
+func generateTokenByClaims(claims *Claims) (string, error) {
+	keySet, err := GetKeySet()
 	if err != nil {
 		return "", err
 	}
-	return value, nil
+
+	// Firmar el token con la clave activa (incluye el header "kid")
+	return keySet.Sign(claims)
+}
+
+//-----------------------------------------\\
+
+// ParseAccessToken valida un access token y retorna sus claims.
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, TokenTypeAccess)
 }
 
-func GetFieldInToken(token string, field string) (string, error) {
-	claims, err := GetClaims(token)
+// ParseRefreshToken valida un refresh token y retorna sus claims.
+func ParseRefreshToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, TokenTypeRefresh)
+}
+
+// ParseEmailToken valida un token de email y retorna sus claims.
+func ParseEmailToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, TokenTypeEmail)
+}
+
+// ParseEmailVerificationToken valida un token de activación de cuenta y retorna sus claims.
+func ParseEmailVerificationToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, TokenTypeEmailVerification)
+}
+
+// ParseMFAChallengeToken valida un token de challenge MFA y retorna sus claims.
+func ParseMFAChallengeToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, TokenTypeMFAChallenge)
+}
+
+// ParseInvitationToken valida un token de invitación y retorna sus claims.
+func ParseInvitationToken(tokenString string) (*Claims, error) {
+	return parseToken(tokenString, TokenTypeInvitation)
+}
+
+// parseToken verifica firma, algoritmo, emisor, audiencia, expiración (con tolerancia de reloj)
+// y que el token sea del tipo esperado.
+func parseToken(tokenString string, tokenType string) (*Claims, error) {
+	keySet, err := GetKeySet()
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(keySet.Algorithms()),
+		jwt.WithIssuer(getIssuer()),
+		jwt.WithAudience(getAudience()),
+		jwt.WithLeeway(getLeeway()),
+		jwt.WithIssuedAt(),
+		jwt.WithExpirationRequired(),
+	)
+
+	claims := &Claims{}
+	token, err := parser.ParseWithClaims(tokenString, claims, keySet.keyFunc)
+	if err != nil || !token.Valid {
+		return nil, validations.ErrInvalidToken
 	}
 
-	value, ok := (*claims)[field].(string)
-	if !ok {
-		return "", validations.ErrInvalidToken
+	if claims.Type != tokenType {
+		return nil, validations.ErrInvalidToken
 	}
-	return value, nil
+
+	if tokenType != TokenTypeEmail && claims.Subject == "" {
+		return nil, validations.ErrInvalidToken
+	}
+
+	return claims, nil
 }
 
-/*
-func GetBdNameInToken(r *http.Request) (string, error) {
-	id, err := GetFieldOfHeaderToken(r, "id")
-	if err != nil {
-		return "", err
+func GetTokenInHeader(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", nil
 	}
-	return id + "_DB", nil
+
+	token := strings.TrimPrefix(authHeader, "Bearer ")
+
+	return token, nil
 }
-*/
@@ -0,0 +1,346 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos de firma soportados
+const (
+	ALG_HS256 = "HS256"
+	ALG_RS256 = "RS256"
+	ALG_EDDSA = "EdDSA"
+)
+
+// LEGACY_KEY_ID identifica la clave HMAC (JWT_SECRET). Los tokens emitidos sin
+// header "kid" se verifican con esta clave mientras siga configurada.
+const LEGACY_KEY_ID = "hs256"
+
+const MIN_RSA_KEY_BITS = 2048
+
+var (
+	ErrNoSigningKey      = errors.New("no hay una clave de firma configurada")
+	ErrUnknownKeyID      = errors.New("kid desconocido")
+	ErrUnsupportedKey    = errors.New("tipo de clave no soportado")
+	ErrAlgorithmMismatch = errors.New("el algoritmo del token no coincide con el de la clave")
+)
+
+// Key es una clave de firma o verificación identificada por su kid.
+// Las claves que solo tienen parte pública se usan únicamente para verificar.
+type Key struct {
+	ID        string
+	Algorithm string
+	signKey   interface{} // *rsa.PrivateKey, ed25519.PrivateKey o []byte
+	verifyKey interface{} // *rsa.PublicKey, ed25519.PublicKey o []byte
+}
+
+// NewHMACKey crea una clave simétrica HS256.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{ID: id, Algorithm: ALG_HS256, signKey: secret, verifyKey: secret}
+}
+
+// NewKey crea una clave a partir de una clave privada o pública RSA/Ed25519.
+// Si id está vacío se usa el thumbprint RFC 7638 de la clave pública.
+func NewKey(id string, key interface{}) (*Key, error) {
+	k := &Key{ID: id}
+
+	switch v := key.(type) {
+	case *rsa.PrivateKey:
+		k.Algorithm, k.signKey, k.verifyKey = ALG_RS256, v, &v.PublicKey
+	case *rsa.PublicKey:
+		k.Algorithm, k.verifyKey = ALG_RS256, v
+	case ed25519.PrivateKey:
+		k.Algorithm, k.signKey, k.verifyKey = ALG_EDDSA, v, v.Public().(ed25519.PublicKey)
+	case ed25519.PublicKey:
+		k.Algorithm, k.verifyKey = ALG_EDDSA, v
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if pub, ok := k.verifyKey.(*rsa.PublicKey); ok && pub.N.BitLen() < MIN_RSA_KEY_BITS {
+		return nil, fmt.Errorf("la clave RSA debe tener al menos %d bits", MIN_RSA_KEY_BITS)
+	}
+
+	if k.ID == "" {
+		thumbprint, err := k.Thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		k.ID = thumbprint
+	}
+
+	return k, nil
+}
+
+// CanSign indica si la clave tiene parte privada.
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// IsSymmetric indica si la clave es HMAC (no se publica en el JWKS).
+func (k *Key) IsSymmetric() bool {
+	return k.Algorithm == ALG_HS256
+}
+
+// method retorna el método de firma de golang-jwt correspondiente al algoritmo.
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// KeySet agrupa la clave activa (usada para firmar) y todas las claves aceptadas para verificar.
+// Durante una rotación conviven la clave nueva y las anteriores hasta que expiren sus tokens.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+// NewKeySet crea un KeySet con la clave activa y claves adicionales de verificación.
+func NewKeySet(active *Key, verificationKeys ...*Key) (*KeySet, error) {
+	if active == nil || !active.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+
+	ks := &KeySet{active: active, keys: map[string]*Key{active.ID: active}}
+	for _, k := range verificationKeys {
+		if _, exists := ks.keys[k.ID]; exists {
+			continue
+		}
+		ks.keys[k.ID] = k
+	}
+
+	return ks, nil
+}
+
+// Active retorna la clave usada para firmar.
+func (ks *KeySet) Active() *Key {
+	return ks.active
+}
+
+// Algorithms retorna los algoritmos aceptados al verificar.
+func (ks *KeySet) Algorithms() []string {
+	seen := map[string]bool{}
+	var algs []string
+	for _, k := range ks.keys {
+		if !seen[k.Algorithm] {
+			seen[k.Algorithm] = true
+			algs = append(algs, k.Algorithm)
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// CanSignIDTokens indica si la clave activa puede firmar ID tokens. Con HMAC no: los clientes
+// OAuth no tienen el secreto para verificarlos y, si lo tuvieran, podrían emitir tokens propios.
+func (ks *KeySet) CanSignIDTokens() bool {
+	return !ks.active.IsSymmetric()
+}
+
+// IDTokenAlgorithms retorna los algoritmos asimétricos del conjunto, los únicos con los que se
+// firman (y se aceptan) ID tokens.
+func (ks *KeySet) IDTokenAlgorithms() []string {
+	algs := []string{}
+	for _, alg := range ks.Algorithms() {
+		if alg != ALG_HS256 {
+			algs = append(algs, alg)
+		}
+	}
+	return algs
+}
+
+// Sign firma los claims con la clave activa e incluye su kid en el header.
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.active.method(), claims)
+	token.Header["kid"] = ks.active.ID
+	return token.SignedString(ks.active.signKey)
+}
+
+// keyFunc resuelve la clave de verificación a partir del header "kid" del token.
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = LEGACY_KEY_ID
+	}
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != key.Algorithm {
+		return nil, ErrAlgorithmMismatch
+	}
+
+	return key.verifyKey, nil
+}
+
+//----------- CARGA DE CLAVES -----------\\
+
+var (
+	keySet     *KeySet
+	keySetErr  error
+	keySetOnce sync.Once
+)
+
+// InitKeySet carga las claves desde variables de entorno. Se llama al iniciar la aplicación
+// para fallar rápido ante una configuración inválida.
+func InitKeySet() error {
+	keySetOnce.Do(func() {
+		keySet, keySetErr = LoadKeySetFromEnv()
+	})
+	return keySetErr
+}
+
+// GetKeySet retorna el KeySet global, cargándolo si todavía no se inicializó.
+func GetKeySet() (*KeySet, error) {
+	if err := InitKeySet(); err != nil {
+		return nil, err
+	}
+	return keySet, nil
+}
+
+// LoadKeySetFromEnv arma el KeySet según la configuración:
+//   - JWT_KEYS_DIR: directorio con archivos <kid>.pem (privados para firmar, públicos solo para verificar).
+//     JWT_ACTIVE_KID indica cuál firma; si hay una sola clave privada se usa esa.
+//   - JWT_PRIVATE_KEY_FILE: una única clave privada PEM (kid en JWT_KEY_ID o thumbprint).
+//   - Si no hay ninguna, se firma con HS256 usando JWT_SECRET.
+//
+// Si JWT_SECRET está definido junto a claves asimétricas, se mantiene solo para verificar
+// tokens emitidos antes de la migración.
+func LoadKeySetFromEnv() (*KeySet, error) {
+	var legacy *Key
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		legacy = NewHMACKey(LEGACY_KEY_ID, []byte(secret))
+	}
+
+	var keys []*Key
+	var active *Key
+
+	if dir := os.Getenv("JWT_KEYS_DIR"); dir != "" {
+		var err error
+		keys, err = loadKeysFromDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		active, err = selectActiveKey(keys, os.Getenv("JWT_ACTIVE_KID"))
+		if err != nil {
+			return nil, err
+		}
+	} else if file := os.Getenv("JWT_PRIVATE_KEY_FILE"); file != "" {
+		key, err := loadKeyFromFile(file, os.Getenv("JWT_KEY_ID"))
+		if err != nil {
+			return nil, err
+		}
+		if !key.CanSign() {
+			return nil, fmt.Errorf("%s no contiene una clave privada", file)
+		}
+		active = key
+	}
+
+	if active == nil {
+		return NewKeySet(legacy)
+	}
+
+	if legacy != nil {
+		keys = append(keys, legacy)
+	}
+
+	return NewKeySet(active, keys...)
+}
+
+// loadKeysFromDir carga todos los archivos .pem de un directorio usando el nombre como kid.
+func loadKeysFromDir(dir string) ([]*Key, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*Key
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadKeyFromFile(file, kid)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no se encontraron claves .pem en %s", dir)
+	}
+
+	return keys, nil
+}
+
+// selectActiveKey elige la clave de firma entre las cargadas.
+func selectActiveKey(keys []*Key, activeKID string) (*Key, error) {
+	var signers []*Key
+	for _, k := range keys {
+		if activeKID != "" && k.ID == activeKID {
+			if !k.CanSign() {
+				return nil, fmt.Errorf("la clave activa %s no es privada", activeKID)
+			}
+			return k, nil
+		}
+		if k.CanSign() {
+			signers = append(signers, k)
+		}
+	}
+
+	if activeKID != "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyID, activeKID)
+	}
+	if len(signers) != 1 {
+		return nil, errors.New("hay más de una clave privada, defina JWT_ACTIVE_KID")
+	}
+
+	return signers[0], nil
+}
+
+// loadKeyFromFile lee un archivo PEM con una clave RSA o Ed25519.
+func loadKeyFromFile(file, kid string) (*Key, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := ParsePEMKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return NewKey(kid, parsed)
+}
+
+// ParsePEMKey decodifica una clave privada (PKCS#1/PKCS#8) o pública (PKIX/PKCS#1) en PEM.
+func ParsePEMKey(data []byte) (interface{}, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM inválido")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKey, block.Type)
+	}
+}
@@ -0,0 +1,361 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myproject/internal/models"
+	"myproject/pkg/validations"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useKeySet reemplaza el KeySet global durante el test
+func useKeySet(t *testing.T, ks *KeySet) {
+	t.Helper()
+	keySetOnce.Do(func() {})
+	previous, previousErr := keySet, keySetErr
+	keySet, keySetErr = ks, nil
+	t.Cleanup(func() { keySet, keySetErr = previous, previousErr })
+}
+
+func newRSAKey(t *testing.T, id string) *Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+	key, err := NewKey(id, private)
+	if err != nil {
+		t.Fatalf("new RSA key: %v", err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T, id string) *Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate Ed25519 key: %v", err)
+	}
+	key, err := NewKey(id, private)
+	if err != nil {
+		t.Fatalf("new Ed25519 key: %v", err)
+	}
+	return key
+}
+
+// publicOnly retorna la clave con solo su parte pública, como las claves retiradas de una rotación
+func publicOnly(t *testing.T, key *Key) *Key {
+	t.Helper()
+	public, err := NewKey(key.ID, key.verifyKey)
+	if err != nil {
+		t.Fatalf("public key: %v", err)
+	}
+	return public
+}
+
+func newAccessClaims(subject string) *Claims {
+	return &Claims{Type: TokenTypeAccess, RegisteredClaims: newRegisteredClaimsFor(subject, time.Hour)}
+}
+
+// signWith firma los claims con una clave y un header kid arbitrarios
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func TestSignAndVerify(t *testing.T) {
+	for _, key := range []*Key{newRSAKey(t, ""), newEd25519Key(t, "ed-1")} {
+		ks, err := NewKeySet(key)
+		if err != nil {
+			t.Fatalf("%s: key set: %v", key.Algorithm, err)
+		}
+		useKeySet(t, ks)
+
+		signed, err := ks.Sign(newAccessClaims("user-1"))
+		if err != nil {
+			t.Fatalf("%s: sign: %v", key.Algorithm, err)
+		}
+		token, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+		if err != nil || token.Header["kid"] != key.ID || token.Header["alg"] != key.Algorithm {
+			t.Fatalf("%s: header = %v, err = %v", key.Algorithm, token.Header, err)
+		}
+
+		claims, err := ParseAccessToken(signed)
+		if err != nil || claims.Subject != "user-1" {
+			t.Fatalf("%s: claims = %+v, err = %v", key.Algorithm, claims, err)
+		}
+		if _, err := ParseRefreshToken(signed); !errors.Is(err, validations.ErrInvalidToken) {
+			t.Fatalf("%s: access token accepted as refresh token: %v", key.Algorithm, err)
+		}
+	}
+}
+
+func TestRotatedKeyID(t *testing.T) {
+	previous := newRSAKey(t, "2024-01")
+	current := newEd25519Key(t, "2025-01")
+
+	oldSet, _ := NewKeySet(previous)
+	oldToken, err := oldSet.Sign(newAccessClaims("user-1"))
+	if err != nil {
+		t.Fatalf("sign with previous key: %v", err)
+	}
+
+	// Tras la rotación la clave anterior solo verifica
+	ks, err := NewKeySet(current, publicOnly(t, previous))
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	useKeySet(t, ks)
+
+	if claims, err := ParseAccessToken(oldToken); err != nil || claims.Subject != "user-1" {
+		t.Fatalf("token of the previous key: claims = %+v, err = %v", claims, err)
+	}
+	newToken, err := ks.Sign(newAccessClaims("user-2"))
+	if err != nil {
+		t.Fatalf("sign with current key: %v", err)
+	}
+	if claims, err := ParseAccessToken(newToken); err != nil || claims.Subject != "user-2" {
+		t.Fatalf("token of the current key: claims = %+v, err = %v", claims, err)
+	}
+	if ks.Active().ID != "2025-01" {
+		t.Fatalf("active kid = %q, want 2025-01", ks.Active().ID)
+	}
+	if algs := ks.Algorithms(); len(algs) != 2 || algs[0] != ALG_EDDSA || algs[1] != ALG_RS256 {
+		t.Fatalf("algorithms = %v", algs)
+	}
+}
+
+func TestUnknownKeyID(t *testing.T) {
+	key := newRSAKey(t, "2025-01")
+	ks, _ := NewKeySet(key)
+	useKeySet(t, ks)
+
+	// Una clave con un kid que el KeySet no conoce
+	other := newRSAKey(t, "retired")
+	signed := signWith(t, jwt.SigningMethodRS256, other.ID, other.signKey, newAccessClaims("user-1"))
+	if _, err := ParseAccessToken(signed); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("unknown kid: error = %v, want ErrInvalidToken", err)
+	}
+	if _, err := ks.keyFunc(&jwt.Token{Header: map[string]interface{}{"kid": "retired"}, Method: jwt.SigningMethodRS256}); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("keyFunc: error = %v, want ErrUnknownKeyID", err)
+	}
+
+	// Sin JWT_SECRET los tokens sin kid no tienen con qué verificarse
+	signed = signWith(t, jwt.SigningMethodRS256, "", key.signKey, newAccessClaims("user-1"))
+	if _, err := ParseAccessToken(signed); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("token without kid: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLegacyTokenWithoutKeyID(t *testing.T) {
+	legacy := NewHMACKey(LEGACY_KEY_ID, []byte("legacy-secret"))
+	ks, err := NewKeySet(newRSAKey(t, "2025-01"), legacy)
+	if err != nil {
+		t.Fatalf("key set: %v", err)
+	}
+	useKeySet(t, ks)
+
+	// Los tokens emitidos antes de la migración no tienen header kid
+	signed := signWith(t, jwt.SigningMethodHS256, "", []byte("legacy-secret"), newAccessClaims("user-1"))
+	if claims, err := ParseAccessToken(signed); err != nil || claims.Subject != "user-1" {
+		t.Fatalf("legacy token: claims = %+v, err = %v", claims, err)
+	}
+
+	signed = signWith(t, jwt.SigningMethodHS256, "", []byte("otro-secreto"), newAccessClaims("user-1"))
+	if _, err := ParseAccessToken(signed); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("legacy token with another secret: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	key := newRSAKey(t, "2025-01")
+	ks, _ := NewKeySet(key, NewHMACKey(LEGACY_KEY_ID, []byte("legacy-secret")))
+	useKeySet(t, ks)
+
+	// HS256 "firmado" con la clave pública RSA, que es pública (PEM o DER)
+	der, err := x509.MarshalPKIXPublicKey(key.verifyKey)
+	if err != nil {
+		t.Fatalf("marshal public key: %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	for _, secret := range [][]byte{publicPEM, der} {
+		for _, kid := range []string{key.ID, ""} {
+			signed := signWith(t, jwt.SigningMethodHS256, kid, secret, newAccessClaims("attacker"))
+			if _, err := ParseAccessToken(signed); !errors.Is(err, validations.ErrInvalidToken) {
+				t.Fatalf("HS256 with the RSA public key (kid %q): error = %v, want ErrInvalidToken", kid, err)
+			}
+		}
+	}
+
+	// Un algoritmo distinto al de la clave del kid se rechaza aunque sea soportado
+	if _, err := ks.keyFunc(&jwt.Token{Header: map[string]interface{}{"kid": key.ID}, Method: jwt.SigningMethodHS256}); !errors.Is(err, ErrAlgorithmMismatch) {
+		t.Fatalf("keyFunc: error = %v, want ErrAlgorithmMismatch", err)
+	}
+
+	// alg "none" nunca es aceptado
+	signed := signWith(t, jwt.SigningMethodNone, key.ID, jwt.UnsafeAllowNoneSignatureType, newAccessClaims("attacker"))
+	if _, err := ParseAccessToken(signed); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("alg none: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestIDTokenRequiresAsymmetricKey(t *testing.T) {
+	user := &models.User{ID: "user-1"}
+	params := IDTokenParams{ClientID: "client-1", Scope: "openid"}
+	legacy := NewHMACKey(LEGACY_KEY_ID, []byte("legacy-secret"))
+
+	// Solo con JWT_SECRET no se emiten ID tokens
+	hmacOnly, _ := NewKeySet(legacy)
+	useKeySet(t, hmacOnly)
+	if _, err := GenerateIDToken(user, params, time.Minute); !errors.Is(err, validations.ErrIDTokenSigningKeyRequired) {
+		t.Fatalf("HS256 id token: error = %v, want ErrIDTokenSigningKeyRequired", err)
+	}
+	if algs := hmacOnly.IDTokenAlgorithms(); len(algs) != 0 {
+		t.Fatalf("id token algorithms = %v, want none", algs)
+	}
+
+	// Con una clave asimétrica activa se firma con ella y HS256 no se acepta ni se anuncia
+	ks, _ := NewKeySet(newEd25519Key(t, "2025-01"), legacy)
+	useKeySet(t, ks)
+	signed, err := GenerateIDToken(user, params, time.Minute)
+	if err != nil {
+		t.Fatalf("id token: %v", err)
+	}
+	if claims, err := ParseIDTokenHint(signed); err != nil || claims.Subject != "user-1" {
+		t.Fatalf("parse id token: claims = %+v, err = %v", claims, err)
+	}
+	if algs := ks.IDTokenAlgorithms(); len(algs) != 1 || algs[0] != ALG_EDDSA {
+		t.Fatalf("id token algorithms = %v, want [%s]", algs, ALG_EDDSA)
+	}
+
+	forged := &IDTokenClaims{Type: TokenTypeID, RegisteredClaims: newRegisteredClaimsFor("user-1", time.Minute)}
+	forged.Audience = jwt.ClaimStrings{"client-1"}
+	signed = signWith(t, jwt.SigningMethodHS256, LEGACY_KEY_ID, []byte("legacy-secret"), forged)
+	if _, err := ParseIDTokenHint(signed); !errors.Is(err, validations.ErrInvalidToken) {
+		t.Fatalf("HS256 id token hint: error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	rsaKey := newRSAKey(t, "b-rsa")
+	edKey := newEd25519Key(t, "a-ed")
+	ks, _ := NewKeySet(rsaKey, publicOnly(t, edKey), NewHMACKey(LEGACY_KEY_ID, []byte("legacy-secret")))
+
+	set := ks.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("keys = %+v, want the two asymmetric keys (HMAC is never published)", set.Keys)
+	}
+
+	ed, rsaJWK := set.Keys[0], set.Keys[1]
+	if ed.KeyID != "a-ed" || ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != ALG_EDDSA || ed.Use != "sig" || ed.X == "" || ed.N != "" {
+		t.Fatalf("Ed25519 JWK = %+v", ed)
+	}
+	if rsaJWK.KeyID != "b-rsa" || rsaJWK.KeyType != "RSA" || rsaJWK.Algorithm != ALG_RS256 || rsaJWK.Use != "sig" || rsaJWK.E != "AQAB" || rsaJWK.N == "" || rsaJWK.X != "" {
+		t.Fatalf("RSA JWK = %+v", rsaJWK)
+	}
+
+	// Cada JWK publicado reconstruye la clave pública que verifica
+	for _, jwk := range set.Keys {
+		public, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("%s: public key: %v", jwk.KeyID, err)
+		}
+		key, err := NewKey("", public)
+		if err != nil {
+			t.Fatalf("%s: key: %v", jwk.KeyID, err)
+		}
+		original := map[string]*Key{"a-ed": edKey, "b-rsa": rsaKey}[jwk.KeyID]
+		want, _ := original.Thumbprint()
+		if key.ID != want {
+			t.Fatalf("%s: thumbprint = %q, want %q", jwk.KeyID, key.ID, want)
+		}
+	}
+}
+
+func TestLoadKeySetFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	writeKey := func(name string, key interface{}, private bool) {
+		var block *pem.Block
+		if private {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				t.Fatalf("marshal %s: %v", name, err)
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		} else {
+			der, err := x509.MarshalPKIXPublicKey(key)
+			if err != nil {
+				t.Fatalf("marshal %s: %v", name, err)
+			}
+			block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+		}
+		if err := os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	current := newEd25519Key(t, "")
+	previous := newRSAKey(t, "")
+	writeKey("2025-01", current.signKey, true)
+	writeKey("2024-01", previous.verifyKey, false)
+
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_SECRET", "legacy-secret")
+	ks, err := LoadKeySetFromEnv()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if ks.Active().ID != "2025-01" || ks.Active().Algorithm != ALG_EDDSA {
+		t.Fatalf("active = %s (%s), want 2025-01 (EdDSA)", ks.Active().ID, ks.Active().Algorithm)
+	}
+	if _, ok := ks.keys[LEGACY_KEY_ID]; !ok || len(ks.keys) != 3 {
+		t.Fatalf("verification keys = %v, want 2025-01, 2024-01 and the legacy secret", ks.Algorithms())
+	}
+
+	// Con dos claves privadas hay que elegir la activa
+	writeKey("2026-01", newRSAKey(t, "").signKey, true)
+	if _, err := LoadKeySetFromEnv(); err == nil {
+		t.Fatal("two private keys without JWT_ACTIVE_KID: expected an error")
+	}
+	t.Setenv("JWT_ACTIVE_KID", "2026-01")
+	if ks, err := LoadKeySetFromEnv(); err != nil || ks.Active().ID != "2026-01" {
+		t.Fatalf("JWT_ACTIVE_KID: err = %v", err)
+	}
+	t.Setenv("JWT_ACTIVE_KID", "2024-01")
+	if _, err := LoadKeySetFromEnv(); err == nil {
+		t.Fatal("public key as active key: expected an error")
+	}
+	t.Setenv("JWT_ACTIVE_KID", "desconocido")
+	if _, err := LoadKeySetFromEnv(); !errors.Is(err, ErrUnknownKeyID) {
+		t.Fatalf("unknown JWT_ACTIVE_KID: error = %v, want ErrUnknownKeyID", err)
+	}
+
+	// Sin claves asimétricas se firma con JWT_SECRET
+	t.Setenv("JWT_KEYS_DIR", "")
+	if ks, err := LoadKeySetFromEnv(); err != nil || ks.Active().ID != LEGACY_KEY_ID || !ks.Active().IsSymmetric() {
+		t.Fatalf("legacy only: err = %v", err)
+	}
+	t.Setenv("JWT_SECRET", "")
+	if _, err := LoadKeySetFromEnv(); !errors.Is(err, ErrNoSigningKey) {
+		t.Fatalf("no keys: error = %v, want ErrNoSigningKey", err)
+	}
+}
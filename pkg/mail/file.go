@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// getMailDir retorna el directorio donde FileMailer guarda los emails
+func getMailDir() string {
+	dir := os.Getenv("MAIL_DIR")
+	if dir == "" {
+		return "mail_outbox"
+	}
+	return dir
+}
+
+// FileMailer escribe cada email como un archivo .eml en un directorio.
+// Pensado para desarrollo local y tests: los archivos se abren con cualquier cliente de correo.
+type FileMailer struct {
+	dir string
+}
+
+// NewFileMailer crea un FileMailer, creando el directorio si no existe.
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileMailer{dir: dir}, nil
+}
+
+// Send escribe el mensaje en <dir>/<id>.eml
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Se escribe en un archivo temporal y se renombra para no dejar emails a medio escribir
+	path := filepath.Join(m.dir, msg.ID+".eml")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
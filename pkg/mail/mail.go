@@ -0,0 +1,160 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidMessage = errors.New("mensaje de email inválido")
+	ErrUnknownMailer  = errors.New("MAIL_TRANSPORT desconocido")
+	// ErrMailerRequired indica que falta MAIL_TRANSPORT fuera de desarrollo
+	ErrMailerRequired = errors.New("MAIL_TRANSPORT es obligatorio en Lambda (smtp)")
+)
+
+// Message es un email listo para enviarse, con versión de texto plano y HTML.
+type Message struct {
+	ID      string
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer envía emails. Hay una implementación SMTP y otra que escribe archivos .eml.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// getFromAddress retorna el remitente de los emails desde variables de entorno
+func getFromAddress() string {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		return "no-reply@localhost"
+	}
+	return from
+}
+
+// NewMailerFromEnv crea el Mailer indicado por MAIL_TRANSPORT ("smtp" o "file"). Sin configurar,
+// en desarrollo (fuera de Lambda) se usa "file"; en Lambda es un error, para que los emails de
+// recuperación o verificación no terminen en disco sin que nadie lo note.
+func NewMailerFromEnv() (Mailer, error) {
+	transport := os.Getenv("MAIL_TRANSPORT")
+	if transport == "" {
+		if _, isLambda := os.LookupEnv("LAMBDA_SERVER_PORT"); isLambda {
+			return nil, ErrMailerRequired
+		}
+		log.Printf("[mail] MAIL_TRANSPORT not set, writing emails to %s", getMailDir())
+		transport = "file"
+	}
+
+	switch transport {
+	case "smtp":
+		return NewSMTPMailerFromEnv()
+	case "file":
+		return NewFileMailer(getMailDir())
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMailer, transport)
+	}
+}
+
+// NewMessage crea un mensaje con ID y remitente por defecto.
+func NewMessage(to string, content *Content) *Message {
+	return &Message{
+		ID:      newMessageID(),
+		From:    getFromAddress(),
+		To:      to,
+		Subject: content.Subject,
+		Text:    content.Text,
+		HTML:    content.HTML,
+	}
+}
+
+func newMessageID() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return time.Now().UTC().Format("20060102T150405") + "-" + hex.EncodeToString(buf)
+}
+
+// validate verifica los campos mínimos del mensaje.
+func (m *Message) validate() error {
+	if m.To == "" || m.From == "" || m.Subject == "" || (m.Text == "" && m.HTML == "") {
+		return ErrInvalidMessage
+	}
+
+	// Evita inyectar headers a través de saltos de línea
+	if strings.ContainsAny(m.To+m.From+m.Subject, "\r\n") {
+		return ErrInvalidMessage
+	}
+
+	return nil
+}
+
+// Bytes serializa el mensaje en formato RFC 5322 con partes multipart/alternative.
+func (m *Message) Bytes() ([]byte, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	headers := []struct{ key, value string }{
+		{"From", m.From},
+		{"To", m.To},
+		{"Subject", mime.QEncoding.Encode("utf-8", m.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", "<" + m.ID + "@login-dynamodb>"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + writer.Boundary()},
+	}
+	for _, h := range headers {
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.key, h.value)
+	}
+	buf.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	}
+	for _, p := range parts {
+		if p.body == "" {
+			continue
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,166 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestMessage() *Message {
+	return &Message{
+		ID:      "test-1",
+		From:    "no-reply@example.com",
+		To:      "juan@example.com",
+		Subject: "Recuperá tu contraseña",
+		Text:    "Hola Juan, abrí el link: https://example.com/reset?token=a=b\n",
+		HTML:    "<p>Hola <b>Juan</b></p>",
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	data, err := newTestMessage().Bytes()
+	if err != nil {
+		t.Fatalf("bytes: %v", err)
+	}
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if parsed.Header.Get("To") != "juan@example.com" || parsed.Header.Get("Message-ID") != "<test-1@login-dynamodb>" {
+		t.Fatalf("headers = %v", parsed.Header)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != "Recuperá tu contraseña" {
+		t.Fatalf("subject = %q, err = %v", subject, err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("content type = %q, err = %v", mediaType, err)
+	}
+
+	// multipart.Reader decodifica quoted-printable: las partes vuelven a ser el texto original,
+	// con los saltos de línea como CRLF
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", newTestMessage().Text},
+		{"text/html; charset=utf-8", newTestMessage().HTML},
+	}
+	for _, w := range want {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("%s: %v", w.contentType, err)
+		}
+		body, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Type") != w.contentType || strings.ReplaceAll(string(body), "\r\n", "\n") != w.body {
+			t.Fatalf("part %s = %q", part.Header.Get("Content-Type"), body)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Fatalf("unexpected extra part: %v", err)
+	}
+}
+
+func TestMessageValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Message)
+	}{
+		{"without recipient", func(m *Message) { m.To = "" }},
+		{"without subject", func(m *Message) { m.Subject = "" }},
+		{"without body", func(m *Message) { m.Text, m.HTML = "", "" }},
+		{"header injection in subject", func(m *Message) { m.Subject = "Hola\r\nBcc: otro@example.com" }},
+		{"header injection in recipient", func(m *Message) { m.To = "juan@example.com\nBcc: otro@example.com" }},
+	}
+	for _, tt := range tests {
+		msg := newTestMessage()
+		tt.modify(msg)
+		if _, err := msg.Bytes(); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("%s: error = %v, want ErrInvalidMessage", tt.name, err)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := TemplateData{Name: "Juan <admin>", Link: "https://example.com/login?token=abc", ExpiresInHours: 2, ExpiresInMinutes: 15, Code: "123456", OrganizationName: "La Espiga", InviterName: "Ana", Role: "EMPLOYEE"}
+	templates := []Template{TemplatePasswordReset, TemplateEmailVerification, TemplateInvitation, TemplateMagicLink, TemplateEmailOTP}
+
+	for _, lang := range supportedLanguages {
+		for _, tpl := range templates {
+			content, err := Render(tpl, lang, data)
+			if err != nil {
+				t.Fatalf("%s (%s): %v", tpl, lang, err)
+			}
+			if content.Subject == "" || strings.Contains(content.Subject, "\n") || content.Text == "" || content.HTML == "" {
+				t.Fatalf("%s (%s): content = %+v", tpl, lang, content)
+			}
+			// El HTML escapa los datos; el texto plano los deja tal cual
+			if strings.Contains(content.HTML, "<admin>") {
+				t.Fatalf("%s (%s): name not escaped in HTML", tpl, lang)
+			}
+		}
+	}
+
+	content, _ := Render(TemplateMagicLink, "en-US", data)
+	if !strings.Contains(content.Text, data.Link) || !strings.Contains(content.Text, "15") {
+		t.Fatalf("magic link text = %q", content.Text)
+	}
+	spanish, _ := Render(TemplateMagicLink, "fr", data)
+	if spanish.Subject != "Tu link para iniciar sesión" {
+		t.Fatalf("unsupported language subject = %q, want the default language", spanish.Subject)
+	}
+}
+
+func TestNewMailerFromEnv(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	t.Setenv("MAIL_DIR", dir)
+
+	// En desarrollo, sin MAIL_TRANSPORT se escriben archivos
+	t.Setenv("MAIL_TRANSPORT", "")
+	t.Setenv("LAMBDA_SERVER_PORT", "")
+	os.Unsetenv("LAMBDA_SERVER_PORT") // t.Setenv restaura el valor original al terminar
+	if mailer, err := NewMailerFromEnv(); err != nil {
+		t.Fatalf("development default: %v", err)
+	} else if _, ok := mailer.(*FileMailer); !ok {
+		t.Fatalf("development default = %T, want *FileMailer", mailer)
+	}
+
+	t.Setenv("LAMBDA_SERVER_PORT", "true")
+	if _, err := NewMailerFromEnv(); !errors.Is(err, ErrMailerRequired) {
+		t.Fatalf("lambda without MAIL_TRANSPORT: error = %v, want ErrMailerRequired", err)
+	}
+
+	t.Setenv("MAIL_TRANSPORT", "pigeon")
+	if _, err := NewMailerFromEnv(); !errors.Is(err, ErrUnknownMailer) {
+		t.Fatalf("unknown transport: error = %v, want ErrUnknownMailer", err)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer, err := NewFileMailer(dir)
+	if err != nil {
+		t.Fatalf("new file mailer: %v", err)
+	}
+
+	msg := newTestMessage()
+	if err := mailer.Send(context.Background(), msg); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, msg.ID+".eml"))
+	if err != nil || !bytes.Contains(data, []byte("To: juan@example.com")) {
+		t.Fatalf("eml = %q, err = %v", data, err)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(files) != 0 {
+		t.Fatalf("temporary files left: %v", files)
+	}
+}
@@ -0,0 +1,225 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_QUEUE_SIZE    = 100
+	DEFAULT_QUEUE_WORKERS = 2
+	DEFAULT_MAX_ATTEMPTS  = 5
+	DEFAULT_RETRY_DELAY   = 2 * time.Second
+	MAX_RETRY_DELAY       = 5 * time.Minute
+	SEND_TIMEOUT          = 30 * time.Second
+)
+
+var (
+	ErrQueueFull   = errors.New("la cola de emails está llena")
+	ErrQueueClosed = errors.New("la cola de emails está cerrada")
+)
+
+// QueueOptions configura la cola de envío. Los valores en cero usan los valores por defecto.
+type QueueOptions struct {
+	Size        int
+	Workers     int
+	MaxAttempts int
+	RetryDelay  time.Duration
+	// Synchronous envía el mensaje dentro de Enqueue (con reintentos). Se usa en Lambda,
+	// donde el proceso se congela al responder y un worker en segundo plano no llegaría a enviar.
+	Synchronous bool
+}
+
+// Queue envía los mensajes en segundo plano y reintenta con backoff exponencial
+// cuando el transporte falla.
+type Queue struct {
+	mailer Mailer
+	opts   QueueOptions
+	jobs   chan *job
+
+	mu      sync.Mutex
+	closed  bool
+	pending sync.WaitGroup // mensajes encolados o esperando reintento
+	workers sync.WaitGroup
+}
+
+type job struct {
+	msg     *Message
+	attempt int
+}
+
+// NewQueue crea la cola y arranca sus workers.
+func NewQueue(mailer Mailer, opts QueueOptions) *Queue {
+	if opts.Size <= 0 {
+		opts.Size = DEFAULT_QUEUE_SIZE
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DEFAULT_QUEUE_WORKERS
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DEFAULT_MAX_ATTEMPTS
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = DEFAULT_RETRY_DELAY
+	}
+
+	q := &Queue{mailer: mailer, opts: opts, jobs: make(chan *job, opts.Size)}
+	if !opts.Synchronous {
+		for i := 0; i < opts.Workers; i++ {
+			q.workers.Add(1)
+			go q.work()
+		}
+	}
+
+	return q
+}
+
+// Enqueue agrega un mensaje a la cola. No bloquea: si la cola está llena retorna ErrQueueFull.
+func (q *Queue) Enqueue(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	if q.opts.Synchronous {
+		return q.sendWithRetry(ctx, msg)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	q.pending.Add(1)
+	select {
+	case q.jobs <- &job{msg: msg, attempt: 1}:
+		return nil
+	default:
+		q.pending.Done()
+		return ErrQueueFull
+	}
+}
+
+// Close deja de aceptar mensajes y espera a que se envíen (o agoten sus reintentos)
+// los pendientes, o a que venza ctx.
+func (q *Queue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(q.jobs)
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work procesa mensajes hasta que se cierra la cola.
+func (q *Queue) work() {
+	defer q.workers.Done()
+
+	for j := range q.jobs {
+		ctx, cancel := context.WithTimeout(context.Background(), SEND_TIMEOUT)
+		err := q.mailer.Send(ctx, j.msg)
+		cancel()
+
+		if err == nil {
+			q.pending.Done()
+			continue
+		}
+
+		if j.attempt >= q.opts.MaxAttempts || errors.Is(err, ErrInvalidMessage) {
+			log.Printf("[mail] Descartando email %s para %s tras %d intento(s): %v", j.msg.ID, j.msg.To, j.attempt, err)
+			q.pending.Done()
+			continue
+		}
+
+		delay := q.backoff(j.attempt)
+		log.Printf("[mail] Error enviando email %s (intento %d), reintentando en %s: %v", j.msg.ID, j.attempt, delay, err)
+
+		// El reintento no ocupa un worker mientras espera
+		next := &job{msg: j.msg, attempt: j.attempt + 1}
+		time.AfterFunc(delay, func() { q.jobs <- next })
+	}
+}
+
+// sendWithRetry envía el mensaje en la goroutine actual, reintentando hasta MaxAttempts.
+func (q *Queue) sendWithRetry(ctx context.Context, msg *Message) error {
+	var err error
+	for attempt := 1; attempt <= q.opts.MaxAttempts; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, SEND_TIMEOUT)
+		err = q.mailer.Send(sendCtx, msg)
+		cancel()
+
+		if err == nil || errors.Is(err, ErrInvalidMessage) || attempt == q.opts.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(q.backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+// backoff retorna la espera antes del siguiente intento: RetryDelay * 2^(attempt-1), con tope.
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.RetryDelay << (attempt - 1)
+	if delay <= 0 || delay > MAX_RETRY_DELAY {
+		return MAX_RETRY_DELAY
+	}
+	return delay
+}
+
+//----------- COLA GLOBAL -----------\\
+
+var queue *Queue
+
+// StartQueue crea el Mailer configurado por variables de entorno e inicia la cola global.
+func StartQueue(opts QueueOptions) error {
+	mailer, err := NewMailerFromEnv()
+	if err != nil {
+		return err
+	}
+
+	queue = NewQueue(mailer, opts)
+	return nil
+}
+
+// GetQueue retorna la cola global
+func GetQueue() *Queue {
+	if queue == nil {
+		log.Fatal("Mail queue not initialized. Call StartQueue first.")
+	}
+	return queue
+}
+
+// StopQueue espera a que se envíen los emails pendientes antes de terminar
+func StopQueue(ctx context.Context) {
+	if queue == nil {
+		return
+	}
+
+	if err := queue.Close(ctx); err != nil {
+		log.Printf("Mail queue closed with pending messages: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMailer falla las primeras failures veces que recibe cada mensaje
+type fakeMailer struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	attempts map[string][]time.Time
+	sent     []string
+	block    chan struct{} // si no es nil, Send espera a que se cierre
+}
+
+func newFakeMailer(failures int) *fakeMailer {
+	return &fakeMailer{failures: failures, err: errors.New("smtp: 421 try again later"), attempts: map[string][]time.Time{}}
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg *Message) error {
+	if m.block != nil {
+		<-m.block
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts[msg.ID] = append(m.attempts[msg.ID], time.Now())
+	if len(m.attempts[msg.ID]) <= m.failures {
+		return m.err
+	}
+	m.sent = append(m.sent, msg.ID)
+	return nil
+}
+
+func (m *fakeMailer) attemptsOf(id string) []time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]time.Time{}, m.attempts[id]...)
+}
+
+func newQueuedMessage(id string) *Message {
+	msg := newTestMessage()
+	msg.ID = id
+	return msg
+}
+
+func closeQueue(t *testing.T, q *Queue) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := q.Close(ctx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestQueueRetriesWithBackoff(t *testing.T) {
+	mailer := newFakeMailer(2)
+	q := NewQueue(mailer, QueueOptions{RetryDelay: 20 * time.Millisecond, MaxAttempts: 5})
+
+	if err := q.Enqueue(context.Background(), newQueuedMessage("retry")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	closeQueue(t, q) // espera los reintentos pendientes
+
+	attempts := mailer.attemptsOf("retry")
+	if len(attempts) != 3 || len(mailer.sent) != 1 {
+		t.Fatalf("attempts = %d, sent = %v, want 3 attempts and the message sent", len(attempts), mailer.sent)
+	}
+	// Las esperas se duplican: 20ms y 40ms
+	if first, second := attempts[1].Sub(attempts[0]), attempts[2].Sub(attempts[1]); first < 20*time.Millisecond || second < 40*time.Millisecond {
+		t.Fatalf("delays = %v, %v, want at least 20ms and 40ms", first, second)
+	}
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	mailer := newFakeMailer(100)
+	q := NewQueue(mailer, QueueOptions{RetryDelay: time.Millisecond, MaxAttempts: 3})
+
+	if err := q.Enqueue(context.Background(), newQueuedMessage("unreachable")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	closeQueue(t, q)
+	if attempts := mailer.attemptsOf("unreachable"); len(attempts) != 3 {
+		t.Fatalf("attempts = %d, want MaxAttempts", len(attempts))
+	}
+
+	// Un mensaje inválido no se reintenta
+	mailer = newFakeMailer(100)
+	mailer.err = ErrInvalidMessage
+	q = NewQueue(mailer, QueueOptions{RetryDelay: time.Millisecond, MaxAttempts: 3})
+	q.Enqueue(context.Background(), newQueuedMessage("invalid"))
+	closeQueue(t, q)
+	if attempts := mailer.attemptsOf("invalid"); len(attempts) != 1 {
+		t.Fatalf("invalid message attempts = %d, want 1", len(attempts))
+	}
+}
+
+func TestQueueRejectsInvalidMessages(t *testing.T) {
+	q := NewQueue(newFakeMailer(0), QueueOptions{})
+	defer closeQueue(t, q)
+
+	msg := newQueuedMessage("invalid")
+	msg.To = ""
+	if err := q.Enqueue(context.Background(), msg); !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("error = %v, want ErrInvalidMessage", err)
+	}
+}
+
+func TestQueueFullAndClosed(t *testing.T) {
+	mailer := newFakeMailer(0)
+	mailer.block = make(chan struct{})
+	q := NewQueue(mailer, QueueOptions{Size: 1, Workers: 1})
+
+	// El worker toma el primero y se bloquea; el segundo ocupa la cola y el tercero no entra
+	q.Enqueue(context.Background(), newQueuedMessage("first"))
+	deadline := time.Now().Add(time.Second)
+	for len(q.jobs) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := q.Enqueue(context.Background(), newQueuedMessage("second")); err != nil {
+		t.Fatalf("second: %v", err)
+	}
+	if err := q.Enqueue(context.Background(), newQueuedMessage("third")); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("third: error = %v, want ErrQueueFull", err)
+	}
+
+	// Close espera los pendientes: con el transporte bloqueado vence el contexto
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("close with pending messages: error = %v, want DeadlineExceeded", err)
+	}
+	if err := q.Enqueue(context.Background(), newQueuedMessage("late")); !errors.Is(err, ErrQueueClosed) {
+		t.Fatalf("enqueue after close: error = %v, want ErrQueueClosed", err)
+	}
+
+	close(mailer.block)
+	q.workers.Wait()
+	if len(mailer.sent) != 2 {
+		t.Fatalf("sent = %v, want the two queued messages", mailer.sent)
+	}
+}
+
+func TestSynchronousQueue(t *testing.T) {
+	mailer := newFakeMailer(1)
+	q := NewQueue(mailer, QueueOptions{Synchronous: true, RetryDelay: time.Millisecond, MaxAttempts: 3})
+
+	// En modo sincrónico Enqueue retorna después de enviar, reintentos incluidos
+	if err := q.Enqueue(context.Background(), newQueuedMessage("sync")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if len(mailer.sent) != 1 || len(mailer.attemptsOf("sync")) != 2 {
+		t.Fatalf("sent = %v, attempts = %d", mailer.sent, len(mailer.attemptsOf("sync")))
+	}
+
+	mailer = newFakeMailer(100)
+	q = NewQueue(mailer, QueueOptions{Synchronous: true, RetryDelay: time.Millisecond, MaxAttempts: 2})
+	if err := q.Enqueue(context.Background(), newQueuedMessage("fails")); !errors.Is(err, mailer.err) {
+		t.Fatalf("error = %v, want the transport error", err)
+	}
+}
+
+func TestQueueBackoffIsCapped(t *testing.T) {
+	q := &Queue{opts: QueueOptions{RetryDelay: time.Second}}
+	cases := map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 4: 8 * time.Second, 20: MAX_RETRY_DELAY, 80: MAX_RETRY_DELAY}
+	for attempt, want := range cases {
+		if got := q.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
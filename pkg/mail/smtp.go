@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"time"
+)
+
+const DEFAULT_SMTP_PORT = 587
+const SMTP_TIMEOUT = 30 * time.Second
+
+// SMTPMailer envía emails por SMTP usando STARTTLS cuando el servidor lo soporta.
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// ImplicitTLS usa TLS desde la conexión (puerto 465) en lugar de STARTTLS
+	ImplicitTLS bool
+}
+
+// NewSMTPMailerFromEnv crea un SMTPMailer a partir de SMTP_HOST, SMTP_PORT, SMTP_USERNAME,
+// SMTP_PASSWORD y SMTP_IMPLICIT_TLS.
+func NewSMTPMailerFromEnv() (*SMTPMailer, error) {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return nil, errors.New("SMTP_HOST es requerido para MAIL_TRANSPORT=smtp")
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = DEFAULT_SMTP_PORT
+	}
+
+	return &SMTPMailer{
+		Host:        host,
+		Port:        port,
+		Username:    os.Getenv("SMTP_USERNAME"),
+		Password:    os.Getenv("SMTP_PASSWORD"),
+		ImplicitTLS: os.Getenv("SMTP_IMPLICIT_TLS") == "true",
+	}, nil
+}
+
+// Send envía el mensaje respetando la cancelación del contexto.
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	data, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SMTP_TIMEOUT)
+	defer cancel()
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !m.ImplicitTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: m.Host}); err != nil {
+			return err
+		}
+	}
+
+	if m.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Username, m.Password, m.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// dial abre la conexión SMTP con el deadline del contexto.
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	dialer := &net.Dialer{}
+
+	var conn net.Conn
+	var err error
+	if m.ImplicitTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
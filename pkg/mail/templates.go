@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template identifica un tipo de mensaje. Cada uno tiene un <nombre>.txt (asunto y texto plano)
+// y un <nombre>.html por idioma en templates/<idioma>/.
+type Template string
+
+const (
+	TemplatePasswordReset     Template = "password_reset"
+	TemplateEmailVerification Template = "email_verification"
+	TemplateInvitation        Template = "invitation"
+	TemplateMagicLink         Template = "magic_link"
+	TemplateEmailOTP          Template = "email_otp"
+)
+
+const DEFAULT_LANGUAGE = "es"
+
+var supportedLanguages = []string{"es", "en"}
+
+//go:embed templates
+var templatesFS embed.FS
+
+// TemplateData son los datos disponibles en los templates. Cada template usa los que necesita.
+type TemplateData struct {
+	Name             string
+	Link             string
+	ExpiresInHours   int
+	ExpiresInMinutes int
+	Code             string
+	OrganizationName string
+	InviterName      string
+	Role             string
+}
+
+// Content es el resultado de renderizar un template.
+type Content struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// GetDefaultLanguage retorna el idioma de los emails desde variables de entorno
+func GetDefaultLanguage() string {
+	return normalizeLanguage(os.Getenv("MAIL_LANGUAGE"))
+}
+
+// normalizeLanguage reduce "en-US" a "en" y usa el idioma por defecto si no está soportado.
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	for _, supported := range supportedLanguages {
+		if lang == supported {
+			return lang
+		}
+	}
+	return DEFAULT_LANGUAGE
+}
+
+// Render renderiza el asunto y los cuerpos de texto y HTML de un template.
+func Render(tpl Template, lang string, data TemplateData) (*Content, error) {
+	lang = normalizeLanguage(lang)
+	base := "templates/" + lang + "/" + string(tpl)
+
+	textTpl, err := texttemplate.ParseFS(templatesFS, base+".txt")
+	if err != nil {
+		return nil, fmt.Errorf("template %s (%s): %w", tpl, lang, err)
+	}
+
+	htmlTpl, err := htmltemplate.ParseFS(templatesFS, "templates/layout.html", base+".html")
+	if err != nil {
+		return nil, fmt.Errorf("template %s (%s): %w", tpl, lang, err)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := textTpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := textTpl.ExecuteTemplate(&text, "body", data); err != nil {
+		return nil, err
+	}
+	if err := htmlTpl.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, err
+	}
+
+	return &Content{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
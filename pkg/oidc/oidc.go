@@ -0,0 +1,345 @@
+// Package oidc implementa un cliente (relying party) de OpenID Connect para iniciar sesión con
+// proveedores externos: discovery, authorization code con PKCE y verificación del ID token
+// con las claves publicadas por el proveedor. No depende de ningún proveedor en particular.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	tokens "myproject/pkg/jwt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DEFAULT_SCOPES son los scopes pedidos si el proveedor no configura otros
+var DEFAULT_SCOPES = []string{"openid", "email", "profile"}
+
+// JWKS_REFRESH_INTERVAL es el tiempo mínimo entre dos descargas de las claves del proveedor.
+// Ante un kid desconocido se vuelven a descargar (rotación), pero no más seguido que esto.
+const JWKS_REFRESH_INTERVAL = time.Minute
+
+// CLOCK_SKEW es la tolerancia al validar exp, iat y nbf del ID token
+const CLOCK_SKEW = time.Minute
+
+var (
+	ErrDiscovery       = errors.New("no se pudo obtener la configuración del proveedor")
+	ErrTokenExchange   = errors.New("el proveedor rechazó el código de autorización")
+	ErrInvalidIDToken  = errors.New("ID token inválido")
+	ErrNonceMismatch   = errors.New("el nonce del ID token no coincide")
+	ErrUnknownSigner   = errors.New("el ID token está firmado con una clave desconocida")
+	ErrMissingIDToken  = errors.New("el proveedor no devolvió un ID token")
+	ErrIssuerMismatch  = errors.New("el issuer del proveedor no coincide con el configurado")
+	ErrInvalidProvider = errors.New("configuración del proveedor incompleta")
+)
+
+// signingAlgorithms son los algoritmos aceptados para el ID token. Los simétricos (HS*) se
+// excluyen: el secreto del cliente no debe servir para firmar tokens del proveedor.
+var signingAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// ProviderConfig es la configuración de un proveedor de identidad.
+type ProviderConfig struct {
+	ID           string   // identificador usado en las rutas (google, azure, corp, ...)
+	Name         string   // nombre para mostrar
+	Issuer       string   // URL del issuer; la configuración se lee de /.well-known/openid-configuration
+	ClientID     string   // client_id registrado en el proveedor
+	ClientSecret string   // vacío para clientes públicos
+	Scopes       []string // por defecto DEFAULT_SCOPES
+}
+
+// Metadata son los datos de OpenID Connect Discovery que usa el cliente.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// TokenResponse es la respuesta del token endpoint del proveedor.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// IDTokenClaims son los claims del ID token del proveedor que usa la API.
+type IDTokenClaims struct {
+	Nonce           string `json:"nonce,omitempty"`
+	AuthorizedParty string `json:"azp,omitempty"`
+	Email           string `json:"email,omitempty"`
+	EmailVerified   bool   `json:"email_verified,omitempty"`
+	Name            string `json:"name,omitempty"`
+	GivenName       string `json:"given_name,omitempty"`
+	FamilyName      string `json:"family_name,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Provider es un proveedor de identidad. La configuración y las claves se descargan al usarlo
+// por primera vez y quedan en memoria; si la descarga falla se reintenta en el próximo uso.
+type Provider struct {
+	config     ProviderConfig
+	httpClient *http.Client
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// NewProvider crea un proveedor. Con httpClient nil se usa un cliente con timeout de 10 segundos.
+func NewProvider(config ProviderConfig, httpClient *http.Client) (*Provider, error) {
+	if config.ID == "" || config.Issuer == "" || config.ClientID == "" {
+		return nil, ErrInvalidProvider
+	}
+	if config.Name == "" {
+		config.Name = config.ID
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = DEFAULT_SCOPES
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Provider{config: config, httpClient: httpClient}, nil
+}
+
+// ID retorna el identificador del proveedor
+func (p *Provider) ID() string {
+	return p.config.ID
+}
+
+// Name retorna el nombre para mostrar del proveedor
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// AuthCodeURL arma la URL de autorización del proveedor con state, nonce y el code_challenge S256.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURI, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", ErrDiscovery
+	}
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), nil
+}
+
+// Exchange canjea el código de autorización por los tokens del proveedor. Los clientes
+// confidenciales se autentican con HTTP Basic (client_secret_basic).
+func (p *Provider) Exchange(ctx context.Context, code, redirectURI, codeVerifier string) (*TokenResponse, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d: %s", ErrTokenExchange, resp.StatusCode, body)
+	}
+
+	var tokenResp TokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenExchange, err)
+	}
+	if tokenResp.IDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	return &tokenResp, nil
+}
+
+// VerifyIDToken verifica la firma del ID token con las claves del proveedor y valida issuer,
+// audiencia (client_id), vigencia y nonce.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	metadata, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.getKey(ctx, metadata, kid)
+		},
+		jwt.WithValidMethods(signingAlgorithms),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(CLOCK_SKEW),
+	)
+	if err != nil {
+		if errors.Is(err, ErrUnknownSigner) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	// Con varias audiencias el token debe estar emitido para este cliente (OIDC Core 3.1.3.7)
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, ErrInvalidIDToken
+	}
+
+	if claims.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	return claims, nil
+}
+
+// getMetadata retorna la configuración del proveedor, descargándola la primera vez.
+func (p *Provider) getMetadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, discoveryURL, &metadata); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDiscovery, err)
+	}
+
+	// El issuer publicado debe ser exactamente el configurado (OIDC Discovery 4.3)
+	if metadata.Issuer != p.config.Issuer {
+		return nil, ErrIssuerMismatch
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, ErrDiscovery
+	}
+
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// getKey retorna la clave pública del kid. Si no la conoce vuelve a descargar el JWKS,
+// como mucho una vez cada JWKS_REFRESH_INTERVAL. Un kid vacío solo se acepta si el
+// proveedor publica una única clave.
+func (p *Provider) getKey(ctx context.Context, metadata *Metadata, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < JWKS_REFRESH_INTERVAL {
+		return nil, ErrUnknownSigner
+	}
+
+	var set tokens.JWKS
+	if err := p.getJSON(ctx, metadata.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			continue // tipos de clave no soportados
+		}
+		keys[jwk.KeyID] = key
+	}
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, ErrUnknownSigner
+}
+
+// lookupKey busca la clave en las descargadas. Debe llamarse con p.mu tomado.
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// getJSON descarga y decodifica un documento JSON del proveedor
+func (p *Provider) getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(target)
+}
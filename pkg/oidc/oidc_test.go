@@ -0,0 +1,152 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"myproject/pkg/oidc"
+	"myproject/pkg/oidc/oidctest"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testRedirectURI = "https://app.example.com/auth/callback/fake"
+	testVerifier    = "dBjftJeZ4CVP-mJ92K9f8n3yMTb1ZoG5ulMvV4z9-2qWqA"
+)
+
+var testIdentity = oidctest.Identity{Subject: "248289761001", Email: "juan@example.com", EmailVerified: true, GivenName: "Juan"}
+
+func newTestProvider(t *testing.T) (*oidctest.Server, *oidc.Provider) {
+	t.Helper()
+	server := oidctest.NewServer("client-1", "secret-1")
+	t.Cleanup(server.Close)
+
+	provider, err := oidc.NewProvider(oidc.ProviderConfig{
+		ID:           "fake",
+		Issuer:       server.Issuer(),
+		ClientID:     server.ClientID,
+		ClientSecret: server.ClientSecret,
+	}, nil)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	return server, provider
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, testRedirectURI, "state-1", "nonce-1", challenge(testVerifier))
+	if err != nil {
+		t.Fatalf("auth code url: %v", err)
+	}
+	parsed, _ := url.Parse(authURL)
+	if parsed.Query().Get("scope") != "openid email profile" || parsed.Query().Get("redirect_uri") != testRedirectURI {
+		t.Fatalf("unexpected authorization url: %s", authURL)
+	}
+
+	code, state, err := server.Authorize(authURL, testIdentity)
+	if err != nil || state != "state-1" {
+		t.Fatalf("authorize: state = %q, err = %v", state, err)
+	}
+
+	// Otro verifier no sirve (PKCE), y el código se consume igual
+	if _, err := provider.Exchange(ctx, code, testRedirectURI, "otro-verifier"); !errors.Is(err, oidc.ErrTokenExchange) {
+		t.Fatalf("wrong verifier: error = %v, want ErrTokenExchange", err)
+	}
+
+	code, _, _ = server.Authorize(authURL, testIdentity)
+	tokenResp, err := provider.Exchange(ctx, code, testRedirectURI, testVerifier)
+	if err != nil {
+		t.Fatalf("exchange: %v", err)
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, tokenResp.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if claims.Subject != testIdentity.Subject || claims.Email != testIdentity.Email || !claims.EmailVerified || claims.GivenName != "Juan" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, tokenResp.IDToken, "otro-nonce"); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Fatalf("wrong nonce: error = %v, want ErrNonceMismatch", err)
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+	}{
+		{"other audience", func(c jwt.MapClaims) { c["aud"] = "otro-cliente" }},
+		{"other issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"without expiration", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"without subject", func(c jwt.MapClaims) { delete(c, "sub") }},
+		{"several audiences without azp", func(c jwt.MapClaims) { c["aud"] = []string{"client-1", "otro-cliente"} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := server.IDTokenClaims(testIdentity, "nonce-1")
+			tt.modify(claims)
+			if _, err := provider.VerifyIDToken(ctx, server.SignIDToken(claims), "nonce-1"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Fatalf("error = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+
+	t.Run("symmetric algorithm", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, server.IDTokenClaims(testIdentity, "nonce-1"))
+		signed, _ := token.SignedString([]byte(server.ClientSecret))
+		if _, err := provider.VerifyIDToken(ctx, signed, "nonce-1"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Fatalf("error = %v, want ErrInvalidIDToken", err)
+		}
+	})
+}
+
+func TestVerifyIDTokenAfterKeyRotation(t *testing.T) {
+	server, provider := newTestProvider(t)
+	ctx := context.Background()
+
+	valid := server.SignIDToken(server.IDTokenClaims(testIdentity, ""))
+	if _, err := provider.VerifyIDToken(ctx, valid, ""); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	// Las claves recién descargadas no se vuelven a pedir antes de JWKS_REFRESH_INTERVAL
+	server.RotateKey()
+	rotated := server.SignIDToken(server.IDTokenClaims(testIdentity, ""))
+	if _, err := provider.VerifyIDToken(ctx, rotated, ""); !errors.Is(err, oidc.ErrUnknownSigner) {
+		t.Fatalf("rotated key: error = %v, want ErrUnknownSigner", err)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	server := oidctest.NewServer("client-1", "secret-1")
+	defer server.Close()
+
+	provider, err := oidc.NewProvider(oidc.ProviderConfig{ID: "fake", Issuer: server.Issuer() + "/", ClientID: "client-1"}, nil)
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	if _, err := provider.AuthCodeURL(context.Background(), testRedirectURI, "s", "n", "c"); !errors.Is(err, oidc.ErrIssuerMismatch) {
+		t.Fatalf("error = %v, want ErrIssuerMismatch", err)
+	}
+}
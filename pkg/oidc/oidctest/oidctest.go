@@ -0,0 +1,206 @@
+// Package oidctest contiene un proveedor OpenID Connect en proceso para los tests: publica
+// discovery y JWKS, y su token endpoint valida el cliente, la redirect_uri y PKCE antes de
+// emitir un ID token firmado con RS256.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	tokens "myproject/pkg/jwt"
+	security "myproject/pkg/session"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Identity es el usuario que "inicia sesión" en el proveedor falso.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+}
+
+// pendingCode es un código emitido por Authorize y todavía no canjeado
+type pendingCode struct {
+	identity      Identity
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// Server es el proveedor falso. Se cierra con Close.
+type Server struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	mu    sync.Mutex
+	kid   string
+	key   *rsa.PrivateKey
+	codes map[string]pendingCode
+}
+
+// NewServer inicia un proveedor con un cliente confidencial registrado.
+func NewServer(clientID, clientSecret string) *Server {
+	s := &Server{ClientID: clientID, ClientSecret: clientSecret, codes: map[string]pendingCode{}}
+	s.RotateKey()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("/jwks", s.jwks)
+	mux.HandleFunc("/token", s.token)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Issuer retorna el issuer del proveedor (la URL del servidor)
+func (s *Server) Issuer() string {
+	return s.URL
+}
+
+// RotateKey reemplaza la clave de firma por una nueva con otro kid.
+func (s *Server) RotateKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.key = key
+	s.kid = fmt.Sprintf("key-%d", time.Now().UnixNano())
+}
+
+// Authorize simula que el usuario aprueba el login en el proveedor: recibe la URL de
+// autorización armada por el cliente y retorna el code y el state con los que el
+// proveedor redirigiría a la redirect_uri.
+func (s *Server) Authorize(authURL string, identity Identity) (code, state string, err error) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	query := parsed.Query()
+	if query.Get("client_id") != s.ClientID || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		return "", "", fmt.Errorf("invalid authorization request: %s", authURL)
+	}
+
+	code, err = security.GenerateRandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	s.mu.Lock()
+	s.codes[code] = pendingCode{
+		identity:      identity,
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	s.mu.Unlock()
+
+	return code, query.Get("state"), nil
+}
+
+// SignIDToken firma claims arbitrarios con la clave actual, para probar tokens inválidos.
+func (s *Server) SignIDToken(claims jwt.Claims) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = s.kid
+	signed, err := token.SignedString(s.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// IDTokenClaims arma los claims de un ID token válido para la identidad
+func (s *Server) IDTokenClaims(identity Identity, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            s.Issuer(),
+		"sub":            identity.Subject,
+		"aud":            s.ClientID,
+		"exp":            now.Add(time.Hour).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          identity.Email,
+		"email_verified": identity.EmailVerified,
+		"name":           identity.Name,
+		"given_name":     identity.GivenName,
+		"family_name":    identity.FamilyName,
+	}
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                s.Issuer(),
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"jwks_uri":                              s.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, tokens.JWKS{Keys: []tokens.JWK{{
+		KeyType:   "RSA",
+		KeyID:     s.kid,
+		Use:       "sig",
+		Algorithm: "RS256",
+		N:         base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+		E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+	}}})
+}
+
+// token canjea un código emitido por Authorize (un solo uso)
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok || clientID != s.ClientID || clientSecret != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	s.mu.Lock()
+	pending, found := s.codes[r.PostFormValue("code")]
+	delete(s.codes, r.PostFormValue("code"))
+	s.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+	if !found || r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("redirect_uri") != pending.redirectURI ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != pending.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": "access-" + pending.identity.Subject,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     s.SignIDToken(s.IDTokenClaims(pending.identity, pending.nonce)),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,84 @@
+// Package rbac define los permisos de la API y qué roles (consts.ROLE_*) los tienen.
+package rbac
+
+import (
+	"myproject/pkg/consts"
+	"sort"
+)
+
+// Permission es una acción que la API autoriza según el rol del usuario.
+type Permission string
+
+// Permisos con nombre. Se verifican con middlewares.RequirePermission.
+const (
+	// PERMISSION_USERS_MANAGE permite cambiar el rol de otros usuarios
+	PERMISSION_USERS_MANAGE Permission = "users:manage"
+
+	PERMISSION_ORGANIZATION_UPDATE Permission = "organization:update"
+	PERMISSION_ORGANIZATION_DELETE Permission = "organization:delete"
+
+	PERMISSION_MEMBERS_READ   Permission = "members:read"
+	PERMISSION_MEMBERS_INVITE Permission = "members:invite"
+	// PERMISSION_MEMBERS_MANAGE permite cambiar el rol de los miembros y quitarlos de la organización
+	PERMISSION_MEMBERS_MANAGE Permission = "members:manage"
+)
+
+// rolePermissions es la matriz de permisos de cada rol. Un rol que no figura no tiene ninguno.
+var rolePermissions = map[string][]Permission{
+	consts.ROLE_OWNER: {
+		PERMISSION_USERS_MANAGE,
+		PERMISSION_ORGANIZATION_UPDATE,
+		PERMISSION_ORGANIZATION_DELETE,
+		PERMISSION_MEMBERS_READ,
+		PERMISSION_MEMBERS_INVITE,
+		PERMISSION_MEMBERS_MANAGE,
+	},
+	consts.ROLE_ADMINISTRATIVE: {
+		PERMISSION_USERS_MANAGE,
+		PERMISSION_ORGANIZATION_UPDATE,
+		PERMISSION_MEMBERS_READ,
+		PERMISSION_MEMBERS_INVITE,
+		PERMISSION_MEMBERS_MANAGE,
+	},
+	consts.ROLE_EMPLOYEE: {
+		PERMISSION_MEMBERS_READ,
+	},
+	consts.ROLE_CLIENT: {},
+}
+
+// roleRanks ordena los roles de mayor a menor jerarquía
+var roleRanks = map[string]int{
+	consts.ROLE_OWNER:          4,
+	consts.ROLE_ADMINISTRATIVE: 3,
+	consts.ROLE_EMPLOYEE:       2,
+	consts.ROLE_CLIENT:         1,
+}
+
+// IsValidRole indica si role es uno de los roles definidos en consts.
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasPermission indica si el rol tiene el permiso.
+func HasPermission(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions retorna los permisos del rol ordenados por nombre.
+func Permissions(role string) []Permission {
+	permissions := append([]Permission{}, rolePermissions[role]...)
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
+
+// CanAssignRole indica si un usuario con actorRole puede asignar role, o modificar a quien lo tiene:
+// nadie asigna un rol de mayor jerarquía que el propio (un ADMINISTRATIVE no crea un OWNER).
+func CanAssignRole(actorRole, role string) bool {
+	return IsValidRole(actorRole) && IsValidRole(role) && roleRanks[role] <= roleRanks[actorRole]
+}
@@ -0,0 +1,62 @@
+package rbac
+
+import (
+	"myproject/pkg/consts"
+	"testing"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		role       string
+		permission Permission
+		want       bool
+	}{
+		{consts.ROLE_OWNER, PERMISSION_ORGANIZATION_DELETE, true},
+		{consts.ROLE_ADMINISTRATIVE, PERMISSION_ORGANIZATION_DELETE, false},
+		{consts.ROLE_ADMINISTRATIVE, PERMISSION_MEMBERS_INVITE, true},
+		{consts.ROLE_EMPLOYEE, PERMISSION_MEMBERS_READ, true},
+		{consts.ROLE_EMPLOYEE, PERMISSION_MEMBERS_INVITE, false},
+		{consts.ROLE_CLIENT, PERMISSION_MEMBERS_READ, false},
+		{"", PERMISSION_MEMBERS_READ, false},
+		{"SUPERUSER", PERMISSION_USERS_MANAGE, false},
+	}
+	for _, tt := range tests {
+		if got := HasPermission(tt.role, tt.permission); got != tt.want {
+			t.Errorf("HasPermission(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+		}
+	}
+}
+
+func TestEveryPermissionOfLowerRolesIsInherited(t *testing.T) {
+	// La matriz es jerárquica: un rol tiene todos los permisos de los roles inferiores
+	roles := []string{consts.ROLE_OWNER, consts.ROLE_ADMINISTRATIVE, consts.ROLE_EMPLOYEE, consts.ROLE_CLIENT}
+	for i, role := range roles {
+		for _, lower := range roles[i+1:] {
+			for _, permission := range Permissions(lower) {
+				if !HasPermission(role, permission) {
+					t.Errorf("%s lacks %q granted to %s", role, permission, lower)
+				}
+			}
+		}
+	}
+}
+
+func TestCanAssignRole(t *testing.T) {
+	tests := []struct {
+		actor, role string
+		want        bool
+	}{
+		{consts.ROLE_OWNER, consts.ROLE_OWNER, true},
+		{consts.ROLE_ADMINISTRATIVE, consts.ROLE_OWNER, false},
+		{consts.ROLE_ADMINISTRATIVE, consts.ROLE_ADMINISTRATIVE, true},
+		{consts.ROLE_ADMINISTRATIVE, consts.ROLE_CLIENT, true},
+		{consts.ROLE_EMPLOYEE, consts.ROLE_ADMINISTRATIVE, false},
+		{consts.ROLE_OWNER, "SUPERUSER", false},
+		{"", consts.ROLE_CLIENT, false},
+	}
+	for _, tt := range tests {
+		if got := CanAssignRole(tt.actor, tt.role); got != tt.want {
+			t.Errorf("CanAssignRole(%q, %q) = %v, want %v", tt.actor, tt.role, got, tt.want)
+		}
+	}
+}
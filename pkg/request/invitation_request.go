@@ -7,6 +7,10 @@ type SendInvitationRequest struct {
 }
 
 // AcceptInvitationRequest define la estructura para la petición de aceptar una invitación.
+// Name, LastName y Password solo se envían si el email invitado todavía no tiene cuenta.
 type AcceptInvitationRequest struct {
-	Token string `json:"token" binding:"required"`
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name"`
+	LastName string `json:"last_name"`
+	Password string `json:"password"`
 }
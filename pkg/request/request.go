@@ -1,11 +1,18 @@
 package request
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // -------------- SESSION ----------------\\
 type RegisterUserRequest struct {
 	Name     string `json:"name" binding:"required"`
 	LastName string `json:"last_name" binding:"required"`
 	Email    string `json:"email" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	// CompanyName es opcional: si se envía se crea la organización y el usuario queda como OWNER
+	CompanyName string `json:"company_name"`
 }
 
 type LoginUserRequest struct {
@@ -13,12 +20,142 @@ type LoginUserRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest es un cambio parcial del perfil: los campos omitidos no se modifican.
+// Version es la versión del usuario leída por el cliente (GET /users/me).
+type UpdateProfileRequest struct {
+	Name      *string    `json:"name"`
+	LastName  *string    `json:"last_name"`
+	BirthDate *time.Time `json:"birth_date"`
+	Version   *int64     `json:"version"`
+}
+
+// UpdateRoleRequest es el nuevo rol de un usuario (consts.ROLE_*).
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// SwitchOrganizationRequest es la organización para la que se reemiten los tokens.
+type SwitchOrganizationRequest struct {
+	OrganizationID string `json:"organization_id" binding:"required"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// -------------- MFA ----------------\\
+type VerifyTOTPRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFAChallengeRequest completa un login con MFA: se envía el código TOTP o uno de recuperación.
+type MFAChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// -------------- WEBAUTHN ----------------\\
+// WebAuthnFinishRequest completa una ceremonia WebAuthn. Credential es la PublicKeyCredential
+// retornada por el navegador, serializada con toJSON(); Name solo se usa al registrar una passkey.
+type WebAuthnFinishRequest struct {
+	ChallengeID string          `json:"challenge_id" binding:"required"`
+	Credential  json.RawMessage `json:"credential" binding:"required"`
+	Name        string          `json:"name"`
+}
+
+// -------------- PASSWORDLESS ----------------\\
+// EmailLoginRequest pide un magic link o un código de un solo uso para el email.
+type EmailLoginRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type MagicLinkVerifyRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type EmailOTPVerifyRequest struct {
+	Email string `json:"email" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// -------------- OAUTH ----------------\\
+// CreateOAuthClientRequest registra un cliente OAuth. Los clientes públicos (SPAs, apps móviles)
+// no reciben secreto; si GrantTypes está vacío se permiten authorization_code y refresh_token.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grant_types"`
+	Public       bool     `json:"public"`
+	// PostLogoutRedirectURIs son las URIs admitidas en el cierre de sesión de OpenID Connect
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris"`
+}
+
+// AuthorizeRequest son los parámetros de /oauth/authorize (query string o formulario).
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	// Nonce lo envían los clientes OpenID Connect para asociar el ID token a su solicitud
+	Nonce string
+}
+
+// OAuthTokenRequest son los parámetros de /oauth/token (application/x-www-form-urlencoded).
+// Las credenciales del cliente pueden venir acá o en el header Authorization (HTTP Basic).
+type OAuthTokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+	ClientID     string
+	ClientSecret string
+}
+
+// EndSessionRequest son los parámetros del cierre de sesión de OpenID Connect (/oauth/logout).
+type EndSessionRequest struct {
+	IDTokenHint           string
+	PostLogoutRedirectURI string
+	ClientID              string
+	State                 string
+}
+
+// -------------- FEDERATED LOGIN ----------------\\
+// FederatedCallbackRequest son los parámetros con los que el proveedor externo redirigió al
+// frontend; el frontend los reenvía tras comprobar que el state es el que guardó.
+type FederatedCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
 // -------------- PASSWORD ----------------\\
 type ForgotPasswordRequest struct {
 	Email string `json:"email" binding:"required"`
 }
 
 type ResetPasswordRequest struct {
-	Email    string `json:"email" binding:"required"`
+	Token    string `json:"token" binding:"required"`
 	Password string `json:"password" binding:"required"`
 }
+
+// -------------- CLIENT ----------------\\
+// ClientInfo agrupa los datos del cliente (dispositivo) que origina la petición.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
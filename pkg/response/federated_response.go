@@ -0,0 +1,15 @@
+package response
+
+// IdentityProviderResponse es un proveedor de identidad externo con el que se puede iniciar sesión.
+type IdentityProviderResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FederatedAuthorizationResponse contiene la URL del proveedor a la que redirigir al usuario y
+// el state que el frontend debe guardar para comprobarlo al volver.
+type FederatedAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	ExpiresIn        int    `json:"expires_in"`
+}
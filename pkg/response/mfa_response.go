@@ -0,0 +1,21 @@
+package response
+
+// MFAChallengeResponse es la respuesta de login cuando el usuario tiene MFA habilitado:
+// el login se completa enviando challenge_token y el código a /auth/mfa/challenge.
+type MFAChallengeResponse struct {
+	MFARequired    bool     `json:"mfa_required"`
+	ChallengeToken string   `json:"challenge_token"`
+	Methods        []string `json:"methods"`
+	ExpiresIn      int      `json:"expires_in"`
+}
+
+// TOTPSetupResponse contiene el secreto a cargar en la app de autenticación (o su URI, como QR).
+type TOTPSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// RecoveryCodesResponse contiene los códigos de recuperación. Solo se muestran una vez.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,98 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"myproject/internal/models"
+	tokens "myproject/pkg/jwt"
+)
+
+// OAuthClientResponse es un cliente OAuth. ClientSecret solo se incluye al registrarlo.
+type OAuthClientResponse struct {
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	Name         string    `json:"name"`
+	Public       bool      `json:"public"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"`
+	GrantTypes   []string  `json:"grant_types"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	PostLogoutRedirectURIs []string `json:"post_logout_redirect_uris,omitempty"`
+}
+
+// NewOAuthClientResponse arma la respuesta a partir del modelo
+func NewOAuthClientResponse(client *models.OAuthClient, secret string) *OAuthClientResponse {
+	return &OAuthClientResponse{
+		ClientID:     client.ID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		Public:       client.IsPublic(),
+		RedirectURIs: client.RedirectURIs,
+		Scopes:       client.Scopes,
+		GrantTypes:   client.GrantTypes,
+		CreatedAt:    client.CreatedAt,
+
+		PostLogoutRedirectURIs: client.PostLogoutRedirectURIs,
+	}
+}
+
+// OAuthTokenResponse es la respuesta de /oauth/token (RFC 6749, sección 5.1).
+// No usa el sobre de Response porque los clientes OAuth esperan el formato del estándar.
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthErrorResponse es un error de /oauth/token (RFC 6749, sección 5.2).
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// ResponseOAuth escribe una respuesta de /oauth/token. Los tokens no deben quedar en caché.
+func ResponseOAuth(w http.ResponseWriter, data interface{}, status int) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// UserInfoResponse es la respuesta de /userinfo (OpenID Connect Core, sección 5.3).
+type UserInfoResponse struct {
+	Subject string `json:"sub"`
+	tokens.UserClaims
+}
+
+// NewUserInfoResponse arma la respuesta con los datos del usuario que permiten los scopes concedidos
+func NewUserInfoResponse(user *models.User, scope string) *UserInfoResponse {
+	return &UserInfoResponse{
+		Subject:    user.ID,
+		UserClaims: tokens.NewUserClaims(user, scope),
+	}
+}
+
+// OpenIDConfigurationResponse es el documento de /.well-known/openid-configuration (OpenID Connect Discovery).
+type OpenIDConfigurationResponse struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	EndSessionEndpoint                string   `json:"end_session_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
@@ -0,0 +1,32 @@
+package response
+
+import "time"
+
+// OrganizationResponse es una organización del usuario autenticado con su rol en ella.
+// Current indica la organización para la que se emitió el token actual.
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Current   bool      `json:"current"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InvitationResponse es una invitación pendiente a la organización.
+type InvitationResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	InvitedBy string    `json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AcceptInvitationResponse es la organización a la que se unió el usuario al aceptar una invitación.
+// Registered indica que la cuenta se creó al aceptarla; en ambos casos el usuario inicia sesión con /auth/login.
+type AcceptInvitationResponse struct {
+	OrganizationID   string `json:"organization_id"`
+	OrganizationName string `json:"organization_name"`
+	Role             string `json:"role"`
+	Registered       bool   `json:"registered"`
+}
@@ -0,0 +1,43 @@
+package response
+
+import (
+	"myproject/internal/models"
+	"myproject/pkg/rbac"
+	"time"
+)
+
+// UserResponse es la representación pública de un usuario (sin contraseña ni datos internos).
+type UserResponse struct {
+	ID            string              `json:"id"`
+	PersonalInfo  models.PersonalInfo `json:"personal_info"`
+	Email         string              `json:"email"`
+	EmailVerified bool                `json:"email_verified"`
+	MFAEnabled    bool                `json:"mfa_enabled"`
+	Status        int32               `json:"status"`
+	Role          string              `json:"role"`
+	// Permissions son los permisos del rol, para que el frontend muestre solo las acciones permitidas
+	Permissions []rbac.Permission `json:"permissions"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at,omitempty"`
+	LastSession time.Time         `json:"last_session,omitempty"`
+	// Version se envía de vuelta en PATCH /users/me para detectar ediciones concurrentes
+	Version int64 `json:"version"`
+}
+
+// NewUserResponse arma la respuesta pública a partir del modelo
+func NewUserResponse(user *models.User) *UserResponse {
+	return &UserResponse{
+		ID:            user.ID,
+		PersonalInfo:  user.PersonalInfo,
+		Email:         user.ContactInfo.Email.Address,
+		EmailVerified: user.ContactInfo.Email.IsVerified,
+		MFAEnabled:    user.IsMFAEnabled(),
+		Status:        user.Status,
+		Role:          user.GetRole(),
+		Permissions:   rbac.Permissions(user.GetRole()),
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		LastSession:   user.LastSession,
+		Version:       user.Version,
+	}
+}
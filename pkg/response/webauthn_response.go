@@ -0,0 +1,9 @@
+package response
+
+// WebAuthnOptionsResponse contiene las opciones para navigator.credentials.create/get y el
+// challenge_id a enviar junto con la respuesta del autenticador.
+type WebAuthnOptionsResponse struct {
+	ChallengeID string      `json:"challenge_id"`
+	Options     interface{} `json:"options"`
+	ExpiresIn   int         `json:"expires_in"`
+}
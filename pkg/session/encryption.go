@@ -0,0 +1,74 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+
+	"myproject/pkg/validations"
+)
+
+// getEncryptionKey retorna la clave AES-256 usada para cifrar secretos en la base de datos.
+// MFA_ENCRYPTION_KEY debe ser una clave de 32 bytes codificada en base64 (openssl rand -base64 32).
+func getEncryptionKey() ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(os.Getenv("MFA_ENCRYPTION_KEY"))
+	if err != nil || len(key) != 32 {
+		return nil, validations.ErrEncryptionKeyInvalid
+	}
+	return key, nil
+}
+
+func newGCM() (cipher.AEAD, error) {
+	key, err := getEncryptionKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret cifra un secreto con AES-256-GCM. El resultado (nonce + texto cifrado)
+// se codifica en base64. `context` (por ejemplo, el ID del usuario) queda autenticado:
+// el secreto solo puede descifrarse con el mismo valor.
+func EncryptSecret(plaintext, context string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), []byte(context))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret descifra un valor generado por EncryptSecret con el mismo `context`.
+func DecryptSecret(ciphertext, context string) (string, error) {
+	gcm, err := newGCM()
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted secret")
+	}
+
+	nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, data, []byte(context))
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,95 @@
+// Package totp implementa contraseñas de un solo uso basadas en tiempo (RFC 6238) con los
+// parámetros que soportan todas las apps de autenticación: HMAC-SHA1, 6 dígitos y pasos de 30 segundos.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DIGITS       = 6
+	PERIOD       = 30 // segundos por paso
+	SECRET_BYTES = 20 // 160 bits, el tamaño recomendado por la RFC 4226
+	// SKEW es la cantidad de pasos aceptados antes y después del actual (desfase de reloj)
+	SKEW = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret genera un secreto aleatorio codificado en base32 sin padding.
+func GenerateSecret() (string, error) {
+	buf := make([]byte, SECRET_BYTES)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(buf), nil
+}
+
+// Step retorna el número de paso correspondiente a t.
+func Step(t time.Time) int64 {
+	return t.Unix() / PERIOD
+}
+
+// Code calcula el código del paso `step` (RFC 4226, truncado dinámico).
+func Code(secret string, step int64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return "", err
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", DIGITS, value%1_000_000), nil
+}
+
+// Validate verifica el código contra los pasos cercanos a t y retorna el paso que coincidió.
+// Quien llama debe rechazar pasos ya usados para que un código no sirva dos veces.
+func Validate(secret, code string, t time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != DIGITS {
+		return 0, false
+	}
+
+	current := Step(t)
+	for step := current - SKEW; step <= current+SKEW; step++ {
+		expected, err := Code(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// KeyURI arma la URI otpauth:// que las apps de autenticación leen desde un código QR.
+func KeyURI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(DIGITS))
+	params.Set("period", fmt.Sprint(PERIOD))
+
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
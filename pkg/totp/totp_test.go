@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Vectores de la RFC 6238 (apéndice B) para SHA-1, truncados a 6 dígitos.
+func TestCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range cases {
+		got, err := Code(secret, Step(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("t=%d: code = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	code, _ := Code(secret, Step(now))
+	if step, ok := Validate(secret, code, now); !ok || step != Step(now) {
+		t.Fatalf("current code rejected")
+	}
+
+	// Se tolera un paso de desfase, pero no más
+	previous, _ := Code(secret, Step(now)-1)
+	if _, ok := Validate(secret, previous, now); !ok {
+		t.Fatal("previous step rejected")
+	}
+	old, _ := Code(secret, Step(now)-3)
+	if _, ok := Validate(secret, old, now); ok {
+		t.Fatal("old code accepted")
+	}
+
+	if _, ok := Validate(secret, "12345", now); ok {
+		t.Fatal("short code accepted")
+	}
+}
+
+func TestKeyURI(t *testing.T) {
+	uri := KeyURI("Mi App", "juan@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Mi%20App:juan@example.com?") || !strings.Contains(uri, "secret=JBSWY3DPEHPK3PXP") {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+}
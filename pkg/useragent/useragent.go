@@ -0,0 +1,62 @@
+package useragent
+
+import "strings"
+
+// browsers y operatingSystems se evalúan en orden: los más específicos primero
+// (por ejemplo Edge y Opera incluyen "Chrome" en su User-Agent).
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"okhttp", "App Android"},
+	{"CFNetwork", "App iOS"},
+	{"PostmanRuntime", "Postman"},
+	{"curl/", "curl"},
+}
+
+var operatingSystems = []struct{ token, name string }{
+	{"iPhone", "iPhone"},
+	{"iPad", "iPad"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName arma un nombre legible del dispositivo a partir del User-Agent,
+// por ejemplo "Chrome en Windows". No pretende ser un parser completo.
+func DeviceName(userAgent string) string {
+	if strings.TrimSpace(userAgent) == "" {
+		return "Dispositivo desconocido"
+	}
+
+	browser := match(userAgent, browsers)
+	os := match(userAgent, operatingSystems)
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " en " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	default:
+		return "Dispositivo desconocido"
+	}
+}
+
+func match(userAgent string, candidates []struct{ token, name string }) string {
+	for _, c := range candidates {
+		if strings.Contains(userAgent, c.token) {
+			return c.name
+		}
+	}
+	return ""
+}
@@ -15,6 +15,8 @@ var (
 	ErrInsertDocumentFailed  = errors.New("insert document failed")
 	ErrUpdateDocumentFailed  = errors.New("update document failed")*/
 	ErrDeleteDocumentFailed = errors.New("delete document failed")
+	ErrConditionFailed      = errors.New("condition failed")
+	ErrVersionConflict      = errors.New("the document was modified by another request")
 
 	// API
 	ErrInvalidCode = errors.New("invalid code")
@@ -70,6 +72,59 @@ var (
 	ErrInvalidToken       = errors.New("Invalid token")
 	ErrUserInactive       = errors.New("User is inactive")
 	ErrInvalidUserID      = errors.New("Invalid user id")
+	ErrRefreshTokenReused = errors.New("Refresh token reuse detected")
+	ErrSessionNotFound    = errors.New("Session not found")
+	ErrEmailNotVerified   = errors.New("Email not verified")
+	ErrAccountLocked      = errors.New("Too many failed login attempts, try again later")
+
+	//Roles
+	ErrForbidden           = errors.New("You do not have permission to perform this action")
+	ErrInvalidRole         = errors.New("Invalid role")
+	ErrRoleNotAssignable   = errors.New("You cannot assign this role")
+	ErrCannotChangeOwnRole = errors.New("You cannot change your own role")
+
+	//MFA
+	ErrMFAAlreadyEnabled    = errors.New("MFA is already enabled")
+	ErrMFASetupNotStarted   = errors.New("MFA setup was not started")
+	ErrInvalidMFACode       = errors.New("Invalid MFA code")
+	ErrMFARequired          = errors.New("MFA code required")
+	ErrEncryptionKeyInvalid = errors.New("MFA_ENCRYPTION_KEY must be a base64-encoded 32-byte key")
+
+	//WebAuthn
+	ErrWebAuthnChallengeInvalid = errors.New("WebAuthn challenge is invalid or expired")
+	ErrWebAuthnVerification     = errors.New("WebAuthn verification failed")
+	ErrPasskeyAlreadyRegistered = errors.New("Passkey is already registered")
+	ErrPasskeyNotFound          = errors.New("Passkey not found")
+
+	//Passwordless
+	ErrInvalidEmailOTP = errors.New("Invalid or expired code")
+
+	//OAuth
+	ErrOAuthClientNotFound       = errors.New("OAuth client not found")
+	ErrOAuthRedirectURIInvalid   = errors.New("Invalid redirect_uri")
+	ErrOAuthGrantTypeInvalid     = errors.New("Invalid grant type")
+	ErrOAuthScopeInvalid         = errors.New("Invalid scope")
+	ErrOAuthClientNameRequired   = errors.New("Client name is required")
+	ErrInsufficientScope         = errors.New("Insufficient scope")
+	ErrOAuthTokenNotAllowed      = errors.New("OAuth client tokens are not allowed on this route")
+	ErrIDTokenSigningKeyRequired = errors.New("ID tokens require an asymmetric signing key (JWT_PRIVATE_KEY_FILE or JWT_KEYS_DIR)")
+
+	//Federated login
+	ErrIdentityProviderNotFound    = errors.New("Identity provider not found")
+	ErrIdentityProviderUnavailable = errors.New("Identity provider is unavailable")
+	ErrFederatedStateInvalid       = errors.New("Login state is invalid or expired")
+	ErrFederatedLoginFailed        = errors.New("Identity provider login failed")
+	ErrFederatedEmailNotVerified   = errors.New("The identity provider did not return a verified email")
+	ErrFederatedAccountNotFound    = errors.New("No account is linked to this identity")
+	ErrIdentityNotFound            = errors.New("Linked identity not found")
+
+	//Invitations
+	ErrOrganizationRequired = errors.New("Select an organization first")
+	ErrInvitationNotFound   = errors.New("Invitation not found")
+	ErrInvitationInvalid    = errors.New("Invitation is invalid or expired")
+	ErrInvitationPending    = errors.New("There is already a pending invitation for this email")
+	ErrAlreadyMember        = errors.New("The user is already a member of the organization")
+	ErrRegistrationRequired = errors.New("Create an account to accept the invitation")
 
 	//Register
 	ErrRequiredName       = errors.New("Name is required")
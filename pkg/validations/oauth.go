@@ -0,0 +1,30 @@
+package validations
+
+// Códigos de error de OAuth 2.0 (RFC 6749, secciones 4.1.2.1 y 5.2)
+const (
+	OAUTH_INVALID_REQUEST           = "invalid_request"
+	OAUTH_INVALID_CLIENT            = "invalid_client"
+	OAUTH_INVALID_GRANT             = "invalid_grant"
+	OAUTH_UNAUTHORIZED_CLIENT       = "unauthorized_client"
+	OAUTH_UNSUPPORTED_GRANT_TYPE    = "unsupported_grant_type"
+	OAUTH_UNSUPPORTED_RESPONSE_TYPE = "unsupported_response_type"
+	OAUTH_INVALID_SCOPE             = "invalid_scope"
+	OAUTH_ACCESS_DENIED             = "access_denied"
+	OAUTH_SERVER_ERROR              = "server_error"
+)
+
+// OAuthError es un error de OAuth 2.0 que se informa al cliente con el formato del estándar
+// ({"error", "error_description"} o parámetros en la redirect_uri).
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+// NewOAuthError crea un OAuthError.
+func NewOAuthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
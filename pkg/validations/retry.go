@@ -0,0 +1,23 @@
+package validations
+
+import "time"
+
+// RetryAfterError acompaña a un error con el tiempo que el cliente debe esperar
+// antes de reintentar. Los handlers lo traducen al header Retry-After.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+// NewRetryAfterError crea un RetryAfterError.
+func NewRetryAfterError(err error, retryAfter time.Duration) *RetryAfterError {
+	return &RetryAfterError{Err: err, RetryAfter: retryAfter}
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
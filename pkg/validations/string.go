@@ -66,3 +66,8 @@ func IsValidEmail(email string) bool {
 }
 
 var digitsRegex = regexp.MustCompile(`^[0-9]+$`)
+
+// NormalizeEmail retorna el email en la forma en que se guarda y se compara (sin espacios y en minúsculas).
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}